@@ -43,7 +43,9 @@ import (
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func allowed(method string) bool {
@@ -157,6 +159,12 @@ func queryHandler(w http.ResponseWriter, r *http.Request) {
 		x.SetStatus(w, x.ErrorInvalidRequest, err.Error())
 		return
 	}
+	// If explain is set, the plan of the query is returned under the _explain_ key of the data.
+	explainMode, err := query.ParseExplainMode(r.URL.Query().Get("explain"))
+	if err != nil {
+		x.SetStatus(w, x.ErrorInvalidRequest, err.Error())
+		return
+	}
 	queryTimeout, err := parseDuration(r, "timeout")
 	if err != nil {
 		x.SetStatus(w, x.ErrorInvalidRequest, err.Error())
@@ -168,6 +176,14 @@ func queryHandler(w http.ResponseWriter, r *http.Request) {
 		x.SetStatus(w, x.ErrorInvalidRequest, err.Error())
 		return
 	}
+	// If id is set, execute the persisted query registered with that id. The body, if any, only
+	// carries the variables for it.
+	persistedQueryId := r.URL.Query().Get("id")
+	persistedQueryVersion, err := parseUint64(r, "version")
+	if err != nil {
+		x.SetStatus(w, x.ErrorInvalidRequest, err.Error())
+		return
+	}
 
 	body := readRequest(w, r)
 	if body == nil {
@@ -179,35 +195,42 @@ func queryHandler(w http.ResponseWriter, r *http.Request) {
 		Variables map[string]string `json:"variables"`
 	}
 
-	contentType := r.Header.Get("Content-Type")
-	mediaType, contentTypeParams, err := mime.ParseMediaType(contentType)
-	if err != nil {
-		x.SetStatus(w, x.ErrorInvalidRequest, "Invalid Content-Type")
-	}
-	if charset, ok := contentTypeParams["charset"]; ok && strings.ToLower(charset) != "utf-8" {
-		x.SetStatus(w, x.ErrorInvalidRequest, "Unsupported charset. "+
-			"Supported charset is UTF-8")
-		return
-	}
+	if persistedQueryId == "" || len(body) > 0 {
+		contentType := r.Header.Get("Content-Type")
+		mediaType, contentTypeParams, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			x.SetStatus(w, x.ErrorInvalidRequest, "Invalid Content-Type")
+		}
+		if charset, ok := contentTypeParams["charset"]; ok && strings.ToLower(charset) != "utf-8" {
+			x.SetStatus(w, x.ErrorInvalidRequest, "Unsupported charset. "+
+				"Supported charset is UTF-8")
+			return
+		}
 
-	switch mediaType {
-	case "application/json":
-		if err := json.Unmarshal(body, &params); err != nil {
-			jsonErr := convertJSONError(string(body), err)
-			x.SetStatus(w, x.ErrorInvalidRequest, jsonErr.Error())
+		switch mediaType {
+		case "application/json":
+			if err := json.Unmarshal(body, &params); err != nil {
+				jsonErr := convertJSONError(string(body), err)
+				x.SetStatus(w, x.ErrorInvalidRequest, jsonErr.Error())
+				return
+			}
+		case "application/graphql+-", "application/dql":
+			params.Query = string(body)
+		default:
+			x.SetStatus(w, x.ErrorInvalidRequest, "Unsupported Content-Type. "+
+				"Supported content types are application/json, application/graphql+-,"+
+				"application/dql")
 			return
 		}
-	case "application/graphql+-", "application/dql":
-		params.Query = string(body)
-	default:
-		x.SetStatus(w, x.ErrorInvalidRequest, "Unsupported Content-Type. "+
-			"Supported content types are application/json, application/graphql+-,application/dql")
-		return
 	}
 
 	ctx := context.WithValue(r.Context(), query.DebugKey, isDebugMode)
+	ctx = context.WithValue(ctx, query.ExplainKey, explainMode)
 	ctx = x.AttachAccessJwt(ctx, r)
 	ctx = x.AttachRemoteIP(ctx, r)
+	if persistedQueryId != "" {
+		ctx = edgraph.AttachPersistedQuery(ctx, persistedQueryId, int(persistedQueryVersion))
+	}
 
 	if queryTimeout != 0 {
 		var cancel context.CancelFunc
@@ -248,7 +271,11 @@ func queryHandler(w http.ResponseWriter, r *http.Request) {
 	// Core processing happens here.
 	resp, err := (&edgraph.Server{}).Query(ctx, &req)
 	if err != nil {
-		x.SetStatusWithData(w, x.ErrorInvalidRequest, err.Error())
+		code := x.ErrorInvalidRequest
+		if status.Code(err) == codes.ResourceExhausted {
+			code = x.ErrorLimitExceeded
+		}
+		x.SetStatusWithData(w, code, err.Error())
 		return
 	}
 	// Add cost to the header.
@@ -148,6 +148,8 @@ they form a Raft group and provide synchronous replication.
 		Flag("percentage",
 			"Cache percentages summing up to 100 for various caches (FORMAT: PostingListCache,"+
 				"PstoreBlockCache,PstoreIndexCache)").
+		Flag("query-plans",
+			"Maximum number of parsed DQL queries to cache for reuse. Set to 0 to disable.").
 		String())
 
 	flag.String("raft", worker.RaftDefaults, z.NewSuperFlagHelp(worker.RaftDefaults).
@@ -181,6 +183,10 @@ they form a Raft group and provide synchronous replication.
 				"to whitelist for performing admin actions (i.e., --security "+
 				`"whitelist=144.142.126.254,127.0.0.1:127.0.0.3,192.168.0.0/16,host.docker.`+
 				`internal").`).
+		Flag("persisted-queries-only",
+			"If set, DQL requests containing a query are only served when they execute a "+
+				"persisted query registered through the /admin API by its id. Mutations without "+
+				"a query are not affected.").
 		String())
 
 	flag.String("limit", worker.LimitDefaults, z.NewSuperFlagHelp(worker.LimitDefaults).
@@ -208,6 +214,17 @@ they form a Raft group and provide synchronous replication.
 				"worker in a failed state. Use -1 to retry infinitely.").
 		Flag("txn-abort-after", "Abort any pending transactions older than this duration."+
 			" The liveness of a transaction is determined by its last mutation.").
+		Flag("query-uids",
+			"The maximum number of uids that a query can touch. Set to 0 for no limit. It can "+
+				"be overridden per namespace and ACL group through the /admin API.").
+		Flag("query-depth",
+			"The maximum depth of a query, including the levels expanded by @recurse. Set to 0 "+
+				"for no limit.").
+		Flag("query-result-bytes",
+			"The maximum size in bytes of the response of a query. Set to 0 for no limit.").
+		Flag("query-memory-bytes",
+			"The maximum memory in bytes used to build the response of a query. Set to 0 for "+
+				"no limit.").
 		String())
 
 	flag.String("ludicrous", worker.LudicrousDefaults, z.NewSuperFlagHelp(worker.LudicrousDefaults).
@@ -228,9 +245,40 @@ they form a Raft group and provide synchronous replication.
 		Flag("extensions",
 			"Enables extensions in GraphQL response body.").
 		Flag("poll-interval",
-			"The polling interval for GraphQL subscription.").
+			"The polling interval for GraphQL subscriptions with @custom or @lambda fields. "+
+				"Other subscriptions are updated on the commits that touch the data they read.").
 		Flag("lambda-url",
 			"The URL of a lambda server that implements custom GraphQL Javascript resolvers.").
+		Flag("lambda-embedded",
+			"Runs the lambda resolvers inside the alpha, as WASM modules uploaded for each "+
+				"namespace with the updateLambdaModule mutation of /admin, instead of calling a "+
+				"lambda server. It can't be used along with lambda-url.").
+		Flag("lambda-memory-mb",
+			"The max memory in MB that an embedded lambda can use while handling a request.").
+		Flag("lambda-timeout",
+			"The max time that an embedded lambda can take to handle a request.").
+		Flag("custom-http-cache-mb",
+			"The size in MB of the cache for the responses of @custom fields that set a "+
+				"cacheTTL. 0 disables the cache.").
+		Flag("custom-http-conns-per-host",
+			"The max number of @custom HTTP requests that are sent to a remote host at the "+
+				"same time. 0 means no limit.").
+		Flag("max-query-depth",
+			"The max nesting of the fields in a GraphQL operation. 0 means no limit. It can be "+
+				"changed with the config mutation of /admin.").
+		Flag("max-query-aliases",
+			"The max number of aliased fields in a GraphQL operation. 0 means no limit. It can "+
+				"be changed with the config mutation of /admin.").
+		Flag("max-query-cost",
+			"The max cost of a GraphQL operation, where each field costs 1 and the cost of the "+
+				"fields selected in a field with a first argument is multiplied by its value, or "+
+				"by default-list-multiplier in a list field without one. 0 means no limit. It can "+
+				"be changed with the config mutation of /admin. The limits don't apply to the "+
+				"operations of /admin.").
+		Flag("default-list-multiplier",
+			"The multiplier of the cost of the fields selected in a list field without a first "+
+				"argument, for max-query-cost. It can be changed with the config mutation of "+
+				"/admin.").
 		String())
 
 	flag.String("cdc", worker.CDCDefaults, z.NewSuperFlagHelp(worker.CDCDefaults).
@@ -462,6 +510,7 @@ func serveGRPC(l net.Listener, tlsCfg *tls.Config, closer *z.Closer) {
 
 	s := grpc.NewServer(opt...)
 	api.RegisterDgraphServer(s, &edgraph.Server{})
+	s.RegisterService(&dqlSubscriptionServiceDesc, &dqlSubscriptionServer{})
 	hapi.RegisterHealthServer(s, health.NewServer())
 	worker.RegisterZeroProxyServer(s)
 
@@ -498,6 +547,7 @@ func setupServer(closer *z.Closer) {
 
 	baseMux.HandleFunc("/query", queryHandler)
 	baseMux.HandleFunc("/query/", queryHandler)
+	baseMux.HandleFunc("/query/subscribe", dqlSubscriptionHandler())
 	baseMux.HandleFunc("/mutate", mutationHandler)
 	baseMux.HandleFunc("/mutate/", mutationHandler)
 	baseMux.HandleFunc("/commit", commitHandler)
@@ -582,6 +632,7 @@ func setupServer(closer *z.Closer) {
 		// TODO - Verify why do we do this and does it have to be done for all namespaces.
 		e = globalEpoch[x.GalaxyNamespace]
 		atomic.StoreUint64(e, math.MaxUint64)
+		atomic.StoreUint64(dqlSubs.epoch, math.MaxUint64)
 
 		// Stops grpc/http servers; Already accepted connections are not closed.
 		if err := grpcListener.Close(); err != nil {
@@ -662,6 +713,8 @@ func run() {
 		opts.HmacSecret = keys.AclKey
 		opts.AccessJwtTtl = keys.AclAccessTtl
 		opts.RefreshJwtTtl = keys.AclRefreshTtl
+		opts.AclJwtAlg = keys.AclJwtAlg
+		opts.AclOidc = keys.AclOidc
 		glog.Info("ACL secret key loaded successfully.")
 	}
 
@@ -709,6 +762,7 @@ func run() {
 		TLSClientConfig:     tlsClientConf,
 		TLSServerConfig:     tlsServerConf,
 		HmacSecret:          opts.HmacSecret,
+		AclJwtAlg:           opts.AclJwtAlg,
 		Audit:               opts.Audit != nil,
 		Badger:              bopts,
 	}
@@ -732,6 +786,12 @@ func run() {
 	x.Config.LimitNormalizeNode = int(x.Config.Limit.GetInt64("normalize-node"))
 	x.Config.QueryTimeout = x.Config.Limit.GetDuration("query-timeout")
 	x.Config.MaxRetries = x.Config.Limit.GetInt64("max-retries")
+	x.Config.LimitQueryUids = x.Config.Limit.GetUint64("query-uids")
+	x.Config.LimitQueryDepth = x.Config.Limit.GetUint64("query-depth")
+	x.Config.LimitQueryResultBytes = x.Config.Limit.GetUint64("query-result-bytes")
+	x.Config.LimitQueryMemoryBytes = x.Config.Limit.GetUint64("query-memory-bytes")
+	x.Config.PersistedQueriesOnly = security.GetBool("persisted-queries-only")
+	x.Config.QueryPlanCacheSize = int(cache.GetInt64("query-plans"))
 
 	x.Config.GraphQL = z.NewSuperFlag(Alpha.Conf.GetString("graphql")).MergeAndCheckDefault(
 		worker.GraphQLDefaults)
@@ -747,6 +807,10 @@ func run() {
 				graphqlLambdaUrl.String())
 			return
 		}
+		if x.Config.GraphQL.GetBool("lambda-embedded") {
+			glog.Errorf("--graphql lambda-url can't be used along with lambda-embedded")
+			return
+		}
 	}
 	edgraph.Init()
 
@@ -809,11 +873,16 @@ func run() {
 		}
 	}()
 
-	updaters := z.NewCloser(2)
+	updaters := z.NewCloser(6)
 	go func() {
 		worker.StartRaftNodes(worker.State.WALstore, bindall)
 		atomic.AddUint32(&initDone, 1)
 
+		go edgraph.SubscribeForPersistedQueryUpdates(updaters)
+		go edgraph.SubscribeForQueryLimitUpdates(updaters)
+		go edgraph.SubscribeForJwtKeyUpdates(updaters)
+		go edgraph.SubscribeForLambdaModuleUpdates(updaters)
+
 		// initialization of the admin account can only be done after raft nodes are running
 		// and health check passes
 		edgraph.InitializeAcl(updaters)
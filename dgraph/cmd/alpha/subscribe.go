@@ -0,0 +1,193 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alpha
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/dgraph-io/graphql-transport-ws/graphqlws"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/vtta/dgraph/edgraph"
+	"github.com/vtta/dgraph/graphql/subscription"
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+// dqlSubscriptions holds the pollers of the subscriptions to DQL queries, one per namespace.
+type dqlSubscriptions struct {
+	sync.Mutex
+	// epoch is set to math.MaxUint64 to terminate all the subscriptions when the server exits.
+	epoch   *uint64
+	pollers map[uint64]*subscription.Poller
+}
+
+var dqlSubs = &dqlSubscriptions{
+	epoch:   new(uint64),
+	pollers: make(map[uint64]*subscription.Poller),
+}
+
+func runDqlSubscription(ctx context.Context, req *api.Request) (*api.Response, error) {
+	return (&edgraph.Server{}).Query(ctx, req)
+}
+
+// subscribe subscribes to the result of the DQL query, run with the credentials in the header. It
+// returns the channel through which the results are sent, which is closed when the subscription
+// ends. The subscription ends when the context is done.
+func (ds *dqlSubscriptions) subscribe(ctx context.Context, req *api.Request,
+	header http.Header) (<-chan interface{}, error) {
+	ns := x.ExtractNamespaceHTTP(&http.Request{Header: header})
+	ds.Lock()
+	poller, ok := ds.pollers[ns]
+	if !ok {
+		poller = subscription.NewDqlPoller(ds.epoch, runDqlSubscription, ns, worker.WatchCommits)
+		ds.pollers[ns] = poller
+	}
+	ds.Unlock()
+
+	res, err := poller.AddDqlSubscriber(req, header)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		poller.TerminateSubscription(res.BucketID, res.SubscriptionID)
+	}()
+	return res.UpdateCh, nil
+}
+
+// dqlWsService serves the subscriptions to DQL queries over WebSocket, with the same protocol as
+// the GraphQL subscriptions. The query of a subscription is a DQL query, and its variables are the
+// variables of the query.
+type dqlWsService struct{}
+
+func (s *dqlWsService) Subscribe(ctx context.Context, document, operationName string,
+	variableValues map[string]interface{}) (<-chan interface{}, error) {
+	// The credentials can be given either as headers of the HTTP request or in the INIT payload.
+	header := http.Header{}
+	if reqHeader, ok := ctx.Value("RequestHeader").(http.Header); ok {
+		header = reqHeader.Clone()
+	}
+	if payload, _ := ctx.Value("Header").(json.RawMessage); len(payload) > 0 {
+		headers := make(map[string]interface{})
+		if err := json.Unmarshal(payload, &headers); err != nil {
+			return nil, err
+		}
+		for k, v := range headers {
+			if vStr, ok := v.(string); ok {
+				header.Set(k, vStr)
+			}
+		}
+	}
+
+	vars := make(map[string]string, len(variableValues))
+	for k, v := range variableValues {
+		if vStr, ok := v.(string); ok {
+			vars[k] = vStr
+			continue
+		}
+		js, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		vars[k] = string(js)
+	}
+	return dqlSubs.subscribe(ctx, &api.Request{Query: document, Vars: vars}, header)
+}
+
+// dqlSubscriptionHandler serves the subscriptions to DQL queries at /query/subscribe.
+func dqlSubscriptionHandler() http.HandlerFunc {
+	return graphqlws.NewHandlerFunc(&dqlWsService{},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			x.AddCorsHeaders(w)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			x.SetStatus(w, x.ErrorInvalidRequest,
+				"Subscriptions are served over WebSocket, with the graphql-ws protocol")
+		}))
+}
+
+// subscriptionHeaders maps the gRPC metadata of the subscriptions to the HTTP headers that carry
+// the same over WebSocket.
+var subscriptionHeaders = map[string]string{
+	"accessjwt":            "X-Dgraph-AccessToken",
+	"apikey":               "X-Dgraph-ApiKey",
+	"x-dgraph-incremental": subscription.IncrementalHeader,
+}
+
+// dqlSubscriber is the server of the streaming subscriptions to DQL queries over gRPC.
+type dqlSubscriber interface {
+	Subscribe(req *api.Request, stream grpc.ServerStream) error
+}
+
+type dqlSubscriptionServer struct{}
+
+// Subscribe sends the result of the query in the request, then every new result of it, as the
+// Json of the responses streamed back. It runs until the client cancels the call.
+func (s *dqlSubscriptionServer) Subscribe(req *api.Request, stream grpc.ServerStream) error {
+	header := http.Header{}
+	if md, ok := metadata.FromIncomingContext(stream.Context()); ok {
+		for key, name := range subscriptionHeaders {
+			if values := md.Get(key); len(values) > 0 {
+				header.Set(name, values[0])
+			}
+		}
+	}
+
+	updates, err := dqlSubs.subscribe(stream.Context(), req, header)
+	if err != nil {
+		return err
+	}
+	for update := range updates {
+		js, err := json.Marshal(update)
+		if err != nil {
+			return err
+		}
+		if err := stream.SendMsg(&api.Response{Json: js}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dqlSubscriptionServiceDesc describes the dgraph.Subscriptions gRPC service, whose Subscribe
+// method streams the results of a DQL query. Its messages are the api.Request and api.Response
+// messages of the Dgraph service, so clients call it with grpc.ClientConn.NewStream.
+var dqlSubscriptionServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dgraph.Subscriptions",
+	HandlerType: (*dqlSubscriber)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Subscribe",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := &api.Request{}
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(dqlSubscriber).Subscribe(req, stream)
+			},
+			ServerStreams: true,
+		},
+	},
+	Metadata: "dgraph.Subscriptions",
+}
@@ -125,6 +125,11 @@ func (s *Server) authenticateLogin(ctx context.Context, request *api.LoginReques
 
 	var user *acl.User
 	if len(request.RefreshToken) > 0 {
+		if isOidcToken(request.RefreshToken) {
+			// The users of the identity provider are authenticated by it, and use its tokens as
+			// access tokens.
+			return nil, errors.Errorf("unable to authenticate: invalid refresh token")
+		}
 		userData, err := validateToken(request.RefreshToken)
 		if err != nil {
 			return nil, errors.Wrapf(err, "unable to authenticate the refresh token %v",
@@ -173,6 +178,8 @@ type userData struct {
 	namespace uint64
 	userId    string
 	groupIds  []string
+	// scope is the scope of the API key that the token has been issued for, if any.
+	scope *ApiKeyScope
 }
 
 // validateToken verifies the signature and expiration of the jwt, and if validation passes,
@@ -226,7 +233,13 @@ func validateToken(jwtStr string) (*userData, error) {
 			groupIds = append(groupIds, groupId)
 		}
 	}
-	return &userData{namespace: uint64(namespace), userId: userId, groupIds: groupIds}, nil
+
+	scope, err := scopeFromClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+	return &userData{namespace: uint64(namespace), userId: userId, groupIds: groupIds,
+		scope: scope}, nil
 }
 
 // validateLoginRequest validates that the login request has either the refresh token or the
@@ -253,35 +266,23 @@ func validateLoginRequest(request *api.LoginRequest) error {
 // getAccessJwt constructs an access jwt with the given user id, groupIds, namespace
 // and expiration TTL specified by worker.Config.AccessJwtTtl
 func getAccessJwt(userId string, groups []acl.Group, namespace uint64) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	return signJwt(jwt.MapClaims{
 		"userid":    userId,
 		"groups":    acl.GetGroupIDs(groups),
 		"namespace": namespace,
 		// set the jwt exp according to the ttl
 		"exp": time.Now().Add(worker.Config.AccessJwtTtl).Unix(),
 	})
-
-	jwtString, err := token.SignedString([]byte(worker.Config.HmacSecret))
-	if err != nil {
-		return "", errors.Errorf("unable to encode jwt to string: %v", err)
-	}
-	return jwtString, nil
 }
 
 // getRefreshJwt constructs a refresh jwt with the given user id, namespace and expiration ttl
 // specified by worker.Config.RefreshJwtTtl
 func getRefreshJwt(userId string, namespace uint64) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	return signJwt(jwt.MapClaims{
 		"userid":    userId,
 		"namespace": namespace,
 		"exp":       time.Now().Add(worker.Config.RefreshJwtTtl).Unix(),
 	})
-
-	jwtString, err := token.SignedString([]byte(worker.Config.HmacSecret))
-	if err != nil {
-		return "", errors.Errorf("unable to encode jwt to string: %v", err)
-	}
-	return jwtString, nil
 }
 
 const queryUser = `
@@ -391,6 +392,9 @@ func SubscribeForAclUpdates(closer *z.Closer) {
 			glog.Fatalf("Got a key from subscription which is not parsable: %s", err)
 		}
 		glog.V(3).Infof("Got ACL update via subscription for attr: %s", pk.Attr)
+		// The users, groups and API keys that the cached API key JWTs were minted for may have
+		// changed.
+		apiKeyJwts.reset()
 
 		ns, _ := x.ParseNamespaceAttr(pk.Attr)
 		if err := retrieveAcls(ns, kv.GetVersion()); err != nil {
@@ -409,6 +413,10 @@ const queryAcls = `
 		dgraph.rule.predicate
 		dgraph.rule.permission
 	}
+	dgraph.acl.node_rule {
+		dgraph.rule.type
+		dgraph.rule.filter
+	}
 	~dgraph.user.group{
 		dgraph.xid
 	}
@@ -420,9 +428,17 @@ var aclPrefixes = [][]byte{
 	x.PredicatePrefix(x.GalaxyAttr("dgraph.rule.permission")),
 	x.PredicatePrefix(x.GalaxyAttr("dgraph.rule.predicate")),
 	x.PredicatePrefix(x.GalaxyAttr("dgraph.acl.rule")),
+	x.PredicatePrefix(x.GalaxyAttr("dgraph.acl.node_rule")),
+	x.PredicatePrefix(x.GalaxyAttr("dgraph.rule.type")),
+	x.PredicatePrefix(x.GalaxyAttr("dgraph.rule.filter")),
 	x.PredicatePrefix(x.GalaxyAttr("dgraph.user.group")),
 	x.PredicatePrefix(x.GalaxyAttr("dgraph.type.Group")),
 	x.PredicatePrefix(x.GalaxyAttr("dgraph.xid")),
+	x.PredicatePrefix(x.GalaxyAttr("dgraph.api_key.id")),
+	x.PredicatePrefix(x.GalaxyAttr("dgraph.api_key.secret")),
+	x.PredicatePrefix(x.GalaxyAttr("dgraph.api_key.owner")),
+	x.PredicatePrefix(x.GalaxyAttr("dgraph.api_key.expiry")),
+	x.PredicatePrefix(x.GalaxyAttr("dgraph.api_key.scope")),
 }
 
 // upserts the Groot account.
@@ -466,6 +482,18 @@ func InitializeAcl(closer *z.Closer) {
 	for ns := range schema.State().Namespaces() {
 		upsertGuardianAndGroot(ns)
 	}
+
+	for closer.Ctx().Err() == nil {
+		ctx, cancel := context.WithTimeout(closer.Ctx(), time.Minute)
+		err := initializeJwtKeys(ctx)
+		cancel()
+		if err != nil {
+			glog.Infof("Unable to initialize the JWT keys. Error: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		break
+	}
 }
 
 // upsertGuardian must be called after setting the namespace in the context.
@@ -622,6 +650,32 @@ func extractUserAndGroups(ctx context.Context) (*userData, error) {
 	return validateToken(accessJwt)
 }
 
+// aclUser returns the user making the request. It returns an empty string if ACL isn't enabled or
+// the request doesn't carry a valid access JWT.
+func aclUser(ctx context.Context) string {
+	if len(worker.Config.HmacSecret) == 0 {
+		return ""
+	}
+	userData, err := extractUserAndGroups(ctx)
+	if err != nil {
+		return ""
+	}
+	return userData.userId
+}
+
+// aclGroups returns the groups of the user making the request. It returns nil if ACL isn't
+// enabled or the request doesn't carry a valid access JWT.
+func aclGroups(ctx context.Context) []string {
+	if len(worker.Config.HmacSecret) == 0 {
+		return nil
+	}
+	userData, err := extractUserAndGroups(ctx)
+	if err != nil {
+		return nil
+	}
+	return userData.groupIds
+}
+
 type authPredResult struct {
 	allowed []string
 	blocked map[string]struct{}
@@ -655,21 +709,32 @@ func authorizePreds(ctx context.Context, userData *userData, preds []string,
 			blockedPreds[pred] = struct{}{}
 		}
 	}
+	for pred := range userData.scope.blocked(preds) {
+		blockedPreds[pred] = struct{}{}
+	}
 
 	if worker.HasAccessToAllPreds(ns, groupIds, aclOp) {
 		// Setting allowed to nil allows access to all predicates. Note that the access to ACL
 		// predicates will still be blocked.
-		return &authPredResult{allowed: nil, blocked: blockedPreds}, nil
+		return &authPredResult{allowed: userData.scope.restrict(ns, nil), blocked: blockedPreds},
+			nil
+	}
+	userPerms := worker.AclCachePtr.GetUserPredPerms(userId)
+	if userPerms == nil {
+		// The users that aren't in the cluster, like the ones of the tokens issued by an identity
+		// provider or for the API keys owned by a group, get the permissions of their groups.
+		userPerms = worker.AclCachePtr.GetGroupsPredPerms(ns, groupIds)
 	}
 	// User can have multiple permission for same predicate, add predicate
-	allowedPreds := make([]string, 0, len(worker.AclCachePtr.GetUserPredPerms(userId)))
+	allowedPreds := make([]string, 0, len(userPerms))
 	// only if the acl.Op is covered in the set of permissions for the user
-	for predicate, perm := range worker.AclCachePtr.GetUserPredPerms(userId) {
+	for predicate, perm := range userPerms {
 		if (perm & aclOp.Code) > 0 {
 			allowedPreds = append(allowedPreds, predicate)
 		}
 	}
-	return &authPredResult{allowed: allowedPreds, blocked: blockedPreds}, nil
+	return &authPredResult{allowed: userData.scope.restrict(ns, allowedPreds),
+		blocked: blockedPreds}, nil
 }
 
 // authorizeAlter parses the Schema in the operation and authorizes the operation
@@ -713,6 +778,14 @@ func authorizeAlter(ctx context.Context, op *api.Operation) error {
 		userId = userData.userId
 		groupIds = userData.groupIds
 
+		if userData.scope != nil && (isDropAll(op) || op.DropOp == api.Operation_DATA) {
+			return status.Errorf(codes.PermissionDenied,
+				"API keys with a scope are not allowed to drop all data")
+		}
+		if err := userData.scope.authorizeWrite(preds, "alter"); err != nil {
+			return err
+		}
+
 		if x.IsGuardian(groupIds) {
 			// Members of guardian group are allowed to alter anything.
 			return nil
@@ -827,6 +900,10 @@ func authorizeMutation(ctx context.Context, gmu *gql.Mutation) error {
 		userId = userData.userId
 		groupIds = userData.groupIds
 
+		if err := userData.scope.authorizeWrite(preds, "mutate"); err != nil {
+			return err
+		}
+
 		if x.IsGuardian(groupIds) {
 			// Members of guardians group are allowed to mutate anything
 			// (including delete) except the permission of the acl predicates.
@@ -836,6 +913,7 @@ func authorizeMutation(ctx context.Context, gmu *gql.Mutation) error {
 			case isAclPredMutation(gmu.Del):
 				return errors.Errorf("ACL predicates can't be deleted")
 			}
+			gmu.AllowedPreds = userData.scope.restrict(userData.namespace, nil)
 			return nil
 		}
 		result, err := authorizePreds(ctx, userData, preds, acl.Write)
@@ -958,8 +1036,21 @@ func authorizeQuery(ctx context.Context, parsedReq *gql.Result, graphql bool) er
 		return nil
 	}
 
-	var userId string
-	var groupIds []string
+	userData, err := extractUserAndGroups(ctx)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	_, _, err = authorizeQueryFor(ctx, userData, parsedReq, graphql)
+	return err
+}
+
+// authorizeQueryFor drops the predicates that the given user isn't allowed to read from the query,
+// and returns them along with the query variables dropped because they are defined by them.
+func authorizeQueryFor(ctx context.Context, userData *userData, parsedReq *gql.Result,
+	graphql bool) (map[string]struct{}, map[string]struct{}, error) {
+
+	userId := userData.userId
+	groupIds := userData.groupIds
 	predsAndvars := parsePredsFromQuery(parsedReq.Query)
 	preds := predsAndvars.preds
 	varsToPredMap := predsAndvars.vars
@@ -972,17 +1063,11 @@ func authorizeQuery(ctx context.Context, parsedReq *gql.Result, graphql bool) er
 	}
 
 	doAuthorizeQuery := func() (map[string]struct{}, []string, error) {
-		userData, err := extractUserAndGroups(ctx)
-		if err != nil {
-			return nil, nil, status.Error(codes.Unauthenticated, err.Error())
-		}
-
-		userId = userData.userId
-		groupIds = userData.groupIds
-
 		if x.IsGuardian(groupIds) {
-			// Members of guardian groups are allowed to query anything.
-			return nil, nil, nil
+			// Members of guardian groups are allowed to query anything, within the scope of their
+			// API key if they use one.
+			return userData.scope.blocked(preds), userData.scope.restrict(userData.namespace, nil),
+				nil
 		}
 
 		result, err := authorizePreds(ctx, userData, preds, acl.Read)
@@ -991,7 +1076,7 @@ func authorizeQuery(ctx context.Context, parsedReq *gql.Result, graphql bool) er
 
 	blockedPreds, allowedPreds, err := doAuthorizeQuery()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	if span := otrace.FromContext(ctx); span != nil {
@@ -1004,6 +1089,7 @@ func authorizeQuery(ctx context.Context, parsedReq *gql.Result, graphql bool) er
 		}).String())
 	}
 
+	blockedVars := make(map[string]struct{})
 	if len(blockedPreds) != 0 {
 		// For GraphQL requests, we allow filtered access to the ACL predicates.
 		// Filter for user_id and group_id is applied for the currently logged in user.
@@ -1020,7 +1106,6 @@ func authorizeQuery(ctx context.Context, parsedReq *gql.Result, graphql bool) er
 			delete(blockedPreds, "~dgraph.user.group")
 		}
 
-		blockedVars := make(map[string]struct{})
 		for predicate := range blockedPreds {
 			if variable, found := predToVarsMap[predicate]; found {
 				// Add variables to blockedPreds to delete from Query
@@ -1036,7 +1121,7 @@ func authorizeQuery(ctx context.Context, parsedReq *gql.Result, graphql bool) er
 		parsedReq.Query[i].AllowedPreds = allowedPreds
 	}
 
-	return nil
+	return blockedPreds, blockedVars, nil
 }
 
 func authorizeSchemaQuery(ctx context.Context, er *query.ExecutionResult) error {
@@ -1068,8 +1153,9 @@ func authorizeSchemaQuery(ctx context.Context, er *query.ExecutionResult) error
 
 		groupIds := userData.groupIds
 		if x.IsGuardian(groupIds) {
-			// Members of guardian groups are allowed to query anything.
-			return nil, nil
+			// Members of guardian groups are allowed to query anything, within the scope of their
+			// API key if they use one.
+			return userData.scope.blocked(preds), nil
 		}
 		result, err := authorizePreds(ctx, userData, preds, acl.Read)
 		return result.blocked, err
@@ -1156,6 +1242,11 @@ func AuthorizeGuardians(ctx context.Context) error {
 			return status.Error(codes.PermissionDenied, fmt.Sprintf("Only guardians are "+
 				"allowed access. User '%v' is not a member of guardians group.", userId))
 		}
+		if userData.scope != nil {
+			// The scope of an API key restricts it to the data, whoever it is issued for.
+			return status.Error(codes.PermissionDenied,
+				"API keys with a scope are not allowed to do this operation")
+		}
 	}
 
 	return nil
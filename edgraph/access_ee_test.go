@@ -53,9 +53,9 @@ func sliceCompare(x, y []string) bool {
 func TestValidateToken(t *testing.T) {
 	expiry := time.Now().Add(time.Minute * 30).Unix()
 	userDataList := []userData{
-		{1234567890, "user1", []string{"701", "702"}},
-		{2345678901, "user2", []string{"703", "701"}},
-		{3456789012, "user3", []string{"702", "703"}},
+		{1234567890, "user1", []string{"701", "702"}, nil},
+		{2345678901, "user2", []string{"703", "701"}, nil},
+		{3456789012, "user3", []string{"702", "703"}, nil},
 	}
 
 	for _, userdata := range userDataList {
@@ -93,9 +93,9 @@ func TestGetAccessJwt(t *testing.T) {
 
 	g := acl.GetGroupIDs(grpLst)
 	userDataList := []userData{
-		{1234567890, "user1", []string{"701", "702"}},
-		{2345678901, "user2", []string{"703", "701"}},
-		{3456789012, "user3", []string{"702", "703"}},
+		{1234567890, "user1", []string{"701", "702"}, nil},
+		{2345678901, "user2", []string{"703", "701"}, nil},
+		{3456789012, "user3", []string{"702", "703"}, nil},
 	}
 
 	for _, userdata := range userDataList {
@@ -111,9 +111,9 @@ func TestGetAccessJwt(t *testing.T) {
 
 func TestGetRefreshJwt(t *testing.T) {
 	userDataList := []userData{
-		{1234567890, "user1", []string{"701", "702"}},
-		{2345678901, "user2", []string{"703", "701"}},
-		{3456789012, "user3", []string{"702", "703"}},
+		{1234567890, "user1", []string{"701", "702"}, nil},
+		{2345678901, "user2", []string{"703", "701"}, nil},
+		{3456789012, "user3", []string{"702", "703"}, nil},
 	}
 
 	for _, userdata := range userDataList {
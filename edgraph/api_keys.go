@@ -0,0 +1,58 @@
+// +build oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"time"
+
+	"github.com/vtta/dgraph/x"
+)
+
+type ApiKeyScope struct {
+	ReadOnly   bool
+	Predicates []string
+}
+
+type ApiKey struct {
+	Id        string
+	User      string
+	Group     string
+	ExpiresAt time.Time
+	Scope     *ApiKeyScope
+}
+
+type ApiKeyInput struct {
+	User      string
+	Group     string
+	ExpiresAt time.Time
+	Scope     *ApiKeyScope
+}
+
+func CreateApiKey(ctx context.Context, input *ApiKeyInput) (string, *ApiKey, error) {
+	return "", nil, x.ErrNotSupported
+}
+
+func ListApiKeys(ctx context.Context) ([]*ApiKey, error) {
+	return nil, x.ErrNotSupported
+}
+
+func RevokeApiKey(ctx context.Context, id string) error {
+	return x.ErrNotSupported
+}
@@ -0,0 +1,565 @@
+//go:build !oss
+// +build !oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. All rights reserved.
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/vtta/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package edgraph
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+const (
+	// apiKeyPrefix starts the API keys, which look like dgk.<namespace>.<id>.<secret>.
+	apiKeyPrefix = "dgk"
+	// apiKeyCacheTtl is how long the access JWT minted for an API key is reused for. The cache is
+	// also reset whenever the ACL predicates change, so that revoked keys stop working at once.
+	apiKeyCacheTtl = time.Minute
+	// maxCachedApiKeys bounds the number of API keys that the access JWTs are cached for.
+	maxCachedApiKeys = 1024
+)
+
+func init() {
+	x.ApiKeyJwt = apiKeyJwt
+}
+
+// ApiKeyScope restricts what can be done with an API key, on top of the ACL rules of the groups
+// that it is issued for.
+type ApiKeyScope struct {
+	// ReadOnly keys can't be used to alter the schema or to mutate data.
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Predicates, if set, are the only predicates that the key can be used to access.
+	Predicates []string `json:"predicates,omitempty"`
+}
+
+// ApiKey describes an API key, without its secret.
+type ApiKey struct {
+	Id string
+	// Exactly one of User and Group is set, to the ACL user or group that the key is issued for.
+	User  string
+	Group string
+	// ExpiresAt is zero if the key doesn't expire.
+	ExpiresAt time.Time
+	Scope     *ApiKeyScope
+}
+
+// ApiKeyInput is the input to CreateApiKey.
+type ApiKeyInput struct {
+	User      string
+	Group     string
+	ExpiresAt time.Time
+	Scope     *ApiKeyScope
+}
+
+// scopeFromClaims returns the scope of the API key that a JWT has been issued for, or nil if it
+// hasn't been issued for one.
+func scopeFromClaims(claims jwt.MapClaims) (*ApiKeyScope, error) {
+	claim, ok := claims["scope"]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(claim)
+	if err != nil {
+		return nil, errors.Errorf("scope in claims is not valid:%v", claim)
+	}
+	var scope ApiKeyScope
+	if err := json.Unmarshal(data, &scope); err != nil {
+		return nil, errors.Errorf("scope in claims is not valid:%v", claim)
+	}
+	return &scope, nil
+}
+
+func (s *ApiKeyScope) isEmpty() bool {
+	return s == nil || (!s.ReadOnly && len(s.Predicates) == 0)
+}
+
+func (s *ApiKeyScope) allows(pred string) bool {
+	if s == nil || len(s.Predicates) == 0 || pred == "dgraph.type" {
+		return true
+	}
+	for _, p := range s.Predicates {
+		if p == pred {
+			return true
+		}
+	}
+	return false
+}
+
+// blocked returns the predicates that aren't in the scope.
+func (s *ApiKeyScope) blocked(preds []string) map[string]struct{} {
+	blocked := make(map[string]struct{})
+	for _, pred := range preds {
+		if !s.allows(strings.TrimPrefix(pred, "~")) {
+			blocked[pred] = struct{}{}
+		}
+	}
+	return blocked
+}
+
+// restrict restricts the predicates of the namespace allowed by the ACL rules, nil meaning all of
+// them, to the ones in the scope.
+func (s *ApiKeyScope) restrict(ns uint64, allowed []string) []string {
+	if s == nil || len(s.Predicates) == 0 {
+		return allowed
+	}
+	restricted := make([]string, 0, len(s.Predicates))
+	if allowed == nil {
+		for _, pred := range s.Predicates {
+			restricted = append(restricted, x.NamespaceAttr(ns, pred))
+		}
+		return restricted
+	}
+	for _, pred := range allowed {
+		if s.allows(x.ParseAttr(pred)) {
+			restricted = append(restricted, pred)
+		}
+	}
+	return restricted
+}
+
+// authorizeWrite returns an error if the predicates can't be written with the API key.
+func (s *ApiKeyScope) authorizeWrite(preds []string, op string) error {
+	if s == nil {
+		return nil
+	}
+	if s.ReadOnly {
+		return status.Errorf(codes.PermissionDenied, "unauthorized to %s with a read-only API key",
+			op)
+	}
+	if blocked := s.blocked(preds); len(blocked) > 0 {
+		var msg strings.Builder
+		for key := range blocked {
+			x.Check2(msg.WriteString(key))
+			x.Check2(msg.WriteString(" "))
+		}
+		return status.Errorf(codes.PermissionDenied,
+			"unauthorized to %s following predicates: %s\n", op, msg.String())
+	}
+	return nil
+}
+
+// parseApiKey splits an API key into the namespace it belongs to, its id and its secret.
+func parseApiKey(apiKey string) (uint64, string, string, error) {
+	parts := strings.Split(apiKey, ".")
+	if len(parts) != 4 || parts[0] != apiKeyPrefix || parts[2] == "" || parts[3] == "" {
+		return 0, "", "", errors.Errorf("invalid API key")
+	}
+	ns, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, "", "", errors.Errorf("invalid API key")
+	}
+	return ns, parts[2], parts[3], nil
+}
+
+func formatApiKey(ns uint64, id, secret string) string {
+	return strings.Join([]string{apiKeyPrefix, strconv.FormatUint(ns, 10), id, secret}, ".")
+}
+
+type cachedApiKeyJwt struct {
+	jwt    string
+	expiry time.Time
+}
+
+// apiKeyJwtCache caches the access JWTs minted for the API keys by the hash of the keys, so that
+// the keys don't have to be looked up for every request.
+type apiKeyJwtCache struct {
+	sync.Mutex
+	jwts map[[sha256.Size]byte]cachedApiKeyJwt
+}
+
+var apiKeyJwts = &apiKeyJwtCache{}
+
+func (c *apiKeyJwtCache) get(hash [sha256.Size]byte) (string, bool) {
+	c.Lock()
+	defer c.Unlock()
+	cached, ok := c.jwts[hash]
+	if !ok || !time.Now().Before(cached.expiry) {
+		return "", false
+	}
+	return cached.jwt, true
+}
+
+func (c *apiKeyJwtCache) set(hash [sha256.Size]byte, jwt string, expiry time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	if c.jwts == nil || len(c.jwts) >= maxCachedApiKeys {
+		c.jwts = make(map[[sha256.Size]byte]cachedApiKeyJwt)
+	}
+	c.jwts[hash] = cachedApiKeyJwt{jwt: jwt, expiry: expiry}
+}
+
+func (c *apiKeyJwtCache) reset() {
+	c.Lock()
+	defer c.Unlock()
+	c.jwts = nil
+}
+
+const queryApiKey = `
+	query search($id: string, $secret: string){
+	  key(func: eq(dgraph.api_key.id, $id)) @filter(type(dgraph.type.ApiKey)) {
+	    dgraph.api_key.id
+	    secret_match: checkpwd(dgraph.api_key.secret, $secret)
+	    dgraph.api_key.expiry
+	    dgraph.api_key.scope
+	    dgraph.api_key.owner {
+	      dgraph.xid
+	      dgraph.type
+	      dgraph.user.group {
+	        dgraph.xid
+	      }
+	    }
+	  }
+	}`
+
+type apiKeyNode struct {
+	Uid         string    `json:"uid"`
+	Id          string    `json:"dgraph.api_key.id"`
+	SecretMatch bool      `json:"secret_match"`
+	Expiry      time.Time `json:"dgraph.api_key.expiry"`
+	Scope       string    `json:"dgraph.api_key.scope"`
+	Owner       *struct {
+		Xid    string   `json:"dgraph.xid"`
+		Types  []string `json:"dgraph.type"`
+		Groups []struct {
+			Xid string `json:"dgraph.xid"`
+		} `json:"dgraph.user.group"`
+	} `json:"dgraph.api_key.owner"`
+}
+
+func (n *apiKeyNode) toApiKey() (*ApiKey, error) {
+	k := &ApiKey{Id: n.Id, ExpiresAt: n.Expiry}
+	if n.Scope != "" {
+		k.Scope = &ApiKeyScope{}
+		if err := json.Unmarshal([]byte(n.Scope), k.Scope); err != nil {
+			return nil, errors.Wrapf(err, "while unmarshalling the scope of API key %s", n.Id)
+		}
+	}
+	if n.Owner == nil || n.Owner.Xid == "" {
+		return nil, errors.Errorf("the owner of API key %s no longer exists", n.Id)
+	}
+	for _, typ := range n.Owner.Types {
+		switch typ {
+		case "dgraph.type.User":
+			k.User = n.Owner.Xid
+		case "dgraph.type.Group":
+			k.Group = n.Owner.Xid
+		}
+	}
+	if k.User == "" && k.Group == "" {
+		return nil, errors.Errorf("the owner of API key %s is neither a user nor a group", n.Id)
+	}
+	return k, nil
+}
+
+// apiKeyJwt returns an access JWT for the user or the group that the API key is issued for. The
+// JWT expires when the key does, and carries the scope of the key.
+func apiKeyJwt(apiKey string) (string, error) {
+	if len(worker.Config.HmacSecret) == 0 {
+		return "", errors.Errorf("API keys can only be used if ACL is enabled")
+	}
+	hash := sha256.Sum256([]byte(apiKey))
+	if token, ok := apiKeyJwts.get(hash); ok {
+		return token, nil
+	}
+
+	ns, id, secret, err := parseApiKey(apiKey)
+	if err != nil {
+		return "", err
+	}
+	req := &Request{
+		req: &api.Request{
+			Query: queryApiKey,
+			Vars:  map[string]string{"$id": id, "$secret": secret},
+		},
+		doAuth: NoAuthorize,
+	}
+	resp, err := (&Server{}).doQuery(x.AttachNamespace(context.Background(), ns), req)
+	if err != nil {
+		glog.Errorf("Error while querying API key %s: %v", id, err)
+		return "", err
+	}
+	var res struct {
+		Key []*apiKeyNode `json:"key"`
+	}
+	if err := json.Unmarshal(resp.GetJson(), &res); err != nil {
+		return "", err
+	}
+	if len(res.Key) == 0 || !res.Key[0].SecretMatch {
+		return "", errors.Errorf("invalid API key")
+	}
+	n := res.Key[0]
+	k, err := n.toApiKey()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	exp := now.Add(worker.Config.AccessJwtTtl)
+	if !k.ExpiresAt.IsZero() {
+		if !now.Before(k.ExpiresAt) {
+			return "", errors.Errorf("API key %s has expired", k.Id)
+		}
+		if k.ExpiresAt.Before(exp) {
+			exp = k.ExpiresAt
+		}
+	}
+	userId, groups := k.User, []string{k.Group}
+	if k.User == "" {
+		// The key acts as a member of the group only.
+		userId = "apikey:" + k.Id
+	} else {
+		groups = groups[:0]
+		for _, g := range n.Owner.Groups {
+			groups = append(groups, g.Xid)
+		}
+	}
+	claims := jwt.MapClaims{
+		"userid":    userId,
+		"groups":    groups,
+		"namespace": ns,
+		"exp":       exp.Unix(),
+	}
+	if !k.Scope.isEmpty() {
+		claims["scope"] = k.Scope
+	}
+	token, err := signJwt(claims)
+	if err != nil {
+		return "", err
+	}
+
+	cacheExpiry := time.Unix(exp.Unix(), 0)
+	if now.Add(apiKeyCacheTtl).Before(cacheExpiry) {
+		cacheExpiry = now.Add(apiKeyCacheTtl)
+	}
+	apiKeyJwts.set(hash, token, cacheExpiry)
+	return token, nil
+}
+
+const queryAclOwner = `
+	query search($xid: string){
+	  owner(func: eq(dgraph.xid, $xid)) @filter(type(%s)) {
+	    uid
+	  }
+	}`
+
+// CreateApiKey creates an API key for an ACL user or group of the namespace of the request, and
+// returns the key along with its description. The key can't be retrieved afterwards.
+func CreateApiKey(ctx context.Context, input *ApiKeyInput) (string, *ApiKey, error) {
+	if len(worker.Config.HmacSecret) == 0 {
+		return "", nil, errors.Errorf("ACL must be enabled to create API keys")
+	}
+	if (input.User == "") == (input.Group == "") {
+		return "", nil, errors.Errorf("exactly one of user and group must be given")
+	}
+	if !input.ExpiresAt.IsZero() && !input.ExpiresAt.After(time.Now()) {
+		return "", nil, errors.Errorf("expiry must be in the future, got %s",
+			input.ExpiresAt.Format(time.RFC3339))
+	}
+	ns, err := x.ExtractNamespace(ctx)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "while creating API key")
+	}
+
+	ownerXid, ownerType := input.User, "dgraph.type.User"
+	if input.Group != "" {
+		ownerXid, ownerType = input.Group, "dgraph.type.Group"
+	}
+	req := &Request{
+		req: &api.Request{
+			Query: fmt.Sprintf(queryAclOwner, ownerType),
+			Vars:  map[string]string{"$xid": ownerXid},
+		},
+		doAuth: NoAuthorize,
+	}
+	resp, err := (&Server{}).doQuery(ctx, req)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "while querying the owner of the API key")
+	}
+	var res struct {
+		Owner []struct {
+			Uid string `json:"uid"`
+		} `json:"owner"`
+	}
+	if err := json.Unmarshal(resp.GetJson(), &res); err != nil {
+		return "", nil, err
+	}
+	if len(res.Owner) == 0 {
+		return "", nil, errors.Errorf("%s %q doesn't exist",
+			strings.ToLower(strings.TrimPrefix(ownerType, "dgraph.type.")), ownerXid)
+	}
+
+	idBytes := make([]byte, 8)
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", nil, err
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", nil, err
+	}
+	id := hex.EncodeToString(idBytes)
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	strVal := func(val string) *api.Value {
+		return &api.Value{Val: &api.Value_StrVal{StrVal: val}}
+	}
+	mu := &api.Mutation{Set: []*api.NQuad{
+		{Subject: "_:key", Predicate: "dgraph.type", ObjectValue: strVal("dgraph.type.ApiKey")},
+		{Subject: "_:key", Predicate: "dgraph.api_key.id", ObjectValue: strVal(id)},
+		{Subject: "_:key", Predicate: "dgraph.api_key.secret", ObjectValue: strVal(secret)},
+		{Subject: "_:key", Predicate: "dgraph.api_key.owner", ObjectId: res.Owner[0].Uid},
+	}}
+	if !input.ExpiresAt.IsZero() {
+		mu.Set = append(mu.Set, &api.NQuad{Subject: "_:key", Predicate: "dgraph.api_key.expiry",
+			ObjectValue: strVal(input.ExpiresAt.Format(time.RFC3339))})
+	}
+	scope := input.Scope
+	if scope.isEmpty() {
+		scope = nil
+	} else {
+		data, err := json.Marshal(scope)
+		if err != nil {
+			return "", nil, err
+		}
+		mu.Set = append(mu.Set, &api.NQuad{Subject: "_:key", Predicate: "dgraph.api_key.scope",
+			ObjectValue: strVal(string(data))})
+	}
+	req = &Request{
+		req: &api.Request{
+			Mutations: []*api.Mutation{mu},
+			StartTs:   resp.GetTxn().GetStartTs(),
+			CommitNow: true,
+		},
+		doAuth: NoAuthorize,
+	}
+	if _, err := (&Server{}).doQuery(context.WithValue(ctx, IsGraphql, true), req); err != nil {
+		return "", nil, errors.Wrapf(err, "while storing API key")
+	}
+
+	glog.Infof("Created API key %s for %s %s in namespace %#x", id, ownerType, ownerXid, ns)
+	return formatApiKey(ns, id, secret), &ApiKey{Id: id, User: input.User, Group: input.Group,
+		ExpiresAt: input.ExpiresAt, Scope: scope}, nil
+}
+
+const queryApiKeys = `
+	{
+	  keys(func: type(dgraph.type.ApiKey)) {
+	    uid
+	    dgraph.api_key.id
+	    dgraph.api_key.expiry
+	    dgraph.api_key.scope
+	    dgraph.api_key.owner {
+	      dgraph.xid
+	      dgraph.type
+	    }
+	  }
+	}`
+
+func getApiKeyNodes(ctx context.Context) ([]*apiKeyNode, *api.Response, error) {
+	if len(worker.Config.HmacSecret) == 0 {
+		return nil, nil, errors.Errorf("ACL must be enabled to manage API keys")
+	}
+	req := &Request{
+		req: &api.Request{
+			Query: queryApiKeys,
+		},
+		doAuth: NoAuthorize,
+	}
+	resp, err := (&Server{}).doQuery(ctx, req)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "while querying API keys")
+	}
+	var res struct {
+		Keys []*apiKeyNode `json:"keys"`
+	}
+	if err := json.Unmarshal(resp.GetJson(), &res); err != nil {
+		return nil, nil, err
+	}
+	return res.Keys, resp, nil
+}
+
+// ListApiKeys returns the API keys of the namespace of the request.
+func ListApiKeys(ctx context.Context) ([]*ApiKey, error) {
+	nodes, _, err := getApiKeyNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*ApiKey, 0, len(nodes))
+	for _, n := range nodes {
+		k, err := n.toApiKey()
+		if err != nil {
+			// Keep listing the keys whose owner has been deleted, so that they can be revoked.
+			glog.Warningf("Listing API key without its owner: %v", err)
+			k = &ApiKey{Id: n.Id, ExpiresAt: n.Expiry}
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// RevokeApiKey deletes the API key with the given id from the namespace of the request. The key
+// stops working on this alpha at once, and on the others once they get the ACL update.
+func RevokeApiKey(ctx context.Context, id string) error {
+	nodes, resp, err := getApiKeyNodes(ctx)
+	if err != nil {
+		return err
+	}
+	var uid string
+	for _, n := range nodes {
+		if n.Id == id {
+			uid = n.Uid
+			break
+		}
+	}
+	if uid == "" {
+		return errors.Errorf("API key %q doesn't exist", id)
+	}
+
+	req := &Request{
+		req: &api.Request{
+			Mutations: []*api.Mutation{{
+				Del: []*api.NQuad{{
+					Subject:     uid,
+					Predicate:   x.Star,
+					ObjectValue: &api.Value{Val: &api.Value_DefaultVal{DefaultVal: x.Star}},
+				}},
+			}},
+			StartTs:   resp.GetTxn().GetStartTs(),
+			CommitNow: true,
+		},
+		doAuth: NoAuthorize,
+	}
+	if _, err := (&Server{}).doQuery(context.WithValue(ctx, IsGraphql, true), req); err != nil {
+		return errors.Wrapf(err, "while revoking API key")
+	}
+	apiKeyJwts.reset()
+	glog.Infof("Revoked API key %s", id)
+	return nil
+}
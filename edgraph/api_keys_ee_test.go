@@ -0,0 +1,97 @@
+//go:build !oss
+// +build !oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. All rights reserved.
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/vtta/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package edgraph
+
+import (
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+func TestParseApiKey(t *testing.T) {
+	ns, id, secret, err := parseApiKey(formatApiKey(2, "0123abcd", "s3cr-et_"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), ns)
+	require.Equal(t, "0123abcd", id)
+	require.Equal(t, "s3cr-et_", secret)
+
+	for _, apiKey := range []string{"", "dgk.2.id", "abc.2.id.secret", "dgk.ns.id.secret",
+		"dgk.2..secret", "dgk.2.id.", "dgk.2.id.sec.ret"} {
+		_, _, _, err := parseApiKey(apiKey)
+		require.Error(t, err, apiKey)
+	}
+}
+
+func TestApiKeyScope(t *testing.T) {
+	var noScope *ApiKeyScope
+	require.True(t, noScope.isEmpty())
+	require.Empty(t, noScope.blocked([]string{"name"}))
+	require.Nil(t, noScope.restrict(1, nil))
+	require.NoError(t, noScope.authorizeWrite([]string{"name"}, "mutate"))
+
+	scope := &ApiKeyScope{Predicates: []string{"name", "friend"}}
+	require.Equal(t, map[string]struct{}{"age": {}},
+		scope.blocked([]string{"name", "~friend", "age", "dgraph.type"}))
+	require.ElementsMatch(t, []string{x.NamespaceAttr(1, "name"), x.NamespaceAttr(1, "friend")},
+		scope.restrict(1, nil))
+	require.Equal(t, []string{x.NamespaceAttr(1, "name")},
+		scope.restrict(1, []string{x.NamespaceAttr(1, "name"), x.NamespaceAttr(1, "age")}))
+	require.NoError(t, scope.authorizeWrite([]string{"name", "dgraph.type"}, "mutate"))
+	require.Error(t, scope.authorizeWrite([]string{"name", "age"}, "mutate"))
+
+	readOnly := &ApiKeyScope{ReadOnly: true}
+	require.False(t, readOnly.isEmpty())
+	require.Empty(t, readOnly.blocked([]string{"name"}))
+	require.Error(t, readOnly.authorizeWrite(nil, "alter"))
+}
+
+func TestApiKeyJwtScope(t *testing.T) {
+	defer func(conf worker.Options) {
+		worker.Config = conf
+	}(worker.Config)
+	worker.Config.AclJwtAlg = "HS256"
+
+	scope := &ApiKeyScope{ReadOnly: true, Predicates: []string{"name"}}
+	token, err := signJwt(jwt.MapClaims{
+		"userid":    "apikey:0123abcd",
+		"groups":    []string{"dev"},
+		"namespace": 2,
+		"exp":       time.Now().Add(time.Minute).Unix(),
+		"scope":     scope,
+	})
+	require.NoError(t, err)
+	ud, err := validateToken(token)
+	require.NoError(t, err)
+	require.Equal(t, &userData{namespace: 2, userId: "apikey:0123abcd", groupIds: []string{"dev"},
+		scope: scope}, ud)
+
+	// The JWTs minted for the API keys are cached until they expire.
+	hash := [32]byte{1}
+	apiKeyJwts.set(hash, token, time.Now().Add(time.Minute))
+	cached, ok := apiKeyJwts.get(hash)
+	require.True(t, ok)
+	require.Equal(t, token, cached)
+	apiKeyJwts.set(hash, token, time.Now().Add(-time.Second))
+	_, ok = apiKeyJwts.get(hash)
+	require.False(t, ok)
+	apiKeyJwts.set(hash, token, time.Now().Add(time.Minute))
+	apiKeyJwts.reset()
+	_, ok = apiKeyJwts.get(hash)
+	require.False(t, ok)
+}
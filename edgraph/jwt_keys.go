@@ -0,0 +1,51 @@
+// +build oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/ristretto/z"
+
+	"github.com/vtta/dgraph/x"
+)
+
+type JwtKey struct {
+	Kid       string
+	Alg       string
+	CreatedAt time.Time
+	RetireAt  time.Time
+}
+
+func GetJwtKeys(ctx context.Context) ([]*JwtKey, error) {
+	return nil, x.ErrNotSupported
+}
+
+func RotateJwtKey(ctx context.Context, overlap time.Duration) (*JwtKey, error) {
+	return nil, x.ErrNotSupported
+}
+
+// SubscribeForJwtKeyUpdates is an empty method since ACL is only supported in the enterprise
+// version.
+func SubscribeForJwtKeyUpdates(closer *z.Closer) {
+	// do nothing
+	<-closer.HasBeenClosed()
+	closer.Done()
+}
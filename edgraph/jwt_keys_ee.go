@@ -0,0 +1,485 @@
+//go:build !oss
+// +build !oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. All rights reserved.
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/vtta/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package edgraph
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	bpb "github.com/dgraph-io/badger/v3/pb"
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/dgraph-io/ristretto/z"
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+const queryJwtKeys = `
+	{
+		q(func: has(dgraph.acl.jwt_key)) {
+			uid
+			dgraph.acl.jwt_key
+		}
+	}`
+
+var (
+	jwtKeyPrefixes = [][]byte{
+		x.PredicatePrefix(x.GalaxyAttr("dgraph.acl.jwt_key")),
+	}
+
+	jwtKeys = &jwtKeyStore{keys: make(map[string]*jwtKey)}
+)
+
+func init() {
+	x.JwtValidator = validateAsymmetricJwt
+}
+
+// JwtKey is a key pair that the access and refresh JWTs are signed with, when --acl jwt-alg asks
+// for an asymmetric algorithm.
+type JwtKey struct {
+	// Kid is the id of the key, which the JWTs signed with it carry in their kid header.
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	// CreatedAt is when the key was created. The JWTs are signed with the newest key that isn't
+	// retiring.
+	CreatedAt time.Time `json:"created_at"`
+	// RetireAt is when the JWTs signed with the key stop being accepted. It is zero unless the key
+	// has been rotated.
+	RetireAt time.Time `json:"retire_at"`
+}
+
+// storedJwtKey is the key pair as it is stored in the dgraph.acl.jwt_key predicate.
+type storedJwtKey struct {
+	JwtKey
+	// PrivateKey is the PKCS #8 encoded private key, encrypted with the HMAC secret so that it
+	// can't be read out of the cluster by itself.
+	PrivateKey []byte `json:"private_key"`
+}
+
+type jwtKey struct {
+	JwtKey
+	private crypto.Signer
+}
+
+func (k *jwtKey) isRetired(now time.Time) bool {
+	return !k.RetireAt.IsZero() && now.After(k.RetireAt)
+}
+
+// jwtKeyStore holds the key pairs of the cluster, which are shared by all the alphas through the
+// galaxy namespace.
+type jwtKeyStore struct {
+	sync.RWMutex
+	keys map[string]*jwtKey
+	// current is the key that the JWTs are signed with.
+	current *jwtKey
+}
+
+func (s *jwtKeyStore) set(keys []*jwtKey) {
+	s.Lock()
+	defer s.Unlock()
+	s.keys = make(map[string]*jwtKey, len(keys))
+	s.current = nil
+	for _, k := range keys {
+		s.keys[k.Kid] = k
+		if !k.RetireAt.IsZero() {
+			continue
+		}
+		if s.current == nil || k.CreatedAt.After(s.current.CreatedAt) ||
+			(k.CreatedAt.Equal(s.current.CreatedAt) && k.Kid > s.current.Kid) {
+			s.current = k
+		}
+	}
+}
+
+func (s *jwtKeyStore) signingKey() *jwtKey {
+	s.RLock()
+	defer s.RUnlock()
+	return s.current
+}
+
+// get returns the key with the given id, unless it has been retired.
+func (s *jwtKeyStore) get(kid string) (*jwtKey, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	k, ok := s.keys[kid]
+	if !ok || k.isRetired(time.Now()) {
+		return nil, false
+	}
+	return k, true
+}
+
+// isAsymmetricJwtAlg tells if the JWTs are signed with the key pairs of the cluster rather than the
+// HMAC secret.
+func isAsymmetricJwtAlg() bool {
+	switch worker.Config.AclJwtAlg {
+	case "", jwt.SigningMethodHS256.Alg():
+		return false
+	}
+	return true
+}
+
+// jwtKid returns the kid header of the JWT, or an empty string if it has none.
+func jwtKid(jwtStr string) string {
+	token, _, err := new(jwt.Parser).ParseUnverified(jwtStr, jwt.MapClaims{})
+	if err != nil {
+		return ""
+	}
+	kid, _ := token.Header["kid"].(string)
+	return kid
+}
+
+// isClusterKeyToken tells if the JWT is signed with one of the key pairs of the cluster.
+func isClusterKeyToken(jwtStr string) bool {
+	kid := jwtKid(jwtStr)
+	if kid == "" {
+		return false
+	}
+	_, ok := jwtKeys.get(kid)
+	return ok
+}
+
+// validateAsymmetricJwt validates the JWTs that aren't signed with the HMAC secret for
+// x.ParseJWT. They are accepted if they are signed with one of the key pairs of the cluster, or
+// issued by the configured identity provider.
+func validateAsymmetricJwt(jwtStr string) (jwt.MapClaims, error) {
+	if isClusterKeyToken(jwtStr) {
+		return parseClusterKeyJwt(jwtStr)
+	}
+	v := getOidcValidator()
+	if v == nil {
+		return nil, errors.Errorf("unable to parse jwt token: unexpected signing method")
+	}
+	return v.validate(jwtStr)
+}
+
+func parseClusterKeyJwt(jwtStr string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(jwtStr, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		k, ok := jwtKeys.get(kid)
+		if !ok {
+			return nil, errors.Errorf("unknown or retired key %q", kid)
+		}
+		if token.Method.Alg() != k.Alg {
+			return nil, errors.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return k.private.Public(), nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse jwt token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.Errorf("claims in jwt token is not map claims")
+	}
+	return claims, nil
+}
+
+// signJwt signs the JWT with the current key pair of the cluster if --acl jwt-alg asks for an
+// asymmetric algorithm, or with the HMAC secret otherwise.
+func signJwt(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	var key interface{} = []byte(worker.Config.HmacSecret)
+	if isAsymmetricJwtAlg() {
+		k := jwtKeys.signingKey()
+		if k == nil {
+			return "", errors.Errorf("no key to sign the jwt with is available yet")
+		}
+		token = jwt.NewWithClaims(jwt.GetSigningMethod(k.Alg), claims)
+		token.Header["kid"] = k.Kid
+		key = k.private
+	}
+
+	jwtString, err := token.SignedString(key)
+	if err != nil {
+		return "", errors.Errorf("unable to encode jwt to string: %v", err)
+	}
+	return jwtString, nil
+}
+
+// jwtKeyCipher returns the cipher that the private keys are encrypted with.
+func jwtKeyCipher() (cipher.AEAD, error) {
+	secret := sha256.Sum256(worker.Config.HmacSecret)
+	block, err := aes.NewCipher(secret[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// newJwtKey generates a key pair for the given algorithm.
+func newJwtKey(alg string) (*jwtKey, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case jwt.SigningMethodES256.Alg():
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, errors.Errorf("unsupported algorithm for JWT keys: %s", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	thumbprint, err := (&jose.JSONWebKey{Key: private.Public()}).Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return &jwtKey{
+		JwtKey: JwtKey{
+			Kid:       base64.RawURLEncoding.EncodeToString(thumbprint),
+			Alg:       alg,
+			CreatedAt: time.Now().UTC(),
+		},
+		private: private,
+	}, nil
+}
+
+func (k *jwtKey) marshal() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.private)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := jwtKeyCipher()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&storedJwtKey{JwtKey: k.JwtKey, PrivateKey: aead.Seal(nonce, nonce, der, nil)})
+}
+
+func unmarshalJwtKey(data []byte) (*jwtKey, error) {
+	var stored storedJwtKey
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	aead, err := jwtKeyCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(stored.PrivateKey) < aead.NonceSize() {
+		return nil, errors.Errorf("invalid private key")
+	}
+	nonce, sealed := stored.PrivateKey[:aead.NonceSize()], stored.PrivateKey[aead.NonceSize():]
+	der, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while decrypting the private key")
+	}
+	private, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, errors.Errorf("unsupported private key type %T", private)
+	}
+	return &jwtKey{JwtKey: stored.JwtKey, private: signer}, nil
+}
+
+type jwtKeyNode struct {
+	uid string
+	key *jwtKey
+}
+
+func getJwtKeyNodes(ctx context.Context) ([]jwtKeyNode, *api.Response, error) {
+	req := &Request{
+		req: &api.Request{
+			Query: queryJwtKeys,
+		},
+		doAuth: NoAuthorize,
+	}
+	resp, err := (&Server{}).doQuery(x.AttachNamespace(ctx, x.GalaxyNamespace), req)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "while querying JWT keys")
+	}
+
+	var res struct {
+		Q []struct {
+			Uid string `json:"uid"`
+			Key string `json:"dgraph.acl.jwt_key"`
+		} `json:"q"`
+	}
+	if len(resp.GetJson()) > 0 {
+		if err := json.Unmarshal(resp.GetJson(), &res); err != nil {
+			return nil, nil, err
+		}
+	}
+	nodes := make([]jwtKeyNode, 0, len(res.Q))
+	for _, q := range res.Q {
+		key, err := unmarshalJwtKey([]byte(q.Key))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "while unmarshalling JWT key %s", q.Uid)
+		}
+		nodes = append(nodes, jwtKeyNode{uid: q.Uid, key: key})
+	}
+	return nodes, resp, nil
+}
+
+// loadJwtKeys reads the key pairs of the cluster into the key store.
+func loadJwtKeys(ctx context.Context) error {
+	nodes, _, err := getJwtKeyNodes(ctx)
+	if err != nil {
+		return err
+	}
+	keys := make([]*jwtKey, 0, len(nodes))
+	for _, n := range nodes {
+		keys = append(keys, n.key)
+	}
+	jwtKeys.set(keys)
+	return nil
+}
+
+// GetJwtKeys returns the key pairs that the JWTs are signed with, the newest first.
+func GetJwtKeys(ctx context.Context) ([]*JwtKey, error) {
+	nodes, _, err := getJwtKeyNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*JwtKey, 0, len(nodes))
+	for _, n := range nodes {
+		keys = append(keys, &n.key.JwtKey)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// RotateJwtKey creates a key pair that the JWTs are signed with from now on. The JWTs signed with
+// the previous keys are still accepted for the given overlap, after which they are retired. The
+// keys retired by previous rotations are removed.
+func RotateJwtKey(ctx context.Context, overlap time.Duration) (*JwtKey, error) {
+	if len(worker.Config.HmacSecret) == 0 {
+		return nil, errors.Errorf("ACL must be enabled to rotate JWT keys")
+	}
+	if !isAsymmetricJwtAlg() {
+		return nil, errors.Errorf("JWT keys can only be rotated if --acl jwt-alg is RS256 or ES256")
+	}
+	if overlap < 0 {
+		return nil, errors.Errorf("overlap must not be negative, got %s", overlap)
+	}
+
+	nodes, resp, err := getJwtKeyNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key, err := newJwtKey(worker.Config.AclJwtAlg)
+	if err != nil {
+		return nil, err
+	}
+
+	mu := &api.Mutation{}
+	setKey := func(subject string, k *jwtKey) error {
+		val, err := k.marshal()
+		if err != nil {
+			return err
+		}
+		mu.Set = append(mu.Set, &api.NQuad{
+			Subject:     subject,
+			Predicate:   "dgraph.acl.jwt_key",
+			ObjectValue: &api.Value{Val: &api.Value_StrVal{StrVal: string(val)}},
+		})
+		return nil
+	}
+	if err := setKey("_:key", key); err != nil {
+		return nil, err
+	}
+	retireAt := key.CreatedAt.Add(overlap)
+	for _, n := range nodes {
+		switch {
+		case n.key.isRetired(key.CreatedAt):
+			mu.Del = append(mu.Del, &api.NQuad{
+				Subject:     n.uid,
+				Predicate:   "dgraph.acl.jwt_key",
+				ObjectValue: &api.Value{Val: &api.Value_DefaultVal{DefaultVal: x.Star}},
+			})
+		case n.key.RetireAt.IsZero() || n.key.RetireAt.After(retireAt):
+			n.key.RetireAt = retireAt
+			if err := setKey(n.uid, n.key); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	req := &Request{
+		req: &api.Request{
+			Mutations: []*api.Mutation{mu},
+			StartTs:   resp.GetTxn().GetStartTs(),
+			CommitNow: true,
+		},
+		doAuth: NoAuthorize,
+	}
+	ctx = context.WithValue(x.AttachNamespace(ctx, x.GalaxyNamespace), IsGraphql, true)
+	if _, err := (&Server{}).doQuery(ctx, req); err != nil {
+		return nil, errors.Wrapf(err, "while storing JWT key")
+	}
+	if err := loadJwtKeys(ctx); err != nil {
+		return nil, err
+	}
+	glog.Infof("Rotated the JWT keys, the new key is %s", key.Kid)
+	return &key.JwtKey, nil
+}
+
+// initializeJwtKeys loads the key pairs of the cluster, and creates one if none can sign the JWTs
+// with the algorithm asked for by --acl jwt-alg.
+func initializeJwtKeys(ctx context.Context) error {
+	if !isAsymmetricJwtAlg() {
+		return nil
+	}
+	if err := loadJwtKeys(ctx); err != nil {
+		return err
+	}
+	if k := jwtKeys.signingKey(); k != nil && k.Alg == worker.Config.AclJwtAlg {
+		return nil
+	}
+	_, err := RotateJwtKey(ctx, worker.Config.RefreshJwtTtl)
+	return err
+}
+
+// SubscribeForJwtKeyUpdates subscribes for the JWT key predicate and reloads the key store
+// whenever it changes, so that the keys rotated through other alphas are picked up by this one.
+func SubscribeForJwtKeyUpdates(closer *z.Closer) {
+	if len(worker.Config.HmacSecret) == 0 {
+		// the acl feature is not turned on
+		closer.Done()
+		return
+	}
+	worker.SubscribeForUpdates(jwtKeyPrefixes, x.IgnoreBytes, func(kvs *bpb.KVList) {
+		if kvs == nil || len(kvs.Kv) == 0 {
+			return
+		}
+		glog.V(3).Infof("Got JWT keys update via subscription.")
+		if err := loadJwtKeys(closer.Ctx()); err != nil {
+			glog.Errorf("Unable to load the JWT keys: %v", err)
+		}
+	}, 1, closer)
+}
@@ -0,0 +1,109 @@
+//go:build !oss
+// +build !oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. All rights reserved.
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/vtta/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package edgraph
+
+import (
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/ee/acl"
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+func TestJwtKeys(t *testing.T) {
+	defer func(conf worker.Options) {
+		worker.Config = conf
+		jwtKeys.set(nil)
+	}(worker.Config)
+	worker.Config.HmacSecret = x.SensitiveByteSlice("0123456789abcdef0123456789abcdef")
+	worker.Config.AccessJwtTtl = time.Hour
+	worker.Config.AclJwtAlg = "RS256"
+
+	_, err := getAccessJwt("alice", nil, 0)
+	require.Error(t, err)
+
+	rsaKey, err := newJwtKey("RS256")
+	require.NoError(t, err)
+	ecKey, err := newJwtKey("ES256")
+	require.NoError(t, err)
+	ecKey.CreatedAt = rsaKey.CreatedAt.Add(-time.Minute)
+
+	// The keys survive being stored, but not a change of the HMAC secret.
+	data, err := rsaKey.marshal()
+	require.NoError(t, err)
+	stored, err := unmarshalJwtKey(data)
+	require.NoError(t, err)
+	require.Equal(t, rsaKey.JwtKey, stored.JwtKey)
+	require.True(t, rsaKey.private.(*rsa.PrivateKey).Equal(stored.private))
+	worker.Config.HmacSecret = x.SensitiveByteSlice("fedcba9876543210fedcba9876543210")
+	_, err = unmarshalJwtKey(data)
+	require.Error(t, err)
+
+	// The JWTs are signed with the newest key, and accepted while the key they are signed with
+	// isn't retired.
+	jwtKeys.set([]*jwtKey{ecKey, stored})
+	require.Equal(t, stored, jwtKeys.signingKey())
+	groups := []acl.Group{{GroupID: "dev"}}
+	token, err := getAccessJwt("alice", groups, 2)
+	require.NoError(t, err)
+	require.Equal(t, rsaKey.Kid, jwtKid(token))
+	require.False(t, isOidcToken(token))
+	ud, err := validateToken(token)
+	require.NoError(t, err)
+	require.Equal(t, &userData{namespace: 2, userId: "alice", groupIds: []string{"dev"}}, ud)
+
+	worker.Config.AclJwtAlg = "ES256"
+	jwtKeys.set([]*jwtKey{ecKey})
+	ecToken, err := getAccessJwt("alice", groups, 2)
+	require.NoError(t, err)
+	_, err = validateToken(token)
+	require.Error(t, err)
+
+	rsaKey.RetireAt = time.Now().Add(time.Hour)
+	ecKey.RetireAt = time.Now().Add(-time.Second)
+	jwtKeys.set([]*jwtKey{ecKey, rsaKey})
+	require.Nil(t, jwtKeys.signingKey())
+	_, err = validateToken(token)
+	require.NoError(t, err)
+	_, err = validateToken(ecToken)
+	require.Error(t, err)
+}
+
+func TestHmacJwtRejectedWithAsymmetricAlg(t *testing.T) {
+	defer func(conf worker.Options, xconf x.WorkerOptions) {
+		worker.Config = conf
+		x.WorkerConfig = xconf
+	}(worker.Config, x.WorkerConfig)
+	worker.Config.HmacSecret = x.SensitiveByteSlice("0123456789abcdef0123456789abcdef")
+	worker.Config.AccessJwtTtl = time.Hour
+	x.WorkerConfig.HmacSecret = worker.Config.HmacSecret
+
+	token, err := getAccessJwt("alice", nil, 0)
+	require.NoError(t, err)
+	_, err = validateToken(token)
+	require.NoError(t, err)
+
+	// The JWTs signed with the HMAC secret aren't accepted once the cluster signs them with its
+	// key pairs.
+	for _, alg := range []string{"RS256", "ES256"} {
+		worker.Config.AclJwtAlg = alg
+		x.WorkerConfig.AclJwtAlg = alg
+		_, err = validateToken(token)
+		require.Error(t, err, alg)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/dgraph-io/ristretto/z"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	bpb "github.com/dgraph-io/badger/v3/pb"
+	"github.com/vtta/dgraph/graphql/lambda"
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+const queryLambdaModule = `
+	{
+		q(func: has(dgraph.graphql.lambda_module)) {
+			uid
+			dgraph.graphql.lambda_module
+		}
+	}`
+
+var lambdaModulePrefixes = [][]byte{
+	x.PredicatePrefix(x.GalaxyAttr("dgraph.graphql.lambda_module")),
+}
+
+// LambdaModule is the WASM module that runs the lambdas of a namespace in the embedded lambda
+// runtime.
+type LambdaModule struct {
+	Module    []byte    `json:"module"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func getLambdaModuleNode(ctx context.Context) (string, *LambdaModule, *api.Response, error) {
+	req := &Request{
+		req: &api.Request{
+			Query: queryLambdaModule,
+		},
+		doAuth: NoAuthorize,
+	}
+	resp, err := (&Server{}).doQuery(ctx, req)
+	if err != nil {
+		return "", nil, nil, errors.Wrapf(err, "while querying lambda module")
+	}
+
+	var res struct {
+		Q []struct {
+			Uid    string `json:"uid"`
+			Module string `json:"dgraph.graphql.lambda_module"`
+		} `json:"q"`
+	}
+	if len(resp.GetJson()) > 0 {
+		if err := json.Unmarshal(resp.GetJson(), &res); err != nil {
+			return "", nil, nil, err
+		}
+	}
+	if len(res.Q) == 0 {
+		return "", nil, resp, nil
+	}
+	var lm LambdaModule
+	if err := json.Unmarshal([]byte(res.Q[0].Module), &lm); err != nil {
+		return "", nil, nil, errors.Wrapf(err, "while unmarshalling lambda module")
+	}
+	return res.Q[0].Uid, &lm, resp, nil
+}
+
+// GetLambdaModule returns the lambda module of the namespace of the request, or nil if it has
+// none.
+func GetLambdaModule(ctx context.Context) (*LambdaModule, error) {
+	_, lm, _, err := getLambdaModuleNode(ctx)
+	return lm, err
+}
+
+// LoadLambdaModule returns the lambda module of the namespace ns, or nil if it has none. It is
+// the lambda.ModuleLoader of the embedded lambda runtime.
+func LoadLambdaModule(ns uint64) ([]byte, error) {
+	lm, err := GetLambdaModule(x.AttachNamespace(context.Background(), ns))
+	if err != nil || lm == nil {
+		return nil, err
+	}
+	return lm.Module, nil
+}
+
+// UpdateLambdaModule sets the lambda module of the namespace of the request, after checking that
+// the embedded lambda runtime can run it. An empty module removes the lambda module.
+func UpdateLambdaModule(ctx context.Context, module []byte) (*LambdaModule, error) {
+	var lm *LambdaModule
+	if len(module) > 0 {
+		if err := lambda.Validate(module); err != nil {
+			return nil, err
+		}
+		lm = &LambdaModule{Module: module, UpdatedAt: time.Now().UTC()}
+	}
+
+	uid, current, resp, err := getLambdaModuleNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mu := &api.Mutation{}
+	switch {
+	case lm == nil && current == nil:
+		// Nothing to remove.
+		return nil, nil
+	case lm == nil:
+		mu.Del = []*api.NQuad{{
+			Subject:     uid,
+			Predicate:   "dgraph.graphql.lambda_module",
+			ObjectValue: &api.Value{Val: &api.Value_DefaultVal{DefaultVal: x.Star}},
+		}}
+	default:
+		val, err := json.Marshal(lm)
+		if err != nil {
+			return nil, err
+		}
+		if uid == "" {
+			uid = "_:lm"
+		}
+		mu.Set = []*api.NQuad{{
+			Subject:     uid,
+			Predicate:   "dgraph.graphql.lambda_module",
+			ObjectValue: &api.Value{Val: &api.Value_StrVal{StrVal: string(val)}},
+		}}
+	}
+
+	req := &Request{
+		req: &api.Request{
+			Mutations: []*api.Mutation{mu},
+			StartTs:   resp.GetTxn().GetStartTs(),
+			CommitNow: true,
+		},
+		doAuth: NoAuthorize,
+	}
+	if _, err := (&Server{}).doQuery(context.WithValue(ctx, IsGraphql, true), req); err != nil {
+		return nil, errors.Wrapf(err, "while storing lambda module")
+	}
+	lambda.ResetModules()
+	return lm, nil
+}
+
+// SubscribeForLambdaModuleUpdates subscribes for the lambda module predicate and drops the
+// compiled lambda modules whenever it changes, so that the modules updated through other alphas
+// are picked up by this one.
+func SubscribeForLambdaModuleUpdates(closer *z.Closer) {
+	worker.SubscribeForUpdates(lambdaModulePrefixes, x.IgnoreBytes, func(kvs *bpb.KVList) {
+		if kvs == nil || len(kvs.Kv) == 0 {
+			return
+		}
+		glog.V(3).Infof("Got lambda module update via subscription.")
+		lambda.ResetModules()
+	}, 1, closer)
+}
@@ -0,0 +1,281 @@
+//go:build !oss
+// +build !oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. All rights reserved.
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/vtta/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package edgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vtta/dgraph/gql"
+	"github.com/vtta/dgraph/protos/pb"
+	"github.com/vtta/dgraph/schema"
+	"github.com/vtta/dgraph/types"
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+// nodeRulesFilter returns the filter that the nodes accessed by a member of the given groups must
+// match as per the node rules of the groups. A node of a type with rules must match the filter of
+// one of them, while the nodes of other types are left alone. It returns an empty string if no
+// rules apply.
+func nodeRulesFilter(ns uint64, groupIds []string) string {
+	filters := worker.AclCachePtr.NodeFilters(ns, groupIds)
+	clauses := make([]string, 0, len(filters))
+	for _, typeName := range sortedTypes(filters) {
+		typeFilters := filters[typeName]
+		sort.Strings(typeFilters)
+		clause := "(NOT type(" + typeName + ")"
+		for _, filter := range typeFilters {
+			clause += " OR (" + filter + ")"
+		}
+		clauses = append(clauses, clause+")")
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// nodeRuleTypes returns the sorted names of the types that the node rules of the given groups
+// apply to.
+func nodeRuleTypes(ns uint64, groupIds []string) []string {
+	return sortedTypes(worker.AclCachePtr.NodeFilters(ns, groupIds))
+}
+
+func sortedTypes(filters map[string][]string) []string {
+	typeNames := make([]string, 0, len(filters))
+	for typeName := range filters {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+	return typeNames
+}
+
+// authorizeNodes applies the node rules of the groups of the user making the request. The query
+// blocks of the request and their uid predicates are filtered down to the nodes that the user can
+// access, and the filter is kept in qc so that the nodes touched by the mutations of the request
+// can be checked against it.
+func authorizeNodes(ctx context.Context, qc *queryContext) error {
+	if len(worker.Config.HmacSecret) == 0 {
+		// the user has not turned on the acl feature
+		return nil
+	}
+
+	userData, err := extractUserAndGroups(ctx)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	if x.IsGuardian(userData.groupIds) {
+		// Members of guardian groups are allowed to access all the nodes.
+		return nil
+	}
+	ns, err := x.ExtractNamespace(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "While authorizing nodes")
+	}
+	if !worker.AclCachePtr.Loaded() {
+		RefreshACLs(ctx)
+	}
+
+	qc.nodeFilter = nodeRulesFilter(ns, userData.groupIds)
+	if qc.nodeFilter == "" {
+		return nil
+	}
+	qc.nodeRuleTypes = nodeRuleTypes(ns, userData.groupIds)
+	for _, gq := range qc.gqlRes.Query {
+		if err := addNodeFilterToQuery(gq, ns, qc.nodeFilter, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addNodeFilterToQuery adds the filter to the query block, if it is at root, and to all its uid
+// predicates.
+func addNodeFilterToQuery(gq *gql.GraphQuery, ns uint64, filter string, root bool) error {
+	if gq.Expand != "" && len(gq.Children) > 0 {
+		// The predicates that expand() results in aren't known yet, so the nodes they lead to
+		// couldn't be filtered.
+		return status.Error(codes.PermissionDenied,
+			"expand() with child predicates can't be used by users with node rules")
+	}
+
+	addFilter := !root && isUidPredicate(ns, gq.Attr)
+	if root {
+		// Blocks without a function only aggregate variables, and shortest path blocks only
+		// follow the predicates that are filtered below.
+		addFilter = (gq.Func != nil || len(gq.UID) > 0) && gq.Alias != "shortest"
+	}
+	if addFilter {
+		nodeFilter, err := gql.ParseFilter(filter)
+		if err != nil {
+			return errors.Wrapf(err, "while applying node rules")
+		}
+		gq.Filter = parentFilter(nodeFilter, gq.Filter)
+	}
+
+	for _, child := range gq.Children {
+		if err := addNodeFilterToQuery(child, ns, filter, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isUidPredicate(ns uint64, attr string) bool {
+	if strings.HasPrefix(attr, "~") {
+		return true
+	}
+	if attr == "" || attr == "uid" || attr == "val" || attr == "expand" {
+		return false
+	}
+	typ, err := schema.State().TypeOf(x.NamespaceAttr(ns, attr))
+	return err == nil && typ == types.UidID
+}
+
+// authorizeMutatedNodes checks, before the mutations of the request are applied, that the user
+// making the request can access all the existing nodes that the mutations set or delete edges
+// of, or edges to, as per the node rules of their groups. The types that have node rules can only
+// be removed from a node by deleting the whole node, so that a node can't be taken out of the
+// rules of its type while keeping its edges.
+func authorizeMutatedNodes(ctx context.Context, qc *queryContext, edges []*pb.DirectedEdge,
+	newUids map[string]uint64) error {
+	if qc.nodeFilter == "" {
+		return nil
+	}
+
+	touched, untyped := mutatedNodes(edges, newUids)
+	if blocked, err := nodesNotMatching(ctx, qc, touched, qc.nodeFilter); err != nil {
+		return err
+	} else if len(blocked) > 0 {
+		return status.Errorf(codes.PermissionDenied,
+			"unauthorized to mutate following nodes: %s", strings.Join(blocked, " "))
+	}
+
+	typeFilters := make([]string, 0, len(qc.nodeRuleTypes))
+	for _, typeName := range qc.nodeRuleTypes {
+		typeFilters = append(typeFilters, "type("+typeName+")")
+	}
+	// The nodes that don't have any of the types are the ones whose types can be removed.
+	untypedFilter := "NOT (" + strings.Join(typeFilters, " OR ") + ")"
+	if blocked, err := nodesNotMatching(ctx, qc, untyped, untypedFilter); err != nil {
+		return err
+	} else if len(blocked) > 0 {
+		return status.Errorf(codes.PermissionDenied,
+			"the types of following nodes can only be removed by deleting the nodes: %s",
+			strings.Join(blocked, " "))
+	}
+	return nil
+}
+
+// authorizeMutationResult checks, after the mutations of the request are applied but before they
+// are committed, that the user making the request can still access all the nodes that the
+// mutations touched, including the ones they created, as per the node rules of their groups. The
+// nodes are read at the start ts of the transaction, which includes its own writes, so that the
+// mutations can neither create nodes outside of the rules nor move nodes out of them.
+func authorizeMutationResult(ctx context.Context, qc *queryContext,
+	edges []*pb.DirectedEdge) error {
+	if qc.nodeFilter == "" {
+		return nil
+	}
+
+	// The nodes created by the mutations are checked as well, now that they exist.
+	touched, _ := mutatedNodes(edges, nil)
+	blocked, err := nodesNotMatching(ctx, qc, touched, qc.nodeFilter)
+	if err != nil {
+		return err
+	}
+	if len(blocked) > 0 {
+		return status.Errorf(codes.PermissionDenied,
+			"mutations would leave following nodes inaccessible: %s", strings.Join(blocked, " "))
+	}
+	return nil
+}
+
+// mutatedNodes returns the nodes that the edges are set or deleted from, or to, and the nodes that
+// the edges delete types from, leaving out the nodes created by the mutations.
+func mutatedNodes(edges []*pb.DirectedEdge, newUids map[string]uint64) (
+	map[uint64]struct{}, map[uint64]struct{}) {
+	created := make(map[uint64]struct{}, len(newUids))
+	for _, uid := range newUids {
+		created[uid] = struct{}{}
+	}
+	touched := make(map[uint64]struct{})
+	untyped := make(map[uint64]struct{})
+	for _, edge := range edges {
+		for _, uid := range []uint64{edge.Entity, edge.ValueId} {
+			if _, ok := created[uid]; !ok && uid != 0 {
+				touched[uid] = struct{}{}
+			}
+		}
+		if _, ok := created[edge.Entity]; !ok && edge.Op == pb.DirectedEdge_DEL &&
+			edge.Attr == "dgraph.type" {
+			untyped[edge.Entity] = struct{}{}
+		}
+	}
+	return touched, untyped
+}
+
+// nodesNotMatching returns the uids of the nodes that don't match the filter, as of the start ts
+// of the request.
+func nodesNotMatching(ctx context.Context, qc *queryContext, nodes map[uint64]struct{},
+	filter string) ([]string, error) {
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+
+	uids := make([]string, 0, len(nodes))
+	for uid := range nodes {
+		uids = append(uids, fmt.Sprintf("%#x", uid))
+	}
+	sort.Strings(uids)
+	req := &Request{
+		req: &api.Request{
+			Query: fmt.Sprintf("{ q(func: uid(%s)) @filter(%s) { uid } }",
+				strings.Join(uids, ", "), filter),
+			StartTs:  qc.req.StartTs,
+			ReadOnly: true,
+		},
+		doAuth: NoAuthorize,
+	}
+	resp, err := (&Server{}).doQuery(ctx, req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while authorizing nodes")
+	}
+	var res struct {
+		Q []struct {
+			Uid string `json:"uid"`
+		} `json:"q"`
+	}
+	if err := json.Unmarshal(resp.GetJson(), &res); err != nil {
+		return nil, err
+	}
+	matching := make(map[string]struct{}, len(res.Q))
+	for _, node := range res.Q {
+		matching[node.Uid] = struct{}{}
+	}
+
+	var blocked []string
+	for _, uid := range uids {
+		if _, ok := matching[uid]; !ok {
+			blocked = append(blocked, uid)
+		}
+	}
+	return blocked, nil
+}
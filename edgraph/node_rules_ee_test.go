@@ -0,0 +1,124 @@
+//go:build !oss
+// +build !oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. All rights reserved.
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/vtta/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package edgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/ee/acl"
+	"github.com/vtta/dgraph/gql"
+	"github.com/vtta/dgraph/protos/pb"
+	"github.com/vtta/dgraph/schema"
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+func TestNodeRulesFilter(t *testing.T) {
+	worker.AclCachePtr.Update(x.GalaxyNamespace, []acl.Group{
+		{
+			GroupID: "acme",
+			NodeRules: []acl.NodeRule{
+				{Type: "Project", Filter: `eq(tenant, "acme")`},
+				{Type: "Invoice", Filter: `eq(tenant, "acme")`},
+			},
+		},
+		{
+			GroupID:   "auditors",
+			NodeRules: []acl.NodeRule{{Type: "Project", Filter: `has(audited)`}},
+		},
+	})
+	defer worker.AclCachePtr.Update(x.GalaxyNamespace, nil)
+
+	require.Equal(t, "", nodeRulesFilter(x.GalaxyNamespace, []string{"dev"}))
+	require.Equal(t, "", nodeRulesFilter(1, []string{"acme"}))
+	require.Equal(t, `(NOT type(Project) OR (has(audited)))`,
+		nodeRulesFilter(x.GalaxyNamespace, []string{"auditors"}))
+	require.Equal(t, `(NOT type(Invoice) OR (eq(tenant, "acme"))) AND `+
+		`(NOT type(Project) OR (eq(tenant, "acme")) OR (has(audited)))`,
+		nodeRulesFilter(x.GalaxyNamespace, []string{"acme", "auditors"}))
+}
+
+func TestAddNodeFilterToQuery(t *testing.T) {
+	require.NoError(t, schema.ParseBytes([]byte(`
+		name: string .
+		tenant: string .
+		member: [uid] @reverse .
+		owner: uid .
+	`), 1))
+
+	res, err := gql.Parse(gql.Request{Str: `{
+		q(func: has(name)) @filter(has(tenant)) {
+			name
+			owner { name }
+			count(member)
+			~member { uid }
+		}
+	}`})
+	require.NoError(t, err)
+	gq := res.Query[0]
+	filter := `(NOT type(Project) OR (eq(tenant, "acme")))`
+	require.NoError(t, addNodeFilterToQuery(gq, x.GalaxyNamespace, filter, true))
+
+	require.Equal(t, "AND", gq.Filter.Op)
+	require.Equal(t, "has", gq.Filter.Child[0].Func.Name)
+	require.Equal(t, "or", gq.Filter.Child[1].Op)
+	require.Nil(t, gq.Children[0].Filter)
+	for _, child := range gq.Children[1:] {
+		require.NotNil(t, child.Filter, child.Attr)
+		require.Equal(t, "or", child.Filter.Op, child.Attr)
+	}
+
+	res, err = gql.Parse(gql.Request{Str: `{
+		q(func: has(name)) {
+			expand(_all_) { name }
+		}
+	}`})
+	require.NoError(t, err)
+	require.Error(t, addNodeFilterToQuery(res.Query[0], x.GalaxyNamespace, filter, true))
+}
+
+func TestMutatedNodesOfCreatedNodes(t *testing.T) {
+	// _:doc is created with a tenant and linked to the existing node 0x1.
+	edges := []*pb.DirectedEdge{
+		{Entity: 0x10, Attr: "tenant", Value: []byte("B"), Op: pb.DirectedEdge_SET},
+		{Entity: 0x10, Attr: "dgraph.type", Value: []byte("Doc"), Op: pb.DirectedEdge_SET},
+		{Entity: 0x1, Attr: "docs", ValueId: 0x10, Op: pb.DirectedEdge_SET},
+	}
+	newUids := map[string]uint64{"doc": 0x10}
+
+	// Before the mutations are applied, only the existing node is checked.
+	touched, untyped := mutatedNodes(edges, newUids)
+	require.Equal(t, map[uint64]struct{}{0x1: {}}, touched)
+	require.Empty(t, untyped)
+
+	// After they are applied, the created node is checked as well.
+	touched, _ = mutatedNodes(edges, nil)
+	require.Equal(t, map[uint64]struct{}{0x1: {}, 0x10: {}}, touched)
+}
+
+func TestMutatedNodesOfMovedNodes(t *testing.T) {
+	// The existing node 0x1 is moved to another tenant and its type is deleted, while the node
+	// 0x2 is deleted as a whole.
+	edges := []*pb.DirectedEdge{
+		{Entity: 0x1, Attr: "tenant", Value: []byte("B"), Op: pb.DirectedEdge_SET},
+		{Entity: 0x1, Attr: "dgraph.type", Value: []byte("Doc"), Op: pb.DirectedEdge_DEL},
+		{Entity: 0x2, Attr: x.Star, Value: []byte(x.Star), Op: pb.DirectedEdge_DEL},
+	}
+
+	touched, untyped := mutatedNodes(edges, nil)
+	require.Equal(t, map[uint64]struct{}{0x1: {}, 0x2: {}}, touched)
+	require.Equal(t, map[uint64]struct{}{0x1: {}}, untyped)
+}
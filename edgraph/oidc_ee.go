@@ -0,0 +1,253 @@
+//go:build !oss
+// +build !oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. All rights reserved.
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/vtta/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package edgraph
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/vtta/dgraph/ee"
+	"github.com/vtta/dgraph/graphql/authorization"
+	"github.com/vtta/dgraph/worker"
+)
+
+// minJwksRefreshInterval is how long we wait before fetching the JWKS again when a token is signed
+// by a key that isn't in it, so that tokens with made up key ids can't make us keep fetching it.
+const minJwksRefreshInterval = time.Minute
+
+var (
+	oidcOnce      sync.Once
+	oidcValidator *oidcTokenValidator
+)
+
+// getOidcValidator returns the validator of the tokens issued by the identity provider configured
+// through the --acl flag, or nil if none is configured.
+func getOidcValidator() *oidcTokenValidator {
+	oidcOnce.Do(func() {
+		if worker.Config.AclOidc != nil {
+			oidcValidator = newOidcTokenValidator(worker.Config.AclOidc)
+		}
+	})
+	return oidcValidator
+}
+
+// oidcTokenValidator validates the access JWTs issued by an external OpenID Connect identity
+// provider against the provider's JSON Web Key set, and maps their claims to the user and the ACL
+// groups of the request.
+type oidcTokenValidator struct {
+	conf       *ee.OidcConfig
+	httpClient *http.Client
+
+	sync.Mutex
+	jwkSet *jose.JSONWebKeySet
+	// expiry is when the JWKS must be fetched again, as per its Cache-Control header. It is zero
+	// if the JWKS doesn't expire.
+	expiry    time.Time
+	fetchedAt time.Time
+}
+
+func newOidcTokenValidator(conf *ee.OidcConfig) *oidcTokenValidator {
+	return &oidcTokenValidator{
+		conf:       conf,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// isOidcToken tells if the token should be validated by the identity provider, i.e. if it isn't
+// signed with the HMAC secret or the key pairs of the cluster like the tokens issued by Dgraph are.
+func isOidcToken(jwtStr string) bool {
+	token, _, err := new(jwt.Parser).ParseUnverified(jwtStr, jwt.MapClaims{})
+	if err != nil {
+		return false
+	}
+	_, ok := token.Method.(*jwt.SigningMethodHMAC)
+	return !ok && !isClusterKeyToken(jwtStr)
+}
+
+// fetchJwks reads the JWKS from the file or the URL it is configured at.
+func (v *oidcTokenValidator) fetchJwks() error {
+	var data []byte
+	var expiry time.Time
+	if strings.HasPrefix(v.conf.Jwks, "http://") || strings.HasPrefix(v.conf.Jwks, "https://") {
+		resp, err := v.httpClient.Get(v.conf.Jwks)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("unexpected status %s", resp.Status)
+		}
+		if data, err = ioutil.ReadAll(resp.Body); err != nil {
+			return err
+		}
+		if maxAge, _ := authorization.ParseMaxAge(resp.Header.Get("Cache-Control")); maxAge > 0 {
+			expiry = time.Now().Add(time.Duration(maxAge) * time.Second)
+		}
+	} else {
+		var err error
+		if data, err = ioutil.ReadFile(v.conf.Jwks); err != nil {
+			return err
+		}
+	}
+
+	jwkSet, err := authorization.ParseJWKs(data)
+	if err != nil {
+		return err
+	}
+	v.jwkSet, v.expiry, v.fetchedAt = jwkSet, expiry, time.Now()
+	return nil
+}
+
+// key returns the key of the JWKS with the given id. The JWKS is fetched again if it has expired,
+// or if it has no such key and wasn't fetched recently, so that rotated keys are picked up.
+func (v *oidcTokenValidator) key(kid string) (interface{}, error) {
+	v.Lock()
+	defer v.Unlock()
+
+	lookup := func() []jose.JSONWebKey {
+		if v.jwkSet == nil {
+			return nil
+		}
+		if kid == "" && len(v.jwkSet.Keys) == 1 {
+			return v.jwkSet.Keys
+		}
+		return v.jwkSet.Key(kid)
+	}
+	expired := !v.expiry.IsZero() && time.Now().After(v.expiry)
+	keys := lookup()
+	if v.jwkSet == nil || expired ||
+		(len(keys) == 0 && time.Since(v.fetchedAt) > minJwksRefreshInterval) {
+		if err := v.fetchJwks(); err != nil {
+			glog.Errorf("Unable to fetch the JWKS from %s: %v", v.conf.Jwks, err)
+			if v.jwkSet == nil {
+				return nil, errors.Wrapf(err, "while fetching the JWKS")
+			}
+		}
+		keys = lookup()
+	}
+	if len(keys) == 0 {
+		return nil, errors.Errorf("no key with kid %q in the JWKS", kid)
+	}
+	return keys[0].Key, nil
+}
+
+// validate verifies the signature, the expiration, the issuer and the audience of the token, and
+// returns the claims that the token maps to, in the same form as the claims of the tokens issued by
+// Dgraph.
+func (v *oidcTokenValidator) validate(jwtStr string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(jwtStr, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
+			return nil, errors.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return v.key(kid)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse jwt token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.Errorf("claims in jwt token is not map claims")
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.Errorf("Token is expired")
+	}
+	if v.conf.Issuer != "" && !claims.VerifyIssuer(v.conf.Issuer, true) {
+		return nil, errors.Errorf("Token is not issued by %s", v.conf.Issuer)
+	}
+	if len(v.conf.Audience) > 0 && !hasAudience(claims["aud"], v.conf.Audience) {
+		return nil, errors.Errorf("Token is not issued for any of the audiences %v",
+			v.conf.Audience)
+	}
+
+	userId, ok := claims[v.conf.UserClaim].(string)
+	if !ok || userId == "" {
+		return nil, errors.Errorf("%s in claims is not a string:%v", v.conf.UserClaim,
+			claims[v.conf.UserClaim])
+	}
+	groupIds, err := v.mapGroups(claims[v.conf.GroupsClaim])
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]interface{}, 0, len(groupIds))
+	for _, group := range groupIds {
+		groups = append(groups, group)
+	}
+	return jwt.MapClaims{
+		"userid":    userId,
+		"groups":    groups,
+		"namespace": float64(v.conf.Namespace),
+		"exp":       claims["exp"],
+	}, nil
+}
+
+// mapGroups maps the values of the groups claim, a string or a list of strings, to ACL groups.
+// The values that aren't in the group map are dropped.
+func (v *oidcTokenValidator) mapGroups(claim interface{}) ([]string, error) {
+	var values []string
+	switch claim := claim.(type) {
+	case nil:
+	case string:
+		values = []string{claim}
+	case []interface{}:
+		for _, value := range claim {
+			s, ok := value.(string)
+			if !ok {
+				return nil, errors.Errorf("unable to convert group to string:%v", value)
+			}
+			values = append(values, s)
+		}
+	default:
+		return nil, errors.Errorf("%s in claims is not a list of strings:%v",
+			v.conf.GroupsClaim, claim)
+	}
+
+	groupIds := make([]string, 0, len(values))
+	for _, value := range values {
+		if group, ok := v.conf.GroupMap[value]; ok {
+			groupIds = append(groupIds, group)
+		}
+	}
+	return groupIds, nil
+}
+
+// hasAudience tells if the aud claim, a string or a list of strings, holds one of the audiences.
+func hasAudience(aud interface{}, audience []string) bool {
+	var values []interface{}
+	switch aud := aud.(type) {
+	case string:
+		values = []interface{}{aud}
+	case []interface{}:
+		values = aud
+	}
+	for _, value := range values {
+		for _, a := range audience {
+			if value == a {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+//go:build !oss
+// +build !oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. All rights reserved.
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/vtta/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package edgraph
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/vtta/dgraph/ee"
+	"github.com/vtta/dgraph/x"
+)
+
+func writeJwks(t *testing.T, path string, keys map[string]interface{}) {
+	var jwkSet jose.JSONWebKeySet
+	for kid, key := range keys {
+		jwkSet.Keys = append(jwkSet.Keys, jose.JSONWebKey{Key: key, KeyID: kid, Use: "sig"})
+	}
+	data, err := json.Marshal(jwkSet)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+}
+
+func signOidcJWT(t *testing.T, method jwt.SigningMethod, kid string, key interface{},
+	claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	tokenString, err := token.SignedString(key)
+	require.NoError(t, err)
+	return tokenString
+}
+
+func TestOidcValidate(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJwks(t, jwksPath, map[string]interface{}{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey})
+
+	v := newOidcTokenValidator(&ee.OidcConfig{
+		Jwks:        jwksPath,
+		Issuer:      "https://sso.example.com",
+		Audience:    []string{"dgraph"},
+		UserClaim:   "email",
+		GroupsClaim: "roles",
+		GroupMap:    map[string]string{"admins": "guardians", "devs": "dev"},
+		Namespace:   2,
+	})
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   "https://sso.example.com",
+			"aud":   []string{"other", "dgraph"},
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"email": "alice@example.com",
+			"roles": []string{"devs", "sales", "admins"},
+		}
+	}
+
+	defer func(validator func(string) (jwt.MapClaims, error)) {
+		x.JwtValidator = validator
+	}(x.JwtValidator)
+	x.JwtValidator = v.validate
+
+	token := signOidcJWT(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims())
+	require.True(t, isOidcToken(token))
+	ud, err := validateToken(token)
+	require.NoError(t, err)
+	require.Equal(t, &userData{namespace: 2, userId: "alice@example.com",
+		groupIds: []string{"dev", "guardians"}}, ud)
+	userId, err := x.ExtractUserName(token)
+	require.NoError(t, err)
+	require.Equal(t, "alice@example.com", userId)
+
+	c := claims()
+	c["aud"], c["roles"] = "dgraph", "devs"
+	ud, err = validateToken(signOidcJWT(t, jwt.SigningMethodES256, "ec", ecKey, c))
+	require.NoError(t, err)
+	require.Equal(t, []string{"dev"}, ud.groupIds)
+
+	// The groups that aren't mapped are dropped, even if they are named after an ACL group, and
+	// so are all the groups if there is no group map.
+	c = claims()
+	c["roles"] = []string{"guardians", "devs"}
+	ud, err = validateToken(signOidcJWT(t, jwt.SigningMethodRS256, "rsa", rsaKey, c))
+	require.NoError(t, err)
+	require.Equal(t, []string{"dev"}, ud.groupIds)
+	groupIds, err := newOidcTokenValidator(&ee.OidcConfig{GroupsClaim: "roles"}).
+		mapGroups([]interface{}{"guardians"})
+	require.NoError(t, err)
+	require.Empty(t, groupIds)
+
+	invalid := map[string]func(jwt.MapClaims){
+		"is not issued by": func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"audiences":        func(c jwt.MapClaims) { c["aud"] = "other" },
+		"expired":          func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"email in claims":  func(c jwt.MapClaims) { delete(c, "email") },
+	}
+	for msg, f := range invalid {
+		c := claims()
+		f(c)
+		_, err := v.validate(signOidcJWT(t, jwt.SigningMethodRS256, "rsa", rsaKey, c))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), msg)
+	}
+
+	// The tokens signed with the HMAC secret are left to validateToken, and the tokens signed with
+	// a key that isn't in the JWKS are rejected.
+	require.False(t, isOidcToken(signOidcJWT(t, jwt.SigningMethodHS256, "rsa", []byte("secret"),
+		claims())))
+	_, err = v.validate(signOidcJWT(t, jwt.SigningMethodHS256, "rsa", []byte("secret"), claims()))
+	require.Error(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, err = v.validate(signOidcJWT(t, jwt.SigningMethodRS256, "rsa", otherKey, claims()))
+	require.Error(t, err)
+	_, err = v.validate(signOidcJWT(t, jwt.SigningMethodRS256, "rotated", otherKey, claims()))
+	require.Error(t, err)
+
+	// The rotated key is picked up once the JWKS can be fetched again.
+	writeJwks(t, jwksPath, map[string]interface{}{"rotated": &otherKey.PublicKey})
+	v.fetchedAt = time.Now().Add(-2 * minJwksRefreshInterval)
+	_, err = v.validate(signOidcJWT(t, jwt.SigningMethodRS256, "rotated", otherKey, claims()))
+	require.NoError(t, err)
+	_, err = v.validate(token)
+	require.Error(t, err)
+}
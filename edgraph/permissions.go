@@ -0,0 +1,70 @@
+// +build oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+
+	"github.com/vtta/dgraph/x"
+)
+
+type AclTarget struct {
+	UserId    string
+	Namespace *uint64
+}
+
+type PredicatePermission struct {
+	Predicate string
+	Read      bool
+	Write     bool
+	Modify    bool
+}
+
+type UserPermissions struct {
+	UserId     string
+	Namespace  uint64
+	Groups     []string
+	Guardian   bool
+	Predicates []*PredicatePermission
+	NodeFilter string
+}
+
+type StrippedPredicate struct {
+	Predicate string
+	Reason    string
+}
+
+type QueryExplanation struct {
+	UserId            string
+	Namespace         uint64
+	Groups            []string
+	Stripped          []*StrippedPredicate
+	StrippedVariables []string
+	NodeFilter        string
+}
+
+func GetUserPermissions(ctx context.Context, target *AclTarget,
+	preds []string) (*UserPermissions, error) {
+	return nil, x.ErrNotSupported
+}
+
+func ExplainQuery(ctx context.Context, target *AclTarget, query string,
+	vars map[string]string) (*QueryExplanation, error) {
+	return nil, x.ErrNotSupported
+}
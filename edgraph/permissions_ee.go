@@ -0,0 +1,228 @@
+//go:build !oss
+// +build !oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. All rights reserved.
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/vtta/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package edgraph
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vtta/dgraph/ee/acl"
+	"github.com/vtta/dgraph/gql"
+	"github.com/vtta/dgraph/schema"
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+// AclTarget names the user whose permissions are looked into. An empty UserId stands for the user
+// making the request, and a nil Namespace for the namespace of the request.
+type AclTarget struct {
+	UserId    string
+	Namespace *uint64
+}
+
+// PredicatePermission is the access that a user has to a predicate.
+type PredicatePermission struct {
+	Predicate string
+	Read      bool
+	Write     bool
+	Modify    bool
+}
+
+// UserPermissions are the effective permissions of a user, as per the ACL rules of its groups and
+// the scope of its API key if it uses one.
+type UserPermissions struct {
+	UserId    string
+	Namespace uint64
+	Groups    []string
+	// Guardian tells if the user is a member of the guardians group, which can access everything.
+	Guardian   bool
+	Predicates []*PredicatePermission
+	// NodeFilter is the filter that the nodes accessed by the user must match as per the node
+	// rules of its groups. It is empty if no rules apply.
+	NodeFilter string
+}
+
+// StrippedPredicate is a predicate that is dropped from a query, along with why it is.
+type StrippedPredicate struct {
+	Predicate string
+	Reason    string
+}
+
+// QueryExplanation tells how a query is restricted by the ACL for a user, without running it.
+type QueryExplanation struct {
+	UserId            string
+	Namespace         uint64
+	Groups            []string
+	Stripped          []*StrippedPredicate
+	StrippedVariables []string
+	NodeFilter        string
+}
+
+// aclTarget returns the context to look into the permissions of the target user with, along with
+// the user. Looking into the permissions of other users requires being a guardian, and into the
+// users of other namespaces being a guardian of the galaxy.
+func aclTarget(ctx context.Context, target *AclTarget) (context.Context, *userData, error) {
+	if len(worker.Config.HmacSecret) == 0 {
+		return nil, nil, errors.Errorf("ACL must be enabled to look into permissions")
+	}
+	caller, err := extractUserAndGroups(ctx)
+	if err != nil {
+		return nil, nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	ns := caller.namespace
+	if target.Namespace != nil && *target.Namespace != ns {
+		if err := AuthGuardianOfTheGalaxy(ctx); err != nil {
+			return nil, nil, err
+		}
+		ns = *target.Namespace
+	}
+	ctx = x.AttachNamespace(ctx, ns)
+	if target.UserId == "" && ns != caller.namespace {
+		return nil, nil, errors.Errorf("a user must be given to look into namespace %#x", ns)
+	}
+	if target.UserId == "" || (target.UserId == caller.userId && ns == caller.namespace) {
+		return ctx, caller, nil
+	}
+
+	if err := AuthorizeGuardians(ctx); err != nil {
+		return nil, nil, err
+	}
+	user, err := authorizeUser(ctx, target.UserId, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, errors.Errorf("user %q doesn't exist in namespace %#x", target.UserId, ns)
+	}
+	return ctx, &userData{namespace: ns, userId: target.UserId,
+		groupIds: acl.GetGroupIDs(user.Groups)}, nil
+}
+
+// predicatePermission returns the access that the user has to the predicate.
+func predicatePermission(ud *userData, pred string) *PredicatePermission {
+	isGuardian := x.IsGuardian(ud.groupIds)
+	allowed := func(op *acl.Operation) bool {
+		if !ud.scope.allows(pred) || (op != acl.Read && ud.scope != nil && ud.scope.ReadOnly) {
+			return false
+		}
+		return isGuardian || worker.AclCachePtr.AuthorizePredicate(ud.groupIds,
+			x.NamespaceAttr(ud.namespace, pred), op) == nil
+	}
+	return &PredicatePermission{
+		Predicate: pred,
+		Read:      allowed(acl.Read),
+		Write:     allowed(acl.Write),
+		Modify:    allowed(acl.Modify),
+	}
+}
+
+// GetUserPermissions returns the effective permissions of the target user on the given predicates,
+// or on all the predicates of its namespace if none are given.
+func GetUserPermissions(ctx context.Context, target *AclTarget,
+	preds []string) (*UserPermissions, error) {
+	ctx, ud, err := aclTarget(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if !worker.AclCachePtr.Loaded() {
+		RefreshACLs(ctx)
+	}
+
+	if len(preds) == 0 {
+		for _, pred := range schema.State().Predicates() {
+			if ns, attr := x.ParseNamespaceAttr(pred); ns == ud.namespace {
+				preds = append(preds, attr)
+			}
+		}
+		sort.Strings(preds)
+	}
+	perms := &UserPermissions{
+		UserId:    ud.userId,
+		Namespace: ud.namespace,
+		Groups:    ud.groupIds,
+		Guardian:  x.IsGuardian(ud.groupIds),
+	}
+	for _, pred := range preds {
+		perms.Predicates = append(perms.Predicates, predicatePermission(ud, pred))
+	}
+	if !perms.Guardian {
+		perms.NodeFilter = nodeRulesFilter(ud.namespace, ud.groupIds)
+	}
+	return perms, nil
+}
+
+// strippedReason tells why the predicate is dropped from the queries of the user.
+func strippedReason(ud *userData, pred string) string {
+	attr := strings.TrimPrefix(pred, "~")
+	switch {
+	case !ud.scope.allows(attr):
+		return "not in the scope of the API key"
+	case x.IsAclPredicate(attr):
+		return "only guardians can read the ACL predicates"
+	default:
+		return "no group of the user has read permission on it"
+	}
+}
+
+// ExplainQuery tells which predicates and variables of the DQL query would be dropped by the ACL
+// if the target user ran it, and which filter the nodes it reads would have to match.
+func ExplainQuery(ctx context.Context, target *AclTarget, query string,
+	vars map[string]string) (*QueryExplanation, error) {
+	ctx, ud, err := aclTarget(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedReq, err := gql.Parse(gql.Request{Str: query, Variables: vars})
+	if err != nil {
+		return nil, err
+	}
+	if err := validateQuery(parsedReq.Query); err != nil {
+		return nil, err
+	}
+	blockedPreds, blockedVars, err := authorizeQueryFor(ctx, ud, &parsedReq, false)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := &QueryExplanation{
+		UserId:    ud.userId,
+		Namespace: ud.namespace,
+		Groups:    ud.groupIds,
+	}
+	for pred := range blockedPreds {
+		if _, ok := blockedVars[pred]; ok {
+			continue
+		}
+		explanation.Stripped = append(explanation.Stripped,
+			&StrippedPredicate{Predicate: pred, Reason: strippedReason(ud, pred)})
+	}
+	sort.Slice(explanation.Stripped, func(i, j int) bool {
+		return explanation.Stripped[i].Predicate < explanation.Stripped[j].Predicate
+	})
+	for v := range blockedVars {
+		explanation.StrippedVariables = append(explanation.StrippedVariables, v)
+	}
+	sort.Strings(explanation.StrippedVariables)
+	if !x.IsGuardian(ud.groupIds) {
+		explanation.NodeFilter = nodeRulesFilter(ud.namespace, ud.groupIds)
+	}
+	return explanation, nil
+}
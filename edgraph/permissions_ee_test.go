@@ -0,0 +1,98 @@
+//go:build !oss
+// +build !oss
+
+/*
+ * Copyright 2022 Dgraph Labs, Inc. All rights reserved.
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/vtta/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package edgraph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/vtta/dgraph/ee/acl"
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+func TestExplainQuery(t *testing.T) {
+	defer func(conf worker.Options, xconf x.WorkerOptions) {
+		worker.Config, x.WorkerConfig = conf, xconf
+	}(worker.Config, x.WorkerConfig)
+	worker.Config.HmacSecret = x.SensitiveByteSlice("0123456789abcdef0123456789abcdef")
+	x.WorkerConfig.HmacSecret = worker.Config.HmacSecret
+	x.WorkerConfig.AclEnabled = true
+	worker.Config.AclJwtAlg = "HS256"
+
+	worker.AclCachePtr.Update(x.GalaxyNamespace, []acl.Group{
+		{
+			GroupID: "dev",
+			Rules: []acl.Acl{
+				{Predicate: "name", Perm: acl.Read.Code},
+				{Predicate: "friend", Perm: acl.Read.Code | acl.Write.Code},
+			},
+			NodeRules: []acl.NodeRule{{Type: "Project", Filter: `eq(team, "dev")`}},
+		},
+	})
+	worker.AclCachePtr.Set()
+	defer worker.AclCachePtr.Update(x.GalaxyNamespace, nil)
+
+	token, err := getAccessJwt("alice", []acl.Group{{GroupID: "dev"}}, x.GalaxyNamespace)
+	require.NoError(t, err)
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.New(map[string]string{"accessJwt": token}))
+
+	ud := &userData{userId: "alice", groupIds: []string{"dev"}}
+	require.Equal(t, &PredicatePermission{Predicate: "friend", Read: true, Write: true},
+		predicatePermission(ud, "friend"))
+	require.Equal(t, &PredicatePermission{Predicate: "age"}, predicatePermission(ud, "age"))
+	ud.scope = &ApiKeyScope{ReadOnly: true}
+	require.Equal(t, &PredicatePermission{Predicate: "friend", Read: true},
+		predicatePermission(ud, "friend"))
+	ud.groupIds = []string{x.GuardiansId}
+	require.Equal(t, &PredicatePermission{Predicate: "age", Read: true},
+		predicatePermission(ud, "age"))
+
+	explanation, err := ExplainQuery(ctx, &AclTarget{}, `{
+		me(func: has(name)) {
+			name
+			age
+			friend {
+				a as dgraph.password
+			}
+		}
+		you(func: uid(a)) {
+			name
+		}
+	}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, &QueryExplanation{
+		UserId:    "alice",
+		Namespace: x.GalaxyNamespace,
+		Groups:    []string{"dev"},
+		Stripped: []*StrippedPredicate{
+			{Predicate: "age", Reason: "no group of the user has read permission on it"},
+			{Predicate: "dgraph.password", Reason: "only guardians can read the ACL predicates"},
+		},
+		StrippedVariables: []string{"a"},
+		NodeFilter:        `(NOT type(Project) OR (eq(team, "dev")))`,
+	}, explanation)
+
+	// Looking into the permissions of other users requires being a guardian.
+	_, err = ExplainQuery(ctx, &AclTarget{UserId: "bob"}, `{ me(func: has(name)) { name } }`, nil)
+	require.Contains(t, err.Error(), "Only guardians are allowed access")
+	ns := uint64(1)
+	_, err = ExplainQuery(ctx, &AclTarget{UserId: "alice", Namespace: &ns},
+		`{ me(func: has(name)) { name } }`, nil)
+	require.Contains(t, err.Error(), "AuthGuardianOfTheGalaxy")
+}
@@ -0,0 +1,403 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/dgraph-io/ristretto/z"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+
+	bpb "github.com/dgraph-io/badger/v3/pb"
+	"github.com/vtta/dgraph/gql"
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+const (
+	// persistedQueryIdKey and persistedQueryVersionKey are the keys in the gRPC metadata which
+	// ask for a persisted query to be executed instead of the query text sent with the request.
+	persistedQueryIdKey      = "persisted-query-id"
+	persistedQueryVersionKey = "persisted-query-version"
+
+	queryPersistedQueries = `
+	query q($id: string) {
+		q(func: eq(dgraph.dql.p_query_id, $id)) @filter(type(dgraph.dql.persisted_query)) {
+			uid
+			dgraph.dql.p_query
+		}
+	}`
+	queryAllPersistedQueries = `
+	{
+		q(func: type(dgraph.dql.persisted_query)) {
+			uid
+			dgraph.dql.p_query
+		}
+	}`
+)
+
+var (
+	errPersistedQueryNotFound = errors.New("PersistedQueryNotFound")
+	errOnlyPersistedQueries   = errors.New("Only persisted queries are allowed on this server. " +
+		"Register the query through the /admin API and execute it by its id.")
+
+	persistedQueryPrefixes = [][]byte{
+		x.PredicatePrefix(x.GalaxyAttr("dgraph.dql.p_query_id")),
+		x.PredicatePrefix(x.GalaxyAttr("dgraph.dql.p_query")),
+	}
+
+	pqCache = &persistedQueryCache{queries: make(map[string]*cachedQuery)}
+)
+
+// PersistedQuery is a named and versioned DQL query registered through the /admin API. Clients
+// execute it by its id, and optionally version, instead of sending the query text.
+type PersistedQuery struct {
+	Id      string `json:"id"`
+	Version int    `json:"version"`
+	Query   string `json:"query"`
+	// Variables maps the variables declared by the query to their DQL type, e.g. "string!".
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// cachedQuery is a persisted query along with its plan, so that hot queries don't have to be
+// parsed again.
+type cachedQuery struct {
+	*PersistedQuery
+	*queryPlan
+}
+
+// persistedQueryCache caches the persisted queries looked up by this alpha. Entries are keyed by
+// namespace, id and version, where version 0 stands for the latest version of the query.
+type persistedQueryCache struct {
+	sync.RWMutex
+	queries map[string]*cachedQuery
+}
+
+func persistedQueryKey(ns uint64, id string, version int) string {
+	return fmt.Sprintf("%#x|%s|%d", ns, id, version)
+}
+
+func (c *persistedQueryCache) get(key string) *cachedQuery {
+	c.RLock()
+	defer c.RUnlock()
+	return c.queries[key]
+}
+
+func (c *persistedQueryCache) set(key string, cq *cachedQuery) {
+	c.Lock()
+	defer c.Unlock()
+	c.queries[key] = cq
+}
+
+func (c *persistedQueryCache) reset() {
+	c.Lock()
+	defer c.Unlock()
+	c.queries = make(map[string]*cachedQuery)
+}
+
+// AttachPersistedQuery adds the id and version of the persisted query that needs to be executed
+// into the grpc context metadata. A version of 0 executes the latest version of the query.
+func AttachPersistedQuery(ctx context.Context, id string, version int) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		md = metadata.New(nil)
+	}
+	md.Set(persistedQueryIdKey, id)
+	md.Set(persistedQueryVersionKey, strconv.Itoa(version))
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// extractPersistedQuery returns the id and version of the persisted query that the request asks
+// to execute, if any.
+func extractPersistedQuery(ctx context.Context) (string, int, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", 0, nil
+	}
+	ids := md.Get(persistedQueryIdKey)
+	if len(ids) == 0 || ids[0] == "" {
+		return "", 0, nil
+	}
+	var version int
+	if versions := md.Get(persistedQueryVersionKey); len(versions) > 0 && versions[0] != "" {
+		var err error
+		if version, err = strconv.Atoi(versions[0]); err != nil || version < 0 {
+			return "", 0, errors.Errorf("Invalid persisted query version: %q", versions[0])
+		}
+	}
+	return ids[0], version, nil
+}
+
+// getPersistedQuery returns the requested version of the persisted query with the given id in the
+// namespace of the request. A version of 0 returns the latest version of the query.
+func getPersistedQuery(ctx context.Context, id string, version int) (*cachedQuery, error) {
+	ns, err := x.ExtractNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := persistedQueryKey(ns, id, version)
+	if cq := pqCache.get(key); cq != nil {
+		return cq, nil
+	}
+
+	pqs, err := GetPersistedQueries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(pqs) == 0 {
+		return nil, errPersistedQueryNotFound
+	}
+	// GetPersistedQueries returns the versions in ascending order.
+	pq := pqs[len(pqs)-1]
+	if version != 0 {
+		pq = nil
+		for _, q := range pqs {
+			if q.Version == version {
+				pq = q
+				break
+			}
+		}
+		if pq == nil {
+			return nil, errPersistedQueryNotFound
+		}
+	}
+
+	cq := &cachedQuery{PersistedQuery: pq, queryPlan: newQueryPlan(pq.Query)}
+	pqCache.set(key, cq)
+	return cq, nil
+}
+
+// GetPersistedQueries returns all the versions of the persisted query with the given id, or all
+// the persisted queries if id is empty, sorted by their id and version.
+func GetPersistedQueries(ctx context.Context, id string) ([]*PersistedQuery, error) {
+	req := &Request{
+		req: &api.Request{
+			Query:    queryAllPersistedQueries,
+			ReadOnly: true,
+		},
+		doAuth: NoAuthorize,
+	}
+	if id != "" {
+		req.req.Query = queryPersistedQueries
+		req.req.Vars = map[string]string{"$id": id}
+	}
+	resp, err := (&Server{}).doQuery(ctx, req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while querying persisted queries")
+	}
+	nodes, err := unmarshalPersistedQueries(resp.GetJson())
+	if err != nil {
+		return nil, err
+	}
+
+	pqs := make([]*PersistedQuery, 0, len(nodes))
+	for _, n := range nodes {
+		pqs = append(pqs, n.query)
+	}
+	sort.Slice(pqs, func(i, j int) bool {
+		if pqs[i].Id != pqs[j].Id {
+			return pqs[i].Id < pqs[j].Id
+		}
+		return pqs[i].Version < pqs[j].Version
+	})
+	return pqs, nil
+}
+
+type persistedQueryNode struct {
+	uid   string
+	query *PersistedQuery
+}
+
+func unmarshalPersistedQueries(js []byte) ([]persistedQueryNode, error) {
+	var resp struct {
+		Q []struct {
+			Uid    string `json:"uid"`
+			PQuery string `json:"dgraph.dql.p_query"`
+		} `json:"q"`
+	}
+	if len(js) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(js, &resp); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]persistedQueryNode, 0, len(resp.Q))
+	for _, q := range resp.Q {
+		var pq PersistedQuery
+		if err := json.Unmarshal([]byte(q.PQuery), &pq); err != nil {
+			return nil, errors.Wrapf(err, "while unmarshalling persisted query %s", q.Uid)
+		}
+		nodes = append(nodes, persistedQueryNode{uid: q.Uid, query: &pq})
+	}
+	return nodes, nil
+}
+
+// RegisterPersistedQuery stores query as the next version of the persisted query with the given
+// id in the namespace of the request and returns it.
+func RegisterPersistedQuery(ctx context.Context, id, query string) (*PersistedQuery, error) {
+	id = strings.TrimSpace(id)
+	query = strings.TrimSpace(query)
+	if id == "" {
+		return nil, errors.New("The id of a persisted query can't be empty")
+	}
+	if query == "" {
+		return nil, errors.New("The query of a persisted query can't be empty")
+	}
+	vars, err := gql.ParseVariables(query)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while parsing persisted query %q", id)
+	}
+	for name, typ := range vars {
+		if typ == "" {
+			return nil, errors.Errorf("Type of variable %v not specified", name)
+		}
+	}
+
+	// Read the existing versions and write the new one in the same transaction, so that two
+	// concurrent registrations of the same id can't end up with the same version. The upsert
+	// directive on dgraph.dql.p_query_id makes one of them abort.
+	req := &Request{
+		req: &api.Request{
+			Query: queryPersistedQueries,
+			Vars:  map[string]string{"$id": id},
+		},
+		doAuth: NoAuthorize,
+	}
+	resp, err := (&Server{}).doQuery(ctx, req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while querying persisted query %q", id)
+	}
+	nodes, err := unmarshalPersistedQueries(resp.GetJson())
+	if err != nil {
+		return nil, err
+	}
+	pq := &PersistedQuery{Id: id, Version: 1, Query: query, Variables: vars}
+	for _, n := range nodes {
+		if n.query.Version >= pq.Version {
+			pq.Version = n.query.Version + 1
+		}
+	}
+	val, err := json.Marshal(pq)
+	if err != nil {
+		return nil, err
+	}
+
+	req = &Request{
+		req: &api.Request{
+			Mutations: []*api.Mutation{{
+				Set: []*api.NQuad{
+					{
+						Subject:     "_:pq",
+						Predicate:   "dgraph.dql.p_query_id",
+						ObjectValue: &api.Value{Val: &api.Value_StrVal{StrVal: id}},
+					},
+					{
+						Subject:     "_:pq",
+						Predicate:   "dgraph.dql.p_query",
+						ObjectValue: &api.Value{Val: &api.Value_StrVal{StrVal: string(val)}},
+					},
+					{
+						Subject:   "_:pq",
+						Predicate: "dgraph.type",
+						ObjectValue: &api.Value{Val: &api.Value_StrVal{
+							StrVal: "dgraph.dql.persisted_query"}},
+					},
+				},
+			}},
+			StartTs:   resp.GetTxn().GetStartTs(),
+			CommitNow: true,
+		},
+		doAuth: NoAuthorize,
+	}
+	if _, err := (&Server{}).doQuery(context.WithValue(ctx, IsGraphql, true), req); err != nil {
+		return nil, errors.Wrapf(err, "while storing persisted query %q", id)
+	}
+	pqCache.reset()
+	return pq, nil
+}
+
+// DeletePersistedQuery deletes the given version of the persisted query with the given id, or all
+// its versions if version is 0. It returns the number of versions that were deleted.
+func DeletePersistedQuery(ctx context.Context, id string, version int) (int, error) {
+	req := &Request{
+		req: &api.Request{
+			Query: queryPersistedQueries,
+			Vars:  map[string]string{"$id": id},
+		},
+		doAuth: NoAuthorize,
+	}
+	resp, err := (&Server{}).doQuery(ctx, req)
+	if err != nil {
+		return 0, errors.Wrapf(err, "while querying persisted query %q", id)
+	}
+	nodes, err := unmarshalPersistedQueries(resp.GetJson())
+	if err != nil {
+		return 0, err
+	}
+
+	var del []*api.NQuad
+	for _, n := range nodes {
+		if version != 0 && n.query.Version != version {
+			continue
+		}
+		del = append(del, &api.NQuad{
+			Subject:     n.uid,
+			Predicate:   x.Star,
+			ObjectValue: &api.Value{Val: &api.Value_DefaultVal{DefaultVal: x.Star}},
+		})
+	}
+	if len(del) == 0 {
+		return 0, errPersistedQueryNotFound
+	}
+
+	req = &Request{
+		req: &api.Request{
+			Mutations: []*api.Mutation{{Del: del}},
+			StartTs:   resp.GetTxn().GetStartTs(),
+			CommitNow: true,
+		},
+		doAuth: NoAuthorize,
+	}
+	if _, err := (&Server{}).doQuery(context.WithValue(ctx, IsGraphql, true), req); err != nil {
+		return 0, errors.Wrapf(err, "while deleting persisted query %q", id)
+	}
+	pqCache.reset()
+	return len(del), nil
+}
+
+// SubscribeForPersistedQueryUpdates subscribes for the persisted query predicates and clears the
+// persisted query cache whenever any of them change, so that queries registered or deleted through
+// other alphas are picked up by this one.
+func SubscribeForPersistedQueryUpdates(closer *z.Closer) {
+	worker.SubscribeForUpdates(persistedQueryPrefixes, x.IgnoreBytes, func(kvs *bpb.KVList) {
+		if kvs == nil || len(kvs.Kv) == 0 {
+			return
+		}
+		glog.V(3).Infof("Got persisted query update via subscription.")
+		pqCache.reset()
+	}, 1, closer)
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestExtractPersistedQuery(t *testing.T) {
+	id, version, err := extractPersistedQuery(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "", id)
+	require.Equal(t, 0, version)
+
+	ctx := AttachPersistedQuery(context.Background(), "friends", 3)
+	id, version, err = extractPersistedQuery(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "friends", id)
+	require.Equal(t, 3, version)
+
+	md := metadata.New(map[string]string{
+		persistedQueryIdKey:      "friends",
+		persistedQueryVersionKey: "latest",
+	})
+	_, _, err = extractPersistedQuery(metadata.NewIncomingContext(context.Background(), md))
+	require.Error(t, err)
+}
@@ -0,0 +1,279 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/dgraph-io/ristretto/z"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	ostats "go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	bpb "github.com/dgraph-io/badger/v3/pb"
+	"github.com/vtta/dgraph/query"
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+const (
+	queryAllQueryLimits = `
+	{
+		q(func: has(dgraph.query_limits)) {
+			uid
+			dgraph.query_limits
+		}
+	}`
+	queryQueryLimitsByKey = `
+	query q($key: string) {
+		q(func: eq(dgraph.query_limits_key, $key)) {
+			ql as uid
+		}
+	}`
+)
+
+var (
+	queryLimitsPrefixes = [][]byte{
+		x.PredicatePrefix(x.GalaxyAttr("dgraph.query_limits")),
+	}
+
+	qlCache = &queryLimitsCache{namespaces: make(map[uint64][]*QueryLimits)}
+)
+
+// QueryLimits are the limits on the resources used by the queries of a namespace, or of the
+// members of an ACL group in it. They override the defaults set by the --limit flag.
+type QueryLimits struct {
+	// Group is the ACL group that the limits apply to. The limits apply to the whole namespace if
+	// it is empty.
+	Group string `json:"group,omitempty"`
+	query.Limits
+}
+
+// queryLimitsCache caches the query limits of the namespaces looked up by this alpha.
+type queryLimitsCache struct {
+	sync.RWMutex
+	namespaces map[uint64][]*QueryLimits
+}
+
+func (c *queryLimitsCache) get(ns uint64) ([]*QueryLimits, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	qls, ok := c.namespaces[ns]
+	return qls, ok
+}
+
+func (c *queryLimitsCache) set(ns uint64, qls []*QueryLimits) {
+	c.Lock()
+	defer c.Unlock()
+	c.namespaces[ns] = qls
+}
+
+func (c *queryLimitsCache) reset() {
+	c.Lock()
+	defer c.Unlock()
+	c.namespaces = make(map[uint64][]*QueryLimits)
+}
+
+// defaultQueryLimits returns the limits set by the --limit flag.
+func defaultQueryLimits() query.Limits {
+	return query.Limits{
+		Uids:        x.Config.LimitQueryUids,
+		Depth:       x.Config.LimitQueryDepth,
+		ResultBytes: x.Config.LimitQueryResultBytes,
+		MemoryBytes: x.Config.LimitQueryMemoryBytes,
+	}
+}
+
+// queryLimits returns the limits that apply to the queries of the request. The limits of the
+// namespace override the defaults, and the limits of the ACL groups of the user override those of
+// the namespace. If the user is in more than one group with limits, the highest of their limits
+// apply.
+func queryLimits(ctx context.Context) (query.Limits, error) {
+	ns, err := x.ExtractNamespace(ctx)
+	if err != nil {
+		return query.Limits{}, err
+	}
+	qls, ok := qlCache.get(ns)
+	if !ok {
+		if qls, err = GetQueryLimits(ctx); err != nil {
+			return query.Limits{}, err
+		}
+		qlCache.set(ns, qls)
+	}
+	return resolveQueryLimits(qls, aclGroups(ctx)), nil
+}
+
+func resolveQueryLimits(qls []*QueryLimits, groups []string) query.Limits {
+	limits := defaultQueryLimits()
+	var groupLimits query.Limits
+	for _, ql := range qls {
+		switch {
+		case ql.Group == "":
+			limits = limits.Override(ql.Limits)
+		case x.HasString(groups, ql.Group):
+			groupLimits = highestLimits(groupLimits, ql.Limits)
+		}
+	}
+	return limits.Override(groupLimits)
+}
+
+// highestLimits returns the highest of every limit set in a or b.
+func highestLimits(a, b query.Limits) query.Limits {
+	highest := func(x, y uint64) uint64 {
+		if x > y {
+			return x
+		}
+		return y
+	}
+	return query.Limits{
+		Uids:        highest(a.Uids, b.Uids),
+		Depth:       highest(a.Depth, b.Depth),
+		ResultBytes: highest(a.ResultBytes, b.ResultBytes),
+		MemoryBytes: highest(a.MemoryBytes, b.MemoryBytes),
+	}
+}
+
+// checkQueryOverLimit records the query in the metrics if it was aborted for exceeding a limit,
+// and returns the error as a ResourceExhausted status, so that clients can tell it apart. Other
+// errors are returned as they are.
+func checkQueryOverLimit(ctx context.Context, err error) error {
+	var limitErr *query.LimitError
+	if !errors.As(err, &limitErr) {
+		return err
+	}
+	_ = ostats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(x.KeyLimit, limitErr.Limit)},
+		x.NumQueriesOverLimit.M(1))
+	return status.Error(codes.ResourceExhausted, limitErr.Error())
+}
+
+// GetQueryLimits returns the query limits set in the namespace of the request, sorted by group
+// with the limits of the whole namespace first.
+func GetQueryLimits(ctx context.Context) ([]*QueryLimits, error) {
+	req := &Request{
+		req: &api.Request{
+			Query: queryAllQueryLimits,
+		},
+		doAuth: NoAuthorize,
+	}
+	resp, err := (&Server{}).doQuery(ctx, req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while querying query limits")
+	}
+
+	var res struct {
+		Q []struct {
+			Uid    string `json:"uid"`
+			Limits string `json:"dgraph.query_limits"`
+		} `json:"q"`
+	}
+	if len(resp.GetJson()) > 0 {
+		if err := json.Unmarshal(resp.GetJson(), &res); err != nil {
+			return nil, err
+		}
+	}
+	qls := make([]*QueryLimits, 0, len(res.Q))
+	for _, q := range res.Q {
+		var ql QueryLimits
+		if err := json.Unmarshal([]byte(q.Limits), &ql); err != nil {
+			return nil, errors.Wrapf(err, "while unmarshalling query limits %s", q.Uid)
+		}
+		qls = append(qls, &ql)
+	}
+	sort.Slice(qls, func(i, j int) bool { return qls[i].Group < qls[j].Group })
+	return qls, nil
+}
+
+// queryLimitsKey returns the value of dgraph.query_limits_key for the limits of the given group,
+// or of the whole namespace if the group is empty.
+func queryLimitsKey(group string) string {
+	if group == "" {
+		return "namespace"
+	}
+	return "group:" + group
+}
+
+// SetQueryLimits sets the query limits of the given group, or of the whole namespace of the
+// request if the group is empty. The limits that are zero are unset, and the limits of the group
+// are removed if none are set.
+func SetQueryLimits(ctx context.Context, ql *QueryLimits) error {
+	ql.Group = strings.TrimSpace(ql.Group)
+
+	// The limits are looked up by their key and written in a single upsert block. The upsert
+	// directive on dgraph.query_limits_key makes one of two concurrent upserts of the same key
+	// abort, so that a group can't end up with more than one node of limits.
+	mu := &api.Mutation{}
+	if ql.IsZero() {
+		mu.Del = []*api.NQuad{{
+			Subject:     "uid(ql)",
+			Predicate:   x.Star,
+			ObjectValue: &api.Value{Val: &api.Value_DefaultVal{DefaultVal: x.Star}},
+		}}
+	} else {
+		val, err := json.Marshal(ql)
+		if err != nil {
+			return err
+		}
+		mu.Set = []*api.NQuad{
+			{
+				Subject:     "uid(ql)",
+				Predicate:   "dgraph.query_limits_key",
+				ObjectValue: &api.Value{Val: &api.Value_StrVal{StrVal: queryLimitsKey(ql.Group)}},
+			},
+			{
+				Subject:     "uid(ql)",
+				Predicate:   "dgraph.query_limits",
+				ObjectValue: &api.Value{Val: &api.Value_StrVal{StrVal: string(val)}},
+			},
+		}
+	}
+
+	req := &Request{
+		req: &api.Request{
+			Query:     queryQueryLimitsByKey,
+			Vars:      map[string]string{"$key": queryLimitsKey(ql.Group)},
+			Mutations: []*api.Mutation{mu},
+			CommitNow: true,
+		},
+		doAuth: NoAuthorize,
+	}
+	if _, err := (&Server{}).doQuery(context.WithValue(ctx, IsGraphql, true), req); err != nil {
+		return errors.Wrapf(err, "while storing query limits")
+	}
+	qlCache.reset()
+	return nil
+}
+
+// SubscribeForQueryLimitUpdates subscribes for the query limits predicate and clears the query
+// limits cache whenever it changes, so that limits set through other alphas are picked up by this
+// one.
+func SubscribeForQueryLimitUpdates(closer *z.Closer) {
+	worker.SubscribeForUpdates(queryLimitsPrefixes, x.IgnoreBytes, func(kvs *bpb.KVList) {
+		if kvs == nil || len(kvs.Kv) == 0 {
+			return
+		}
+		glog.V(3).Infof("Got query limits update via subscription.")
+		qlCache.reset()
+	}, 1, closer)
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vtta/dgraph/query"
+	"github.com/vtta/dgraph/x"
+)
+
+func TestResolveQueryLimits(t *testing.T) {
+	defer func(c x.Options) { x.Config = c }(x.Config)
+	x.Config.LimitQueryUids = 1000
+	x.Config.LimitQueryDepth = 10
+
+	qls := []*QueryLimits{
+		{Limits: query.Limits{Uids: 100, ResultBytes: 1 << 20}},
+		{Group: "analysts", Limits: query.Limits{Uids: 10000}},
+		{Group: "devs", Limits: query.Limits{Uids: 500, Depth: 20}},
+		{Group: "ops", Limits: query.Limits{MemoryBytes: 1 << 30}},
+	}
+
+	require.Equal(t, query.Limits{Uids: 100, Depth: 10, ResultBytes: 1 << 20},
+		resolveQueryLimits(qls, nil))
+	require.Equal(t, query.Limits{Uids: 500, Depth: 20, ResultBytes: 1 << 20},
+		resolveQueryLimits(qls, []string{"devs"}))
+	require.Equal(t, query.Limits{Uids: 10000, Depth: 20, ResultBytes: 1 << 20},
+		resolveQueryLimits(qls, []string{"devs", "analysts"}))
+	require.Equal(t, query.Limits{Uids: 1000, Depth: 10},
+		resolveQueryLimits(nil, []string{"devs"}))
+}
+
+func TestCheckQueryOverLimit(t *testing.T) {
+	err := errors.New("some error")
+	require.Equal(t, err, checkQueryOverLimit(context.Background(), err))
+
+	err = errors.Wrapf(&query.LimitError{Limit: query.LimitUids, Max: 10, Actual: 12},
+		"while processing query")
+	err = checkQueryOverLimit(context.Background(), err)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Contains(t, err.Error(),
+		"Query exceeded the limit on uids: 12 is more than the allowed 10")
+}
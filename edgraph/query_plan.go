@@ -0,0 +1,99 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"sync"
+
+	"github.com/vtta/dgraph/gql"
+	"github.com/vtta/dgraph/x"
+)
+
+// maxParsedVariables is the maximum number of distinct sets of variables for which the parsed
+// form of a single query is cached.
+const maxParsedVariables = 100
+
+var plans = &queryPlanCache{plans: make(map[string]*queryPlan)}
+
+// queryPlan is a DQL query along with its parsed and validated form for every distinct set of
+// variables it has been executed with, so that hot queries don't have to be parsed again.
+type queryPlan struct {
+	query string
+
+	sync.RWMutex
+	parsed map[string]gql.Result
+}
+
+func newQueryPlan(query string) *queryPlan {
+	return &queryPlan{query: query, parsed: make(map[string]gql.Result)}
+}
+
+// parse returns the parsed form of the query for the given variables. The result is a copy which
+// the caller is free to modify.
+func (p *queryPlan) parse(vars map[string]string) (gql.Result, error) {
+	key := varsKey(vars)
+	p.RLock()
+	res, ok := p.parsed[key]
+	p.RUnlock()
+	if ok {
+		return res.Copy(), nil
+	}
+
+	res, err := gql.Parse(gql.Request{Str: p.query, Variables: vars})
+	if err != nil {
+		return res, err
+	}
+	if err := validateQuery(res.Query); err != nil {
+		return res, err
+	}
+
+	p.Lock()
+	if len(p.parsed) < maxParsedVariables {
+		p.parsed[key] = res
+	}
+	p.Unlock()
+	return res.Copy(), nil
+}
+
+// queryPlanCache holds the plans of the queries executed by this alpha, keyed by the query text.
+// It holds at most x.Config.QueryPlanCacheSize plans.
+type queryPlanCache struct {
+	sync.Mutex
+	plans map[string]*queryPlan
+}
+
+// get returns the plan of the given query. It returns nil if the cache is disabled.
+func (c *queryPlanCache) get(query string) *queryPlan {
+	size := x.Config.QueryPlanCacheSize
+	if size <= 0 {
+		return nil
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if p, ok := c.plans[query]; ok {
+		return p
+	}
+	if len(c.plans) >= size {
+		// Start afresh instead of keeping track of the least used plans. The hot queries will
+		// be back soon enough.
+		c.plans = make(map[string]*queryPlan)
+	}
+	p := newQueryPlan(query)
+	c.plans[query] = p
+	return p
+}
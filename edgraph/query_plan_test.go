@@ -0,0 +1,85 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/query"
+	"github.com/vtta/dgraph/x"
+)
+
+func TestQueryPlanParse(t *testing.T) {
+	p := newQueryPlan(`query q($name: string!) {
+		q(func: eq(name, $name)) {
+			name
+		}
+	}`)
+
+	res, err := p.parse(map[string]string{"$name": "alice"})
+	require.NoError(t, err)
+	require.Equal(t, "alice", res.Query[0].Func.Args[0].Value)
+	// Modifying the result must not change the cached parsed query.
+	res.Query[0].Func.Args[0].Value = "mallory"
+
+	res, err = p.parse(map[string]string{"$name": "alice"})
+	require.NoError(t, err)
+	require.Equal(t, "alice", res.Query[0].Func.Args[0].Value)
+
+	res, err = p.parse(map[string]string{"$name": "bob"})
+	require.NoError(t, err)
+	require.Equal(t, "bob", res.Query[0].Func.Args[0].Value)
+	require.Len(t, p.parsed, 2)
+
+	_, err = p.parse(nil)
+	require.Error(t, err)
+}
+
+func TestQueryPlanCache(t *testing.T) {
+	defer func(size int) { x.Config.QueryPlanCacheSize = size }(x.Config.QueryPlanCacheSize)
+	c := &queryPlanCache{plans: make(map[string]*queryPlan)}
+
+	x.Config.QueryPlanCacheSize = 0
+	require.Nil(t, c.get("{ q(func: uid(1)) { uid } }"))
+
+	x.Config.QueryPlanCacheSize = 2
+	p := c.get("{ q(func: uid(1)) { uid } }")
+	require.NotNil(t, p)
+	require.True(t, p == c.get("{ q(func: uid(1)) { uid } }"))
+	c.get("{ q(func: uid(2)) { uid } }")
+	require.Len(t, c.plans, 2)
+
+	// The cache starts afresh once it is full.
+	c.get("{ q(func: uid(3)) { uid } }")
+	require.Len(t, c.plans, 1)
+	require.False(t, p == c.get("{ q(func: uid(1)) { uid } }"))
+}
+
+func TestAddPlanToJson(t *testing.T) {
+	plan := []*query.PlanNode{{Name: "q", Func: "uid(0x1)"}}
+
+	js, err := addPlanToJson([]byte(`{"q":[{"uid":"0x1"}]}`), plan)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"_explain_":[{"name":"q","func":"uid(0x1)","pushdown":false}],
+		"q":[{"uid":"0x1"}]}`, string(js))
+
+	js, err = addPlanToJson([]byte(`{}`), nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"_explain_":[]}`, string(js))
+}
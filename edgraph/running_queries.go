@@ -0,0 +1,144 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vtta/dgraph/x"
+)
+
+var runningQueries = &runningQueryRegistry{queries: make(map[uint64]*runningQuery)}
+
+// RunningQuery describes a request that is being executed by this alpha.
+type RunningQuery struct {
+	// Id identifies the request among the ones executed by this alpha since it started.
+	Id        uint64
+	Namespace uint64
+	// User is the ACL user that sent the request. It is empty if ACL isn't enabled.
+	User      string
+	StartedAt time.Time
+	// Query is the text of the query of the request. It is empty for a mutation without a query.
+	Query    string
+	Mutation bool
+	GraphQL  bool
+}
+
+type runningQuery struct {
+	RunningQuery
+	cancel context.CancelFunc
+	killed uint32
+}
+
+// runningQueryRegistry holds the requests that are being executed by this alpha, so that they can
+// be listed and killed.
+type runningQueryRegistry struct {
+	sync.RWMutex
+	lastId  uint64
+	queries map[uint64]*runningQuery
+}
+
+// add registers the request, and returns the context to execute it with, which is cancelled if
+// the request gets killed.
+func (r *runningQueryRegistry) add(ctx context.Context, req *api.Request,
+	isGraphQL bool) (context.Context, *runningQuery) {
+	ns, _ := x.ExtractNamespace(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	rq := &runningQuery{
+		RunningQuery: RunningQuery{
+			Namespace: ns,
+			User:      aclUser(ctx),
+			StartedAt: time.Now(),
+			Query:     req.GetQuery(),
+			Mutation:  len(req.GetMutations()) > 0,
+			GraphQL:   isGraphQL,
+		},
+		cancel: cancel,
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.lastId++
+	rq.Id = r.lastId
+	r.queries[rq.Id] = rq
+	return ctx, rq
+}
+
+// remove unregisters the request once it is done. If it was killed, the error it failed with is
+// replaced with one that says so.
+func (r *runningQueryRegistry) remove(rq *runningQuery, err error) error {
+	r.Lock()
+	delete(r.queries, rq.Id)
+	r.Unlock()
+	rq.cancel()
+
+	if err != nil && atomic.LoadUint32(&rq.killed) == 1 {
+		return status.Errorf(codes.Canceled, "Query %d was killed", rq.Id)
+	}
+	return err
+}
+
+// ListRunningQueries returns the requests that are being executed by this alpha in the namespace
+// of the request, sorted by the time they started at. The guardians of the galaxy get the requests
+// of all the namespaces.
+func ListRunningQueries(ctx context.Context) ([]RunningQuery, error) {
+	ns, err := x.ExtractNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	runningQueries.RLock()
+	rqs := make([]RunningQuery, 0, len(runningQueries.queries))
+	for _, rq := range runningQueries.queries {
+		if ns == x.GalaxyNamespace || rq.Namespace == ns {
+			rqs = append(rqs, rq.RunningQuery)
+		}
+	}
+	runningQueries.RUnlock()
+
+	sort.Slice(rqs, func(i, j int) bool { return rqs[i].Id < rqs[j].Id })
+	return rqs, nil
+}
+
+// KillQuery cancels the request with the given id, which must be in the namespace of the request
+// unless it is made by a guardian of the galaxy. The request is cancelled in all the groups it is
+// being processed by, as they get their work through calls made with the context of the request.
+func KillQuery(ctx context.Context, id uint64) error {
+	ns, err := x.ExtractNamespace(ctx)
+	if err != nil {
+		return err
+	}
+
+	runningQueries.RLock()
+	rq, ok := runningQueries.queries[id]
+	runningQueries.RUnlock()
+	if !ok || (ns != x.GalaxyNamespace && rq.Namespace != ns) {
+		return errors.Errorf("No query with id %d is running", id)
+	}
+	atomic.StoreUint32(&rq.killed, 1)
+	rq.cancel()
+	return nil
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vtta/dgraph/protos/pb"
+	"github.com/vtta/dgraph/query"
+	"github.com/vtta/dgraph/x"
+)
+
+func TestKillQuery(t *testing.T) {
+	galaxy := x.AttachNamespace(context.Background(), x.GalaxyNamespace)
+	ns1 := x.AttachNamespace(context.Background(), 1)
+
+	ctx1, rq1 := runningQueries.add(ns1, &api.Request{Query: "{ q(func: has(name)) { name } }"},
+		false)
+	ctx2, rq2 := runningQueries.add(galaxy, &api.Request{Mutations: []*api.Mutation{{}}}, true)
+
+	rqs, err := ListRunningQueries(ns1)
+	require.NoError(t, err)
+	require.Len(t, rqs, 1)
+	require.Equal(t, rq1.Id, rqs[0].Id)
+	require.Equal(t, uint64(1), rqs[0].Namespace)
+	require.False(t, rqs[0].Mutation)
+
+	rqs, err = ListRunningQueries(galaxy)
+	require.NoError(t, err)
+	require.Len(t, rqs, 2)
+	require.True(t, rqs[1].Mutation)
+	require.True(t, rqs[1].GraphQL)
+
+	// A query can't be killed from another namespace.
+	require.Error(t, KillQuery(ns1, rq2.Id))
+	require.NoError(t, ctx2.Err())
+
+	require.NoError(t, KillQuery(ns1, rq1.Id))
+	require.Equal(t, context.Canceled, ctx1.Err())
+	err = runningQueries.remove(rq1, ctx1.Err())
+	require.Equal(t, codes.Canceled, status.Code(err))
+	require.Error(t, KillQuery(ns1, rq1.Id))
+
+	require.NoError(t, runningQueries.remove(rq2, nil))
+	require.Equal(t, context.Canceled, ctx2.Err())
+	rqs, err = ListRunningQueries(galaxy)
+	require.NoError(t, err)
+	require.Empty(t, rqs)
+}
+
+func TestKilledQueryIsNotCached(t *testing.T) {
+	ns1 := x.AttachNamespace(context.Background(), 1)
+	req := &api.Request{Query: "{ q(func: has(name)) { name } }"}
+	cacheKey := queryCacheKey(ns1, req)
+	defer queryCache.Delete(cacheKey)
+
+	ctx, rq := runningQueries.add(ns1, req, false)
+	started := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := processCached(ctx, cacheKey,
+			func(ctx context.Context) (query.ExecutionResult, error) {
+				close(started)
+				<-ctx.Done()
+				return query.ExecutionResult{}, ctx.Err()
+			})
+		done <- runningQueries.remove(rq, err)
+	}()
+	<-started
+	require.NoError(t, KillQuery(ns1, rq.Id))
+	require.Equal(t, codes.Canceled, status.Code(<-done))
+
+	// The query is run again rather than served the result of the killed one.
+	ctx, rq = runningQueries.add(ns1, req, false)
+	ran := false
+	er, err := processCached(ctx, cacheKey,
+		func(ctx context.Context) (query.ExecutionResult, error) {
+			ran = true
+			return query.ExecutionResult{Types: []*pb.TypeUpdate{{TypeName: "Person"}}}, nil
+		})
+	require.NoError(t, runningQueries.remove(rq, err))
+	require.True(t, ran)
+	require.Len(t, er.Types, 1)
+}
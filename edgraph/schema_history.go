@@ -0,0 +1,346 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+
+	"github.com/vtta/dgraph/protos/pb"
+	"github.com/vtta/dgraph/schema"
+	"github.com/vtta/dgraph/types"
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+// SchemaChangeKind is the kind of change that a schema update makes to a predicate or a type.
+type SchemaChangeKind string
+
+const (
+	PredicateAdded   SchemaChangeKind = "PREDICATE_ADDED"
+	PredicateRemoved SchemaChangeKind = "PREDICATE_REMOVED"
+	TypeChanged      SchemaChangeKind = "TYPE_CHANGED"
+	IndexAdded       SchemaChangeKind = "INDEX_ADDED"
+	IndexRemoved     SchemaChangeKind = "INDEX_REMOVED"
+	DirectiveChanged SchemaChangeKind = "DIRECTIVE_CHANGED"
+	DgraphTypeAdded  SchemaChangeKind = "DGRAPH_TYPE_ADDED"
+)
+
+// SchemaChangeSeverity tells how disruptive a schema change is. Severities are ordered, so that
+// the severity of a set of changes is the highest severity among them.
+type SchemaChangeSeverity int
+
+const (
+	// SchemaChangeSafe changes only add to the schema.
+	SchemaChangeSafe SchemaChangeSeverity = iota
+	// SchemaChangeExpensive changes rebuild indexes, which takes a while on big predicates.
+	SchemaChangeExpensive
+	// SchemaChangeDestructive changes drop indexes or data, or break queries that used them.
+	SchemaChangeDestructive
+)
+
+func (s SchemaChangeSeverity) String() string {
+	switch s {
+	case SchemaChangeExpensive:
+		return "EXPENSIVE"
+	case SchemaChangeDestructive:
+		return "DESTRUCTIVE"
+	default:
+		return "SAFE"
+	}
+}
+
+// SchemaChange is a change that applying a schema would make to the current Dgraph schema.
+type SchemaChange struct {
+	Kind     SchemaChangeKind
+	Severity SchemaChangeSeverity
+	// Predicate is the changed predicate, and Type is the changed Dgraph type. Either of them
+	// may be empty, e.g. Predicate is empty for an added type.
+	Predicate string
+	Type      string
+	// From and To describe what changed, e.g. the tokenizers of an index.
+	From string
+	To   string
+}
+
+// GetGQLSchemaHistory returns the versions of the GraphQL schema recorded for the namespace of
+// the request, sorted by their version.
+func GetGQLSchemaHistory(ctx context.Context) ([]*worker.GQLSchemaVersion, error) {
+	if !x.WorkerConfig.AclEnabled {
+		ctx = x.AttachNamespace(ctx, x.GalaxyNamespace)
+	}
+	history, err := worker.GetGQLSchemaHistory(ctx, worker.State.GetTimestamp(true))
+	return history, errors.Wrapf(err, "while reading GraphQL schema history")
+}
+
+// DiffSchema returns the changes that altering the Dgraph schema of the namespace of the request
+// with dgraphSchema would make, without applying them. Like an alter, it only looks at the
+// predicates and types in dgraphSchema, as the ones that aren't in it are left as they are.
+func DiffSchema(ctx context.Context, dgraphSchema string) ([]*SchemaChange, error) {
+	if !x.WorkerConfig.AclEnabled {
+		ctx = x.AttachNamespace(ctx, x.GalaxyNamespace)
+	}
+	if strings.TrimSpace(dgraphSchema) == "" {
+		return nil, nil
+	}
+	parsed, err := parseSchemaFromAlterOperation(ctx, &api.Operation{Schema: dgraphSchema})
+	if err != nil {
+		return nil, err
+	}
+
+	preds := make([]string, 0, len(parsed.Preds))
+	for _, su := range parsed.Preds {
+		preds = append(preds, su.Predicate)
+	}
+	var current []*pb.SchemaNode
+	if len(preds) > 0 {
+		if current, err = worker.GetSchemaOverNetwork(ctx,
+			&pb.SchemaRequest{Predicates: preds}); err != nil {
+			return nil, errors.Wrapf(err, "while reading the current schema")
+		}
+	}
+	typeNames := make([]string, 0, len(parsed.Types))
+	for _, tu := range parsed.Types {
+		typeNames = append(typeNames, tu.TypeName)
+	}
+	var currentTypes []*pb.TypeUpdate
+	if len(typeNames) > 0 {
+		if currentTypes, err = worker.GetTypes(ctx,
+			&pb.SchemaRequest{Types: typeNames}); err != nil {
+			return nil, errors.Wrapf(err, "while reading the current types")
+		}
+	}
+	return diffSchema(current, currentTypes, parsed), nil
+}
+
+// predSchema is the part of the schema of a predicate that a schema change can touch, in a form
+// that is the same for the current schema and the parsed one.
+type predSchema struct {
+	typ        string
+	list       bool
+	tokenizers []string
+	reverse    bool
+	count      bool
+	upsert     bool
+	lang       bool
+	noConflict bool
+}
+
+func predSchemaFromNode(n *pb.SchemaNode) *predSchema {
+	return &predSchema{
+		typ:        n.Type,
+		list:       n.List,
+		tokenizers: n.Tokenizer,
+		reverse:    n.Reverse,
+		count:      n.Count,
+		upsert:     n.Upsert,
+		lang:       n.Lang,
+		noConflict: n.NoConflict,
+	}
+}
+
+func predSchemaFromUpdate(su *pb.SchemaUpdate) *predSchema {
+	ps := &predSchema{
+		typ:        types.TypeID(su.ValueType).Name(),
+		list:       su.List,
+		reverse:    su.Directive == pb.SchemaUpdate_REVERSE,
+		count:      su.Count,
+		upsert:     su.Upsert,
+		lang:       su.Lang,
+		noConflict: su.NoConflict,
+	}
+	if su.Directive == pb.SchemaUpdate_INDEX {
+		ps.tokenizers = su.Tokenizer
+	}
+	return ps
+}
+
+func (ps *predSchema) typeName() string {
+	if ps.list {
+		return "[" + ps.typ + "]"
+	}
+	return ps.typ
+}
+
+// diffSchema compares the parsed schema with the current schema of its predicates and types,
+// and classifies the changes it makes. The changes are sorted by predicate and type.
+func diffSchema(current []*pb.SchemaNode, currentTypes []*pb.TypeUpdate,
+	parsed *schema.ParsedSchema) []*SchemaChange {
+	currentPreds := make(map[string]*predSchema, len(current))
+	for _, n := range current {
+		currentPreds[n.Predicate] = predSchemaFromNode(n)
+	}
+
+	var changes []*SchemaChange
+	for _, su := range parsed.Preds {
+		pred := x.ParseAttr(su.Predicate)
+		to := predSchemaFromUpdate(su)
+		from, ok := currentPreds[su.Predicate]
+		if !ok {
+			changes = append(changes, &SchemaChange{Kind: PredicateAdded,
+				Severity: SchemaChangeSafe, Predicate: pred, To: to.typeName()})
+			continue
+		}
+		changes = append(changes, diffPredSchema(pred, from, to)...)
+	}
+
+	typesByName := make(map[string]*pb.TypeUpdate, len(currentTypes))
+	for _, tu := range currentTypes {
+		typesByName[tu.TypeName] = tu
+	}
+	for _, tu := range parsed.Types {
+		typ := x.ParseAttr(tu.TypeName)
+		cur, ok := typesByName[tu.TypeName]
+		if !ok {
+			changes = append(changes, &SchemaChange{Kind: DgraphTypeAdded,
+				Severity: SchemaChangeSafe, Type: typ})
+			continue
+		}
+		fields := make(map[string]struct{}, len(tu.Fields))
+		for _, f := range tu.Fields {
+			fields[f.Predicate] = struct{}{}
+		}
+		// A predicate that is no longer in the type keeps its data, but expand(_all_) and
+		// deleting the nodes of the type no longer see it.
+		for _, f := range cur.Fields {
+			if _, ok := fields[f.Predicate]; !ok {
+				changes = append(changes, &SchemaChange{Kind: PredicateRemoved,
+					Severity: SchemaChangeDestructive, Predicate: x.ParseAttr(f.Predicate),
+					Type: typ})
+			}
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Predicate != changes[j].Predicate {
+			return changes[i].Predicate < changes[j].Predicate
+		}
+		return changes[i].Type < changes[j].Type
+	})
+	return changes
+}
+
+// diffPredSchema returns the changes from the current schema of a predicate to its new schema.
+func diffPredSchema(pred string, from, to *predSchema) []*SchemaChange {
+	var changes []*SchemaChange
+	add := func(kind SchemaChangeKind, severity SchemaChangeSeverity, from, to string) {
+		changes = append(changes, &SchemaChange{Kind: kind, Severity: severity, Predicate: pred,
+			From: from, To: to})
+	}
+
+	// Values that can't be converted to the new type are lost, and so are all but one of the
+	// values of a list that becomes a scalar.
+	if from.typ != to.typ || (from.list && !to.list) {
+		add(TypeChanged, SchemaChangeDestructive, from.typeName(), to.typeName())
+	} else if !from.list && to.list {
+		add(TypeChanged, SchemaChangeSafe, from.typeName(), to.typeName())
+	}
+
+	added, removed := tokenizerDiff(from.tokenizers, to.tokenizers)
+	if len(removed) > 0 {
+		add(IndexRemoved, SchemaChangeDestructive, "@index("+strings.Join(removed, ", ")+")", "")
+	}
+	if len(added) > 0 {
+		add(IndexAdded, SchemaChangeExpensive, "", "@index("+strings.Join(added, ", ")+")")
+	}
+	for _, d := range []struct {
+		name     string
+		from, to bool
+	}{{"@reverse", from.reverse, to.reverse}, {"@count", from.count, to.count}} {
+		switch {
+		case d.from && !d.to:
+			add(IndexRemoved, SchemaChangeDestructive, d.name, "")
+		case !d.from && d.to:
+			add(IndexAdded, SchemaChangeExpensive, "", d.name)
+		}
+	}
+
+	// Dropping @lang drops the language tagged values, the other directives only change how
+	// mutations are checked.
+	for _, d := range []struct {
+		name     string
+		from, to bool
+		severity SchemaChangeSeverity
+	}{
+		{"@lang", from.lang, to.lang, SchemaChangeDestructive},
+		{"@upsert", from.upsert, to.upsert, SchemaChangeSafe},
+		{"@noconflict", from.noConflict, to.noConflict, SchemaChangeSafe},
+	} {
+		switch {
+		case d.from && !d.to:
+			add(DirectiveChanged, d.severity, d.name, "")
+		case !d.from && d.to:
+			add(DirectiveChanged, SchemaChangeSafe, "", d.name)
+		}
+	}
+	return changes
+}
+
+// tokenizerDiff returns the tokenizers that are in to but not in from, and the ones that are in
+// from but not in to, both sorted.
+func tokenizerDiff(from, to []string) (added, removed []string) {
+	inFrom := make(map[string]struct{}, len(from))
+	for _, t := range from {
+		inFrom[t] = struct{}{}
+	}
+	inTo := make(map[string]struct{}, len(to))
+	for _, t := range to {
+		inTo[t] = struct{}{}
+		if _, ok := inFrom[t]; !ok {
+			added = append(added, t)
+		}
+	}
+	for _, t := range from {
+		if _, ok := inTo[t]; !ok {
+			removed = append(removed, t)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// SchemaChangesSeverity returns the highest severity among the changes, or SchemaChangeSafe if
+// there are none.
+func SchemaChangesSeverity(changes []*SchemaChange) SchemaChangeSeverity {
+	severity := SchemaChangeSafe
+	for _, c := range changes {
+		if c.Severity > severity {
+			severity = c.Severity
+		}
+	}
+	return severity
+}
+
+// GetGQLSchemaVersion returns the given version from the GraphQL schema history of the namespace
+// of the request.
+func GetGQLSchemaVersion(ctx context.Context, version int) (*worker.GQLSchemaVersion, error) {
+	history, err := GetGQLSchemaHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range history {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return nil, errors.Errorf("GraphQL schema version %d not found", version)
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package edgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/protos/pb"
+	"github.com/vtta/dgraph/schema"
+	"github.com/vtta/dgraph/x"
+)
+
+func TestDiffSchema(t *testing.T) {
+	current := []*pb.SchemaNode{
+		{Predicate: x.GalaxyAttr("name"), Type: "string", Index: true,
+			Tokenizer: []string{"exact"}},
+		{Predicate: x.GalaxyAttr("age"), Type: "int"},
+		{Predicate: x.GalaxyAttr("friend"), Type: "uid", List: true, Reverse: true},
+		{Predicate: x.GalaxyAttr("nick"), Type: "string", List: true, Lang: true},
+		{Predicate: x.GalaxyAttr("email"), Type: "string", Index: true,
+			Tokenizer: []string{"hash"}, Upsert: true},
+	}
+	currentTypes := []*pb.TypeUpdate{{
+		TypeName: x.GalaxyAttr("Person"),
+		Fields: []*pb.SchemaUpdate{
+			{Predicate: x.GalaxyAttr("name")},
+			{Predicate: x.GalaxyAttr("age")},
+			{Predicate: x.GalaxyAttr("friend")},
+		},
+	}}
+	parsed, err := schema.ParseWithNamespace(`
+		name: string @index(term, exact) .
+		age: string .
+		friend: [uid] @count .
+		nick: string .
+		email: string @index(hash) @upsert .
+		bio: string @index(fulltext) .
+		type Person {
+			name
+			friend
+		}
+		type Pet {
+			name
+		}`, x.GalaxyNamespace)
+	require.NoError(t, err)
+
+	changes := diffSchema(current, currentTypes, parsed)
+	require.Equal(t, []*SchemaChange{
+		{Kind: DgraphTypeAdded, Severity: SchemaChangeSafe, Type: "Pet"},
+		{Kind: TypeChanged, Severity: SchemaChangeDestructive, Predicate: "age", From: "int",
+			To: "string"},
+		{Kind: PredicateRemoved, Severity: SchemaChangeDestructive, Predicate: "age",
+			Type: "Person"},
+		{Kind: PredicateAdded, Severity: SchemaChangeSafe, Predicate: "bio", To: "string"},
+		{Kind: IndexRemoved, Severity: SchemaChangeDestructive, Predicate: "friend",
+			From: "@reverse"},
+		{Kind: IndexAdded, Severity: SchemaChangeExpensive, Predicate: "friend", To: "@count"},
+		{Kind: IndexAdded, Severity: SchemaChangeExpensive, Predicate: "name",
+			To: "@index(term)"},
+		{Kind: TypeChanged, Severity: SchemaChangeDestructive, Predicate: "nick",
+			From: "[string]", To: "string"},
+		{Kind: DirectiveChanged, Severity: SchemaChangeDestructive, Predicate: "nick",
+			From: "@lang"},
+	}, changes)
+	require.Equal(t, SchemaChangeDestructive, SchemaChangesSeverity(changes))
+	require.Equal(t, SchemaChangeSafe, SchemaChangesSeverity(nil))
+}
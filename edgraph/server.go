@@ -542,6 +542,11 @@ func (s *Server) Alter(ctx context.Context, op *api.Operation) (*api.Payload, er
 	if err != nil {
 		return empty, err
 	}
+	// Record the alter in the schema history, so that it shows up next to the GraphQL schema
+	// updates that rollbacks are diffed against.
+	if err = worker.RecordDQLSchemaVersion(ctx, op.Schema); err != nil {
+		return empty, errors.Wrapf(err, "While recording the schema version")
+	}
 
 	// wait for indexing to complete or context to be canceled.
 	if err = worker.WaitForIndexing(ctx, !op.RunInBackground); err != nil {
@@ -595,6 +600,9 @@ func (s *Server) doMutate(ctx context.Context, qc *queryContext, resp *api.Respo
 	if err != nil {
 		return err
 	}
+	if err := authorizeMutatedNodes(ctx, qc, edges, newUids); err != nil {
+		return err
+	}
 	ns, err := x.ExtractNamespace(ctx)
 	if err != nil {
 		return errors.Wrapf(err, "While doing mutations:")
@@ -631,6 +639,15 @@ func (s *Server) doMutate(ctx context.Context, qc *queryContext, resp *api.Respo
 		resp.Txn.CommitTs = qc.req.StartTs
 		return err
 	}
+	if err == nil {
+		if err := authorizeMutationResult(ctx, qc, edges); err != nil {
+			// The mutations touched nodes that the user can't access anymore, so the transaction
+			// is aborted whether or not it was meant to be committed right away.
+			resp.Txn.Aborted = true
+			_, _ = worker.CommitOverNetwork(ctx, resp.Txn)
+			return err
+		}
+	}
 	// calculateMutationMetrics calculate cost for the mutation.
 	calculateMutationMetrics := func() {
 		cost := uint64(len(newUids) + len(edges))
@@ -1004,6 +1021,14 @@ type queryContext struct {
 	// 1B) and resulting in OOM. We are limiting number of nquads which can be inserted in
 	// a single request.
 	nquadsCount int
+	// pq is the persisted query that is being executed, if any. Its cached parsed form is used
+	// instead of parsing the query text again.
+	pq *cachedQuery
+	// nodeFilter is the filter that the nodes accessed by the request must match as per the ACL
+	// node rules of the user. It is empty if no node rules apply.
+	nodeFilter string
+	// nodeRuleTypes are the names of the types that the node rules of the user apply to.
+	nodeRuleTypes []string
 }
 
 // Request represents a query request sent to the doQuery() method on the Server.
@@ -1015,6 +1040,8 @@ type Request struct {
 	gqlField gqlSchema.Field
 	// doAuth tells whether this request needs ACL authorization or not
 	doAuth AuthMode
+	// pq is the persisted query that the request executes, if any
+	pq *cachedQuery
 }
 
 // Health handles /health and /health?all requests.
@@ -1170,7 +1197,26 @@ func (s *Server) Query(ctx context.Context, req *api.Request) (*api.Response, er
 			defer cancel()
 		}
 	}
-	return s.doQuery(ctx, &Request{req: req, doAuth: getAuthMode(ctx)})
+
+	id, version, err := extractPersistedQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pq *cachedQuery
+	switch {
+	case id != "":
+		if strings.TrimSpace(req.GetQuery()) != "" || len(req.GetMutations()) > 0 {
+			return nil, errors.Errorf("A persisted query can't be executed along with a query " +
+				"or mutations")
+		}
+		if pq, err = getPersistedQuery(ctx, id, version); err != nil {
+			return nil, err
+		}
+		req.Query = pq.Query
+	case x.Config.PersistedQueriesOnly && strings.TrimSpace(req.GetQuery()) != "":
+		return nil, errOnlyPersistedQueries
+	}
+	return s.doQuery(ctx, &Request{req: req, doAuth: getAuthMode(ctx), pq: pq})
 }
 
 var pendingQueries int64
@@ -1265,12 +1311,26 @@ func (s *Server) doQuery(ctx context.Context, req *Request) (resp *api.Response,
 		}
 	}
 
+	if req.doAuth == NeedAuthorize {
+		// Only the requests made by users are listed, internal queries run on their behalf are
+		// cancelled along with them.
+		var rq *runningQuery
+		ctx, rq = runningQueries.add(ctx, req.req, isGraphQL)
+		defer func() {
+			rerr = runningQueries.remove(rq, rerr)
+		}()
+	} else {
+		// Internal queries run on behalf of a request aren't explained along with it.
+		ctx = context.WithValue(ctx, query.ExplainKey, query.ExplainNone)
+	}
+
 	qc := &queryContext{
 		req:      req.req,
 		latency:  l,
 		span:     span,
 		graphql:  isGraphQL,
 		gqlField: req.gqlField,
+		pq:       req.pq,
 	}
 	if rerr = parseRequest(qc); rerr != nil {
 		return
@@ -1308,8 +1368,17 @@ func (s *Server) doQuery(ctx context.Context, req *Request) (resp *api.Response,
 		}()
 	}
 
+	if req.doAuth == NeedAuthorize {
+		limits, err := queryLimits(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ctx = query.WithLimits(ctx, limits)
+	}
+
 	var gqlErrs error
 	if resp, rerr = processQuery(ctx, qc); rerr != nil {
+		rerr = checkQueryOverLimit(ctx, rerr)
 		// if rerr is just some error from GraphQL encoding, then we need to continue the normal
 		// execution ignoring the error as we still need to assign latency info to resp. If we can
 		// change the api.Response proto to have a field to contain GraphQL errors, that would be
@@ -1357,6 +1426,15 @@ func processQuery(ctx context.Context, qc *queryContext) (*api.Response, error)
 	if ctx.Err() != nil {
 		return resp, ctx.Err()
 	}
+	explain := query.GetExplainMode(ctx)
+	if explain != query.ExplainNone {
+		if len(qc.gmuList) > 0 {
+			return resp, errors.Errorf("Explain is not supported for upserts")
+		}
+		if qc.req.RespFormat == api.Request_RDF {
+			return resp, errors.Errorf("Explain is only supported for queries with JSON response")
+		}
+	}
 	if x.WorkerConfig.LudicrousEnabled {
 		qc.req.StartTs = posting.Oracle().MaxAssigned()
 	}
@@ -1398,31 +1476,19 @@ func processQuery(ctx context.Context, qc *queryContext) (*api.Response, error)
 	resp.Txn = &api.TxnContext{StartTs: qc.req.StartTs}
 
 	// Core processing happens here.
+	cacheKey := queryCacheKey(ctx, qc.req)
 	Process := func(ctx context.Context) (er query.ExecutionResult, err error) {
-		var hit bool
-		var result query.ExecutionResult
-		queryCache.Range(func(k any, v any) bool {
-			if k.(string) == qc.req.Query {
-				if bool(glog.V(3)) {
-					glog.Infof("Query cache hit: %+v %+v\n", qc.req.Query, v)
-				}
-				hit = true
-				result = v.(query.ExecutionResult)
-			}
-			return !hit
-		})
-		if hit {
-			return result, nil
-		}
-		er, err = qr.Process(ctx)
-    // if queryCache == nil {
-    //   queryCache = new(sync.Map)
-    // }
-		queryCache.Store(qc.req.Query, er)
-		if bool(glog.V(3)) {
-			glog.Infof("Cached new query: %+v %+v\n", qc.req.Query, er)
+		if explain != query.ExplainNone {
+			// The cached results neither have a plan nor are worth analyzing.
+			return qr.Process(ctx)
 		}
-		return
+		if len(worker.Config.HmacSecret) != 0 {
+			// The results of the queries of a user depend on the predicates they can read, the
+			// node rules and query limits of their groups and the scope of their API key, none
+			// of which are a part of the cache key, so they can't be shared with other users.
+			return qr.Process(ctx)
+		}
+		return processCached(ctx, cacheKey, qr.Process)
 	}
 
 	er, err := Process(ctx)
@@ -1458,6 +1524,9 @@ func processQuery(ctx context.Context, qc *queryContext) (*api.Response, error)
 			respMap["types"] = formatTypes(er.Types)
 		}
 		resp.Json, err = json.Marshal(respMap)
+	} else if explain == query.ExplainPlan {
+		// The query wasn't executed, so there is nothing but the plan to return.
+		resp.Json = []byte("{}")
 	} else if qc.req.RespFormat == api.Request_RDF {
 		resp.Rdf, err = query.ToRDF(qc.latency, er.Subgraphs)
 	} else {
@@ -1468,6 +1537,11 @@ func processQuery(ctx context.Context, qc *queryContext) (*api.Response, error)
 	if err != nil && (qc.gqlField == nil || !x.IsGqlErrorList(err)) {
 		return resp, err
 	}
+	if explain != query.ExplainNone {
+		if resp.Json, err = addPlanToJson(resp.Json, er.Plan); err != nil {
+			return resp, err
+		}
+	}
 	qc.span.Annotatef(nil, "Response = %s", resp.Json)
 
 	// varToUID contains a map of variable name to the uids corresponding to it.
@@ -1524,6 +1598,83 @@ func processQuery(ctx context.Context, qc *queryContext) (*api.Response, error)
 	return resp, err
 }
 
+// addPlanToJson adds the plan of a query to its JSON response under the _explain_ key.
+func addPlanToJson(js []byte, plan []*query.PlanNode) ([]byte, error) {
+	if plan == nil {
+		plan = []*query.PlanNode{}
+	}
+	planJs, err := json.Marshal(plan)
+	if err != nil {
+		return js, errors.Wrapf(err, "while marshalling the query plan")
+	}
+
+	var out bytes.Buffer
+	out.WriteString(`{"_explain_":`)
+	out.Write(planJs)
+	js = bytes.TrimSpace(js)
+	if len(js) > 2 && js[0] == '{' {
+		out.WriteByte(',')
+		out.Write(js[1:])
+	} else {
+		out.WriteByte('}')
+	}
+	return out.Bytes(), nil
+}
+
+// processCached returns the result stored under cacheKey in the queryCache, if any, or else the
+// result of process, which is stored under cacheKey unless it failed. A query that failed, like one
+// that was killed while running, has an incomplete result that must not be served again.
+func processCached(ctx context.Context, cacheKey string,
+	process func(context.Context) (query.ExecutionResult, error)) (query.ExecutionResult, error) {
+	var hit bool
+	var result query.ExecutionResult
+	queryCache.Range(func(k any, v any) bool {
+		if k.(string) == cacheKey {
+			if bool(glog.V(3)) {
+				glog.Infof("Query cache hit: %+v %+v\n", cacheKey, v)
+			}
+			hit = true
+			result = v.(query.ExecutionResult)
+		}
+		return !hit
+	})
+	if hit {
+		return result, nil
+	}
+	er, err := process(ctx)
+	if err != nil {
+		return er, err
+	}
+	queryCache.Store(cacheKey, er)
+	if bool(glog.V(3)) {
+		glog.Infof("Cached new query: %+v %+v\n", cacheKey, er)
+	}
+	return er, nil
+}
+
+// queryCacheKey returns the key under which the result of req is stored in the queryCache. The
+// same query text gives different results in different namespaces or with different variables,
+// so both are a part of the key.
+func queryCacheKey(ctx context.Context, req *api.Request) string {
+	ns, _ := x.ExtractNamespace(ctx)
+	return fmt.Sprintf("%#x|%s|%s", ns, req.Query, varsKey(req.Vars))
+}
+
+// varsKey returns a string that uniquely identifies the given set of query variables.
+func varsKey(vars map[string]string) string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(strconv.Quote(name))
+		b.WriteString(strconv.Quote(vars[name]))
+	}
+	return b.String()
+}
+
 // parseRequest parses the incoming request
 func parseRequest(qc *queryContext) error {
 	start := time.Now()
@@ -1531,6 +1682,20 @@ func parseRequest(qc *queryContext) error {
 		qc.latency.Parsing = time.Since(start)
 	}()
 
+	if qc.pq != nil {
+		var err error
+		qc.gqlRes, err = qc.pq.parse(qc.req.Vars)
+		return err
+	}
+	// Queries built by the GraphQL layer are not cached, as they are rarely the same.
+	if len(qc.req.Mutations) == 0 && qc.gqlField == nil {
+		if plan := plans.get(qc.req.Query); plan != nil {
+			var err error
+			qc.gqlRes, err = plan.parse(qc.req.Vars)
+			return err
+		}
+	}
+
 	var needVars []string
 	upsertQuery := qc.req.Query
 	if len(qc.req.Mutations) > 0 {
@@ -1574,6 +1739,9 @@ func authorizeRequest(ctx context.Context, qc *queryContext) error {
 	if err := authorizeQuery(ctx, &qc.gqlRes, qc.graphql); err != nil {
 		return err
 	}
+	if err := authorizeNodes(ctx, qc); err != nil {
+		return err
+	}
 
 	// TODO(Aman): can be optimized to do the authorization in just one func call
 	for _, gmu := range qc.gmuList {
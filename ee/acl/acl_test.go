@@ -2365,15 +2365,64 @@ func TestSchemaQueryWithACL(t *testing.T) {
 	schemaQuery := "schema{}"
 	grootSchema := `{
   "schema": [
+    {
+      "predicate": "dgraph.acl.jwt_key",
+      "type": "string"
+    },
+    {
+      "predicate": "dgraph.acl.node_rule",
+      "type": "uid",
+      "list": true
+    },
     {
       "predicate": "dgraph.acl.rule",
       "type": "uid",
       "list": true
 	},
+    {
+      "predicate": "dgraph.api_key.expiry",
+      "type": "datetime"
+    },
+    {
+      "predicate": "dgraph.api_key.id",
+      "type": "string",
+      "index": true,
+      "tokenizer": [
+        "exact"
+      ],
+      "upsert": true
+    },
+    {
+      "predicate": "dgraph.api_key.owner",
+      "type": "uid"
+    },
+    {
+      "predicate": "dgraph.api_key.scope",
+      "type": "string"
+    },
+    {
+      "predicate": "dgraph.api_key.secret",
+      "type": "password"
+    },
 	{
 		"predicate":"dgraph.drop.op",
 		"type":"string"
 	},
+	{
+		"predicate":"dgraph.dql.p_query",
+		"type":"string"
+	},
+	{
+		"predicate":"dgraph.dql.p_query_id",
+		"type":"string",
+		"index":true,
+		"tokenizer":["exact"],
+		"upsert":true
+	},
+	{
+		"predicate":"dgraph.graphql.lambda_module",
+		"type":"string"
+	},
 	{
 		"predicate":"dgraph.graphql.p_query",
 		"type":"string",
@@ -2384,6 +2433,10 @@ func TestSchemaQueryWithACL(t *testing.T) {
       "predicate": "dgraph.graphql.schema",
       "type": "string"
 	},
+    {
+      "predicate": "dgraph.graphql.version",
+      "type": "string"
+    },
     {
       "predicate": "dgraph.graphql.xid",
       "type": "string",
@@ -2397,6 +2450,23 @@ func TestSchemaQueryWithACL(t *testing.T) {
       "predicate": "dgraph.password",
       "type": "password"
     },
+    {
+      "predicate": "dgraph.query_limits",
+      "type": "string"
+    },
+    {
+      "predicate": "dgraph.query_limits_key",
+      "type": "string",
+      "index": true,
+      "tokenizer": [
+        "exact"
+      ],
+      "upsert": true
+    },
+    {
+      "predicate": "dgraph.rule.filter",
+      "type": "string"
+    },
     {
       "predicate": "dgraph.rule.permission",
       "type": "int"
@@ -2410,6 +2480,14 @@ func TestSchemaQueryWithACL(t *testing.T) {
       ],
       "upsert": true
     },
+    {
+      "predicate": "dgraph.rule.type",
+      "type": "string",
+      "index": true,
+      "tokenizer": [
+        "exact"
+      ]
+    },
     {
       "predicate": "dgraph.type",
       "type": "string",
@@ -2436,6 +2514,17 @@ func TestSchemaQueryWithACL(t *testing.T) {
     }
   ],
   "types": [
+    {
+      "fields": [
+        {
+          "name": "dgraph.dql.p_query_id"
+        },
+        {
+          "name": "dgraph.dql.p_query"
+        }
+      ],
+      "name": "dgraph.dql.persisted_query"
+    },
     {
       "fields": [
         {
@@ -2455,6 +2544,34 @@ func TestSchemaQueryWithACL(t *testing.T) {
 		],
 		"name": "dgraph.graphql.persisted_query"
 	},
+    {
+      "fields": [
+        {
+          "name": "dgraph.graphql.version"
+        }
+      ],
+      "name": "dgraph.graphql.schema_version"
+    },
+    {
+      "fields": [
+        {
+          "name": "dgraph.api_key.id"
+        },
+        {
+          "name": "dgraph.api_key.secret"
+        },
+        {
+          "name": "dgraph.api_key.owner"
+        },
+        {
+          "name": "dgraph.api_key.expiry"
+        },
+        {
+          "name": "dgraph.api_key.scope"
+        }
+      ],
+      "name": "dgraph.type.ApiKey"
+    },
     {
       "fields": [
         {
@@ -2462,10 +2579,24 @@ func TestSchemaQueryWithACL(t *testing.T) {
         },
         {
           "name": "dgraph.acl.rule"
+        },
+        {
+          "name": "dgraph.acl.node_rule"
         }
       ],
       "name": "dgraph.type.Group"
     },
+    {
+      "fields": [
+        {
+          "name": "dgraph.rule.type"
+        },
+        {
+          "name": "dgraph.rule.filter"
+        }
+      ],
+      "name": "dgraph.type.NodeRule"
+    },
     {
       "fields": [
         {
@@ -2505,6 +2636,10 @@ func TestSchemaQueryWithACL(t *testing.T) {
     }
   ],
   "types": [
+    {
+      "fields": [],
+      "name": "dgraph.dql.persisted_query"
+    },
     {
       "fields": [],
       "name": "dgraph.graphql"
@@ -2513,10 +2648,22 @@ func TestSchemaQueryWithACL(t *testing.T) {
 		"fields":[],
 		"name":"dgraph.graphql.persisted_query"
 	},
+    {
+      "fields": [],
+      "name": "dgraph.graphql.schema_version"
+    },
+    {
+      "fields": [],
+      "name": "dgraph.type.ApiKey"
+    },
     {
       "fields": [],
       "name": "dgraph.type.Group"
     },
+    {
+      "fields": [],
+      "name": "dgraph.type.NodeRule"
+    },
     {
       "fields": [],
       "name": "dgraph.type.Rule"
@@ -3588,6 +3735,170 @@ func TestDropAllShouldResetGuardiansAndGroot(t *testing.T) {
 	deleteGuardiansGroupAndGrootUserShouldFail(t)
 }
 
+func createGroupWithNodeRules(t *testing.T, token *testutil.HttpToken, name string,
+	nodeRules []map[string]string) {
+	params := testutil.GraphQLParams{
+		Query: `
+		mutation addGroup($name: String!, $nodeRules: [NodeRuleRef]) {
+			addGroup(input: [{name: $name, nodeRules: $nodeRules}]) {
+				group {
+					name
+				}
+			}
+		}`,
+		Variables: map[string]interface{}{
+			"name":      name,
+			"nodeRules": nodeRules,
+		},
+	}
+	resp := makeRequestAndRefreshTokenIfNecessary(t, token, params)
+	resp.RequireNoGraphQLErrors(t)
+}
+
+func TestQueryResultsOfUsersWithDifferentNodeRules(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	dg, err := testutil.DgraphClientWithGroot(testutil.SockAddr)
+	require.NoError(t, err)
+	testutil.DropAll(t, dg)
+	require.NoError(t, dg.Alter(ctx, &api.Operation{Schema: `
+		name: string .
+		tenant: string @index(exact) .
+		type Project {
+			name
+			tenant
+		}
+	`}))
+	_, err = dg.NewTxn().Mutate(ctx, &api.Mutation{
+		SetNquads: []byte(`
+			_:a <name> "rocket" .
+			_:a <tenant> "acme" .
+			_:a <dgraph.type> "Project" .
+			_:b <name> "anvil" .
+			_:b <tenant> "globex" .
+			_:b <dgraph.type> "Project" .
+		`),
+		CommitNow: true,
+	})
+	require.NoError(t, err)
+
+	resetUser(t)
+	token := testutil.GrootHttpLogin(adminEndpoint)
+	deleteUser(t, token, "bob", false).RequireNoGraphQLErrors(t)
+	createUser(t, token, "bob", "bobpassword").RequireNoGraphQLErrors(t)
+	rules := []rule{{"name", Read.Code}, {"tenant", Read.Code}, {"dgraph.type", Read.Code}}
+	for group, member := range map[string]string{"acme": userid, "globex": "bob"} {
+		createGroupWithNodeRules(t, token, group, []map[string]string{
+			{"type": "Project", "filter": fmt.Sprintf(`eq(tenant, "%s")`, group)},
+		})
+		addRulesToGroup(t, token, group, rules)
+		addToGroup(t, token, member, group)
+	}
+	time.Sleep(defaultTimeToSleep)
+
+	// The same query is run by both users, in turn, a couple of times so that the results of
+	// one of them would be served to the other one if they were shared.
+	query := `{ q(func: type(Project), orderasc: name) { name } }`
+	users := []struct {
+		userid, password, result string
+	}{
+		{userid, userpassword, `{"q": [{"name": "rocket"}]}`},
+		{"bob", "bobpassword", `{"q": [{"name": "anvil"}]}`},
+	}
+	for i := 0; i < 2; i++ {
+		for _, user := range users {
+			userClient, err := testutil.DgraphClient(testutil.SockAddr)
+			require.NoError(t, err)
+			require.NoError(t, userClient.LoginIntoNamespace(ctx, user.userid, user.password,
+				x.GalaxyNamespace))
+			resp, err := userClient.NewReadOnlyTxn().Query(ctx, query)
+			require.NoError(t, err)
+			testutil.CompareJSON(t, user.result, string(resp.GetJson()))
+		}
+	}
+}
+
+func TestMutationsOfUsersWithNodeRules(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	dg, err := testutil.DgraphClientWithGroot(testutil.SockAddr)
+	require.NoError(t, err)
+	testutil.DropAll(t, dg)
+	require.NoError(t, dg.Alter(ctx, &api.Operation{Schema: `
+		name: string .
+		tenant: string @index(exact) .
+		type Project {
+			name
+			tenant
+		}
+	`}))
+	resp, err := dg.NewTxn().Mutate(ctx, &api.Mutation{
+		SetNquads: []byte(`
+			_:a <name> "rocket" .
+			_:a <tenant> "acme" .
+			_:a <dgraph.type> "Project" .
+		`),
+		CommitNow: true,
+	})
+	require.NoError(t, err)
+	rocket := resp.Uids["a"]
+
+	resetUser(t)
+	token := testutil.GrootHttpLogin(adminEndpoint)
+	createGroupWithNodeRules(t, token, "acme", []map[string]string{
+		{"type": "Project", "filter": `eq(tenant, "acme")`},
+	})
+	addRulesToGroup(t, token, "acme", []rule{{"name", Read.Code | Write.Code},
+		{"tenant", Read.Code | Write.Code}, {"dgraph.type", Read.Code | Write.Code}})
+	addToGroup(t, token, userid, "acme")
+	time.Sleep(defaultTimeToSleep)
+
+	userClient, err := testutil.DgraphClient(testutil.SockAddr)
+	require.NoError(t, err)
+	require.NoError(t, userClient.LoginIntoNamespace(ctx, userid, userpassword,
+		x.GalaxyNamespace))
+	mutate := func(set, del string) error {
+		_, err := userClient.NewTxn().Mutate(ctx, &api.Mutation{
+			SetNquads: []byte(set),
+			DelNquads: []byte(del),
+			CommitNow: true,
+		})
+		return err
+	}
+
+	// Nodes can't be created outside of the rules.
+	err = mutate(`
+		_:b <name> "anvil" .
+		_:b <tenant> "globex" .
+		_:b <dgraph.type> "Project" .
+	`, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "mutations would leave following nodes inaccessible")
+	require.NoError(t, mutate(`
+		_:b <name> "anvil" .
+		_:b <tenant> "acme" .
+		_:b <dgraph.type> "Project" .
+	`, ""))
+
+	// Nodes can't be moved out of the rules, either by changing them or by removing their type.
+	err = mutate(fmt.Sprintf(`<%s> <tenant> "globex" .`, rocket), "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "mutations would leave following nodes inaccessible")
+	err = mutate("", fmt.Sprintf(`<%s> <dgraph.type> "Project" .`, rocket))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "can only be removed by deleting the nodes")
+
+	resp, err = dg.NewReadOnlyTxn().Query(ctx,
+		`{ q(func: has(tenant), orderasc: name) { name tenant dgraph.type } }`)
+	require.NoError(t, err)
+	testutil.CompareJSON(t, `{"q": [
+		{"name": "anvil", "tenant": "acme", "dgraph.type": ["Project"]},
+		{"name": "rocket", "tenant": "acme", "dgraph.type": ["Project"]}
+	]}`, string(resp.GetJson()))
+}
+
 func TestMain(m *testing.M) {
 	adminEndpoint = "http://" + testutil.SockAddrHttp + "/admin"
 	fmt.Printf("Using adminEndpoint for acl package: %s\n", adminEndpoint)
@@ -112,12 +112,20 @@ type Acl struct {
 	Perm      int32  `json:"dgraph.rule.permission"`
 }
 
+// NodeRule restricts the nodes of a type that the members of a group can access to the ones
+// matching a DQL filter, e.g. eq(tenant, "acme").
+type NodeRule struct {
+	Type   string `json:"dgraph.rule.type"`
+	Filter string `json:"dgraph.rule.filter"`
+}
+
 // Group represents a group in the ACL system.
 type Group struct {
-	Uid     string `json:"uid"`
-	GroupID string `json:"dgraph.xid"`
-	Users   []User `json:"~dgraph.user.group"`
-	Rules   []Acl  `json:"dgraph.acl.rule"`
+	Uid       string     `json:"uid"`
+	GroupID   string     `json:"dgraph.xid"`
+	Users     []User     `json:"~dgraph.user.group"`
+	Rules     []Acl      `json:"dgraph.acl.rule"`
+	NodeRules []NodeRule `json:"dgraph.acl.node_rule"`
 }
 
 // GetUid returns the UID of the group.
@@ -112,6 +112,8 @@ func auditGrpc(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo)
 	extractUser := func(md metadata.MD) {
 		if t := md.Get("accessJwt"); len(t) > 0 {
 			user = getUser(t[0], false)
+		} else if t := md.Get("apikey"); len(t) > 0 {
+			user = getApiKeyUser(t[0])
 		} else if t := md.Get("auth-token"); len(t) > 0 {
 			user = getUser(t[0], true)
 		} else {
@@ -159,6 +161,8 @@ func auditHttp(w *ResponseWriter, r *http.Request) {
 	var user string
 	if token := r.Header.Get("X-Dgraph-AccessToken"); token != "" {
 		user = getUser(token, false)
+	} else if apiKey := r.Header.Get("X-Dgraph-ApiKey"); apiKey != "" {
+		user = getApiKeyUser(apiKey)
 	} else if token := r.Header.Get("X-Dgraph-AuthToken"); token != "" {
 		user = getUser(token, true)
 	} else {
@@ -322,6 +326,17 @@ func getUser(token string, poorman bool) string {
 	return user
 }
 
+func getApiKeyUser(apiKey string) string {
+	if x.ApiKeyJwt == nil {
+		return UnknownUser
+	}
+	token, err := x.ApiKeyJwt(apiKey)
+	if err != nil {
+		return UnknownUser
+	}
+	return getUser(token, false)
+}
+
 type ResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
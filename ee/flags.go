@@ -31,7 +31,32 @@ type Keys struct {
 	AclKey        x.SensitiveByteSlice
 	AclAccessTtl  time.Duration
 	AclRefreshTtl time.Duration
-	EncKey        x.SensitiveByteSlice
+	// AclJwtAlg is the algorithm that the JWTs issued by Dgraph are signed with.
+	AclJwtAlg string
+	// AclOidc is nil unless the access JWTs issued by an external identity provider are accepted.
+	AclOidc *OidcConfig
+	EncKey  x.SensitiveByteSlice
+}
+
+// OidcConfig holds the configuration for accepting access JWTs issued by an external OpenID
+// Connect identity provider.
+type OidcConfig struct {
+	// Jwks is the path or the http(s) URL of the JSON Web Key set used to verify the tokens.
+	Jwks string
+	// Issuer is the issuer that the tokens must be issued by, if set.
+	Issuer string
+	// Audience holds the audiences that the tokens must be issued for one of, if set.
+	Audience []string
+	// UserClaim is the claim holding the id of the user.
+	UserClaim string
+	// GroupsClaim is the claim holding the groups of the user.
+	GroupsClaim string
+	// GroupMap maps the values of the groups claim to ACL groups. The values that aren't mapped
+	// are ignored, so that a group of the identity provider can't grant access to an ACL group,
+	// e.g. guardians, just by being named after it.
+	GroupMap map[string]string
+	// Namespace is the namespace that the users of the identity provider belong to.
+	Namespace uint64
 }
 
 const (
@@ -39,6 +64,15 @@ const (
 	flagAclAccessTtl  = "access-ttl"
 	flagAclRefreshTtl = "refresh-ttl"
 	flagAclSecretFile = "secret-file"
+	flagAclJwtAlg     = "jwt-alg"
+
+	flagAclOidcJwks        = "oidc-jwks"
+	flagAclOidcIssuer      = "oidc-issuer"
+	flagAclOidcAudience    = "oidc-audience"
+	flagAclOidcUserClaim   = "oidc-user-claim"
+	flagAclOidcGroupsClaim = "oidc-groups-claim"
+	flagAclOidcGroupMap    = "oidc-group-map"
+	flagAclOidcNamespace   = "oidc-namespace"
 
 	flagEnc        = "encryption"
 	flagEncKeyFile = "key-file"
@@ -66,10 +100,16 @@ func RegisterEncFlag(flag *pflag.FlagSet) {
 }
 
 var (
-	AclDefaults = fmt.Sprintf("%s=%s; %s=%s; %s=%s",
-		flagAclAccessTtl, "6h",
-		flagAclRefreshTtl, "30d",
-		flagAclSecretFile, "")
+	AclDefaults = fmt.Sprintf("%s=%s; %s=%s; %s=%s; %s=%s; ", flagAclAccessTtl, "6h",
+		flagAclRefreshTtl, "30d", flagAclSecretFile, "", flagAclJwtAlg, "HS256") +
+		fmt.Sprintf("%s=%s; %s=%s; %s=%s; %s=%s; %s=%s; %s=%s; %s=%s",
+			flagAclOidcJwks, "",
+			flagAclOidcIssuer, "",
+			flagAclOidcAudience, "",
+			flagAclOidcUserClaim, "sub",
+			flagAclOidcGroupsClaim, "groups",
+			flagAclOidcGroupMap, "",
+			flagAclOidcNamespace, "0")
 	EncDefaults = fmt.Sprintf("%s=%s", flagEncKeyFile, "")
 )
 
@@ -131,6 +171,28 @@ func registerAclFlag(flag *pflag.FlagSet) {
 			"The TTL for the access JWT.").
 		Flag("refresh-ttl",
 			"The TTL for the refresh JWT.").
+		Flag("jwt-alg",
+			"The algorithm that the JWTs are signed with: HS256 signs them with the HMAC secret, "+
+				"while RS256 and ES256 sign them with key pairs stored in the cluster, which can "+
+				"be rotated through the rotateJwtKey mutation of /admin.").
+		Flag("oidc-jwks",
+			"The path or the URL of the JSON Web Key set of an OpenID Connect identity provider. "+
+				"If set, the access JWTs issued by the provider are accepted along with the ones "+
+				"issued by Dgraph.").
+		Flag("oidc-issuer",
+			"The issuer that the JWTs of the identity provider must be issued by.").
+		Flag("oidc-audience",
+			"Comma separated list of audiences, one of which the JWTs of the identity provider "+
+				"must be issued for.").
+		Flag("oidc-user-claim",
+			"The claim of the JWTs of the identity provider holding the id of the user.").
+		Flag("oidc-groups-claim",
+			"The claim of the JWTs of the identity provider holding the groups of the user.").
+		Flag("oidc-group-map",
+			"Comma separated list of claim-value:group pairs mapping the values of the groups "+
+				"claim to ACL groups. The values that aren't mapped are ignored.").
+		Flag("oidc-namespace",
+			"The namespace that the users of the identity provider belong to.").
 		String()
 	flag.String(flagAcl, AclDefaults, helpText)
 }
@@ -15,6 +15,7 @@ package ee
 import (
 	"fmt"
 	"io/ioutil"
+	"strings"
 
 	"github.com/dgraph-io/ristretto/z"
 	"github.com/spf13/viper"
@@ -62,6 +63,59 @@ func GetKeys(config *viper.Viper) (*Keys, error) {
 	// Get remaining keys
 	keys.AclAccessTtl = aclSuperFlag.GetDuration(flagAclAccessTtl)
 	keys.AclRefreshTtl = aclSuperFlag.GetDuration(flagAclRefreshTtl)
+	keys.AclJwtAlg = strings.ToUpper(aclSuperFlag.GetString(flagAclJwtAlg))
+	switch keys.AclJwtAlg {
+	case "HS256", "RS256", "ES256":
+	default:
+		return nil, fmt.Errorf("flags: %s must be one of HS256, RS256 or ES256, got %s",
+			flagAclJwtAlg, keys.AclJwtAlg)
+	}
+	if keys.AclOidc, err = getOidcConfig(aclSuperFlag); err != nil {
+		return nil, err
+	}
+	if keys.AclOidc != nil && keys.AclKey == nil {
+		return nil, fmt.Errorf("flags: ACL secret key must be set to accept OIDC tokens")
+	}
 
 	return keys, nil
 }
+
+// getOidcConfig returns the OIDC configuration set in the acl SuperFlag, or nil if no JWKS is set.
+func getOidcConfig(aclSuperFlag *z.SuperFlag) (*OidcConfig, error) {
+	jwks := aclSuperFlag.GetString(flagAclOidcJwks)
+	if jwks == "" {
+		return nil, nil
+	}
+	conf := &OidcConfig{
+		Jwks:        jwks,
+		Issuer:      aclSuperFlag.GetString(flagAclOidcIssuer),
+		UserClaim:   aclSuperFlag.GetString(flagAclOidcUserClaim),
+		GroupsClaim: aclSuperFlag.GetString(flagAclOidcGroupsClaim),
+		Namespace:   aclSuperFlag.GetUint64(flagAclOidcNamespace),
+	}
+	for _, aud := range strings.Split(aclSuperFlag.GetString(flagAclOidcAudience), ",") {
+		if aud = strings.TrimSpace(aud); aud != "" {
+			conf.Audience = append(conf.Audience, aud)
+		}
+	}
+	if conf.UserClaim == "" {
+		return nil, fmt.Errorf("flags: %s must not be empty", flagAclOidcUserClaim)
+	}
+
+	groupMap := aclSuperFlag.GetString(flagAclOidcGroupMap)
+	for _, pair := range strings.Split(groupMap, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || strings.TrimSpace(kv[1]) == "" {
+			return nil, fmt.Errorf("flags: invalid %s pair %q, expected claim-value:group",
+				flagAclOidcGroupMap, pair)
+		}
+		if conf.GroupMap == nil {
+			conf.GroupMap = make(map[string]string)
+		}
+		conf.GroupMap[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return conf, nil
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/vtta/dgraph/lex"
 	"github.com/vtta/dgraph/protos/pb"
+	"github.com/vtta/dgraph/types"
 	"github.com/vtta/dgraph/x"
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
@@ -655,6 +656,195 @@ func ParseWithNeedVars(r Request, needVars []string) (res Result, rerr error) {
 	return res, nil
 }
 
+// ParseFilter parses a filter as written in the @filter directive of a query, e.g.
+// eq(name, "alice") AND has(age). The filter can't use query variables.
+func ParseFilter(filter string) (*FilterTree, error) {
+	res, err := Parse(Request{Str: fmt.Sprintf("{ q(func: uid(0x1)) @filter(%s) { uid } }",
+		filter)})
+	if err != nil {
+		return nil, errors.Wrapf(err, "while parsing filter %q", filter)
+	}
+	if len(res.Query) != 1 || res.Query[0].Filter == nil || len(res.Query[0].Children) != 1 {
+		return nil, errors.Errorf("Invalid filter %q", filter)
+	}
+	return res.Query[0].Filter, nil
+}
+
+// ParseVariables returns the GraphQL variables declared by the named query blocks in query,
+// mapped to their declared type, e.g. {"$name": "string!"}. Unlike Parse, it doesn't need the
+// values of the variables, so it can be used to inspect a query ahead of its execution.
+func ParseVariables(query string) (map[string]string, error) {
+	var lexer lex.Lexer
+	lexer.Reset(query)
+	lexer.Run(lexTopLevel)
+	if err := lexer.ValidateResult(); err != nil {
+		return nil, err
+	}
+
+	vmap := make(varMap)
+	it := lexer.NewIterator()
+	for it.Next() {
+		item := it.Item()
+		if item.Typ != itemOpType || item.Val != "query" {
+			continue
+		}
+	L:
+		for it.Next() {
+			item = it.Item()
+			switch item.Typ {
+			case itemLeftRound:
+				if err := parseGqlVariables(it, vmap); err != nil {
+					return nil, err
+				}
+				break L
+			case itemLeftCurl:
+				break L
+			}
+		}
+	}
+
+	vars := make(map[string]string, len(vmap))
+	for name, info := range vmap {
+		vars[name] = info.Type
+	}
+	return vars, nil
+}
+
+// Copy returns a deep copy of res. Processing a query mutates its parsed tree, e.g. ACL strips
+// the blocked predicates from it, so a Result that is reused across requests must be copied
+// before each use.
+func (res *Result) Copy() Result {
+	var out Result
+	for _, gq := range res.Query {
+		out.Query = append(out.Query, gq.copy())
+	}
+	for _, v := range res.QueryVars {
+		out.QueryVars = append(out.QueryVars, &Vars{
+			Defines: copySlice(v.Defines),
+			Needs:   copySlice(v.Needs),
+		})
+	}
+	if res.Schema != nil {
+		out.Schema = &pb.SchemaRequest{
+			GroupId:    res.Schema.GroupId,
+			Predicates: copySlice(res.Schema.Predicates),
+			Fields:     copySlice(res.Schema.Fields),
+			Types:      copySlice(res.Schema.Types),
+		}
+	}
+	return out
+}
+
+func (gq *GraphQuery) copy() *GraphQuery {
+	if gq == nil {
+		return nil
+	}
+	// Start with a shallow copy and then replace everything that the query processing is
+	// allowed to modify in place.
+	out := *gq
+	out.UID = copySlice(gq.UID)
+	out.Langs = copySlice(gq.Langs)
+	out.NeedsVar = copySlice(gq.NeedsVar)
+	out.Func = gq.Func.copy()
+	if gq.Order != nil {
+		out.Order = make([]*pb.Order, 0, len(gq.Order))
+		for _, o := range gq.Order {
+			out.Order = append(out.Order, &pb.Order{Attr: o.Attr, Desc: o.Desc,
+				Langs: copySlice(o.Langs)})
+		}
+	}
+	out.Filter = gq.Filter.copy()
+	out.MathExp = gq.MathExp.copy()
+	out.ShortestPathArgs = ShortestPathArgs{
+		From: gq.ShortestPathArgs.From.copy(),
+		To:   gq.ShortestPathArgs.To.copy(),
+	}
+	out.Cascade = copySlice(gq.Cascade)
+	out.FacetsFilter = gq.FacetsFilter.copy()
+	out.GroupbyAttrs = copySlice(gq.GroupbyAttrs)
+	if gq.Facets != nil {
+		out.Facets = &pb.FacetParams{AllKeys: gq.Facets.AllKeys}
+		for _, p := range gq.Facets.Param {
+			out.Facets.Param = append(out.Facets.Param, &pb.FacetParam{Key: p.Key, Alias: p.Alias})
+		}
+	}
+	if gq.FacetsOrder != nil {
+		out.FacetsOrder = make([]*FacetOrder, 0, len(gq.FacetsOrder))
+		for _, o := range gq.FacetsOrder {
+			fo := *o
+			out.FacetsOrder = append(out.FacetsOrder, &fo)
+		}
+	}
+	out.AllowedPreds = copySlice(gq.AllowedPreds)
+	if gq.Args != nil {
+		out.Args = make(map[string]string, len(gq.Args))
+		for k, v := range gq.Args {
+			out.Args[k] = v
+		}
+	}
+	if gq.FacetVar != nil {
+		out.FacetVar = make(map[string]string, len(gq.FacetVar))
+		for k, v := range gq.FacetVar {
+			out.FacetVar[k] = v
+		}
+	}
+	if gq.Children != nil {
+		out.Children = make([]*GraphQuery, 0, len(gq.Children))
+		for _, child := range gq.Children {
+			out.Children = append(out.Children, child.copy())
+		}
+	}
+	return &out
+}
+
+func (f *FilterTree) copy() *FilterTree {
+	if f == nil {
+		return nil
+	}
+	out := &FilterTree{Op: f.Op, Func: f.Func.copy()}
+	for _, child := range f.Child {
+		out.Child = append(out.Child, child.copy())
+	}
+	return out
+}
+
+func (fn *Function) copy() *Function {
+	if fn == nil {
+		return nil
+	}
+	out := *fn
+	out.Args = copySlice(fn.Args)
+	out.UID = copySlice(fn.UID)
+	out.NeedsVar = copySlice(fn.NeedsVar)
+	return &out
+}
+
+func (mt *MathTree) copy() *MathTree {
+	if mt == nil {
+		return nil
+	}
+	out := &MathTree{Fn: mt.Fn, Var: mt.Var, Const: mt.Const}
+	if mt.Val != nil {
+		out.Val = make(map[uint64]types.Val, len(mt.Val))
+		for k, v := range mt.Val {
+			out.Val[k] = v
+		}
+	}
+	for _, child := range mt.Child {
+		out.Child = append(out.Child, child.copy())
+	}
+	return out
+}
+
+// copySlice returns a copy of s that preserves the difference between nil and empty slices, which
+// is meaningful for some of the fields, e.g. uid() with an empty list of UIDs.
+func copySlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
 func validateResult(res *Result) error {
 	seenQueryAliases := make(map[string]bool)
 	for _, q := range res.Query {
@@ -25,6 +25,7 @@ import (
 	"github.com/dgraph-io/dgo/v210/protos/api"
 	"github.com/vtta/dgraph/chunker"
 	"github.com/vtta/dgraph/lex"
+	"github.com/vtta/dgraph/protos/pb"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
@@ -5453,3 +5454,78 @@ func TestEmptyId(t *testing.T) {
 	_, err := Parse(r)
 	require.Error(t, err, "ID cannot be empty")
 }
+
+func TestParseVariables(t *testing.T) {
+	query := `
+	query test($a: int, $b: string! , $c: bool = true) {
+		me(func: uid(0x1)) @filter(eq(age, $a)) {
+			name
+		}
+	}`
+	vars, err := ParseVariables(query)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"$a": "int", "$b": "string!", "$c": "bool"}, vars)
+
+	vars, err = ParseVariables(`{ me(func: uid(0x1)) { name } }`)
+	require.NoError(t, err)
+	require.Empty(t, vars)
+
+	_, err = ParseVariables(`query test($a int) { me(func: uid(0x1)) { name } }`)
+	require.Error(t, err)
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter(`eq(tenant, "acme") OR NOT type(Project)`)
+	require.NoError(t, err)
+	require.Equal(t, `(OR (eq tenant "acme") (NOT (type)))`, filter.debugString())
+
+	for _, f := range []string{
+		``,
+		`eq(tenant, "acme"`,
+		`eq(tenant, val(a))`,
+		`eq(tenant, "acme")) { uid } } { q2(func: has(name)) @filter(has(name)`,
+	} {
+		_, err := ParseFilter(f)
+		require.Error(t, err, "filter %q", f)
+	}
+}
+
+func TestResultCopy(t *testing.T) {
+	query := `
+	query test($name: string) {
+		me(func: eq(name, $name), first: 10) @filter(has(age) AND has(friend)) @cascade {
+			name
+			friend(orderasc: name) @facets(orderdesc: since, close) {
+				name
+			}
+		}
+	}`
+	res, err := Parse(Request{Str: query, Variables: map[string]string{"$name": "alice"}})
+	require.NoError(t, err)
+
+	cp := res.Copy()
+	require.Equal(t, res, cp)
+
+	// Mutate the copy the same way query processing does and make sure that the original is
+	// left untouched.
+	delete(cp.Query[0].Args, "first")
+	cp.Query[0].Func.Args[0].Value = "bob"
+	cp.Query[0].Filter.Child = cp.Query[0].Filter.Child[:0]
+	cp.Query[0].Children[1].Order[0].Attr = "age"
+	cp.Query[0].Children[1].Order[0].Desc = true
+	cp.Query[0].Children[1].Order = cp.Query[0].Children[1].Order[:0]
+	cp.Query[0].Children[1].Facets.Param[0].Alias = "closeness"
+	cp.Query[0].Children[1].Facets.AllKeys = true
+	cp.Query[0].Children[1].FacetsOrder[0].Desc = false
+	cp.Query[0].Children = cp.Query[0].Children[:1]
+
+	require.Equal(t, "10", res.Query[0].Args["first"])
+	require.Equal(t, "alice", res.Query[0].Func.Args[0].Value)
+	require.Len(t, res.Query[0].Filter.Child, 2)
+	require.Len(t, res.Query[0].Children, 2)
+	require.Len(t, res.Query[0].Children[1].Order, 1)
+	require.Equal(t, &pb.Order{Attr: "name"}, res.Query[0].Children[1].Order[0])
+	require.Equal(t, "", res.Query[0].Children[1].Facets.Param[0].Alias)
+	require.False(t, res.Query[0].Children[1].Facets.AllKeys)
+	require.True(t, res.Query[0].Children[1].FacetsOrder[0].Desc)
+}
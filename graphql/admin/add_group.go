@@ -30,7 +30,8 @@ func (mrw *addGroupRewriter) RewriteQueries(
 
 // Rewrite rewrites schema.Mutation into dql upsert mutations only for Group type.
 // It ensures that only the last rule out of all duplicate rules in input is preserved.
-// A rule is duplicate if it has same predicate name as another rule.
+// A rule is duplicate if it has same predicate name as another rule, and a node rule is
+// duplicate if it has the same type as another node rule.
 func (mrw *addGroupRewriter) Rewrite(
 	ctx context.Context,
 	m schema.Mutation,
@@ -43,6 +44,16 @@ func (mrw *addGroupRewriter) Rewrite(
 		rules, _ := groupInput.(map[string]interface{})["rules"].([]interface{})
 		rules, _ = removeDuplicateRuleRef(rules)
 		addGroupInput[i].(map[string]interface{})["rules"] = rules
+
+		nodeRules, ok := groupInput.(map[string]interface{})["nodeRules"].([]interface{})
+		if !ok {
+			continue
+		}
+		nodeRules, errs := removeDuplicateNodeRuleRef(nodeRules)
+		if len(errs) != 0 {
+			return nil, schema.GQLWrapf(errs, "failed to rewrite mutation payload")
+		}
+		addGroupInput[i].(map[string]interface{})["nodeRules"] = nodeRules
 	}
 
 	m.SetArgTo(schema.InputArgName, addGroupInput)
@@ -95,6 +106,42 @@ func removeDuplicateRuleRef(rules []interface{}) ([]interface{}, x.GqlErrorList)
 	return rules[:i], errs
 }
 
+// removeDuplicateNodeRuleRef removes duplicate node rules based on type value, and checks that
+// the filters of the node rules are valid. For duplicate node rules, only the last node rule with
+// duplicate type is preserved.
+func removeDuplicateNodeRuleRef(rules []interface{}) ([]interface{}, x.GqlErrorList) {
+	var errs x.GqlErrorList
+	typeMap := make(map[string]int, len(rules))
+	i := 0
+
+	for j, rule := range rules {
+		typ, _ := rule.(map[string]interface{})["type"].(string)
+		filter, _ := rule.(map[string]interface{})["filter"].(string)
+
+		if typ == "" {
+			err := fmt.Errorf("at index %d: type value can't be empty string", j)
+			errs = append(errs, schema.AsGQLErrors(err)...)
+			continue
+		}
+		if _, err := gql.ParseFilter(filter); err != nil {
+			err = fmt.Errorf("at index %d: %v", j, err)
+			errs = append(errs, schema.AsGQLErrors(err)...)
+			continue
+		}
+
+		// this ensures that only the last node rule with duplicate type is preserved
+		if idx, ok := typeMap[typ]; !ok {
+			typeMap[typ] = i
+			rules[i] = rule
+			i++
+		} else {
+			rules[idx] = rule
+		}
+	}
+
+	return rules[:i], errs
+}
+
 func appendEmptyPredicateError(errs x.GqlErrorList, i int) x.GqlErrorList {
 	err := fmt.Errorf("at index %d: predicate value can't be empty string", i)
 	errs = append(errs, schema.AsGQLErrors(err)...)
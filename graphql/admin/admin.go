@@ -27,6 +27,7 @@ import (
 
 	badgerpb "github.com/dgraph-io/badger/v3/pb"
 	"github.com/vtta/dgraph/edgraph"
+	"github.com/vtta/dgraph/graphql/lambda"
 	"github.com/vtta/dgraph/graphql/resolve"
 	"github.com/vtta/dgraph/graphql/schema"
 	"github.com/vtta/dgraph/protos/pb"
@@ -210,6 +211,85 @@ const (
 		schema: String!
 	}
 
+	"""
+	A version of the schema. Every update that changes the GraphQL schema is recorded as the next
+	version, and so is every DQL alter of the schema.
+	"""
+	type GQLSchemaVersion {
+		version: Int!
+
+		"""
+		The GraphQL schema. It is empty for the versions recording a DQL alter.
+		"""
+		schema: String!
+
+		"""
+		The schema that a DQL alter applied. It is null for GraphQL schema updates.
+		"""
+		dqlSchema: String
+		createdAt: DateTime!
+
+		"""
+		The ACL user that made the update. It is null if ACL isn't enabled.
+		"""
+		author: String
+	}
+
+	enum SchemaChangeKind {
+		PREDICATE_ADDED
+		PREDICATE_REMOVED
+		TYPE_CHANGED
+		INDEX_ADDED
+		INDEX_REMOVED
+		DIRECTIVE_CHANGED
+		DGRAPH_TYPE_ADDED
+	}
+
+	enum SchemaChangeSeverity {
+		"""
+		The change only adds to the schema.
+		"""
+		SAFE
+
+		"""
+		The change rebuilds indexes, which takes a while on big predicates.
+		"""
+		EXPENSIVE
+
+		"""
+		The change drops indexes or data, or breaks the queries that used them.
+		"""
+		DESTRUCTIVE
+	}
+
+	"""
+	A change that a schema update would make to a predicate or a type of the Dgraph schema.
+	"""
+	type SchemaChange {
+		kind: SchemaChangeKind!
+		severity: SchemaChangeSeverity!
+		predicate: String
+		type: String
+
+		"""
+		What the predicate or type had before the change, e.g. the removed index tokenizers.
+		"""
+		from: String
+
+		"""
+		What the predicate or type has after the change, e.g. the added index tokenizers.
+		"""
+		to: String
+	}
+
+	type SchemaDiff {
+		"""
+		The highest severity among the changes.
+		"""
+		severity: SchemaChangeSeverity!
+		changes: [SchemaChange!]!
+	}
+
 	input ExportInput {
 		"""
 		Data format for the export, e.g. "rdf" or "json" (default: "rdf")
@@ -303,6 +383,30 @@ const (
 		False value of logDQLRequest disables above.
 		"""
 		logDQLRequest: Boolean
+
+		"""
+		The max nesting of the fields in a GraphQL operation. 0 means no limit. The limits on
+		GraphQL operations don't apply to the operations of /admin.
+		"""
+		maxQueryDepth: Int
+
+		"""
+		The max number of aliased fields in a GraphQL operation. 0 means no limit.
+		"""
+		maxQueryAliases: Int
+
+		"""
+		The max cost of a GraphQL operation. Each field costs 1, and the cost of the fields
+		selected in a field with a first argument is multiplied by the value of first, or by
+		defaultListMultiplier in a list field without one. 0 means no limit.
+		"""
+		maxQueryCost: Int
+
+		"""
+		The multiplier of the cost of the fields selected in a list field without a first
+		argument.
+		"""
+		defaultListMultiplier: Int
 	}
 
 	type ConfigPayload {
@@ -311,6 +415,10 @@ const (
 
 	type Config {
 		cacheMb: Float
+		maxQueryDepth: Int
+		maxQueryAliases: Int
+		maxQueryCost: Int
+		defaultListMultiplier: Int
 	}
 
 	input RemoveNodeInput {
@@ -389,6 +497,183 @@ const (
 		response: AssignedIds
 	}
 
+	"""
+	A DQL query registered with the server, that clients execute by its id, e.g. /query?id=<id>,
+	instead of sending the query text.
+	"""
+	type PersistedQuery {
+		id: String!
+
+		"""
+		Version of the query. Every registration of a query with an existing id adds a new
+		version, and the latest version is executed unless a version is asked for.
+		"""
+		version: Int!
+
+		query: String!
+
+		"""
+		Variables declared by the query along with their DQL types.
+		"""
+		variables: [PersistedQueryVariable]
+	}
+
+	type PersistedQueryVariable {
+		name: String!
+		type: String!
+	}
+
+	input AddPersistedQueryInput {
+		"""
+		Id with which clients execute the query.
+		"""
+		id: String!
+
+		"""
+		The DQL query. Any variables used by it must be declared along with their types.
+		"""
+		query: String!
+	}
+
+	type AddPersistedQueryPayload {
+		persistedQuery: PersistedQuery
+	}
+
+	input DeletePersistedQueryInput {
+		id: String!
+
+		"""
+		Version of the query to delete. All the versions are deleted if it isn't specified.
+		"""
+		version: Int
+	}
+
+	type DeletePersistedQueryPayload {
+		response: Response
+	}
+
+	"""
+	Limits on the resources used by a single query. A limit that isn't set falls back to the
+	limits of the namespace, and then to the --limit flag of the alphas.
+	"""
+	type QueryLimits {
+		"""
+		ACL group whose members the limits apply to. The limits apply to the whole namespace if
+		it isn't set. Members of several groups get the highest of their limits.
+		"""
+		group: String
+
+		"""
+		Maximum number of uids that a query can touch.
+		"""
+		uids: UInt64
+
+		"""
+		Maximum depth of a query, including the levels expanded by @recurse.
+		"""
+		depth: UInt64
+
+		"""
+		Maximum size in bytes of the response of a query.
+		"""
+		resultBytes: UInt64
+
+		"""
+		Maximum memory in bytes used to build the response of a query.
+		"""
+		memoryBytes: UInt64
+	}
+
+	input SetQueryLimitsInput {
+		"""
+		ACL group to set the limits of. The limits of the whole namespace are set if it isn't given.
+		"""
+		group: String
+
+		"""
+		Limits that aren't given, or are 0, are unset.
+		"""
+		uids: UInt64
+		depth: UInt64
+		resultBytes: UInt64
+		memoryBytes: UInt64
+	}
+
+	type SetQueryLimitsPayload {
+		queryLimits: QueryLimits
+	}
+
+	"""
+	The WASM module that runs the lambdas of the namespace, when the alphas are started with
+	--graphql lambda-embedded=true. It gets the request that a lambda server would get on its
+	stdin, and writes the response on its stdout.
+	"""
+	type LambdaModule {
+		"""
+		Size of the module in bytes.
+		"""
+		size: Int!
+
+		"""
+		Hex encoded SHA-256 hash of the module, to tell which module is being run.
+		"""
+		sha256: String!
+		updatedAt: DateTime!
+	}
+
+	input UpdateLambdaModuleInput {
+		"""
+		Base64 encoded WASM module. It must be a WASI command, e.g. JavaScript compiled with Javy.
+		The lambda module of the namespace is removed if it isn't given.
+		"""
+		module: String
+	}
+
+	type UpdateLambdaModulePayload {
+		lambdaModule: LambdaModule
+	}
+
+	"""
+	A query or mutation that is being executed by the alpha.
+	"""
+	type RunningQuery {
+		"""
+		Id with which the query can be killed. Ids are unique to the alpha executing the query.
+		"""
+		id: UInt64!
+		namespace: UInt64!
+
+		"""
+		ACL user that sent the query, if ACL is enabled.
+		"""
+		user: String
+		startedAt: DateTime!
+		durationMs: Int64!
+
+		"""
+		Text of the DQL query. It is empty for a mutation without a query.
+		"""
+		query: String
+		mutation: Boolean!
+
+		"""
+		Tells if the query was sent through the /graphql endpoint, in which case it is the DQL
+		query that the GraphQL query was rewritten to.
+		"""
+		graphql: Boolean!
+	}
+
+	input KillQueryInput {
+		"""
+		Id of the query, as listed by listQueries.
+		"""
+		id: UInt64!
+	}
+
+	type KillQueryPayload {
+		response: Response
+	}
+
 	` + adminTypes + `
 
 	type Query {
@@ -397,6 +682,40 @@ const (
 		state: MembershipState
 		config: Config
 		task(input: TaskInput!): TaskPayload
+
+		"""
+		Get all the versions of the persisted DQL query with the given id, or all the persisted
+		DQL queries if no id is given.
+		"""
+		getPersistedQueries(id: String): [PersistedQuery]
+
+		"""
+		Get all the recorded versions of the GraphQL schema, oldest first.
+		"""
+		getSchemaHistory: [GQLSchemaVersion]
+
+		"""
+		Get the changes that updating the schema would make to the Dgraph schema, without making
+		them. Exactly one of a GraphQL schema or a DQL schema must be given.
+		"""
+		diffSchema(schema: String, dqlSchema: String): SchemaDiff
+
+		"""
+		Get the query limits set for the namespace and its ACL groups.
+		"""
+		getQueryLimits: [QueryLimits]
+
+		"""
+		Get the lambda module of the namespace.
+		"""
+		getLambdaModule: LambdaModule
+
+		"""
+		List the queries and mutations that are being executed by this alpha. Guardians of the
+		galaxy get the queries of all the namespaces.
+		"""
+		listQueries: [RunningQuery]
+
 		` + adminQueries + `
 	}
 
@@ -408,6 +727,13 @@ const (
 		"""
 		updateGQLSchema(input: UpdateGQLSchemaInput!) : UpdateGQLSchemaPayload
 
+		"""
+		Update the Dgraph cluster to serve the given version of the GraphQL schema again. This is
+		recorded as a new version of the schema. Rollbacks that would make destructive changes to
+		the Dgraph schema are refused unless force is true.
+		"""
+		rollbackSchema(version: Int!, force: Boolean): UpdateGQLSchemaPayload
+
 		"""
 		Starts an export of all data in the cluster.  Export format should be 'rdf' (the default
 		if no format is given), or 'json'.
@@ -445,6 +771,33 @@ const (
 		"""
 		assign(input: AssignInput!): AssignPayload
 
+		"""
+		Register a DQL query as the next version of the persisted query with the given id.
+		"""
+		addPersistedQuery(input: AddPersistedQueryInput!): AddPersistedQueryPayload
+
+		"""
+		Delete a version, or all the versions, of a persisted DQL query.
+		"""
+		deletePersistedQuery(input: DeletePersistedQueryInput!): DeletePersistedQueryPayload
+
+		"""
+		Set the query limits of the namespace, or of an ACL group in it.
+		"""
+		setQueryLimits(input: SetQueryLimitsInput!): SetQueryLimitsPayload
+
+		"""
+		Set, or remove, the lambda module of the namespace, which runs its lambdas in the
+		embedded lambda runtime of the alphas.
+		"""
+		updateLambdaModule(input: UpdateLambdaModuleInput!): UpdateLambdaModulePayload
+
+		"""
+		Kill a query or mutation that is being executed by this alpha. The work it fanned out to
+		other groups is cancelled along with it.
+		"""
+		killQuery(input: KillQueryInput!): KillQueryPayload
+
 		` + adminMutations + `
 	}
  `
@@ -500,35 +853,54 @@ var (
 		resolve.LoggingMWMutation,
 	}
 	adminQueryMWConfig = map[string]resolve.QueryMiddlewares{
-		"health":       minimalAdminQryMWs, // dgraph checks Guardian auth for health
-		"state":        minimalAdminQryMWs, // dgraph checks Guardian auth for state
-		"config":       gogQryMWs,
-		"listBackups":  gogQryMWs,
-		"getGQLSchema": stdAdminQryMWs,
+		"health":              minimalAdminQryMWs, // dgraph checks Guardian auth for health
+		"state":               minimalAdminQryMWs, // dgraph checks Guardian auth for state
+		"config":              gogQryMWs,
+		"listBackups":         gogQryMWs,
+		"getGQLSchema":        stdAdminQryMWs,
+		"getPersistedQueries": stdAdminQryMWs,
+		"getSchemaHistory":    stdAdminQryMWs,
+		"diffSchema":          stdAdminQryMWs,
+		"getQueryLimits":      stdAdminQryMWs,
+		"getLambdaModule":     stdAdminQryMWs,
+		"listQueries":         stdAdminQryMWs,
+		"getJwtKeys":          gogQryMWs,
+		"listApiKeys":         stdAdminQryMWs,
 		// for queries and mutations related to User/Group, dgraph handles Guardian auth,
 		// so no need to apply GuardianAuth Middleware
 		"queryUser":      minimalAdminQryMWs,
 		"queryGroup":     minimalAdminQryMWs,
 		"getUser":        minimalAdminQryMWs,
 		"getCurrentUser": minimalAdminQryMWs,
+		"getPermissions": minimalAdminQryMWs,
+		"explainQuery":   minimalAdminQryMWs,
 		"getGroup":       minimalAdminQryMWs,
 	}
 	adminMutationMWConfig = map[string]resolve.MutationMiddlewares{
-		"backup":            gogMutMWs,
-		"config":            gogMutMWs,
-		"draining":          gogMutMWs,
-		"export":            stdAdminMutMWs, // dgraph handles the export for other namespaces by guardian of galaxy
-		"login":             minimalAdminMutMWs,
-		"restore":           gogMutMWs,
-		"shutdown":          gogMutMWs,
-		"removeNode":        gogMutMWs,
-		"moveTablet":        gogMutMWs,
-		"assign":            gogMutMWs,
-		"enterpriseLicense": gogMutMWs,
-		"updateGQLSchema":   stdAdminMutMWs,
-		"addNamespace":      gogAclMutMWs,
-		"deleteNamespace":   gogAclMutMWs,
-		"resetPassword":     gogAclMutMWs,
+		"backup":               gogMutMWs,
+		"config":               gogMutMWs,
+		"draining":             gogMutMWs,
+		"export":               stdAdminMutMWs, // dgraph handles the export for other namespaces by guardian of galaxy
+		"login":                minimalAdminMutMWs,
+		"restore":              gogMutMWs,
+		"shutdown":             gogMutMWs,
+		"removeNode":           gogMutMWs,
+		"moveTablet":           gogMutMWs,
+		"assign":               gogMutMWs,
+		"enterpriseLicense":    gogMutMWs,
+		"updateGQLSchema":      stdAdminMutMWs,
+		"rollbackSchema":       stdAdminMutMWs,
+		"addPersistedQuery":    stdAdminMutMWs,
+		"deletePersistedQuery": stdAdminMutMWs,
+		"setQueryLimits":       stdAdminMutMWs,
+		"updateLambdaModule":   stdAdminMutMWs,
+		"killQuery":            stdAdminMutMWs,
+		"addNamespace":         gogAclMutMWs,
+		"deleteNamespace":      gogAclMutMWs,
+		"resetPassword":        gogAclMutMWs,
+		"rotateJwtKey":         gogAclMutMWs,
+		"addApiKey":            stdAdminMutMWs,
+		"revokeApiKey":         stdAdminMutMWs,
 		// for queries and mutations related to User/Group, dgraph handles Guardian auth,
 		// so no need to apply GuardianAuth Middleware
 		"addUser":     minimalAdminMutMWs,
@@ -613,6 +985,19 @@ type adminServer struct {
 // main /graphql endpoint and an admin server.  The result is mainServer, adminServer.
 func NewServers(withIntrospection bool, globalEpoch map[uint64]*uint64,
 	closer *z.Closer) (IServeGraphQL, IServeGraphQL, *GraphQLHealthStore) {
+	schema.InitCustomHTTP(x.Config.GraphQL.GetInt64("custom-http-cache-mb"),
+		int(x.Config.GraphQL.GetInt64("custom-http-conns-per-host")))
+	schema.SetQueryLimits(schema.QueryLimits{
+		MaxDepth:              x.Config.GraphQL.GetInt64("max-query-depth"),
+		MaxAliases:            x.Config.GraphQL.GetInt64("max-query-aliases"),
+		MaxCost:               x.Config.GraphQL.GetInt64("max-query-cost"),
+		DefaultListMultiplier: x.Config.GraphQL.GetInt64("default-list-multiplier"),
+	})
+	if x.Config.GraphQL.GetBool("lambda-embedded") {
+		lambda.Init(x.Config.GraphQL.GetInt64("lambda-memory-mb"),
+			x.Config.GraphQL.GetDuration("lambda-timeout"), edgraph.LoadLambdaModule)
+	}
+
 	gqlSchema, err := schema.FromString("", x.GalaxyNamespace)
 	if err != nil {
 		x.Panic(err)
@@ -646,7 +1031,7 @@ func newAdminResolver(
 	epoch map[uint64]*uint64,
 	closer *z.Closer) *resolve.RequestResolver {
 
-	adminSchema, err := schema.FromString(graphqlAdminSchema, x.GalaxyNamespace)
+	adminSchema, err := schema.AdminFromString(graphqlAdminSchema)
 	if err != nil {
 		x.Panic(err)
 	}
@@ -755,20 +1140,29 @@ func newAdminResolver(
 
 func newAdminResolverFactory() resolve.ResolverFactory {
 	adminMutationResolvers := map[string]resolve.MutationResolverFunc{
-		"addNamespace":      resolveAddNamespace,
-		"backup":            resolveBackup,
-		"config":            resolveUpdateConfig,
-		"deleteNamespace":   resolveDeleteNamespace,
-		"draining":          resolveDraining,
-		"export":            resolveExport,
-		"login":             resolveLogin,
-		"resetPassword":     resolveResetPassword,
-		"restore":           resolveRestore,
-		"shutdown":          resolveShutdown,
-		"removeNode":        resolveRemoveNode,
-		"moveTablet":        resolveMoveTablet,
-		"assign":            resolveAssign,
-		"enterpriseLicense": resolveEnterpriseLicense,
+		"addNamespace":         resolveAddNamespace,
+		"addPersistedQuery":    resolveAddPersistedQuery,
+		"deletePersistedQuery": resolveDeletePersistedQuery,
+		"rollbackSchema":       resolveRollbackSchema,
+		"setQueryLimits":       resolveSetQueryLimits,
+		"updateLambdaModule":   resolveUpdateLambdaModule,
+		"killQuery":            resolveKillQuery,
+		"backup":               resolveBackup,
+		"config":               resolveUpdateConfig,
+		"deleteNamespace":      resolveDeleteNamespace,
+		"draining":             resolveDraining,
+		"export":               resolveExport,
+		"login":                resolveLogin,
+		"resetPassword":        resolveResetPassword,
+		"rotateJwtKey":         resolveRotateJwtKey,
+		"addApiKey":            resolveAddApiKey,
+		"revokeApiKey":         resolveRevokeApiKey,
+		"restore":              resolveRestore,
+		"shutdown":             resolveShutdown,
+		"removeNode":           resolveRemoveNode,
+		"moveTablet":           resolveMoveTablet,
+		"assign":               resolveAssign,
+		"enterpriseLicense":    resolveEnterpriseLicense,
 	}
 
 	rf := resolverFactoryWithErrorMsg(errResolverNotFound).
@@ -789,6 +1183,36 @@ func newAdminResolverFactory() resolve.ResolverFactory {
 		WithQueryResolver("task", func(q schema.Query) resolve.QueryResolver {
 			return resolve.QueryResolverFunc(resolveTask)
 		}).
+		WithQueryResolver("getPersistedQueries", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveGetPersistedQueries)
+		}).
+		WithQueryResolver("getSchemaHistory", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveGetSchemaHistory)
+		}).
+		WithQueryResolver("diffSchema", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveDiffSchema)
+		}).
+		WithQueryResolver("getQueryLimits", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveGetQueryLimits)
+		}).
+		WithQueryResolver("getLambdaModule", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveGetLambdaModule)
+		}).
+		WithQueryResolver("listQueries", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveListQueries)
+		}).
+		WithQueryResolver("getJwtKeys", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveGetJwtKeys)
+		}).
+		WithQueryResolver("listApiKeys", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveListApiKeys)
+		}).
+		WithQueryResolver("getPermissions", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveGetPermissions)
+		}).
+		WithQueryResolver("explainQuery", func(q schema.Query) resolve.QueryResolver {
+			return resolve.QueryResolverFunc(resolveExplainQuery)
+		}).
 		WithQueryResolver("getGQLSchema", func(q schema.Query) resolve.QueryResolver {
 			return resolve.QueryResolverFunc(
 				func(ctx context.Context, query schema.Query) *resolve.Resolved {
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/graphql/schema"
+	"github.com/vtta/dgraph/x"
+)
+
+func TestAdminOperationsIgnoreQueryLimits(t *testing.T) {
+	defer schema.SetQueryLimits(schema.QueryLimits{})
+	schema.SetQueryLimits(schema.QueryLimits{MaxDepth: 1, MaxAliases: 1, MaxCost: 1})
+
+	adminSchema, err := schema.AdminFromString(graphqlAdminSchema)
+	require.NoError(t, err)
+	// The same schema is subject to the limits when it isn't served on /admin.
+	otherSchema, err := schema.FromString(graphqlAdminSchema, x.GalaxyNamespace)
+	require.NoError(t, err)
+
+	for _, query := range []string{
+		`mutation {
+			updateGQLSchema(input: {set: {schema: "type Post { id: ID! }"}}) {
+				gqlSchema { id schema generatedSchema }
+			}
+		}`,
+		`mutation {
+			a: config(input: {maxQueryDepth: 0, maxQueryAliases: 0, maxQueryCost: 0,
+				defaultListMultiplier: 1}) {
+				response { code message }
+			}
+		}`,
+		`query { c: config { maxQueryDepth maxQueryAliases maxQueryCost } }`,
+	} {
+		_, err := adminSchema.Operation(&schema.Request{Query: query})
+		require.NoError(t, err, query)
+		_, err = otherSchema.Operation(&schema.Request{Query: query})
+		require.Error(t, err, query)
+	}
+}
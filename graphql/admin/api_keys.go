@@ -0,0 +1,151 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	"github.com/vtta/dgraph/edgraph"
+	"github.com/vtta/dgraph/graphql/resolve"
+	"github.com/vtta/dgraph/graphql/schema"
+)
+
+func resolveAddApiKey(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
+	input, err := getAddApiKeyInput(m)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	glog.Infof("Got request to add an API key for user %q/group %q through GraphQL admin API",
+		input.User, input.Group)
+
+	apiKey, key, err := edgraph.CreateApiKey(ctx, input)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	return resolve.DataResult(
+		m,
+		map[string]interface{}{m.Name(): map[string]interface{}{
+			"response": response("Success", fmt.Sprintf("Added API key %s", key.Id)),
+			"apiKey":   apiKey,
+			"key":      apiKeyResult(key),
+		}},
+		nil,
+	), true
+}
+
+func resolveRevokeApiKey(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
+	inputArg, _ := m.ArgValue(schema.InputArgName).(map[string]interface{})
+	id, _ := inputArg["id"].(string)
+	glog.Infof("Got request to revoke API key %s through GraphQL admin API", id)
+
+	if err := edgraph.RevokeApiKey(ctx, id); err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	return resolve.DataResult(
+		m,
+		map[string]interface{}{m.Name(): map[string]interface{}{
+			"response": response("Success", fmt.Sprintf("Revoked API key %s", id)),
+		}},
+		nil,
+	), true
+}
+
+func resolveListApiKeys(ctx context.Context, q schema.Query) *resolve.Resolved {
+	keys, err := edgraph.ListApiKeys(ctx)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+
+	results := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, apiKeyResult(key))
+	}
+	return resolve.DataResult(
+		q,
+		map[string]interface{}{q.Name(): results},
+		nil,
+	)
+}
+
+func apiKeyResult(key *edgraph.ApiKey) map[string]interface{} {
+	res := map[string]interface{}{
+		"id":         key.Id,
+		"user":       nil,
+		"group":      nil,
+		"expiresAt":  nil,
+		"readOnly":   false,
+		"predicates": nil,
+	}
+	if key.User != "" {
+		res["user"] = key.User
+	}
+	if key.Group != "" {
+		res["group"] = key.Group
+	}
+	if !key.ExpiresAt.IsZero() {
+		res["expiresAt"] = key.ExpiresAt.Format(time.RFC3339)
+	}
+	if key.Scope != nil {
+		res["readOnly"] = key.Scope.ReadOnly
+		if len(key.Scope.Predicates) > 0 {
+			preds := make([]interface{}, 0, len(key.Scope.Predicates))
+			for _, pred := range key.Scope.Predicates {
+				preds = append(preds, pred)
+			}
+			res["predicates"] = preds
+		}
+	}
+	return res
+}
+
+func getAddApiKeyInput(m schema.Mutation) (*edgraph.ApiKeyInput, error) {
+	inputArg, ok := m.ArgValue(schema.InputArgName).(map[string]interface{})
+	if !ok {
+		return nil, inputArgError(errors.Errorf("can't convert input to map"))
+	}
+
+	input := &edgraph.ApiKeyInput{}
+	input.User, _ = inputArg["user"].(string)
+	input.Group, _ = inputArg["group"].(string)
+	if expiresAt, ok := inputArg["expiresAt"].(string); ok && expiresAt != "" {
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return nil, inputArgError(schema.GQLWrapf(err,
+				"can't convert input.expiresAt to DateTime"))
+		}
+		input.ExpiresAt = t
+	}
+
+	scope := &edgraph.ApiKeyScope{}
+	scope.ReadOnly, _ = inputArg["readOnly"].(bool)
+	if preds, ok := inputArg["predicates"].([]interface{}); ok {
+		for _, pred := range preds {
+			if p, ok := pred.(string); ok {
+				scope.Predicates = append(scope.Predicates, p)
+			}
+		}
+	}
+	if scope.ReadOnly || len(scope.Predicates) > 0 {
+		input.Scope = scope
+	}
+	return input, nil
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/vtta/dgraph/graphql/schema"
 	"github.com/vtta/dgraph/worker"
 	"github.com/golang/glog"
+	"github.com/pkg/errors"
 )
 
 type configInput struct {
@@ -33,6 +34,12 @@ type configInput struct {
 	// logging of all requests coming to alphas. LogDQLRequest type has been kept as *bool instead of
 	// bool to avoid updating WorkerOptions.LogDQLRequest when it has default value of false.
 	LogDQLRequest *bool
+	// MaxQueryDepth, MaxQueryAliases, MaxQueryCost and DefaultListMultiplier are used to update
+	// the limits on the GraphQL operations, when they are specified.
+	MaxQueryDepth         *int64
+	MaxQueryAliases       *int64
+	MaxQueryCost          *int64
+	DefaultListMultiplier *int64
 }
 
 func resolveUpdateConfig(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
@@ -55,6 +62,10 @@ func resolveUpdateConfig(ctx context.Context, m schema.Mutation) (*resolve.Resol
 		worker.UpdateLogDQLRequest(*input.LogDQLRequest)
 	}
 
+	if err = updateQueryLimits(input); err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+
 	return resolve.DataResult(
 		m,
 		map[string]interface{}{m.Name(): response("Success", "Config updated successfully")},
@@ -65,16 +76,54 @@ func resolveUpdateConfig(ctx context.Context, m schema.Mutation) (*resolve.Resol
 func resolveGetConfig(ctx context.Context, q schema.Query) *resolve.Resolved {
 	glog.Info("Got config query through GraphQL admin API")
 
+	limits := schema.GetQueryLimits()
+
 	return resolve.DataResult(
 		q,
 		map[string]interface{}{q.Name(): map[string]interface{}{
-			"cacheMb": json.Number(strconv.FormatInt(worker.Config.CacheMb, 10)),
+			"cacheMb":         json.Number(strconv.FormatInt(worker.Config.CacheMb, 10)),
+			"maxQueryDepth":   json.Number(strconv.FormatInt(limits.MaxDepth, 10)),
+			"maxQueryAliases": json.Number(strconv.FormatInt(limits.MaxAliases, 10)),
+			"maxQueryCost":    json.Number(strconv.FormatInt(limits.MaxCost, 10)),
+			"defaultListMultiplier": json.Number(
+				strconv.FormatInt(limits.DefaultListMultiplier, 10)),
 		}},
 		nil,
 	)
 
 }
 
+// updateQueryLimits updates the limits on the GraphQL operations which are specified in input.
+func updateQueryLimits(input *configInput) error {
+	if input.MaxQueryDepth == nil && input.MaxQueryAliases == nil && input.MaxQueryCost == nil &&
+		input.DefaultListMultiplier == nil {
+		return nil
+	}
+
+	limits := schema.GetQueryLimits()
+	for _, l := range []struct {
+		name  string
+		input *int64
+		limit *int64
+	}{
+		{"maxQueryDepth", input.MaxQueryDepth, &limits.MaxDepth},
+		{"maxQueryAliases", input.MaxQueryAliases, &limits.MaxAliases},
+		{"maxQueryCost", input.MaxQueryCost, &limits.MaxCost},
+		{"defaultListMultiplier", input.DefaultListMultiplier, &limits.DefaultListMultiplier},
+	} {
+		if l.input == nil {
+			continue
+		}
+		if *l.input < 0 {
+			return errors.Errorf("%s must be non-negative", l.name)
+		}
+		*l.limit = *l.input
+	}
+	glog.Infof("Updating the GraphQL query limits to %+v", limits)
+	schema.SetQueryLimits(limits)
+	return nil
+}
+
 func getConfigInput(m schema.Mutation) (*configInput, error) {
 	inputArg := m.ArgValue(schema.InputArgName)
 	inputByts, err := json.Marshal(inputArg)
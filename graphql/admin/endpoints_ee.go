@@ -254,6 +254,7 @@ const adminTypes = `
 		name: String! @id @dgraph(pred: "dgraph.xid")
 		users: [User] @dgraph(pred: "~dgraph.user.group")
 		rules: [Rule] @dgraph(pred: "dgraph.acl.rule")
+		nodeRules: [NodeRule] @dgraph(pred: "dgraph.acl.node_rule")
 	}
 
 	type Rule @dgraph(type: "dgraph.type.Rule") {
@@ -283,6 +284,21 @@ const adminTypes = `
 		permission: Int! @dgraph(pred: "dgraph.rule.permission")
 	}
 
+	type NodeRule @dgraph(type: "dgraph.type.NodeRule") {
+
+		"""
+		Type of the nodes to which the rule applies.
+		"""
+		type: String! @dgraph(pred: "dgraph.rule.type")
+
+		"""
+		DQL filter, as written in @filter, that the nodes of the type must match for the members
+		of the group to query or mutate them, e.g. eq(tenant, "acme").  If the groups of a user
+		have several rules for a type, the nodes matching any of their filters are accessible.
+		"""
+		filter: String! @dgraph(pred: "dgraph.rule.filter")
+	}
+
 	input StringHashFilter {
 		eq: String
 	}
@@ -304,6 +320,7 @@ const adminTypes = `
 	input AddGroupInput {
 		name: String!
 		rules: [RuleRef]
+		nodeRules: [NodeRuleRef]
 	}
 
 	input UserRef {
@@ -340,6 +357,19 @@ const adminTypes = `
 		permission: Int!
 	}
 
+	input NodeRuleRef {
+		"""
+		Type of the nodes to which the rule applies.
+		"""
+		type: String!
+
+		"""
+		DQL filter that the nodes of the type must match for the members of the group to access
+		them.
+		"""
+		filter: String!
+	}
+
 	input UserFilter {
 		name: StringHashFilter
 		and: UserFilter
@@ -378,11 +408,17 @@ const adminTypes = `
 	}
 
 	input SetGroupPatch {
-		rules: [RuleRef!]!
+		rules: [RuleRef!]
+		nodeRules: [NodeRuleRef!]
 	}
 
 	input RemoveGroupPatch {
-		rules: [String!]!
+		rules: [String!]
+
+		"""
+		Types whose node rules are removed.
+		"""
+		nodeRules: [String!]
 	}
 
 	input UpdateGroupInput {
@@ -447,6 +483,208 @@ const adminTypes = `
 	type EnterpriseLicensePayload {
 		response: Response
 	}
+
+	"""
+	A key pair that the JWTs issued on login are signed with, when --acl jwt-alg is RS256 or ES256.
+	"""
+	type JwtKey {
+		"""
+		Id of the key, which the JWTs signed with it carry in their kid header.
+		"""
+		kid: String!
+		alg: String!
+		createdAt: DateTime!
+
+		"""
+		When the JWTs signed with the key stop being accepted. It is null for the key that the
+		JWTs are signed with.
+		"""
+		retireAt: DateTime
+	}
+
+	input RotateJwtKeyInput {
+		"""
+		How long the JWTs signed with the previous keys are still accepted for, e.g. 1h. It
+		defaults to the TTL of the refresh JWTs.
+		"""
+		overlap: String
+	}
+
+	type RotateJwtKeyPayload {
+		response: Response
+		key: JwtKey
+	}
+
+	"""
+	A long-lived key that machine clients can authenticate with, through the X-Dgraph-ApiKey
+	header or the apikey gRPC metadata, instead of logging in.
+	"""
+	type ApiKey {
+		id: String!
+
+		"""
+		The user that the key acts as. Exactly one of user and group is set.
+		"""
+		user: String
+
+		"""
+		The group that the key acts as a member of.
+		"""
+		group: String
+
+		"""
+		When the key stops being accepted. It is null if the key doesn't expire.
+		"""
+		expiresAt: DateTime
+
+		"""
+		Whether the key can only be used to query data.
+		"""
+		readOnly: Boolean!
+
+		"""
+		The only predicates that the key can be used to access, if set.
+		"""
+		predicates: [String!]
+	}
+
+	input AddApiKeyInput {
+		"""
+		The user that the key acts as. Exactly one of user and group must be given.
+		"""
+		user: String
+
+		"""
+		The group that the key acts as a member of.
+		"""
+		group: String
+		expiresAt: DateTime
+
+		"""
+		Whether the key can only be used to query data. The keys with a scope can't be used
+		for the operations restricted to the Guardians.
+		"""
+		readOnly: Boolean
+
+		"""
+		Restrict the key to these predicates, on top of the ACL rules of the user or group.
+		"""
+		predicates: [String!]
+	}
+
+	type AddApiKeyPayload {
+		response: Response
+
+		"""
+		The API key, which can't be retrieved again.
+		"""
+		apiKey: String
+		key: ApiKey
+	}
+
+	input RevokeApiKeyInput {
+		id: String!
+	}
+
+	type RevokeApiKeyPayload {
+		response: Response
+	}
+
+	input GetPermissionsInput {
+		"""
+		The user to get the permissions of. It defaults to the user making the request; looking
+		into other users requires being a Guardian.
+		"""
+		user: String
+
+		"""
+		The namespace of the user. It defaults to the namespace of the request; looking into
+		other namespaces requires being a Guardian of the galaxy.
+		"""
+		namespace: Int
+
+		"""
+		The predicates to get the permissions on. It defaults to all the predicates of the
+		namespace.
+		"""
+		predicates: [String!]
+	}
+
+	type PredicatePermission {
+		predicate: String!
+		read: Boolean!
+		write: Boolean!
+		modify: Boolean!
+	}
+
+	type UserPermissions {
+		user: String!
+		namespace: UInt64!
+		groups: [String!]
+
+		"""
+		Whether the user is a member of the guardians group, which can access everything.
+		"""
+		guardian: Boolean!
+
+		"""
+		The filter that the nodes accessed by the user must match as per the node rules of its
+		groups, if any apply.
+		"""
+		nodeFilter: String
+		predicates: [PredicatePermission]
+	}
+
+	input ExplainQueryInput {
+		"""
+		The user to explain the query for. It defaults to the user making the request; looking
+		into other users requires being a Guardian.
+		"""
+		user: String
+
+		"""
+		The namespace of the user. It defaults to the namespace of the request; looking into
+		other namespaces requires being a Guardian of the galaxy.
+		"""
+		namespace: Int
+
+		"""
+		The DQL query, which isn't run.
+		"""
+		query: String!
+
+		"""
+		The variables of the query, as a JSON object of strings.
+		"""
+		variables: String
+	}
+
+	type StrippedPredicate {
+		predicate: String!
+		reason: String!
+	}
+
+	type QueryExplanation {
+		user: String!
+		namespace: UInt64!
+		groups: [String!]
+
+		"""
+		The predicates that are dropped from the query.
+		"""
+		strippedPredicates: [StrippedPredicate]
+
+		"""
+		The query variables that are dropped because they are defined by dropped predicates.
+		"""
+		strippedVariables: [String!]
+
+		"""
+		The filter that the nodes read by the query must match as per the node rules, if any
+		apply.
+		"""
+		nodeFilter: String
+	}
 	`
 
 const adminMutations = `
@@ -519,6 +757,22 @@ const adminMutations = `
 	Apply enterprise license.
 	"""
 	enterpriseLicense(input: EnterpriseLicenseInput!): EnterpriseLicensePayload
+
+	"""
+	Create a key pair that the JWTs are signed with from now on, and retire the previous ones
+	after the overlap. Can only be used by the Guardians of the galaxy.
+	"""
+	rotateJwtKey(input: RotateJwtKeyInput): RotateJwtKeyPayload
+
+	"""
+	Add an API key for a user or a group of the namespace.
+	"""
+	addApiKey(input: AddApiKeyInput!): AddApiKeyPayload
+
+	"""
+	Revoke an API key of the namespace.
+	"""
+	revokeApiKey(input: RevokeApiKeyInput!): RevokeApiKeyPayload
 	`
 
 const adminQueries = `
@@ -537,4 +791,25 @@ const adminQueries = `
 	Get the information about the backups at a given location.
 	"""
 	listBackups(input: ListBackupsInput!) : [Manifest]
+
+	"""
+	Get the key pairs that the JWTs are signed with, the newest first.
+	"""
+	getJwtKeys: [JwtKey]
+
+	"""
+	Get the API keys of the namespace.
+	"""
+	listApiKeys: [ApiKey]
+
+	"""
+	Get the effective permissions of a user on the predicates, as per the ACL rules of its
+	groups.
+	"""
+	getPermissions(input: GetPermissionsInput): UserPermissions
+
+	"""
+	Tell which predicates the ACL would drop from a DQL query for a user, without running it.
+	"""
+	explainQuery(input: ExplainQueryInput!): QueryExplanation
 	`
@@ -33,6 +33,7 @@ import (
 	"github.com/vtta/dgraph/graphql/resolve"
 	"github.com/vtta/dgraph/graphql/schema"
 	"github.com/vtta/dgraph/graphql/subscription"
+	"github.com/vtta/dgraph/worker"
 	"github.com/vtta/dgraph/x"
 	"github.com/dgraph-io/graphql-transport-ws/graphqlws"
 	"github.com/golang/glog"
@@ -82,7 +83,7 @@ func (gh *graphqlHandler) Set(ns uint64, schemaEpoch *uint64, resolver *resolve.
 	gh.resolverMux.Unlock()
 
 	gh.pollerMux.Lock()
-	gh.poller[ns] = subscription.NewPoller(schemaEpoch, resolver)
+	gh.poller[ns] = subscription.NewPoller(schemaEpoch, resolver, ns, worker.WatchCommits)
 	gh.pollerMux.Unlock()
 }
 
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/vtta/dgraph/edgraph"
+	"github.com/vtta/dgraph/graphql/resolve"
+	"github.com/vtta/dgraph/graphql/schema"
+	"github.com/vtta/dgraph/worker"
+)
+
+func resolveRotateJwtKey(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
+	overlap, err := getRotateJwtKeyInput(m)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	glog.Infof("Got request to rotate the JWT keys with an overlap of %s through GraphQL admin API",
+		overlap)
+
+	key, err := edgraph.RotateJwtKey(ctx, overlap)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	return resolve.DataResult(
+		m,
+		map[string]interface{}{m.Name(): map[string]interface{}{
+			"response": response("Success", fmt.Sprintf("Rotated the JWT keys, the JWTs "+
+				"signed with the previous keys are accepted for %s", overlap)),
+			"key": jwtKeyResult(key),
+		}},
+		nil,
+	), true
+}
+
+func resolveGetJwtKeys(ctx context.Context, q schema.Query) *resolve.Resolved {
+	keys, err := edgraph.GetJwtKeys(ctx)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+
+	results := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, jwtKeyResult(key))
+	}
+	return resolve.DataResult(
+		q,
+		map[string]interface{}{q.Name(): results},
+		nil,
+	)
+}
+
+func jwtKeyResult(key *edgraph.JwtKey) map[string]interface{} {
+	var retireAt interface{}
+	if !key.RetireAt.IsZero() {
+		retireAt = key.RetireAt.Format(time.RFC3339)
+	}
+	return map[string]interface{}{
+		"kid":       key.Kid,
+		"alg":       key.Alg,
+		"createdAt": key.CreatedAt.Format(time.RFC3339),
+		"retireAt":  retireAt,
+	}
+}
+
+// getRotateJwtKeyInput returns the overlap of the rotation, which is the refresh JWT TTL unless it
+// is set.
+func getRotateJwtKeyInput(m schema.Mutation) (time.Duration, error) {
+	inputArg, ok := m.ArgValue(schema.InputArgName).(map[string]interface{})
+	if !ok {
+		return worker.Config.RefreshJwtTtl, nil
+	}
+	overlap, ok := inputArg["overlap"].(string)
+	if !ok || overlap == "" {
+		return worker.Config.RefreshJwtTtl, nil
+	}
+	d, err := time.ParseDuration(overlap)
+	if err != nil {
+		return 0, inputArgError(schema.GQLWrapf(err, "can't convert input.overlap to duration"))
+	}
+	return d, nil
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	"github.com/vtta/dgraph/edgraph"
+	"github.com/vtta/dgraph/graphql/resolve"
+	"github.com/vtta/dgraph/graphql/schema"
+)
+
+func resolveUpdateLambdaModule(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
+	module, err := getUpdateLambdaModuleInput(m)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	glog.Infof("Got request to update lambda module (%d bytes) through GraphQL admin API",
+		len(module))
+
+	lm, err := edgraph.UpdateLambdaModule(ctx, module)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	return resolve.DataResult(
+		m,
+		map[string]interface{}{m.Name(): map[string]interface{}{
+			"lambdaModule": lambdaModuleResult(lm),
+		}},
+		nil,
+	), true
+}
+
+func resolveGetLambdaModule(ctx context.Context, q schema.Query) *resolve.Resolved {
+	lm, err := edgraph.GetLambdaModule(ctx)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+	return resolve.DataResult(
+		q,
+		map[string]interface{}{q.Name(): lambdaModuleResult(lm)},
+		nil,
+	)
+}
+
+func lambdaModuleResult(lm *edgraph.LambdaModule) interface{} {
+	if lm == nil {
+		return nil
+	}
+	hash := sha256.Sum256(lm.Module)
+	return map[string]interface{}{
+		"size":      json.Number(strconv.Itoa(len(lm.Module))),
+		"sha256":    hex.EncodeToString(hash[:]),
+		"updatedAt": lm.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func getUpdateLambdaModuleInput(m schema.Mutation) ([]byte, error) {
+	inputArg, ok := m.ArgValue(schema.InputArgName).(map[string]interface{})
+	if !ok {
+		return nil, inputArgError(errors.Errorf("can't convert input to map"))
+	}
+	encoded, ok := inputArg["module"]
+	if !ok || encoded == nil {
+		return nil, nil
+	}
+	s, ok := encoded.(string)
+	if !ok {
+		return nil, inputArgError(errors.Errorf("can't convert input.module to string"))
+	}
+	module, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, inputArgError(schema.GQLWrapf(err, "input.module isn't base64 encoded"))
+	}
+	return module, nil
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/vtta/dgraph/edgraph"
+	"github.com/vtta/dgraph/graphql/resolve"
+	"github.com/vtta/dgraph/graphql/schema"
+)
+
+func resolveGetPermissions(ctx context.Context, q schema.Query) *resolve.Resolved {
+	inputArg, _ := q.ArgValue(schema.InputArgName).(map[string]interface{})
+	target, err := getAclTarget(inputArg)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+	var preds []string
+	if predsArg, ok := inputArg["predicates"].([]interface{}); ok {
+		for _, pred := range predsArg {
+			if p, ok := pred.(string); ok {
+				preds = append(preds, p)
+			}
+		}
+	}
+
+	perms, err := edgraph.GetUserPermissions(ctx, target, preds)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+	predicates := make([]interface{}, 0, len(perms.Predicates))
+	for _, p := range perms.Predicates {
+		predicates = append(predicates, map[string]interface{}{
+			"predicate": p.Predicate,
+			"read":      p.Read,
+			"write":     p.Write,
+			"modify":    p.Modify,
+		})
+	}
+	return resolve.DataResult(
+		q,
+		map[string]interface{}{q.Name(): map[string]interface{}{
+			"user":       perms.UserId,
+			"namespace":  json.Number(strconv.FormatUint(perms.Namespace, 10)),
+			"groups":     toInterfaceSlice(perms.Groups),
+			"guardian":   perms.Guardian,
+			"nodeFilter": nullIfEmpty(perms.NodeFilter),
+			"predicates": predicates,
+		}},
+		nil,
+	)
+}
+
+func resolveExplainQuery(ctx context.Context, q schema.Query) *resolve.Resolved {
+	inputArg, ok := q.ArgValue(schema.InputArgName).(map[string]interface{})
+	if !ok {
+		return resolve.EmptyResult(q, inputArgError(errors.Errorf("can't convert input to map")))
+	}
+	target, err := getAclTarget(inputArg)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+	query, _ := inputArg["query"].(string)
+	var vars map[string]string
+	if v, ok := inputArg["variables"].(string); ok && v != "" {
+		if err := json.Unmarshal([]byte(v), &vars); err != nil {
+			return resolve.EmptyResult(q, inputArgError(schema.GQLWrapf(err,
+				"can't convert input.variables to a map of strings")))
+		}
+	}
+
+	explanation, err := edgraph.ExplainQuery(ctx, target, query, vars)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+	stripped := make([]interface{}, 0, len(explanation.Stripped))
+	for _, s := range explanation.Stripped {
+		stripped = append(stripped, map[string]interface{}{
+			"predicate": s.Predicate,
+			"reason":    s.Reason,
+		})
+	}
+	return resolve.DataResult(
+		q,
+		map[string]interface{}{q.Name(): map[string]interface{}{
+			"user":               explanation.UserId,
+			"namespace":          json.Number(strconv.FormatUint(explanation.Namespace, 10)),
+			"groups":             toInterfaceSlice(explanation.Groups),
+			"strippedPredicates": stripped,
+			"strippedVariables":  toInterfaceSlice(explanation.StrippedVariables),
+			"nodeFilter":         nullIfEmpty(explanation.NodeFilter),
+		}},
+		nil,
+	)
+}
+
+func getAclTarget(inputArg map[string]interface{}) (*edgraph.AclTarget, error) {
+	target := &edgraph.AclTarget{}
+	target.UserId, _ = inputArg["user"].(string)
+	if nsArg, ok := inputArg["namespace"]; ok && nsArg != nil {
+		ns, err := parseAsUint64(nsArg)
+		if err != nil {
+			return nil, inputArgError(schema.GQLWrapf(err,
+				"can't convert input.namespace to uint64"))
+		}
+		target.Namespace = &ns
+	}
+	return target, nil
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
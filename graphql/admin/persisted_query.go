@@ -0,0 +1,142 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/golang/glog"
+
+	"github.com/vtta/dgraph/edgraph"
+	"github.com/vtta/dgraph/graphql/resolve"
+	"github.com/vtta/dgraph/graphql/schema"
+)
+
+type addPersistedQueryInput struct {
+	Id    string
+	Query string
+}
+
+type deletePersistedQueryInput struct {
+	Id      string
+	Version int
+}
+
+func resolveAddPersistedQuery(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
+	input, err := getAddPersistedQueryInput(m)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	glog.Infof("Got request to add persisted query %q through GraphQL admin API", input.Id)
+
+	pq, err := edgraph.RegisterPersistedQuery(ctx, input.Id, input.Query)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	return resolve.DataResult(
+		m,
+		map[string]interface{}{m.Name(): map[string]interface{}{
+			"persistedQuery": persistedQueryResult(pq),
+		}},
+		nil,
+	), true
+}
+
+func resolveDeletePersistedQuery(ctx context.Context, m schema.Mutation) (*resolve.Resolved,
+	bool) {
+	input, err := getDeletePersistedQueryInput(m)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	glog.Infof("Got request to delete persisted query %q through GraphQL admin API", input.Id)
+
+	num, err := edgraph.DeletePersistedQuery(ctx, input.Id, input.Version)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	return resolve.DataResult(
+		m,
+		map[string]interface{}{m.Name(): response("Success",
+			fmt.Sprintf("Deleted %d version(s) of persisted query %q", num, input.Id))},
+		nil,
+	), true
+}
+
+func resolveGetPersistedQueries(ctx context.Context, q schema.Query) *resolve.Resolved {
+	id, _ := q.ArgValue("id").(string)
+	pqs, err := edgraph.GetPersistedQueries(ctx, id)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+
+	results := make([]interface{}, 0, len(pqs))
+	for _, pq := range pqs {
+		results = append(results, persistedQueryResult(pq))
+	}
+	return resolve.DataResult(
+		q,
+		map[string]interface{}{q.Name(): results},
+		nil,
+	)
+}
+
+func persistedQueryResult(pq *edgraph.PersistedQuery) map[string]interface{} {
+	names := make([]string, 0, len(pq.Variables))
+	for name := range pq.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	vars := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		vars = append(vars, map[string]interface{}{"name": name, "type": pq.Variables[name]})
+	}
+
+	return map[string]interface{}{
+		"id":        pq.Id,
+		"version":   json.Number(strconv.Itoa(pq.Version)),
+		"query":     pq.Query,
+		"variables": vars,
+	}
+}
+
+func getAddPersistedQueryInput(m schema.Mutation) (*addPersistedQueryInput, error) {
+	inputArg := m.ArgValue(schema.InputArgName)
+	inputByts, err := json.Marshal(inputArg)
+	if err != nil {
+		return nil, schema.GQLWrapf(err, "couldn't get input argument")
+	}
+
+	var input addPersistedQueryInput
+	err = json.Unmarshal(inputByts, &input)
+	return &input, schema.GQLWrapf(err, "couldn't get input argument")
+}
+
+func getDeletePersistedQueryInput(m schema.Mutation) (*deletePersistedQueryInput, error) {
+	inputArg := m.ArgValue(schema.InputArgName)
+	inputByts, err := json.Marshal(inputArg)
+	if err != nil {
+		return nil, schema.GQLWrapf(err, "couldn't get input argument")
+	}
+
+	var input deletePersistedQueryInput
+	err = json.Unmarshal(inputByts, &input)
+	return &input, schema.GQLWrapf(err, "couldn't get input argument")
+}
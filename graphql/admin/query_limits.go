@@ -0,0 +1,114 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	"github.com/vtta/dgraph/edgraph"
+	"github.com/vtta/dgraph/graphql/resolve"
+	"github.com/vtta/dgraph/graphql/schema"
+)
+
+func resolveSetQueryLimits(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
+	ql, err := getSetQueryLimitsInput(m)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	glog.Infof("Got request to set query limits of group %q through GraphQL admin API", ql.Group)
+
+	if err := edgraph.SetQueryLimits(ctx, ql); err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	return resolve.DataResult(
+		m,
+		map[string]interface{}{m.Name(): map[string]interface{}{
+			"queryLimits": queryLimitsResult(ql),
+		}},
+		nil,
+	), true
+}
+
+func resolveGetQueryLimits(ctx context.Context, q schema.Query) *resolve.Resolved {
+	qls, err := edgraph.GetQueryLimits(ctx)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+
+	results := make([]interface{}, 0, len(qls))
+	for _, ql := range qls {
+		results = append(results, queryLimitsResult(ql))
+	}
+	return resolve.DataResult(
+		q,
+		map[string]interface{}{q.Name(): results},
+		nil,
+	)
+}
+
+func queryLimitsResult(ql *edgraph.QueryLimits) map[string]interface{} {
+	limit := func(v uint64) interface{} {
+		if v == 0 {
+			return nil
+		}
+		return json.Number(strconv.FormatUint(v, 10))
+	}
+	return map[string]interface{}{
+		"group":       ql.Group,
+		"uids":        limit(ql.Uids),
+		"depth":       limit(ql.Depth),
+		"resultBytes": limit(ql.ResultBytes),
+		"memoryBytes": limit(ql.MemoryBytes),
+	}
+}
+
+func getSetQueryLimitsInput(m schema.Mutation) (*edgraph.QueryLimits, error) {
+	inputArg, ok := m.ArgValue(schema.InputArgName).(map[string]interface{})
+	if !ok {
+		return nil, inputArgError(errors.Errorf("can't convert input to map"))
+	}
+
+	ql := &edgraph.QueryLimits{}
+	if group, ok := inputArg["group"]; ok && group != nil {
+		if ql.Group, ok = group.(string); !ok {
+			return nil, inputArgError(errors.Errorf("can't convert input.group to string"))
+		}
+	}
+	for name, limit := range map[string]*uint64{
+		"uids":        &ql.Uids,
+		"depth":       &ql.Depth,
+		"resultBytes": &ql.ResultBytes,
+		"memoryBytes": &ql.MemoryBytes,
+	} {
+		val, ok := inputArg[name]
+		if !ok || val == nil {
+			continue
+		}
+		v, err := parseAsUint64(val)
+		if err != nil {
+			return nil, inputArgError(schema.GQLWrapf(err, "can't convert input.%s to uint64",
+				name))
+		}
+		*limit = v
+	}
+	return ql, nil
+}
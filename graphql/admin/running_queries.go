@@ -0,0 +1,82 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	"github.com/vtta/dgraph/edgraph"
+	"github.com/vtta/dgraph/graphql/resolve"
+	"github.com/vtta/dgraph/graphql/schema"
+)
+
+func resolveListQueries(ctx context.Context, q schema.Query) *resolve.Resolved {
+	rqs, err := edgraph.ListRunningQueries(ctx)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+
+	results := make([]interface{}, 0, len(rqs))
+	for _, rq := range rqs {
+		durationMs := time.Since(rq.StartedAt).Milliseconds()
+		results = append(results, map[string]interface{}{
+			"id":         json.Number(strconv.FormatUint(rq.Id, 10)),
+			"namespace":  json.Number(strconv.FormatUint(rq.Namespace, 10)),
+			"user":       rq.User,
+			"startedAt":  rq.StartedAt.Format(time.RFC3339),
+			"durationMs": json.Number(strconv.FormatInt(durationMs, 10)),
+			"query":      rq.Query,
+			"mutation":   rq.Mutation,
+			"graphql":    rq.GraphQL,
+		})
+	}
+	return resolve.DataResult(
+		q,
+		map[string]interface{}{q.Name(): results},
+		nil,
+	)
+}
+
+func resolveKillQuery(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
+	inputArg, ok := m.ArgValue(schema.InputArgName).(map[string]interface{})
+	if !ok {
+		return resolve.EmptyResult(m, inputArgError(errors.Errorf("can't convert input to map"))),
+			false
+	}
+	id, err := parseAsUint64(inputArg["id"])
+	if err != nil {
+		return resolve.EmptyResult(m, inputArgError(schema.GQLWrapf(err,
+			"can't convert input.id to uint64"))), false
+	}
+	glog.Infof("Got request to kill query %d through GraphQL admin API", id)
+
+	if err := edgraph.KillQuery(ctx, id); err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	return resolve.DataResult(
+		m,
+		map[string]interface{}{m.Name(): response("Success", fmt.Sprintf("Killed query %d", id))},
+		nil,
+	), true
+}
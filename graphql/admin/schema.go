@@ -47,10 +47,15 @@ func (usr *updateSchemaResolver) Resolve(ctx context.Context, m schema.Mutation)
 	if err != nil {
 		return resolve.EmptyResult(m, err), false
 	}
+	return updateGQLSchema(ctx, m, input.Set.Schema)
+}
 
+// updateGQLSchema validates the given GraphQL schema and updates the cluster to serve it.
+func updateGQLSchema(ctx context.Context, m schema.Mutation, sch string) (*resolve.Resolved,
+	bool) {
 	// We just need to validate the schema. Schema is later set in `resetSchema()` when the schema
 	// is returned from badger.
-	schHandler, err := schema.NewHandler(input.Set.Schema, false)
+	schHandler, err := schema.NewHandler(sch, false)
 	if err != nil {
 		return resolve.EmptyResult(m, err), false
 	}
@@ -60,7 +65,7 @@ func (usr *updateSchemaResolver) Resolve(ctx context.Context, m schema.Mutation)
 		return resolve.EmptyResult(m, err), false
 	}
 
-	resp, err := edgraph.UpdateGQLSchema(ctx, input.Set.Schema, schHandler.DGSchema())
+	resp, err := edgraph.UpdateGQLSchema(ctx, sch, schHandler.DGSchema())
 	if err != nil {
 		return resolve.EmptyResult(m, err), false
 	}
@@ -71,7 +76,7 @@ func (usr *updateSchemaResolver) Resolve(ctx context.Context, m schema.Mutation)
 			m.Name(): map[string]interface{}{
 				"gqlSchema": map[string]interface{}{
 					"id":              query.UidToHex(resp.Uid),
-					"schema":          input.Set.Schema,
+					"schema":          sch,
 					"generatedSchema": schHandler.GQLSchema(),
 				}}},
 		nil), true
@@ -0,0 +1,180 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	"github.com/vtta/dgraph/edgraph"
+	"github.com/vtta/dgraph/graphql/resolve"
+	"github.com/vtta/dgraph/graphql/schema"
+)
+
+func resolveGetSchemaHistory(ctx context.Context, q schema.Query) *resolve.Resolved {
+	history, err := edgraph.GetGQLSchemaHistory(ctx)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+
+	results := make([]interface{}, 0, len(history))
+	for _, v := range history {
+		results = append(results, map[string]interface{}{
+			"version":   json.Number(strconv.Itoa(v.Version)),
+			"schema":    v.Schema,
+			"dqlSchema": nullableString(v.DQLSchema),
+			"createdAt": v.CreatedAt.Format(time.RFC3339),
+			"author":    nullableString(v.Author),
+		})
+	}
+	return resolve.DataResult(
+		q,
+		map[string]interface{}{q.Name(): results},
+		nil,
+	)
+}
+
+func resolveDiffSchema(ctx context.Context, q schema.Query) *resolve.Resolved {
+	gqlSchema, hasGQLSchema := q.ArgValue("schema").(string)
+	dqlSchema, hasDQLSchema := q.ArgValue("dqlSchema").(string)
+	if hasGQLSchema == hasDQLSchema {
+		return resolve.EmptyResult(q,
+			errors.New("Exactly one of schema and dqlSchema must be given to diffSchema"))
+	}
+
+	if hasGQLSchema {
+		schHandler, err := schema.NewHandler(gqlSchema, false)
+		if err != nil {
+			return resolve.EmptyResult(q, err)
+		}
+		dqlSchema = schHandler.DGSchema()
+	}
+	changes, err := edgraph.DiffSchema(ctx, dqlSchema)
+	if err != nil {
+		return resolve.EmptyResult(q, err)
+	}
+
+	results := make([]interface{}, 0, len(changes))
+	for _, c := range changes {
+		results = append(results, map[string]interface{}{
+			"kind":      string(c.Kind),
+			"severity":  c.Severity.String(),
+			"predicate": nullableString(c.Predicate),
+			"type":      nullableString(c.Type),
+			"from":      nullableString(c.From),
+			"to":        nullableString(c.To),
+		})
+	}
+	return resolve.DataResult(
+		q,
+		map[string]interface{}{q.Name(): map[string]interface{}{
+			"severity": edgraph.SchemaChangesSeverity(changes).String(),
+			"changes":  results,
+		}},
+		nil,
+	)
+}
+
+func resolveRollbackSchema(ctx context.Context, m schema.Mutation) (*resolve.Resolved, bool) {
+	version, err := getRollbackSchemaVersion(m)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	glog.Infof("Got request to roll back GraphQL schema to version %d", version)
+
+	v, err := edgraph.GetGQLSchemaVersion(ctx, version)
+	if err != nil {
+		return resolve.EmptyResult(m, err), false
+	}
+	if v.IsDQL() {
+		return resolve.EmptyResult(m, errors.Errorf(
+			"version %d records a DQL alter, only GraphQL schema versions can be rolled back to",
+			version)), false
+	}
+	if force, _ := m.ArgValue("force").(bool); !force {
+		if err := checkRollbackChanges(ctx, v.Schema); err != nil {
+			return resolve.EmptyResult(m, err), false
+		}
+	}
+	// Rolling back applies the old schema as a new update, so it is recorded as the next version.
+	return updateGQLSchema(ctx, m, v.Schema)
+}
+
+// checkRollbackChanges returns an error listing the changes that rolling back to the GraphQL
+// schema would make to the Dgraph schema, if any of them is destructive.
+func checkRollbackChanges(ctx context.Context, gqlSchema string) error {
+	if gqlSchema == "" {
+		return nil
+	}
+	schHandler, err := schema.NewHandler(gqlSchema, false)
+	if err != nil {
+		return err
+	}
+	changes, err := edgraph.DiffSchema(ctx, schHandler.DGSchema())
+	if err != nil {
+		return err
+	}
+	if edgraph.SchemaChangesSeverity(changes) != edgraph.SchemaChangeDestructive {
+		return nil
+	}
+
+	var destructive []string
+	for _, c := range changes {
+		if c.Severity == edgraph.SchemaChangeDestructive {
+			destructive = append(destructive, describeSchemaChange(c))
+		}
+	}
+	return errors.Errorf("rolling back would make destructive changes to the schema: %s. "+
+		"Set force to roll back anyway", strings.Join(destructive, "; "))
+}
+
+func describeSchemaChange(c *edgraph.SchemaChange) string {
+	parts := []string{string(c.Kind)}
+	if c.Predicate != "" {
+		parts = append(parts, "predicate "+c.Predicate)
+	}
+	if c.Type != "" {
+		parts = append(parts, "type "+c.Type)
+	}
+	if c.From != "" || c.To != "" {
+		parts = append(parts, "from "+strconv.Quote(c.From)+" to "+strconv.Quote(c.To))
+	}
+	return strings.Join(parts, " ")
+}
+
+func getRollbackSchemaVersion(m schema.Mutation) (int, error) {
+	b, err := json.Marshal(m.ArgValue("version"))
+	if err != nil {
+		return 0, schema.GQLWrapf(err, "couldn't get version argument")
+	}
+	var version int
+	err = json.Unmarshal(b, &version)
+	return version, schema.GQLWrapf(err, "couldn't get version argument")
+}
+
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
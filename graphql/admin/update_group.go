@@ -2,6 +2,7 @@ package admin
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	dgoapi "github.com/dgraph-io/dgo/v210/protos/api"
@@ -34,7 +35,7 @@ func (urw *updateGroupRewriter) RewriteQueries(
 // only for Group type. It ensures that if a rule already exists in db, it is updated;
 // otherwise, it is created. It also ensures that only the last rule out of all
 // duplicate rules in input is preserved. A rule is duplicate if it has same predicate
-// name as another rule.
+// name as another rule. Node rules are handled the same way, based on their type.
 func (urw *updateGroupRewriter) Rewrite(
 	ctx context.Context,
 	m schema.Mutation,
@@ -67,7 +68,8 @@ func (urw *updateGroupRewriter) Rewrite(
 			predicate := rule["predicate"]
 			permission := rule["permission"]
 
-			addAclRuleQuery(upsertQuery, predicate.(string), variable)
+			addAclRuleQuery(upsertQuery, "dgraph.acl.rule", "dgraph.rule.predicate",
+				predicate.(string), variable)
 
 			nonExistentJson := []byte(fmt.Sprintf(`
 			{
@@ -110,7 +112,8 @@ func (urw *updateGroupRewriter) Rewrite(
 			}
 
 			variable := urw.VarGen.Next(ruleType, "", "", false)
-			addAclRuleQuery(upsertQuery, predicate.(string), variable)
+			addAclRuleQuery(upsertQuery, "dgraph.acl.rule", "dgraph.rule.predicate",
+				predicate.(string), variable)
 
 			deleteJson := []byte(fmt.Sprintf(`[
 				{
@@ -133,6 +136,83 @@ func (urw *updateGroupRewriter) Rewrite(
 		}
 	}
 
+	nodeRuleType := m.MutatedType().Field("nodeRules").Type()
+	if setArg != nil {
+		rules, _ := setArg.(map[string]interface{})["nodeRules"].([]interface{})
+		rules, errs := removeDuplicateNodeRuleRef(rules)
+		if len(errs) != 0 {
+			errSet = schema.AppendGQLErrs(errSet,
+				schema.GQLWrapf(errs, "failed to rewrite set payload"))
+		}
+		for _, ruleI := range rules {
+			rule := ruleI.(map[string]interface{})
+			variable := urw.VarGen.Next(nodeRuleType, "", "", false)
+			typ, filter := rule["type"].(string), rule["filter"].(string)
+
+			addAclRuleQuery(upsertQuery, "dgraph.acl.node_rule", "dgraph.rule.type", typ,
+				variable)
+
+			nonExistentJson, err := json.Marshal(map[string]interface{}{
+				"uid": srcUID,
+				"dgraph.acl.node_rule": []interface{}{map[string]interface{}{
+					"uid":                "_:" + variable,
+					"dgraph.type":        nodeRuleType.DgraphName(),
+					"dgraph.rule.type":   typ,
+					"dgraph.rule.filter": filter,
+				}},
+			})
+			if err != nil {
+				return nil, err
+			}
+			existsJson, err := json.Marshal(map[string]interface{}{
+				"uid":                fmt.Sprintf("uid(%s)", variable),
+				"dgraph.rule.filter": filter,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			mutSet = append(mutSet, &dgoapi.Mutation{
+				SetJson: nonExistentJson,
+				Cond: fmt.Sprintf(`@if(gt(len(%s),0) AND eq(len(%s),0))`, resolve.MutationQueryVar,
+					variable),
+			}, &dgoapi.Mutation{
+				SetJson: existsJson,
+				Cond: fmt.Sprintf(`@if(gt(len(%s),0) AND gt(len(%s),0))`, resolve.MutationQueryVar,
+					variable),
+			})
+		}
+	}
+
+	if delArg != nil {
+		types, _ := delArg.(map[string]interface{})["nodeRules"].([]interface{})
+		for _, typ := range types {
+			if typ == "" {
+				continue
+			}
+
+			variable := urw.VarGen.Next(nodeRuleType, "", "", false)
+			addAclRuleQuery(upsertQuery, "dgraph.acl.node_rule", "dgraph.rule.type",
+				typ.(string), variable)
+
+			deleteJson := []byte(fmt.Sprintf(`[
+				{
+					"uid": "%s",
+					"dgraph.acl.node_rule": ["uid(%s)"]
+				},
+				{
+					"uid": "uid(%s)"
+				}
+			]`, srcUID, variable, variable))
+
+			mutDel = append(mutDel, &dgoapi.Mutation{
+				DeleteJson: deleteJson,
+				Cond: fmt.Sprintf(`@if(gt(len(%s),0) AND gt(len(%s),0))`, resolve.MutationQueryVar,
+					variable),
+			})
+		}
+	}
+
 	// if there is no mutation being performed as a result of some specific input,
 	// then we don't need to do the upsertQuery for group
 	if len(mutSet) == 0 && len(mutDel) == 0 {
@@ -162,11 +242,12 @@ func (urw *updateGroupRewriter) MutatedRootUIDs(
 	return ((*resolve.UpdateRewriter)(urw)).MutatedRootUIDs(mutation, assigned, result)
 }
 
-// addAclRuleQuery adds a *gql.GraphQuery to upsertQuery.Children to query a rule inside a group
-// based on its predicate value.
-func addAclRuleQuery(upsertQuery []*gql.GraphQuery, predicate, variable string) {
+// addAclRuleQuery adds a *gql.GraphQuery to upsertQuery.Children to query a rule inside a group,
+// through the rule predicate of the group, based on the value of its key predicate, e.g. the
+// predicate of a rule or the type of a node rule.
+func addAclRuleQuery(upsertQuery []*gql.GraphQuery, rulePred, keyPred, key, variable string) {
 	upsertQuery[0].Children = append(upsertQuery[0].Children, &gql.GraphQuery{
-		Attr:  "dgraph.acl.rule",
+		Attr:  rulePred,
 		Alias: variable,
 		Var:   variable,
 		Filter: &gql.FilterTree{
@@ -176,10 +257,10 @@ func addAclRuleQuery(upsertQuery []*gql.GraphQuery, predicate, variable string)
 				Name: "eq",
 				Args: []gql.Arg{
 					{
-						Value: "dgraph.rule.predicate",
+						Value: keyPred,
 					},
 					{
-						Value: predicate,
+						Value: key,
 					},
 				},
 			},
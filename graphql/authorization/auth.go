@@ -467,23 +467,11 @@ func (a *AuthMeta) FetchJWK(i int) error {
 		return err
 	}
 
-	type JwkArray struct {
-		JWKs []json.RawMessage `json:"keys"`
-	}
-
-	var jwkArray JwkArray
-	err = json.Unmarshal(data, &jwkArray)
+	jwkSet, err := ParseJWKs(data)
 	if err != nil {
 		return err
 	}
-
-	a.jwkSet[i] = &jose.JSONWebKeySet{Keys: make([]jose.JSONWebKey, len(jwkArray.JWKs))}
-	for k, jwk := range jwkArray.JWKs {
-		err = a.jwkSet[i].Keys[k].UnmarshalJSON(jwk)
-		if err != nil {
-			return err
-		}
-	}
+	a.jwkSet[i] = jwkSet
 
 	// Try to Parse the Remaining time in the expiry of signing keys
 	// from the `max-age` directive in the `Cache-Control` Header
@@ -502,6 +490,26 @@ func (a *AuthMeta) FetchJWK(i int) error {
 	return nil
 }
 
+// ParseJWKs parses the given JSON Web Key set.
+func ParseJWKs(data []byte) (*jose.JSONWebKeySet, error) {
+	type JwkArray struct {
+		JWKs []json.RawMessage `json:"keys"`
+	}
+
+	var jwkArray JwkArray
+	if err := json.Unmarshal(data, &jwkArray); err != nil {
+		return nil, err
+	}
+
+	jwkSet := &jose.JSONWebKeySet{Keys: make([]jose.JSONWebKey, len(jwkArray.JWKs))}
+	for k, jwk := range jwkArray.JWKs {
+		if err := jwkSet.Keys[k].UnmarshalJSON(jwk); err != nil {
+			return nil, err
+		}
+	}
+	return jwkSet, nil
+}
+
 func (a *AuthMeta) refreshJWK(i int) error {
 	var err error
 	for i := 0; i < 3; i++ {
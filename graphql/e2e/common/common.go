@@ -824,6 +824,7 @@ func RunAll(t *testing.T) {
 	t.Run("query aggregate on empty scalar data", queryAggregateOnEmptyData2)
 	t.Run("query aggregate with alias", queryAggregateWithAlias)
 	t.Run("query aggregate with repeated fields", queryAggregateWithRepeatedFields)
+	t.Run("query aggregate with groupBy", queryAggregateWithGroupBy)
 	t.Run("query aggregate at child level", queryAggregateAtChildLevel)
 	t.Run("query aggregate at child level with filter", queryAggregateAtChildLevelWithFilter)
 	t.Run("query aggregate at child level with empty data", queryAggregateAtChildLevelWithEmptyData)
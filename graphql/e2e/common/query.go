@@ -3297,6 +3297,48 @@ func queryAggregateWithAlias(t *testing.T) {
 		string(gqlResponse.Data))
 }
 
+func queryAggregateWithGroupBy(t *testing.T) {
+	queryPostParams := &GraphQLParams{
+		Query: `query {
+			aggregatePost(groupBy: [isPublished]) {
+				count
+				groups {
+					key {
+						isPublished
+						title
+					}
+					count
+					numLikesMax
+					avg: numLikesAvg
+				}
+			}
+		}`,
+	}
+
+	gqlResponse := queryPostParams.ExecuteAsPost(t, GraphqlURL)
+	RequireNoGQLErrors(t, gqlResponse)
+	testutil.CompareJSON(t,
+		`{
+			"aggregatePost": {
+				"count": 4,
+				"groups": [
+					{
+						"key": { "isPublished": false, "title": null },
+						"count": 1,
+						"numLikesMax": 1,
+						"avg": 1
+					}, {
+						"key": { "isPublished": true, "title": null },
+						"count": 3,
+						"numLikesMax": 100,
+						"avg": 88
+					}
+				]
+			}
+		}`,
+		string(gqlResponse.Data))
+}
+
 func queryAggregateWithRepeatedFields(t *testing.T) {
 	queryPostParams := &GraphQLParams{
 		Query: `query {
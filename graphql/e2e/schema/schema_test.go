@@ -689,6 +689,122 @@ func TestDeleteSchemaAndExport(t *testing.T) {
 	require.NotEqual(t, schemaResp.Id, newSchemaResp.Id)
 }
 
+// TestSchemaHistoryAndRollback checks that schema updates are recorded as versions, that
+// diffSchema classifies the changes of a schema, and that a version can be rolled back to.
+func TestSchemaHistoryAndRollback(t *testing.T) {
+	schemaV1 := `
+	type History {
+		id: ID!
+		name: String! @search(by: [exact])
+	}`
+	schemaV2 := `
+	type History {
+		id: ID!
+		name: String! @search(by: [exact, term])
+		age: Int
+	}`
+	common.SafelyUpdateGQLSchema(t, groupOneHTTP, schemaV1, nil)
+	common.SafelyUpdateGQLSchema(t, groupOneHTTP, schemaV2, nil)
+
+	type schemaVersion struct {
+		Version   int
+		Schema    string
+		DqlSchema string
+	}
+	getHistory := func() []schemaVersion {
+		params := &common.GraphQLParams{
+			Query: `query { getSchemaHistory { version schema dqlSchema createdAt author } }`,
+		}
+		resp := params.ExecuteAsPost(t, groupOneAdminServer)
+		common.RequireNoGQLErrors(t, resp)
+		var result struct {
+			GetSchemaHistory []schemaVersion
+		}
+		require.NoError(t, json.Unmarshal(resp.Data, &result))
+		return result.GetSchemaHistory
+	}
+	history := getHistory()
+	require.GreaterOrEqual(t, len(history), 2)
+	v1, v2 := history[len(history)-2], history[len(history)-1]
+	require.Equal(t, schemaV1, v1.Schema)
+	require.Equal(t, schemaV2, v2.Schema)
+	require.Equal(t, v1.Version+1, v2.Version)
+
+	params := &common.GraphQLParams{
+		Query: `query ($schema: String) {
+			diffSchema(schema: $schema) {
+				severity
+				changes { kind severity predicate type from to }
+			}
+		}`,
+		Variables: map[string]interface{}{"schema": schemaV1},
+	}
+	resp := params.ExecuteAsPost(t, groupOneAdminServer)
+	common.RequireNoGQLErrors(t, resp)
+	testutil.CompareJSON(t, `{
+		"diffSchema": {
+			"severity": "DESTRUCTIVE",
+			"changes": [
+				{
+					"kind": "PREDICATE_REMOVED",
+					"severity": "DESTRUCTIVE",
+					"predicate": "History.age",
+					"type": "History",
+					"from": null,
+					"to": null
+				},
+				{
+					"kind": "INDEX_REMOVED",
+					"severity": "DESTRUCTIVE",
+					"predicate": "History.name",
+					"type": null,
+					"from": "@index(term)",
+					"to": null
+				}
+			]
+		}
+	}`, string(resp.Data))
+
+	// The rollback would remove History.age and an index, so it needs force.
+	params = &common.GraphQLParams{
+		Query: `mutation ($version: Int!, $force: Boolean) {
+			rollbackSchema(version: $version, force: $force) { gqlSchema { schema } }
+		}`,
+		Variables: map[string]interface{}{"version": v1.Version},
+	}
+	resp = params.ExecuteAsPost(t, groupOneAdminServer)
+	require.Len(t, resp.Errors, 1)
+	require.Contains(t, resp.Errors[0].Message,
+		"rolling back would make destructive changes to the schema: "+
+			"PREDICATE_REMOVED predicate History.age type History")
+	require.Equal(t, v2, getHistory()[len(history)-1])
+
+	oldCounter := common.RetryProbeGraphQL(t, groupOneHTTP, nil).SchemaUpdateCounter
+	params.Variables["force"] = true
+	resp = params.ExecuteAsPost(t, groupOneAdminServer)
+	common.RequireNoGQLErrors(t, resp)
+	common.AssertSchemaUpdateCounterIncrement(t, groupOneHTTP, oldCounter, nil)
+	require.Equal(t, schemaV1, common.AssertGetGQLSchemaRequireId(t, groupOneHTTP, nil).Schema)
+
+	history = getHistory()
+	require.Equal(t, schemaVersion{Version: v2.Version + 1, Schema: schemaV1},
+		history[len(history)-1])
+
+	// DQL alters are recorded too, but can't be rolled back to.
+	dg, err := testutil.DgraphClient(groupOnegRPC)
+	require.NoError(t, err)
+	dqlSchema := "History.nickname: string @index(exact) ."
+	require.NoError(t, dg.Alter(context.Background(), &api.Operation{Schema: dqlSchema}))
+	history = getHistory()
+	require.Equal(t, schemaVersion{Version: v2.Version + 2, DqlSchema: dqlSchema},
+		history[len(history)-1])
+
+	params.Variables = map[string]interface{}{"version": v2.Version + 2, "force": true}
+	resp = params.ExecuteAsPost(t, groupOneAdminServer)
+	require.Len(t, resp.Errors, 1)
+	require.Contains(t, resp.Errors[0].Message, "records a DQL alter")
+}
+
 func updateGQLSchemaConcurrent(t *testing.T, schema, authority string) bool {
 	res := common.RetryUpdateGQLSchema(t, authority, schema, nil)
 	err := res.Errors.Error()
@@ -0,0 +1,270 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package lambda is the embedded lambda runtime of the alpha. It runs the lambda resolvers,
+// @lambdaOnMutate webhooks and lambda validators of custom scalars as WASM modules, one for each
+// namespace, instead of sending them to a lambda server.
+//
+// A lambda module is a WASI command, e.g. JavaScript compiled with Javy, or Go, Rust or
+// AssemblyScript compiled for WASI. For every request, a new instance of the module reads the
+// JSON body that a lambda server would get from its stdin, and writes the JSON response that the
+// lambda server would send to its stdout. Exiting with a non-zero code fails the request, with
+// what the module wrote to its stderr as the error. What it writes to its stderr otherwise is
+// logged.
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+
+	"github.com/vtta/dgraph/x"
+)
+
+// wasmPageSize is the size in bytes of a page of WASM memory.
+const wasmPageSize = 64 << 10
+
+var (
+	// rt is the embedded lambda runtime. It is nil when the runtime isn't enabled.
+	rt   *runtime
+	rtMu sync.RWMutex
+
+	errNoRuntime = errors.New("the embedded lambda runtime isn't enabled, " +
+		"start the alpha with --graphql lambda-embedded=true to use it")
+)
+
+// ModuleLoader returns the lambda module uploaded for the namespace ns, or nil if there is none.
+type ModuleLoader func(ns uint64) ([]byte, error)
+
+type runtime struct {
+	wazero.Runtime
+	memoryBytes int
+	timeout     time.Duration
+	load        ModuleLoader
+
+	// modules are the compiled lambda modules, by namespace. A namespace without a module is
+	// cached as nil, so that it isn't loaded again on every request.
+	modules   map[uint64]wazero.CompiledModule
+	modulesMu sync.Mutex
+}
+
+// Init starts the embedded lambda runtime, which loads the modules of the namespaces with load.
+// Every lambda call can use up to memoryMB of memory and take up to timeout.
+func Init(memoryMB int64, timeout time.Duration, load ModuleLoader) {
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		// This is what stops the lambdas that run for longer than the timeout.
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(uint32(memoryMB<<20/wasmPageSize)))
+	wasi_snapshot_preview1.MustInstantiate(ctx, r)
+
+	rtMu.Lock()
+	defer rtMu.Unlock()
+	if rt != nil {
+		x.Check(rt.Close(ctx))
+	}
+	rt = &runtime{
+		Runtime:     r,
+		memoryBytes: int(memoryMB << 20),
+		timeout:     timeout,
+		load:        load,
+		modules:     make(map[uint64]wazero.CompiledModule),
+	}
+}
+
+// Close stops the embedded lambda runtime, if it was started.
+func Close() {
+	rtMu.Lock()
+	defer rtMu.Unlock()
+	if rt != nil {
+		x.Check(rt.Close(context.Background()))
+		rt = nil
+	}
+}
+
+func getRuntime() (*runtime, error) {
+	rtMu.RLock()
+	defer rtMu.RUnlock()
+	if rt == nil {
+		return nil, errNoRuntime
+	}
+	return rt, nil
+}
+
+// Validate returns an error if module isn't a lambda module that the embedded runtime can run.
+func Validate(module []byte) error {
+	r, err := getRuntime()
+	if err != nil {
+		return err
+	}
+	compiled, err := r.compile(module)
+	if err != nil {
+		return err
+	}
+	return compiled.Close(context.Background())
+}
+
+// ResetModules drops the compiled lambda modules, so that they are loaded again from their
+// namespaces. It must be called whenever a lambda module is updated.
+func ResetModules() {
+	r, err := getRuntime()
+	if err != nil {
+		return
+	}
+	r.modulesMu.Lock()
+	defer r.modulesMu.Unlock()
+	for ns, compiled := range r.modules {
+		if compiled != nil {
+			// The instances that are still running keep working after this.
+			x.Check(compiled.Close(context.Background()))
+		}
+		delete(r.modules, ns)
+	}
+}
+
+// Serve handles a request that would be sent to a lambda server, by running the lambda module of
+// the namespace of ctx with the body of the request. The namespace is only ever taken from ctx, so
+// that the requests made on behalf of a namespace can't run the module of another one.
+func Serve(ctx context.Context, body []byte) (*http.Response, error) {
+	ns, err := x.ExtractNamespace(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while running the lambda module")
+	}
+
+	out, err := Execute(ctx, ns, body)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(out)),
+		ContentLength: int64(len(out)),
+	}, nil
+}
+
+// Execute runs the lambda module of the namespace ns with the given input, and returns its
+// output.
+func Execute(ctx context.Context, ns uint64, input []byte) ([]byte, error) {
+	r, err := getRuntime()
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := r.module(ns)
+	if err != nil {
+		return nil, err
+	}
+	if compiled == nil {
+		return nil, errors.Errorf("no lambda module has been uploaded for namespace %d", ns)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	// As the memory of the module is limited, so is what it can write in one go. But it could
+	// keep writing in a loop, so the output is limited too.
+	stdout := &limitedBuffer{limit: r.memoryBytes}
+	stderr := &limitedBuffer{limit: r.memoryBytes}
+	mod, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().
+		// Instances without a name can run at the same time.
+		WithName("").
+		WithStdin(bytes.NewReader(input)).
+		WithStdout(stdout).
+		WithStderr(stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader))
+	if mod != nil {
+		_ = mod.Close(context.Background())
+	}
+	if stderr.Len() > 0 && err == nil {
+		glog.V(2).Infof("Lambda of namespace %d: %s", ns, stderr.String())
+	}
+
+	var exitErr *sys.ExitError
+	switch {
+	case err == nil:
+		return stdout.Bytes(), nil
+	case ctx.Err() == context.DeadlineExceeded:
+		return nil, errors.Errorf("lambda of namespace %d didn't finish within %s", ns, r.timeout)
+	case errors.As(err, &exitErr) && stderr.Len() > 0:
+		return nil, errors.Errorf("lambda of namespace %d exited with code %d: %s", ns,
+			exitErr.ExitCode(), strings.TrimSpace(stderr.String()))
+	default:
+		return nil, errors.Wrapf(err, "while running lambda of namespace %d", ns)
+	}
+}
+
+// module returns the compiled lambda module of the namespace ns, or nil if it has none.
+func (r *runtime) module(ns uint64) (wazero.CompiledModule, error) {
+	r.modulesMu.Lock()
+	defer r.modulesMu.Unlock()
+	if compiled, ok := r.modules[ns]; ok {
+		return compiled, nil
+	}
+
+	module, err := r.load(ns)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while loading lambda module of namespace %d", ns)
+	}
+	var compiled wazero.CompiledModule
+	if len(module) > 0 {
+		if compiled, err = r.compile(module); err != nil {
+			return nil, errors.Wrapf(err, "namespace %d", ns)
+		}
+	}
+	r.modules[ns] = compiled
+	return compiled, nil
+}
+
+func (r *runtime) compile(module []byte) (wazero.CompiledModule, error) {
+	compiled, err := r.CompileModule(context.Background(), module)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid lambda module")
+	}
+	if _, ok := compiled.ExportedFunctions()["_start"]; !ok {
+		_ = compiled.Close(context.Background())
+		return nil, errors.New("invalid lambda module: it must be a WASI command exporting _start")
+	}
+	return compiled, nil
+}
+
+// limitedBuffer is a bytes.Buffer that fails the writes that would make it grow beyond limit.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errors.Errorf("lambda output is larger than %d bytes", b.limit)
+	}
+	return b.Buffer.Write(p)
+}
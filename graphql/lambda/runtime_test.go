@@ -0,0 +1,152 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/x"
+)
+
+// The WASM modules in testdata are built from the .wat files next to them.
+func readModule(t *testing.T, name string) []byte {
+	module, err := ioutil.ReadFile(filepath.Join("testdata", name+".wasm"))
+	require.NoError(t, err)
+	return module
+}
+
+// initWithModules starts the runtime with the given modules by namespace, and returns the number
+// of times that the module of each namespace was loaded.
+func initWithModules(t *testing.T, timeout time.Duration, modules map[uint64]string) map[uint64]int {
+	var mu sync.Mutex
+	loads := make(map[uint64]int)
+	Init(16, timeout, func(ns uint64) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		loads[ns]++
+		if name, ok := modules[ns]; ok {
+			return readModule(t, name), nil
+		}
+		return nil, nil
+	})
+	return loads
+}
+
+func TestExecute(t *testing.T) {
+	initWithModules(t, 500*time.Millisecond, map[uint64]string{
+		1: "echo",
+		2: "fail",
+		3: "loop",
+		4: "grow",
+	})
+	defer Close()
+
+	tests := []struct {
+		name string
+		ns   uint64
+		err  string
+	}{
+		{name: "output of the lambda is returned", ns: 1},
+		{name: "namespace without a module", ns: 5,
+			err: "no lambda module has been uploaded for namespace 5"},
+		{name: "stderr is the error when the lambda fails", ns: 2,
+			err: "lambda of namespace 2 exited with code 1: boom"},
+		{name: "lambda is stopped after the timeout", ns: 3,
+			err: "lambda of namespace 3 didn't finish within 500ms"},
+		{name: "lambda can't grow its memory beyond the limit", ns: 4,
+			err: "while running lambda of namespace 4"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			input := []byte(`{"resolver": "Query.echo", "args": {"text": "hello"}}`)
+			out, err := Execute(context.Background(), tc.ns, input)
+			if tc.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, input, out)
+		})
+	}
+}
+
+func TestExecuteConcurrently(t *testing.T) {
+	loads := initWithModules(t, time.Second, map[uint64]string{1: "echo"})
+	defer Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			input := bytes.Repeat([]byte{'a' + byte(i)}, 100<<10)
+			out, err := Execute(context.Background(), 1, input)
+			require.NoError(t, err)
+			require.Equal(t, input, out)
+		}(i)
+	}
+	wg.Wait()
+	// The module is compiled once, and then reused until it is reset.
+	require.Equal(t, 1, loads[1])
+
+	ResetModules()
+	_, err := Execute(context.Background(), 1, []byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, 2, loads[1])
+}
+
+func TestServe(t *testing.T) {
+	initWithModules(t, time.Second, map[uint64]string{1: "echo"})
+	defer Close()
+
+	// The module that is run is the one of the namespace of the request.
+	ctx := x.AttachNamespace(context.Background(), 1)
+	resp, err := Serve(ctx, []byte(`{"resolver": "$webhook"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, `{"resolver": "$webhook"}`, string(body))
+
+	_, err = Serve(x.AttachNamespace(context.Background(), 0), []byte(`{}`))
+	require.Error(t, err)
+	_, err = Serve(context.Background(), []byte(`{}`))
+	require.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	require.Equal(t, errNoRuntime, Validate(readModule(t, "echo")))
+
+	Init(16, time.Second, nil)
+	defer Close()
+	require.NoError(t, Validate(readModule(t, "echo")))
+	require.EqualError(t, Validate(readModule(t, "reactor")),
+		"invalid lambda module: it must be a WASI command exporting _start")
+	err := Validate([]byte("function handle() {}"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid lambda module")
+}
@@ -0,0 +1,7 @@
+;; grow is a lambda module that needs 64MB of memory, and traps if it can't get it.
+(module
+  (memory (export "memory") 1)
+  (func (export "_start")
+    (block $grown
+      (br_if $grown (i32.ne (memory.grow (i32.const 1024)) (i32.const -1)))
+      (unreachable))))
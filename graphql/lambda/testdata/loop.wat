@@ -0,0 +1,6 @@
+;; loop is a lambda module that never finishes.
+(module
+  (memory (export "memory") 1)
+  (func (export "_start")
+    (loop $forever
+      (br $forever))))
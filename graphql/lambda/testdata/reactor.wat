@@ -0,0 +1,4 @@
+;; reactor is a module without a _start function, which can't be a lambda module.
+(module
+  (memory (export "memory") 1)
+  (func (export "handle")))
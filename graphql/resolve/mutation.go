@@ -267,102 +267,34 @@ func (mr *dgraphResolver) rewriteAndExecute(
 	// are then executed and the results processed and returned.
 	var upserts []*UpsertMutation
 	var err error
-	// queries stores rewritten []*gql.GraphQuery by RewriteQueries function. These queries
-	// are then executed and the results are processed
-	var queries []*gql.GraphQuery
-	var filterTypes []string
-	queries, filterTypes, err = mr.mutationRewriter.RewriteQueries(ctx, mutation)
+	var qNameToUID map[string]string
+	mutResp, qNameToUID, err = mr.existenceQueries(ctx, mutation, req,
+		dgraphPreMutationQueryDuration, ext)
 	if err != nil {
-		return emptyResult(schema.GQLWrapf(err, "couldn't rewrite mutation %s", mutation.Name())),
-			resolverFailed
+		return emptyResult(err), resolverFailed
 	}
-	// Execute queries and parse its result into a map
-	qry := dgraph.AsString(queries)
-	req.Query = qry
 
-	// The query will be empty in case there is no reference XID / UID in the mutation.
-	// Don't execute the query in those cases.
-	// The query will also be empty in case this is not an Add or an Update Mutation.
-	if req.Query != "" {
-		// Executing and processing existence queries
-		queryTimer := newtimer(ctx, &dgraphPreMutationQueryDuration.OffsetDuration)
-		queryTimer.Start()
-		mutResp, err = mr.executor.Execute(ctx, req, nil)
-		queryTimer.Stop()
+	// The before mutate hook is called in the transaction of the mutation, once the existence
+	// queries have read what the mutation relies on, and the transaction is only committed once
+	// the hook lets the mutation go through. If the hook replaces the arguments of the mutation,
+	// the existence queries are run again, in the same transaction, for the new arguments.
+	if mutation.HasLambdaBeforeMutate() {
+		replaced, err := callBeforeMutateHook(ctx, mutation, req.StartTs)
 		if err != nil {
 			gqlErr := schema.GQLWrapLocationf(
 				err, mutation.Location(), "mutation %s failed", mutation.Name())
 			return emptyResult(gqlErr), resolverFailed
 		}
-		ext.TouchedUids += mutResp.GetMetrics().GetNumUids()[touchedUidsKey]
-	}
-
-	// Parse the result of query.
-	// mutResp.Json will contain response to the query.
-	// The response is parsed to existenceQueriesResult
-	// dgraph.type is a list that contains types and interfaces the type implements.
-	// Example Response:
-	// {
-	// 	Project_1 :
-	//		[
-	//			{
-	//				"uid" : "0x123",
-	// 				"dgraph.type" : ["Project", "Work"]
-	// 			}
-	//		],
-	//	Column_2 :
-	//		[
-	//			{
-	//				"uid": "0x234",
-	// 				"dgraph.type" : ["Column"]
-	// 			}
-	//		]
-	// }
-	type res struct {
-		Uid   string   `json:"uid"`
-		Types []string `json:"dgraph.type"`
-	}
-	queryResultMap := make(map[string][]res)
-	if mutResp != nil {
-		err = json.Unmarshal(mutResp.Json, &queryResultMap)
-	}
-	if err != nil {
-		gqlErr := schema.GQLWrapLocationf(
-			err, mutation.Location(), "mutation %s failed", mutation.Name())
-		return emptyResult(gqlErr), resolverFailed
-	}
-
-	x.AssertTrue(len(filterTypes) == len(queries))
-	// qNameToType map contains the mapping from the query name to type/interface the query response
-	// has to be filtered upon.
-	qNameToType := make(map[string]string)
-	for i, typ := range filterTypes {
-		qNameToType[queries[i].Attr] = typ
-	}
-	// The above response is parsed into map[string]string as follows:
-	// {
-	// 		"Project_1" : "0x123",
-	// 		"Column_2" : "0x234"
-	// }
-	// As only Add and Update mutations generate queries using RewriteQueries,
-	// qNameToUID map will be non-empty only in case of Add or Update Mutation.
-	qNameToUID := make(map[string]string)
-	for key, result := range queryResultMap {
-		count := 0
-		typ := qNameToType[key]
-		for _, res := range result {
-			if x.HasString(res.Types, typ) {
-				qNameToUID[key] = res.Uid
-				count++
+		if replaced {
+			var resp *dgoapi.Response
+			resp, qNameToUID, err = mr.existenceQueries(ctx, mutation, req,
+				dgraphPreMutationQueryDuration, ext)
+			if resp != nil {
+				mutResp = resp
+			}
+			if err != nil {
+				return emptyResult(err), resolverFailed
 			}
-		}
-		if count > 1 {
-			// Found multiple UIDs for query. This should ideally not happen.
-			// This indicates that there are multiple nodes with same XIDs / UIDs. Throw an error.
-			err = errors.New(fmt.Sprintf("Found multiple nodes with ID: %s", qNameToUID[key]))
-			gqlErr := schema.GQLWrapLocationf(
-				err, mutation.Location(), "mutation %s failed", mutation.Name())
-			return emptyResult(gqlErr), resolverFailed
 		}
 	}
 
@@ -375,7 +307,7 @@ func (mr *dgraphResolver) rewriteAndExecute(
 	}
 	if len(upserts) == 0 {
 		return &Resolved{
-			Data:       completeMutationResult(mutation, nil, 0),
+			Data:       completeMutationResult(mutation, nil, 0, nil),
 			Field:      mutation,
 			Err:        nil,
 			Extensions: ext,
@@ -407,6 +339,23 @@ func (mr *dgraphResolver) rewriteAndExecute(
 		}
 	}
 
+	// For update mutation, if the diff is requested, the values of the nodes from before the
+	// update have to be queried before the mutation.
+	var diffBefore map[string][]json.RawMessage
+	diffField := mutation.DiffField()
+	if mutation.MutationType() == schema.UpdateMutation && diffField != nil {
+		queryTimer := newtimer(ctx, &dgraphPreMutationQueryDuration.OffsetDuration)
+		queryTimer.Start()
+		diffBefore, err = mr.queryDiff(ctx, mutation, diffField, schema.DiffBefore, nil, ext)
+		queryTimer.Stop()
+
+		if err != nil && !x.IsGqlErrorList(err) {
+			return emptyResult(schema.GQLWrapf(err, "couldn't execute query for mutation %s",
+				mutation.Name())), resolverFailed
+		}
+		queryErrs = schema.AppendGQLErrs(queryErrs, err)
+	}
+
 	result := make(map[string]interface{})
 	newNodes := make(map[string]schema.Type)
 
@@ -478,10 +427,28 @@ func (mr *dgraphResolver) rewriteAndExecute(
 		queryErrs = schema.AppendGQLErrs(queryErrs, err)
 		ext.TouchedUids += qryResp.GetMetrics().GetNumUids()[touchedUidsKey]
 	}
+
+	var diffAfter map[string][]json.RawMessage
+	if mutation.MutationType() == schema.UpdateMutation && diffField != nil {
+		uids, err := convertIDsWithErr(
+			mr.mutationRewriter.MutatedRootUIDs(mutation, mutResp.GetUids(), result))
+		queryErrs = schema.AppendGQLErrs(queryErrs, err)
+
+		queryTimer := newtimer(ctx, &dgraphPostMutationQueryDuration.OffsetDuration)
+		queryTimer.Start()
+		diffAfter, err = mr.queryDiff(ctx, mutation, diffField, schema.DiffAfter, uids, ext)
+		queryTimer.Stop()
+
+		if !x.IsGqlErrorList(err) {
+			err = schema.GQLWrapf(err, "couldn't execute query for mutation %s", mutation.Name())
+		}
+		queryErrs = schema.AppendGQLErrs(queryErrs, err)
+	}
 	numUids := getNumUids(mutation, mutResp.Uids, result)
 
 	return &Resolved{
-		Data:  completeMutationResult(mutation, qryResp.GetJson(), numUids),
+		Data: completeMutationResult(mutation, qryResp.GetJson(), numUids,
+			completeDiff(diffField, diffBefore, diffAfter)),
 		Field: mutation,
 		// the error path only contains the query field, so we prepend the mutation response name
 		Err:        schema.PrependPath(queryErrs, mutation.ResponseName()),
@@ -489,13 +456,244 @@ func (mr *dgraphResolver) rewriteAndExecute(
 	}, resolverSucceeded
 }
 
+// existenceQueries runs the queries that find the nodes referred to by the mutation, in the
+// transaction of req, and returns the response along with the uids of the nodes, keyed by the
+// names of the queries.
+func (mr *dgraphResolver) existenceQueries(
+	ctx context.Context,
+	mutation schema.Mutation,
+	req *dgoapi.Request,
+	duration *schema.LabeledOffsetDuration,
+	ext *schema.Extensions) (*dgoapi.Response, map[string]string, error) {
+	// queries stores rewritten []*gql.GraphQuery by RewriteQueries function. These queries
+	// are then executed and the results are processed
+	queries, filterTypes, err := mr.mutationRewriter.RewriteQueries(ctx, mutation)
+	if err != nil {
+		return nil, nil, schema.GQLWrapf(err, "couldn't rewrite mutation %s", mutation.Name())
+	}
+	// Execute queries and parse its result into a map
+	qry := dgraph.AsString(queries)
+	req.Query = qry
+
+	// The query will be empty in case there is no reference XID / UID in the mutation.
+	// Don't execute the query in those cases.
+	// The query will also be empty in case this is not an Add or an Update Mutation.
+	var resp *dgoapi.Response
+	if req.Query != "" {
+		// Executing and processing existence queries
+		queryTimer := newtimer(ctx, &duration.OffsetDuration)
+		queryTimer.Start()
+		resp, err = mr.executor.Execute(ctx, req, nil)
+		queryTimer.Stop()
+		if err != nil {
+			return resp, nil, schema.GQLWrapLocationf(
+				err, mutation.Location(), "mutation %s failed", mutation.Name())
+		}
+		ext.TouchedUids += resp.GetMetrics().GetNumUids()[touchedUidsKey]
+	}
+
+	// Parse the result of query.
+	// resp.Json will contain response to the query.
+	// The response is parsed to existenceQueriesResult
+	// dgraph.type is a list that contains types and interfaces the type implements.
+	// Example Response:
+	// {
+	// 	Project_1 :
+	//		[
+	//			{
+	//				"uid" : "0x123",
+	// 				"dgraph.type" : ["Project", "Work"]
+	// 			}
+	//		],
+	//	Column_2 :
+	//		[
+	//			{
+	//				"uid": "0x234",
+	// 				"dgraph.type" : ["Column"]
+	// 			}
+	//		]
+	// }
+	type res struct {
+		Uid   string   `json:"uid"`
+		Types []string `json:"dgraph.type"`
+	}
+	queryResultMap := make(map[string][]res)
+	if resp != nil {
+		err = json.Unmarshal(resp.Json, &queryResultMap)
+	}
+	if err != nil {
+		return resp, nil, schema.GQLWrapLocationf(
+			err, mutation.Location(), "mutation %s failed", mutation.Name())
+	}
+
+	x.AssertTrue(len(filterTypes) == len(queries))
+	// qNameToType map contains the mapping from the query name to type/interface the query response
+	// has to be filtered upon.
+	qNameToType := make(map[string]string)
+	for i, typ := range filterTypes {
+		qNameToType[queries[i].Attr] = typ
+	}
+	// The above response is parsed into map[string]string as follows:
+	// {
+	// 		"Project_1" : "0x123",
+	// 		"Column_2" : "0x234"
+	// }
+	// As only Add and Update mutations generate queries using RewriteQueries,
+	// qNameToUID map will be non-empty only in case of Add or Update Mutation.
+	qNameToUID := make(map[string]string)
+	for key, result := range queryResultMap {
+		count := 0
+		typ := qNameToType[key]
+		for _, res := range result {
+			if x.HasString(res.Types, typ) {
+				qNameToUID[key] = res.Uid
+				count++
+			}
+		}
+		if count > 1 {
+			// Found multiple UIDs for query. This should ideally not happen.
+			// This indicates that there are multiple nodes with same XIDs / UIDs. Throw an error.
+			err = errors.New(fmt.Sprintf("Found multiple nodes with ID: %s", qNameToUID[key]))
+			return resp, nil, schema.GQLWrapLocationf(
+				err, mutation.Location(), "mutation %s failed", mutation.Name())
+		}
+	}
+
+	return resp, qNameToUID, nil
+}
+
+// diffNodes is the before or after field of the diff in an update mutation's payload, seen as a
+// query for all the nodes that the mutation updates.
+type diffNodes struct {
+	schema.Field
+}
+
+func (d *diffNodes) Type() schema.Type {
+	return &nodeList{d.Field.Type()}
+}
+
+// nodeList is the list type of the nodes of a diffNodes.
+type nodeList struct {
+	schema.Type
+}
+
+func (l *nodeList) ListType() schema.Type {
+	return l.Type
+}
+
+// queryDiff queries the values of the nodes updated by mutation, for the fields of the diff that
+// are named name, ie. before or after.  The uids of the updated nodes are only needed for after.
+// The result maps the response name of each such field to the list of values of the nodes,
+// ordered by their uids.
+func (mr *dgraphResolver) queryDiff(
+	ctx context.Context,
+	mutation schema.Mutation,
+	diff schema.Field,
+	name string,
+	uids []uint64,
+	ext *schema.Extensions) (map[string][]json.RawMessage, error) {
+
+	var errs error
+	results := make(map[string][]json.RawMessage)
+	for _, f := range diff.SelectionSet() {
+		if f.Name() != name {
+			continue
+		}
+
+		var dgQuery []*gql.GraphQuery
+		var err error
+		if name == schema.DiffBefore {
+			dgQuery, err = rewriteDiffBefore(ctx, mutation, f)
+		} else {
+			dgQuery, err = rewriteDiffAfter(ctx, mutation, f, uids)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := mr.executor.Execute(ctx, &dgoapi.Request{Query: dgraph.AsString(dgQuery),
+			ReadOnly: true}, &diffNodes{f})
+		if err != nil && !x.IsGqlErrorList(err) {
+			return nil, err
+		}
+		errs = schema.AppendGQLErrs(errs, err)
+		ext.TouchedUids += resp.GetMetrics().GetNumUids()[touchedUidsKey]
+
+		// The response looks like {"before":[...]}, or {"before":null} if no nodes were found.
+		values := make(map[string][]json.RawMessage)
+		if len(resp.GetJson()) != 0 {
+			if err := json.Unmarshal(resp.GetJson(), &values); err != nil {
+				return nil, err
+			}
+		}
+		results[f.ResponseName()] = values[f.ResponseName()]
+	}
+	return results, errs
+}
+
+// completeDiff builds the JSON for the diff field in an update mutation's payload, from the
+// values that queryDiff found before and after the mutation.  The nodes are matched up by their
+// position, as both lists are ordered by uid.  The output looks like:
+//  [{"before":{"name":"A"},"after":{"name":"B"}},...]
+// completeDiff returns nil if the diff wasn't requested.
+func completeDiff(diff schema.Field, before, after map[string][]json.RawMessage) []byte {
+	if diff == nil {
+		return nil
+	}
+
+	n := 0
+	for _, values := range before {
+		if len(values) > n {
+			n = len(values)
+		}
+	}
+	for _, values := range after {
+		if len(values) > n {
+			n = len(values)
+		}
+	}
+
+	var buf bytes.Buffer
+	x.Check2(buf.WriteRune('['))
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			x.Check2(buf.WriteRune(','))
+		}
+		x.Check2(buf.WriteRune('{'))
+		comma := ""
+		for _, f := range diff.SelectionSet() {
+			x.Check2(buf.WriteString(comma))
+			f.CompleteAlias(&buf)
+
+			values := before[f.ResponseName()]
+			if f.Name() == schema.DiffAfter {
+				values = after[f.ResponseName()]
+			}
+			switch {
+			case f.Name() == schema.Typename:
+				x.Check2(buf.WriteString(`"` + f.TypeName(nil) + `"`))
+			case i < len(values):
+				x.Check2(buf.Write(values[i]))
+			default:
+				x.Check2(buf.Write(schema.JsonNull))
+			}
+			comma = ","
+		}
+		x.Check2(buf.WriteRune('}'))
+	}
+	x.Check2(buf.WriteRune(']'))
+	return buf.Bytes()
+}
+
 // completeMutationResult takes in the result returned for the query field of mutation and builds
 // the JSON required for data field in GraphQL response.
 // The input qryResult can either be nil or of the form:
 //  {"qryFieldAlias":...}
 // and the output will look like:
 //  {"addAuthor":{"qryFieldAlias":...,"numUids":2,"msg":"Deleted"}}
-func completeMutationResult(mutation schema.Mutation, qryResult []byte, numUids int) []byte {
+// diffResult is the JSON for the diff field of update mutations, as built by completeDiff.
+func completeMutationResult(mutation schema.Mutation, qryResult []byte, numUids int,
+	diffResult []byte) []byte {
 	comma := ""
 	var buf bytes.Buffer
 	x.Check2(buf.WriteRune('{'))
@@ -506,6 +704,7 @@ func completeMutationResult(mutation schema.Mutation, qryResult []byte, numUids
 	//  * queryField
 	//  * numUids
 	//  * msg (only for DeleteMutationPayload)
+	//  * diff (only for UpdateMutationPayload)
 	// And __typename can be present anywhere. So, build data accordingly.
 	// Note that all these fields are nullable, so no need to raise non-null errors.
 	for _, f := range mutation.SelectionSet() {
@@ -527,6 +726,12 @@ func completeMutationResult(mutation schema.Mutation, qryResult []byte, numUids
 			// mutation which mutates more than 2 billion uids doesn't seem a practical case.
 			// So, we are skipping coercion here.
 			x.Check2(buf.WriteString(strconv.Itoa(numUids)))
+		case schema.Diff:
+			if len(diffResult) == 0 {
+				x.Check2(buf.Write(schema.JsonEmptyList))
+			} else {
+				x.Check2(buf.Write(diffResult))
+			}
 		default: // this has to be queryField
 			if len(qryResult) == 0 {
 				// don't write null, instead write [] as query field is always a nullable list
@@ -568,10 +773,12 @@ func authorizeNewNodes(
 		return schema.GQLWrapf(err, "authorization failed")
 	}
 	authVariables := customClaims.AuthVariables
+	// The new nodes must also satisfy the add rules of the fields written to them.
+	addRules, _ := mutationFieldRules(m)
 	newRw := &authRewriter{
 		authVariables: authVariables,
 		varGen:        NewVariableGenerator(),
-		selector:      addAuthSelector,
+		selector:      addRules.selector(addAuthSelector),
 		hasAuthRules:  true,
 	}
 
@@ -151,7 +151,12 @@ func (v *VariableGenerator) Next(typ schema.Type, xidName, xidVal string, auth b
 		// ABC.ab.cd and ABC.abc.d
 		// It also ensures that xids from different types gets different variable names
 		// here we are using the assertion that field name or type name can't have "." in them
-		key = typ.FieldOriginatedFrom(xidName) + "." + xidName + "." + xidVal
+		origin := typ.FieldOriginatedFrom(xidName)
+		if origin == "" {
+			// composite ids like (course, student) aren't fields, they belong to typ itself
+			origin = typ.Name()
+		}
+		key = origin + "." + xidName + "." + xidVal
 	}
 
 	if varName, ok := v.xidVarNameMap[key]; ok {
@@ -206,8 +211,9 @@ func NewXidMetadata() *xidMetadata {
 //		invField is not of List type, OR
 //		b. newXidObj has some values other than xid and isn't equal to existingXidObject
 // It is used in places where we don't want to allow duplicates.
+// keyLen is the number of fields making up the xid, which is more than one for composite ids.
 func (xidMetadata *xidMetadata) isDuplicateXid(atTopLevel bool, xidVar string,
-	newXidObj map[string]interface{}, srcField schema.FieldDefinition) bool {
+	newXidObj map[string]interface{}, srcField schema.FieldDefinition, keyLen int) bool {
 	if atTopLevel && xidMetadata.seenAtTopLevel[xidVar] {
 		return true
 	}
@@ -223,7 +229,7 @@ func (xidMetadata *xidMetadata) isDuplicateXid(atTopLevel bool, xidVar string,
 	// and are not equal.
 	// XID should be defined with all its values at one of the places and references with its
 	// XID from other places.
-	if len(newXidObj) > 1 && len(xidMetadata.variableObjMap[xidVar]) > 1 &&
+	if len(newXidObj) > keyLen && len(xidMetadata.variableObjMap[xidVar]) > keyLen &&
 		!reflect.DeepEqual(xidMetadata.variableObjMap[xidVar], newXidObj) {
 		return true
 	}
@@ -272,6 +278,17 @@ func (arw *AddRewriter) RewriteQueries(
 	mutatedType := m.MutatedType()
 	val, _ := m.ArgValue(schema.InputArgName).([]interface{})
 
+	// Values given in variables haven't been checked against @constraint while validating the
+	// operation, so check the whole input before doing anything with it.
+	var constraintErrs x.GqlErrorList
+	for i, obj := range val {
+		constraintErrs = append(constraintErrs, constraintErrors(mutatedType, obj,
+			[]interface{}{m.ResponseName(), schema.InputArgName, i})...)
+	}
+	if len(constraintErrs) > 0 {
+		return nil, nil, constraintErrs
+	}
+
 	var ret []*gql.GraphQuery
 	var retTypes []string
 	var retErrors error
@@ -324,6 +341,13 @@ func (urw *UpdateRewriter) RewriteQueries(
 	setArg := inp["set"]
 	delArg := inp["remove"]
 
+	// see also comment in AddRewriter.RewriteQueries
+	constraintErrs := constraintErrors(mutatedType, setArg,
+		[]interface{}{m.ResponseName(), schema.InputArgName, "set"})
+	if len(constraintErrs) > 0 {
+		return nil, nil, constraintErrs
+	}
+
 	var ret []*gql.GraphQuery
 	var retTypes []string
 	var retErrors error
@@ -421,6 +445,10 @@ func (arw *AddRewriter) Rewrite(
 	m schema.Mutation,
 	idExistence map[string]string) ([]*UpsertMutation, error) {
 
+	if err := checkFieldAuth(ctx, m); err != nil {
+		return nil, err
+	}
+
 	mutationType := Add
 	mutatedType := m.MutatedType()
 	val, _ := m.ArgValue(schema.InputArgName).([]interface{})
@@ -495,10 +523,11 @@ func (arw *AddRewriter) Rewrite(
 				return ret, err
 			}
 
+			_, updateRules := mutationFieldRules(m)
 			authRw := &authRewriter{
 				authVariables: customClaims.AuthVariables,
 				varGen:        varGen,
-				selector:      updateAuthSelector,
+				selector:      updateRules.selector(updateAuthSelector),
 				parentVarName: m.MutatedType().Name() + "Root",
 			}
 			authRw.hasAuthRules = hasAuthRules(m.QueryField(), authRw)
@@ -577,6 +606,10 @@ func (urw *UpdateRewriter) Rewrite(
 	ctx context.Context,
 	m schema.Mutation,
 	idExistence map[string]string) ([]*UpsertMutation, error) {
+	if err := checkFieldAuth(ctx, m); err != nil {
+		return nil, err
+	}
+
 	mutatedType := m.MutatedType()
 
 	varGen := urw.VarGen
@@ -615,10 +648,11 @@ func (urw *UpdateRewriter) Rewrite(
 		return ret, err
 	}
 
+	_, updateRules := mutationFieldRules(m)
 	authRw := &authRewriter{
 		authVariables: customClaims.AuthVariables,
 		varGen:        varGen,
-		selector:      updateAuthSelector,
+		selector:      updateRules.selector(updateAuthSelector),
 		parentVarName: m.MutatedType().Name() + "Root",
 	}
 	authRw.hasAuthRules = hasAuthRules(m.QueryField(), authRw)
@@ -790,7 +824,8 @@ func (arw *AddRewriter) FromMutationResult(
 		selector:      queryAuthSelector,
 		parentVarName: mutation.MutatedType().Name() + "Root",
 	}
-	authRw.hasAuthRules = hasAuthRules(mutation.QueryField(), authRw)
+	authRw.hasAuthRules = hasAuthRules(mutation.QueryField(), authRw) ||
+		hasFieldAuthRules(mutation.QueryField(), customClaims.AuthVariables)
 
 	if errs != nil {
 		return nil, errs
@@ -831,10 +866,75 @@ func (urw *UpdateRewriter) FromMutationResult(
 		selector:      queryAuthSelector,
 		parentVarName: mutation.MutatedType().Name() + "Root",
 	}
-	authRw.hasAuthRules = hasAuthRules(mutation.QueryField(), authRw)
+	authRw.hasAuthRules = hasAuthRules(mutation.QueryField(), authRw) ||
+		hasFieldAuthRules(mutation.QueryField(), customClaims.AuthVariables)
 	return rewriteAsQueryByIds(mutation.QueryField(), uids, authRw), nil
 }
 
+// rewriteDiffBefore rewrites the before field of the diff in an update mutation's payload into
+// a Dgraph query for the nodes the mutation is going to update.  It has to be run before the
+// mutation, so that it finds the values of the nodes from before they are updated.
+func rewriteDiffBefore(
+	ctx context.Context,
+	mutation schema.Mutation,
+	before schema.Field) ([]*gql.GraphQuery, error) {
+
+	customClaims, err := mutation.GetAuthMeta().ExtractCustomClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The nodes are found just like the update mutation finds them, so they are filtered by the
+	// update rules, but what's selected in them is filtered by the query rules.
+	_, updateRules := mutationFieldRules(mutation)
+	authRw := &authRewriter{
+		authVariables: customClaims.AuthVariables,
+		varGen:        NewVariableGenerator(),
+		selector:      updateRules.selector(updateAuthSelector),
+		parentVarName: mutation.MutatedType().Name() + "Root",
+	}
+	authRw.hasAuthRules = hasAuthRules(before, authRw) ||
+		hasFieldAuthRules(before, customClaims.AuthVariables)
+
+	dgQuery := RewriteUpsertQueryFromMutation(mutation, authRw, "", before.DgraphAlias(), "")
+	for _, q := range dgQuery {
+		if q.Attr != before.DgraphAlias() {
+			continue
+		}
+		authRw.selector = queryAuthSelector
+		q.Children = nil
+		selectionAuth := addSelectionSetFrom(q, before, authRw)
+		addUID(q)
+		return append(dgQuery, selectionAuth...), nil
+	}
+	// The update rules didn't let the mutation find any nodes.
+	return dgQuery, nil
+}
+
+// rewriteDiffAfter rewrites the after field of the diff in an update mutation's payload into a
+// Dgraph query for the nodes with the given uids, which the mutation updated.
+func rewriteDiffAfter(
+	ctx context.Context,
+	mutation schema.Mutation,
+	after schema.Field,
+	uids []uint64) ([]*gql.GraphQuery, error) {
+
+	customClaims, err := mutation.GetAuthMeta().ExtractCustomClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	authRw := &authRewriter{
+		authVariables: customClaims.AuthVariables,
+		varGen:        NewVariableGenerator(),
+		selector:      queryAuthSelector,
+		parentVarName: mutation.MutatedType().Name() + "Root",
+	}
+	authRw.hasAuthRules = hasAuthRules(after, authRw) ||
+		hasFieldAuthRules(after, customClaims.AuthVariables)
+	return rewriteAsQueryByIds(after, uids, authRw), nil
+}
+
 func (arw *AddRewriter) MutatedRootUIDs(
 	mutation schema.Mutation,
 	assigned map[string]string,
@@ -977,6 +1077,7 @@ func RewriteUpsertQueryFromMutation(
 	// If it is set to empty, this is either a delete or update mutation.
 	// In that case, we extract the IDs on which to apply this mutation using
 	// extractMutationFilter.
+	fv := newFilterVars(authRw)
 	if nodeID == "" {
 		filter := extractMutationFilter(m)
 		if ids := idFilter(filter, m.MutatedType().IDField()); ids != nil {
@@ -985,7 +1086,7 @@ func RewriteUpsertQueryFromMutation(
 			addTypeFunc(dgQuery[0], m.MutatedType().DgraphName())
 		}
 
-		_ = addFilter(dgQuery[0], m.MutatedType(), filter)
+		_ = addFilter(dgQuery[0], m.MutatedType(), filter, fv)
 	} else {
 		// It means this is called from upsert with Add mutation.
 		// nodeID will be uid of the node to be upserted. We add UID func
@@ -1003,7 +1104,7 @@ func RewriteUpsertQueryFromMutation(
 	}
 	dgQuery = authRw.addAuthQueries(m.MutatedType(), dgQuery, rbac)
 
-	return dgQuery
+	return fv.addTo(dgQuery)
 }
 
 // removeNodeReference removes any reference we know about (via @hasInverse) into a node.
@@ -1054,6 +1155,10 @@ func (drw *deleteRewriter) Rewrite(
 			m.MutationType())
 	}
 
+	if err := checkFieldAuth(ctx, m); err != nil {
+		return nil, err
+	}
+
 	customClaims, err := m.GetAuthMeta().ExtractCustomClaims(ctx)
 	if err != nil {
 		return nil, err
@@ -1098,7 +1203,8 @@ func (drw *deleteRewriter) Rewrite(
 			filterByUid:   true,
 			parentVarName: drw.VarGen.Next(queryField.Type(), "", "", false),
 			varName:       MutationQueryVar,
-			hasAuthRules:  hasAuthRules(queryField, authRw),
+			hasAuthRules: hasAuthRules(queryField, authRw) ||
+				hasFieldAuthRules(queryField, customClaims.AuthVariables),
 		}
 
 		// these queries are responsible for querying the queryField
@@ -1205,6 +1311,156 @@ func deleteAuthSelector(t schema.Type) *schema.RuleNode {
 	return auth.Rules.Delete
 }
 
+// fieldWriteRules are the rules of the @auth directives on the fields that a mutation writes, by
+// the name of the type of the nodes they are written to.
+type fieldWriteRules map[string][]fieldRule
+
+// addObject adds the rules picked by selector for the fields of obj, an object of type typ. If
+// nested isn't nil, the add rules of the fields of the objects nested in obj are added to it, as
+// those objects are new nodes.
+func (rules fieldWriteRules) addObject(
+	typ schema.Type,
+	obj map[string]interface{},
+	selector func(*schema.AuthContainer) *schema.RuleNode,
+	nested fieldWriteRules) {
+
+	auth := typ.AuthRules()
+	for _, fd := range typ.Fields() {
+		val, ok := obj[fd.Name()]
+		if !ok {
+			continue
+		}
+		if auth != nil && auth.Fields[fd.Name()] != nil {
+			if rn := selector(auth.Fields[fd.Name()]); rn != nil {
+				rules.add(typ, fd.Name(), rn)
+			}
+		}
+
+		fieldType := fd.Type()
+		if nested == nil || fieldType.IsInbuiltOrEnumType() || fieldType.IsGeo() ||
+			fieldType.IsUnion() {
+			continue
+		}
+		switch v := val.(type) {
+		case map[string]interface{}:
+			nested.addObject(fieldType, v, addFieldRule, nested)
+		case []interface{}:
+			for _, o := range v {
+				if obj, ok := o.(map[string]interface{}); ok {
+					nested.addObject(fieldType, obj, addFieldRule, nested)
+				}
+			}
+		}
+	}
+}
+
+func (rules fieldWriteRules) add(typ schema.Type, field string, rn *schema.RuleNode) {
+	for _, fr := range rules[typ.Name()] {
+		if fr.field == field {
+			return
+		}
+	}
+	rules[typ.Name()] = append(rules[typ.Name()], fieldRule{typ: typ, field: field, rule: rn})
+}
+
+// check returns an error if any of the rules is statically not satisfied.
+func (rules fieldWriteRules) check(authVariables map[string]interface{}) error {
+	for _, typeRules := range rules {
+		for _, fr := range typeRules {
+			if fr.rule.EvaluateStatic(authVariables) == schema.Negative {
+				return errors.Errorf("authorization failed to write field %s of type %s",
+					fr.field, fr.typ.Name())
+			}
+		}
+	}
+	return nil
+}
+
+// selector returns an auth selector which requires the rules of the fields written to a type on
+// top of the rules that typeSelector picks for the type.
+func (rules fieldWriteRules) selector(
+	typeSelector func(t schema.Type) *schema.RuleNode) func(t schema.Type) *schema.RuleNode {
+	if len(rules) == 0 {
+		return typeSelector
+	}
+
+	return func(t schema.Type) *schema.RuleNode {
+		rn := typeSelector(t)
+		for _, fr := range rules[t.Name()] {
+			if rn == nil {
+				rn = fr.rule
+				continue
+			}
+			rn = &schema.RuleNode{And: []*schema.RuleNode{rn, fr.rule}}
+		}
+		return rn
+	}
+}
+
+func addFieldRule(auth *schema.AuthContainer) *schema.RuleNode {
+	return auth.Add
+}
+
+func updateFieldRule(auth *schema.AuthContainer) *schema.RuleNode {
+	return auth.Update
+}
+
+// mutationFieldRules returns the rules of the @auth directives on the fields that the mutation
+// writes. The fields of the existing nodes that the mutation updates need their update rule,
+// and the fields of the new nodes it adds their add rule.
+func mutationFieldRules(m schema.Mutation) (add, update fieldWriteRules) {
+	add, update = make(fieldWriteRules), make(fieldWriteRules)
+	mutatedType := m.MutatedType()
+	switch m.MutationType() {
+	case schema.AddMutation:
+		upsert, _ := m.ArgValue(schema.UpsertArgName).(bool)
+		objs, _ := m.ArgValue(schema.InputArgName).([]interface{})
+		for _, o := range objs {
+			obj, _ := o.(map[string]interface{})
+			add.addObject(mutatedType, obj, addFieldRule, add)
+			if upsert {
+				update.addObject(mutatedType, obj, updateFieldRule, add)
+			}
+		}
+	case schema.UpdateMutation:
+		inp, _ := m.ArgValue(schema.InputArgName).(map[string]interface{})
+		set, _ := inp["set"].(map[string]interface{})
+		update.addObject(mutatedType, set, updateFieldRule, add)
+		del, _ := inp["remove"].(map[string]interface{})
+		update.addObject(mutatedType, del, updateFieldRule, nil)
+	}
+	return add, update
+}
+
+// checkFieldAuth returns an error if the mutation writes a field that the rule of the @auth
+// directive on it doesn't allow writing, or if it filters, orders or aggregates by a field that
+// can't be read. The rules which can only be evaluated against the data are left to the auth
+// queries of the mutation.
+func checkFieldAuth(ctx context.Context, m schema.Mutation) error {
+	add, update := mutationFieldRules(m)
+	filter := extractMutationFilter(m)
+	filterNames := make(map[string]bool)
+	filterFieldNames(filter, filterNames)
+	readRules := namedFieldRules(m.MutatedType(), filterNames, fieldDefQueryRule, nil)
+	readRules = relatedFilterRules(m.MutatedType(), filter, readRules)
+	readRules = readFieldRules(m.QueryField(), readRules)
+	if len(add) == 0 && len(update) == 0 && len(readRules) == 0 {
+		return nil
+	}
+
+	customClaims, err := m.GetAuthMeta().ExtractCustomClaims(ctx)
+	if err != nil {
+		return err
+	}
+	if err := add.check(customClaims.AuthVariables); err != nil {
+		return err
+	}
+	if err := update.check(customClaims.AuthVariables); err != nil {
+		return err
+	}
+	return checkReadRules(readRules, customClaims.AuthVariables)
+}
+
 func mutationFromFragment(
 	frag *mutationFragment,
 	setBuilder, delBuilder mutationBuilder) (*dgoapi.Mutation, error) {
@@ -1252,6 +1508,102 @@ func checkXIDExistsQuery(
 	return qry
 }
 
+// xidKey is a set of @id fields that together identify a node of a type. Every @id field is a
+// key on its own, unless the type has @compositeId, in which case all of its @id fields make up
+// a single key.
+type xidKey []schema.FieldDefinition
+
+// xidKeys returns the keys made up by the @id fields of typ.
+func xidKeys(typ schema.Type) []xidKey {
+	xids := typ.XIDFields()
+	if len(xids) == 0 {
+		return nil
+	}
+	if typ.HasCompositeID() {
+		return []xidKey{xids}
+	}
+	keys := make([]xidKey, 0, len(xids))
+	for _, xid := range xids {
+		keys = append(keys, xidKey{xid})
+	}
+	return keys
+}
+
+// name returns the name of the field of the key, or the names of all its fields like
+// (course, student) for composite keys.
+func (k xidKey) name() string {
+	if len(k) == 1 {
+		return k[0].Name()
+	}
+	names := make([]string, 0, len(k))
+	for _, xid := range k {
+		names = append(names, xid.Name())
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+// values returns the string forms of the values given in obj for the fields of the key. It
+// returns false if obj doesn't give a value for all of them.
+func (k xidKey) values(obj map[string]interface{}) ([]string, bool, error) {
+	vals := make([]string, 0, len(k))
+	for _, xid := range k {
+		xidVal, ok := obj[xid.Name()]
+		if !ok || xidVal == nil {
+			return nil, false, nil
+		}
+		val, err := extractVal(xidVal, xid.Name(), xid.Type().Name())
+		if err != nil {
+			return nil, false, err
+		}
+		vals = append(vals, val)
+	}
+	return vals, true, nil
+}
+
+// format returns vals, the values of the key, as a single string like ("c1", "s1") for
+// composite keys.
+func (k xidKey) format(vals []string) string {
+	if len(k) == 1 {
+		return vals[0]
+	}
+	quoted := make([]string, 0, len(vals))
+	for _, val := range vals {
+		quoted = append(quoted, strconv.Quote(val))
+	}
+	return "(" + strings.Join(quoted, ", ") + ")"
+}
+
+// existsQuery returns the query looking for the node of type typ which has the values vals for
+// the key, like:
+//
+//	Enrollment_1(func: eq(Enrollment.course, "c1")) @filter(eq(Enrollment.student, "s1")) {
+//		uid
+//		dgraph.type
+//	}
+func (k xidKey) existsQuery(xidVariable string, vals []string, typ schema.Type) *gql.GraphQuery {
+	qry := checkXIDExistsQuery(xidVariable, vals[0], k[0].Name(), typ)
+	var flt []*gql.FilterTree
+	for i, xid := range k[1:] {
+		flt = append(flt, &gql.FilterTree{
+			Func: &gql.Function{
+				Name: "eq",
+				Args: []gql.Arg{
+					{Value: typ.DgraphPredicate(xid.Name())},
+					{Value: maybeQuoteArg("eq", vals[i+1])},
+				},
+			},
+		})
+	}
+	switch len(flt) {
+	case 0:
+	case 1:
+		qry.Filter = flt[0]
+	default:
+		qry.Filter = &gql.FilterTree{Op: "and", Child: flt}
+	}
+	return qry
+}
+
 func checkUIDExistsQuery(val interface{}, variable string) (*gql.GraphQuery, error) {
 	uid, err := asUID(val)
 	if err != nil {
@@ -1396,15 +1748,15 @@ func rewriteObject(
 
 	xids := typ.XIDFields()
 	if len(xids) != 0 {
+		keys := xidKeys(typ)
 		// nonExistingXIDs stores number of uids for which there exist no nodes
 		var nonExistingXIDs int
 		// xidVariables stores the variable names for each XID.
 		var xidVariables []string
-		for _, xid := range xids {
-			var xidString string
-			if xidVal, ok := obj[xid.Name()]; ok && xidVal != nil {
-				xidString, _ = extractVal(xidVal, xid.Name(), xid.Type().Name())
-				variable = varGen.Next(typ, xid.Name(), xidString, false)
+		for _, key := range keys {
+			if xidVals, ok, _ := key.values(obj); ok {
+				xidString := key.format(xidVals)
+				variable = varGen.Next(typ, key.name(), xidString, false)
 
 				// Three cases:
 				// 1. If the queryResult UID exists. Add a reference.
@@ -1433,11 +1785,11 @@ func rewriteObject(
 							var err error
 							if queryAuthSelector(typ) == nil {
 								err = x.GqlErrorf("id %s already exists "+
-									"for field %s inside type %s", xidString, xid.Name(), typ.Name())
+									"for field %s inside type %s", xidString, key.name(), typ.Name())
 							} else {
 								// This error will only be reported in debug mode.
 								err = x.GqlErrorf("GraphQL debug: id %s already exists for "+
-									"field %s inside type %s", xidString, xid.Name(), typ.Name())
+									"field %s inside type %s", xidString, key.name(), typ.Name())
 							}
 							retErrors = append(retErrors, err)
 							return nil, upsertVar, retErrors
@@ -1456,7 +1808,7 @@ func rewriteObject(
 					// We add a new node only if
 					// 1. All the xids are present and
 					// 2. No node exist for any of the xid
-					if nonExistingXIDs == len(xids)-1 {
+					if nonExistingXIDs == len(keys)-1 {
 						exclude := ""
 						if srcField != nil {
 							invField := srcField.Inverse()
@@ -1602,6 +1954,7 @@ func rewriteObject(
 	// Fields are sorted to ensure that they are traversed in specific order each time. Golang maps
 	// don't store keys in sorted order.
 	sort.Strings(fields)
+	customScalars := customScalarFields(typ)
 	for _, field := range fields {
 		val := obj[field]
 
@@ -1613,6 +1966,17 @@ func rewriteObject(
 			fieldName = fieldName[1 : len(fieldName)-1]
 		}
 
+		// Values of custom scalars can be JSON objects or lists, but they are never nodes.
+		if cs := customScalars[field]; cs != nil {
+			scalarVal, err := coerceCustomScalar(ctx, fieldDef, cs, val)
+			if err != nil {
+				retErrors = append(retErrors, err)
+				continue
+			}
+			newObj[fieldName] = scalarVal
+			continue
+		}
+
 		// TODO: Write a function for aggregating data of fragment from child nodes.
 		switch val := val.(type) {
 		case map[string]interface{}:
@@ -1772,63 +2136,60 @@ func existenceQueries(
 		}
 	}
 
-	xids := typ.XIDFields()
-	var xidString string
-	var err error
-	if len(xids) != 0 {
-		for _, xid := range xids {
-			if xidVal, ok := obj[xid.Name()]; ok && xidVal != nil {
-				xidString, err = extractVal(xidVal, xid.Name(), xid.Type().Name())
-				if err != nil {
-					return nil, nil, append(retErrors, err)
+	for _, key := range xidKeys(typ) {
+		xidVals, ok, err := key.values(obj)
+		if err != nil {
+			return nil, nil, append(retErrors, err)
+		}
+		if ok {
+			xidString := key.format(xidVals)
+			variable := varGen.Next(typ, key.name(), xidString, false)
+			// There are two cases:
+			// Case 1: We are at top level:
+			// 	       We return an error if the same node is referenced twice at top level.
+			// Case 2: We are not at top level:
+			//         We don't return an error if one of the occurrences of XID is a reference
+			//         and other is definition.
+			//         We return an error if both occurrences contain values other than XID and are
+			//         not equal.
+			if xidMetadata.variableObjMap[variable] != nil {
+				// if we already encountered an object with same xid earlier, and this object is
+				// considered a duplicate of the existing object, then return error.
+				if xidMetadata.isDuplicateXid(atTopLevel, variable, obj, srcField, len(key)) {
+					err := errors.Errorf("duplicate XID found: %s", xidString)
+					retErrors = append(retErrors, err)
+					return nil, nil, retErrors
 				}
-				variable := varGen.Next(typ, xid.Name(), xidString, false)
-				// There are two cases:
-				// Case 1: We are at top level:
-				// 	       We return an error if the same node is referenced twice at top level.
-				// Case 2: We are not at top level:
-				//         We don't return an error if one of the occurrences of XID is a reference
-				//         and other is definition.
-				//         We return an error if both occurrences contain values other than XID and are
-				//         not equal.
-				if xidMetadata.variableObjMap[variable] != nil {
-					// if we already encountered an object with same xid earlier, and this object is
-					// considered a duplicate of the existing object, then return error.
-					if xidMetadata.isDuplicateXid(atTopLevel, variable, obj, srcField) {
-						err := errors.Errorf("duplicate XID found: %s", xidString)
-						retErrors = append(retErrors, err)
-						return nil, nil, retErrors
-					}
-					// In the other case it is not duplicate, we update variableObjMap in case the new
-					// occurrence of XID is its description and the old occurrence was a reference.
-					// Example:
-					// obj = { "id": "1", "name": "name1"}
-					// xidMetadata.variableObjMap[variable] = { "id": "1" }
-					// In this case, as obj is the correct definition of the object, we update variableObjMap
-					oldObj := xidMetadata.variableObjMap[variable]
-					if len(oldObj) == 1 && len(obj) > 1 {
-						// Continue execution to perform dfs in this case. There may be more nodes
-						// in the subtree of this node.
-						xidMetadata.variableObjMap[variable] = obj
-					} else {
-						// This is just a node reference. No need to proceed further.
-						return ret, retTypes, retErrors
-					}
+				// In the other case it is not duplicate, we update variableObjMap in case the new
+				// occurrence of XID is its description and the old occurrence was a reference.
+				// Example:
+				// obj = { "id": "1", "name": "name1"}
+				// xidMetadata.variableObjMap[variable] = { "id": "1" }
+				// For composite ids, a reference has the values of all the fields of the id.
+				// In this case, as obj is the correct definition of the object, we update variableObjMap
+				oldObj := xidMetadata.variableObjMap[variable]
+				if len(oldObj) == len(key) && len(obj) > len(key) {
+					// Continue execution to perform dfs in this case. There may be more nodes
+					// in the subtree of this node.
+					xidMetadata.variableObjMap[variable] = obj
 				} else {
+					// This is just a node reference. No need to proceed further.
+					return ret, retTypes, retErrors
+				}
+			} else {
 
-					// if not encountered till now, add it to the map,
-					xidMetadata.variableObjMap[variable] = obj
+				// if not encountered till now, add it to the map,
+				xidMetadata.variableObjMap[variable] = obj
 
-					// save if this node was seen at top level.
-					xidMetadata.seenAtTopLevel[variable] = atTopLevel
+				// save if this node was seen at top level.
+				xidMetadata.seenAtTopLevel[variable] = atTopLevel
 
-					// Add the corresponding existence query. As this is the first time we have
-					// encountered this variable, the query is added only once per variable.
-					query := checkXIDExistsQuery(variable, xidString, xid.Name(), typ)
-					ret = append(ret, query)
-					retTypes = append(retTypes, typ.DgraphName())
-					// Don't return just over here as there maybe more nodes in the children tree.
-				}
+				// Add the corresponding existence query. As this is the first time we have
+				// encountered this variable, the query is added only once per variable.
+				query := key.existsQuery(variable, xidVals, typ)
+				ret = append(ret, query)
+				retTypes = append(retTypes, typ.DgraphName())
+				// Don't return just over here as there maybe more nodes in the children tree.
 			}
 		}
 	}
@@ -1841,6 +2202,7 @@ func existenceQueries(
 	// Fields are sorted to ensure that they are traversed in specific order each time. Golang maps
 	// don't store keys in sorted order.
 	sort.Strings(fields)
+	customScalars := customScalarFields(typ)
 	for _, field := range fields {
 		val := obj[field]
 
@@ -1852,6 +2214,11 @@ func existenceQueries(
 			fieldName = fieldName[1 : len(fieldName)-1]
 		}
 
+		// Custom scalars never contain any XID.
+		if customScalars[field] != nil {
+			continue
+		}
+
 		switch val := val.(type) {
 		case map[string]interface{}:
 			if fieldDef.Type().IsUnion() {
@@ -1904,6 +2271,191 @@ func existenceQueries(
 	return ret, retTypes, retErrors
 }
 
+// constraintErrors checks the values in obj, the input given for an object of type typ, against
+// the @constraint directives on the fields of typ, going down into the objects given for its
+// edges. Each error carries the path to the value that breaks a constraint, starting at path.
+func constraintErrors(typ schema.Type, obj interface{}, path []interface{}) x.GqlErrorList {
+	var errs x.GqlErrorList
+	switch obj := obj.(type) {
+	case []interface{}:
+		for i, o := range obj {
+			errs = append(errs, constraintErrors(typ, o, appendPath(path, i))...)
+		}
+	case map[string]interface{}:
+		fieldDefs := make(map[string]schema.FieldDefinition)
+		for _, fd := range typ.Fields() {
+			fieldDefs[fd.Name()] = fd
+		}
+		fields := make([]string, 0, len(obj))
+		for field := range obj {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			// fields like the password of a type with @secret aren't in the type definition
+			fieldDef, ok := fieldDefs[field]
+			if !ok {
+				continue
+			}
+			fieldPath := appendPath(path, field)
+			if c := fieldDef.Constraint(); c != nil {
+				errs = append(errs, constraintValueErrors(c, field, obj[field], fieldPath)...)
+				continue
+			}
+			if !fieldDef.Type().IsInbuiltOrEnumType() && !fieldDef.Type().IsUnion() {
+				errs = append(errs, constraintErrors(fieldDef.Type(), obj[field], fieldPath)...)
+			}
+		}
+	}
+	return errs
+}
+
+// constraintValueErrors checks val, the value given for field, against the constraint c.
+// The values of list fields are checked one by one.
+func constraintValueErrors(c *schema.Constraint, field string, val interface{},
+	path []interface{}) x.GqlErrorList {
+	if vals, ok := val.([]interface{}); ok {
+		var errs x.GqlErrorList
+		for i, v := range vals {
+			errs = append(errs, constraintValueErrors(c, field, v, appendPath(path, i))...)
+		}
+		return errs
+	}
+
+	if err := c.Validate(val); err != nil {
+		b, _ := json.Marshal(val)
+		return x.GqlErrorList{
+			x.GqlErrorf("value %s for field `%s` %s", b, field, err).WithPath(path)}
+	}
+	return nil
+}
+
+// appendPath returns a copy of path with elem appended to it, so that paths built from the
+// same parent don't share their backing array.
+func appendPath(path []interface{}, elem interface{}) []interface{} {
+	result := make([]interface{}, len(path), len(path)+1)
+	copy(result, path)
+	return append(result, elem)
+}
+
+// customScalarFields returns the scalars declared with @scalar of the fields of typ, keyed by
+// the field name.
+func customScalarFields(typ schema.Type) map[string]*schema.CustomScalar {
+	var result map[string]*schema.CustomScalar
+	for _, fd := range typ.Fields() {
+		if cs := fd.Type().CustomScalar(); cs != nil {
+			if result == nil {
+				result = make(map[string]*schema.CustomScalar)
+			}
+			result[fd.Name()] = cs
+		}
+	}
+	return result
+}
+
+// coerceCustomScalar checks that val is a valid value for the field fieldDef of the custom
+// scalar cs, and returns it in the form it is stored in Dgraph. Values of list fields are
+// coerced one by one.
+func coerceCustomScalar(
+	ctx context.Context,
+	fieldDef schema.FieldDefinition,
+	cs *schema.CustomScalar,
+	val interface{}) (interface{}, error) {
+
+	if list, ok := val.([]interface{}); ok && fieldDef.Type().ListType() != nil {
+		result := make([]interface{}, 0, len(list))
+		for _, v := range list {
+			coerced, err := coerceCustomScalar(ctx, fieldDef, cs, v)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, coerced)
+		}
+		return result, nil
+	}
+	if val == nil {
+		return nil, nil
+	}
+
+	invalid := func(reason string) error {
+		b, _ := json.Marshal(val)
+		return errors.Errorf("value %s for field `%s` isn't a valid %s: %s",
+			b, fieldDef.Name(), cs.Name, reason)
+	}
+
+	var coerced interface{}
+	switch cs.BaseType {
+	case "String", "DateTime":
+		s, ok := val.(string)
+		if !ok {
+			return nil, invalid("expected a string")
+		}
+		if cs.Regex != nil && !cs.Regex.MatchString(s) {
+			return nil, invalid(fmt.Sprintf("it doesn't match %s", cs.Regex))
+		}
+		coerced = s
+	case "Int", "Int64":
+		bitSize := 32
+		if cs.BaseType == "Int64" {
+			bitSize = 64
+		}
+		var str string
+		switch v := val.(type) {
+		case json.Number:
+			str = v.String()
+		case int64:
+			str = strconv.FormatInt(v, 10)
+		case float64:
+			str = strconv.FormatFloat(v, 'f', -1, 64)
+		case string:
+			if cs.BaseType == "Int64" {
+				str = v
+			}
+		}
+		i, err := strconv.ParseInt(str, 10, bitSize)
+		if err != nil {
+			return nil, invalid("expected an " + cs.BaseType)
+		}
+		coerced = i
+	case "Float":
+		switch v := val.(type) {
+		case json.Number:
+			f, err := v.Float64()
+			if err != nil {
+				return nil, invalid("expected a Float")
+			}
+			coerced = f
+		case int64:
+			coerced = float64(v)
+		case float64:
+			coerced = v
+		default:
+			return nil, invalid("expected a Float")
+		}
+	case "Boolean":
+		b, ok := val.(bool)
+		if !ok {
+			return nil, invalid("expected a Boolean")
+		}
+		coerced = b
+	default:
+		// JSON values are stored as their JSON encoding.
+		b, err := json.Marshal(val)
+		if err != nil {
+			return nil, invalid(err.Error())
+		}
+		coerced = string(b)
+	}
+
+	if cs.Lambda {
+		if err := validateScalarWithLambda(ctx, fieldDef, cs, val); err != nil {
+			return nil, invalid(err.Error())
+		}
+	}
+	return coerced, nil
+}
+
 func existenceQueriesUnion(
 	ctx context.Context,
 	parentTyp schema.Type,
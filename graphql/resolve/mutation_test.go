@@ -79,6 +79,96 @@ func TestMutationRewriting(t *testing.T) {
 	})
 }
 
+func TestConstraintErrorPaths(t *testing.T) {
+	gqlSchema := test.LoadSchemaFromFile(t, "schema.graphql")
+
+	op, err := gqlSchema.Operation(
+		&schema.Request{
+			Query: `
+			mutation addListing($listings: [AddListingInput!]!) {
+				listings: addListing(input: $listings) {
+					listing {
+						sku
+					}
+				}
+			}`,
+			Variables: map[string]interface{}{
+				"listings": []interface{}{
+					map[string]interface{}{"sku": "TVS-42", "title": "Television"},
+					map[string]interface{}{"sku": "TVS-43", "title": "Television",
+						"reviews": []interface{}{
+							map[string]interface{}{"comment": "Way too small for the price"},
+						}},
+				},
+			},
+		})
+	require.NoError(t, err)
+	mut := test.GetMutation(t, op)
+
+	_, _, err = NewAddRewriter().RewriteQueries(context.Background(), mut)
+	require.Equal(t, x.GqlErrorList{{
+		Message: "value \"Way too small for the price\" for field `comment` must be at most " +
+			"20 characters long",
+		Path: []interface{}{"listings", "input", 1, "reviews", 0, "comment"},
+	}}, err)
+}
+
+func TestUpdateMutationDiff(t *testing.T) {
+	gqlSchema := test.LoadSchemaFromFile(t, "schema.graphql")
+
+	op, err := gqlSchema.Operation(
+		&schema.Request{
+			Query: `
+			mutation {
+				updateEnrollment(input: {filter: {student: {eq: "alice"}}, set: {grade: "B"}}) {
+					diff {
+						before { course grade }
+						after { grade }
+						__typename
+					}
+				}
+			}`,
+		})
+	require.NoError(t, err)
+	mut := test.GetMutation(t, op)
+	diff := mut.DiffField()
+	require.NotNil(t, diff)
+	require.Nil(t, mut.QueryField())
+
+	before, err := rewriteDiffBefore(context.Background(), mut, diff.SelectionSet()[0])
+	require.NoError(t, err)
+	require.Equal(t, `query {
+  UpdateEnrollmentDiff.before(func: type(Enrollment)) @filter(eq(Enrollment.student, "alice")) {
+    Enrollment.course : Enrollment.course
+    Enrollment.grade : Enrollment.grade
+    dgraph.uid : uid
+  }
+}`, dgraph.AsString(before))
+
+	after, err := rewriteDiffAfter(context.Background(), mut, diff.SelectionSet()[1],
+		[]uint64{0x11, 0x12})
+	require.NoError(t, err)
+	require.Equal(t, `query {
+  UpdateEnrollmentDiff.after(func: uid(0x11, 0x12)) {
+    Enrollment.grade : Enrollment.grade
+    dgraph.uid : uid
+  }
+}`, dgraph.AsString(after))
+
+	// The update found two nodes before, but only one of them was readable after it.
+	completed := completeDiff(diff,
+		map[string][]json.RawMessage{"before": {
+			json.RawMessage(`{"course":"maths","grade":"A"}`),
+			json.RawMessage(`{"course":"physics","grade":null}`),
+		}},
+		map[string][]json.RawMessage{"after": {json.RawMessage(`{"grade":"B"}`)}})
+	require.JSONEq(t, `[
+		{"before":{"course":"maths","grade":"A"},"after":{"grade":"B"},
+			"__typename":"UpdateEnrollmentDiff"},
+		{"before":{"course":"physics","grade":null},"after":null,
+			"__typename":"UpdateEnrollmentDiff"}]`, string(completed))
+}
+
 func mutationValidation(t *testing.T, file string, rewriterFactory func() MutationRewriter) {
 	b, err := ioutil.ReadFile(file)
 	require.NoError(t, err, "Unable to read test file")
@@ -19,6 +19,7 @@ package resolve
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
@@ -87,6 +88,25 @@ func hasAuthRules(field schema.Field, authRw *authRewriter) bool {
 	return false
 }
 
+// hasFieldAuthRules tells if any of the fields in the query hierarchy has a query rule in the
+// @auth directive on its definition that can only be evaluated against the data.
+func hasFieldAuthRules(field schema.Field, authVariables map[string]interface{}) bool {
+	if field == nil {
+		return false
+	}
+
+	if rn := fieldQueryRule(field); rn != nil && rn.EvaluateStatic(authVariables) == schema.Uncertain {
+		return true
+	}
+
+	for _, childField := range field.SelectionSet() {
+		if hasFieldAuthRules(childField, authVariables) {
+			return true
+		}
+	}
+	return false
+}
+
 func hasCascadeDirective(field schema.Field) bool {
 	if c := field.Cascade(); c != nil {
 		return true
@@ -124,8 +144,12 @@ func (qr *queryRewriter) Rewrite(
 		selector:      getAuthSelector(gqlQuery.QueryType()),
 		parentVarName: gqlQuery.ConstructedFor().Name() + "Root",
 	}
-	authRw.hasAuthRules = hasAuthRules(gqlQuery, authRw)
+	authRw.hasAuthRules = hasAuthRules(gqlQuery, authRw) ||
+		hasFieldAuthRules(gqlQuery, authRw.authVariables)
 	authRw.hasCascade = hasCascadeDirective(gqlQuery)
+	if err := checkReadRules(readFieldRules(gqlQuery, nil), authRw.authVariables); err != nil {
+		return nil, err
+	}
 
 	switch gqlQuery.QueryType() {
 	case schema.GetQuery:
@@ -153,6 +177,8 @@ func (qr *queryRewriter) Rewrite(
 		return passwordQuery(gqlQuery, authRw)
 	case schema.AggregateQuery:
 		return aggregateQuery(gqlQuery, authRw), nil
+	case schema.ConnectionQuery:
+		return connectionQuery(gqlQuery, authRw)
 	case schema.EntitiesQuery:
 		return entitiesQuery(gqlQuery, authRw)
 	default:
@@ -160,6 +186,168 @@ func (qr *queryRewriter) Rewrite(
 	}
 }
 
+// connectionNodes is a connection query seen as a query for the nodes of its edges. It has the
+// name and arguments of the connection query, but the type and the selection set of the `node`
+// field of the edges.
+type connectionNodes struct {
+	schema.Query
+	nodeType schema.Type
+	// would be nil if the query doesn't ask for edges { node { ... } }
+	node schema.Field
+}
+
+func newConnectionNodes(query schema.Query) *connectionNodes {
+	return &connectionNodes{
+		Query:    query,
+		nodeType: query.ConstructedFor(),
+		node:     schema.ConnectionNode(query),
+	}
+}
+
+func (c *connectionNodes) Type() schema.Type {
+	return c.nodeType
+}
+
+func (c *connectionNodes) SelectionSet() []schema.Field {
+	if c.node == nil {
+		return nil
+	}
+	return c.node.SelectionSet()
+}
+
+func (c *connectionNodes) AbstractType() bool {
+	return c.nodeType.IsInterface() || c.nodeType.IsUnion()
+}
+
+// connectionQuery rewrites a Relay connection query like:
+//
+//	queryPostConnection(order: { asc: title }, first: 10, after: "...") {
+//	  edges {
+//	    cursor
+//	    node { title }
+//	  }
+//	  pageInfo { hasNextPage }
+//	}
+//
+// into a query for the nodes of the connection, which fetches one node more than asked for to
+// find out whether there is a next page:
+//
+//	queryPostConnection(func: type(Post), orderasc: Post.title, first: 11, offset: 2)
+//	    @filter((has(Post.title) AND ge(Post.title, "GraphQL"))) {
+//	  Post.title : Post.title
+//	  dgraph.cursorUid : uid
+//	  dgraph.cursorOrder : Post.title
+//	}
+//
+// The cursor of a node is built from its uid and, if the connection is ordered, the value of
+// the field it is ordered by. Paging after the cursor of an unordered connection is done with
+// the `after` argument of DQL. For ordered connections, it seeks to the value in the cursor
+// and skips the nodes having that value which were already returned, as counted in the cursor.
+// Only the nodes having a value for the field are part of ordered connections.
+func connectionQuery(query schema.Query, authRw *authRewriter) ([]*gql.GraphQuery, error) {
+	nodes := newConnectionNodes(query)
+
+	var cursor *schema.Cursor
+	if after, ok := query.ArgValue("after").(string); ok {
+		var err error
+		if cursor, err = schema.ParseCursor(after); err != nil {
+			return nil, err
+		}
+	}
+	orderField, desc := schema.ConnectionOrder(query)
+	if cursor != nil && cursor.Order != orderField {
+		return nil, errors.Errorf("the cursor %s wasn't returned by a query with the same order",
+			query.ArgValue("after"))
+	}
+	first, hasFirst := query.ArgValue("first").(int64)
+	if hasFirst && first < 0 {
+		return nil, errors.Errorf("first can't be negative, but it is %d", first)
+	}
+
+	dgQuery, rbac := addCommonRules(nodes, nodes.Type(), authRw)
+	if rbac == schema.Negative {
+		return dgQuery, nil
+	}
+
+	mainQuery := dgQuery[0]
+	fv := newFilterVars(authRw)
+	addArgumentsToField(mainQuery, nodes, fv)
+	if hasFirst {
+		mainQuery.Args["first"] = strconv.FormatInt(first+1, 10)
+	}
+
+	orderPred := ""
+	if orderField == "" {
+		if cursor != nil {
+			mainQuery.Args["after"] = cursor.UID
+		}
+	} else {
+		orderPred = nodes.Type().DgraphPredicate(orderField)
+		addConnectionFilter(mainQuery, &gql.Function{
+			Name: "has",
+			Args: []gql.Arg{{Value: orderPred}},
+		})
+		if cursor != nil {
+			var val interface{}
+			d := json.NewDecoder(bytes.NewReader(cursor.Value))
+			d.UseNumber()
+			if err := d.Decode(&val); err != nil || val == nil {
+				return nil, errors.Errorf("%s isn't a valid cursor", query.ArgValue("after"))
+			}
+			fn := "ge"
+			if desc {
+				fn = "le"
+			}
+			addConnectionFilter(mainQuery, &gql.Function{
+				Name: fn,
+				Args: []gql.Arg{{Value: orderPred}, {Value: maybeQuoteArg(fn, val)}},
+			})
+			mainQuery.Args["offset"] = strconv.Itoa(cursor.Ties)
+		}
+	}
+
+	selectionAuth := addSelectionSetFrom(mainQuery, nodes, authRw)
+	// The fields the cursors are built from go last, so that the GraphQL encoder can ignore
+	// them while encoding the nodes.
+	mainQuery.Children = append(mainQuery.Children, &gql.GraphQuery{
+		Alias: schema.ConnectionUidAlias,
+		Attr:  "uid",
+	})
+	if orderPred != "" {
+		mainQuery.Children = append(mainQuery.Children, &gql.GraphQuery{
+			Alias: schema.ConnectionOrderAlias,
+			Attr:  orderPred,
+		})
+	}
+	addUID(mainQuery)
+	addCascadeDirective(mainQuery, nodes)
+
+	dgQuery = authRw.addAuthQueries(nodes.Type(), dgQuery, rbac)
+	dgQuery = fv.addTo(dgQuery)
+	if len(selectionAuth) > 0 {
+		return append(dgQuery, selectionAuth...), nil
+	}
+	return rootQueryOptimization(dgQuery), nil
+}
+
+// addConnectionFilter adds the function fn to the filter of q, along with any filter q
+// already has.
+func addConnectionFilter(q *gql.GraphQuery, fn *gql.Function) {
+	ft := &gql.FilterTree{Func: fn}
+	if q.Filter == nil {
+		q.Filter = ft
+		return
+	}
+	if q.Filter.Op == "and" {
+		q.Filter.Child = append(q.Filter.Child, ft)
+		return
+	}
+	q.Filter = &gql.FilterTree{
+		Op:    "and",
+		Child: []*gql.FilterTree{q.Filter, ft},
+	}
+}
+
 // entitiesQuery rewrites the Apollo `_entities` Query which is sent from the Apollo gateway to a DQL query.
 // This query is sent to the Dgraph service to resolve types `extended` and defined by this service.
 func entitiesQuery(field schema.Query, authRw *authRewriter) ([]*gql.GraphQuery, error) {
@@ -212,14 +400,27 @@ func entitiesQuery(field schema.Query, authRw *authRewriter) ([]*gql.GraphQuery,
 	// and query using `eq` function.
 	// We also don't need to add Order to the query as the results are
 	// automatically returned in the ascending order of the uids.
-	if parsedRepr.KeyField.IsID() && !parsedRepr.KeyField.IsExternal() {
-		addUIDFunc(dgQuery, convertIDs(parsedRepr.KeyVals))
+	keyFields := parsedRepr.KeyFields
+	if len(keyFields) == 1 && keyFields[0].IsID() && !keyFields[0].IsExternal() {
+		addUIDFunc(dgQuery, convertIDs(entityKeyVals(parsedRepr, 0)))
 	} else {
-		addEqFunc(dgQuery, typeDefn.DgraphPredicate(parsedRepr.KeyField.Name()), parsedRepr.KeyVals)
-		// Add the  ascending Order of the keyField in the query.
+		addEqFunc(dgQuery, typeDefn.DgraphPredicate(keyFields[0].Name()),
+			entityKeyVals(parsedRepr, 0))
+		// For a compound key, the root func only matches the first key field, so the nodes are
+		// filtered by the values of all the key fields in each representation, like:-
+		// 	_entities(func: eq(course, "c1", "c2")) @filter((eq(course, "c1") AND
+		// 		eq(student, "s1")) OR (eq(course, "c2") AND eq(student, "s2"))) {
+		//		...
+		//	}
+		if len(keyFields) > 1 {
+			addToFilterTree(dgQuery, compoundKeyFilter(typeDefn, parsedRepr))
+		}
+		// Add the  ascending Order of the keyFields in the query.
 		// The result will be converted into the exact in the resultCompletion step.
-		dgQuery.Order = append(dgQuery.Order,
-			&pb.Order{Attr: typeDefn.DgraphPredicate(parsedRepr.KeyField.Name())})
+		for _, keyField := range keyFields {
+			dgQuery.Order = append(dgQuery.Order,
+				&pb.Order{Attr: typeDefn.DgraphPredicate(keyField.Name())})
+		}
 	}
 	// AddTypeFilter in as the Filter to the Root the Query.
 	// Query will be like :-
@@ -236,6 +437,43 @@ func entitiesQuery(field schema.Query, authRw *authRewriter) ([]*gql.GraphQuery,
 
 }
 
+// entityKeyVals returns the values of the i-th key field in all the representations.
+func entityKeyVals(repr *schema.EntityRepresentations, i int) []interface{} {
+	vals := make([]interface{}, 0, len(repr.KeyVals))
+	for _, keyVals := range repr.KeyVals {
+		vals = append(vals, keyVals[i])
+	}
+	return vals
+}
+
+// compoundKeyFilter returns the filter that matches the nodes whose compound key is in one of the
+// representations.
+func compoundKeyFilter(typ schema.Type, repr *schema.EntityRepresentations) *gql.FilterTree {
+	reprFilters := make([]*gql.FilterTree, 0, len(repr.KeyVals))
+	for _, keyVals := range repr.KeyVals {
+		eqFilters := make([]*gql.FilterTree, 0, len(keyVals))
+		for i, keyVal := range keyVals {
+			eqFilters = append(eqFilters, &gql.FilterTree{
+				Func: &gql.Function{
+					Name: "eq",
+					Args: []gql.Arg{
+						{Value: typ.DgraphPredicate(repr.KeyFields[i].Name())},
+						{Value: maybeQuoteArg("eq", keyVal)},
+					},
+				},
+			})
+		}
+		reprFilters = append(reprFilters, &gql.FilterTree{
+			Op:    "and",
+			Child: eqFilters,
+		})
+	}
+	return &gql.FilterTree{
+		Op:    "or",
+		Child: reprFilters,
+	}
+}
+
 func aggregateQuery(query schema.Query, authRw *authRewriter) []*gql.GraphQuery {
 
 	// Get the type which the count query is written for
@@ -248,9 +486,11 @@ func aggregateQuery(query schema.Query, authRw *authRewriter) []*gql.GraphQuery
 
 	// Add filter
 	filter, _ := query.ArgValue("filter").(map[string]interface{})
-	_ = addFilter(dgQuery[0], mainType, filter)
+	fv := newFilterVars(authRw)
+	_ = addFilter(dgQuery[0], mainType, filter, fv)
 
 	dgQuery = authRw.addAuthQueries(mainType, dgQuery, rbac)
+	dgQuery = fv.addTo(dgQuery)
 
 	// mainQuery is the query with Attr: query.Name()
 	// It is the first query in dgQuery list.
@@ -265,6 +505,7 @@ func aggregateQuery(query schema.Query, authRw *authRewriter) []*gql.GraphQuery
 	// Add selection set to mainQuery and finalMainQuery.
 	isAggregateVarAdded := make(map[string]bool)
 	isCountVarAdded := false
+	groupsQuery := aggregateGroupsQuery(query, mainQuery)
 
 	for _, f := range query.SelectionSet() {
 		// fldName stores Name of the field f.
@@ -328,7 +569,108 @@ func aggregateQuery(query schema.Query, authRw *authRewriter) []*gql.GraphQuery
 		}
 	}
 
-	return append([]*gql.GraphQuery{finalMainQuery}, dgQuery...)
+	if groupsQuery == nil {
+		return append([]*gql.GraphQuery{finalMainQuery}, dgQuery...)
+	}
+	// The groups are returned by their own query, right after the final aggregate<Type> query.
+	// The final query still needs some aggregate for its result not to be null, even if only
+	// the groups were asked for.
+	if len(finalMainQuery.Children) == 0 {
+		mainQuery.Children = append(mainQuery.Children, &gql.GraphQuery{
+			Var:  "countVar",
+			Attr: "count(uid)",
+		})
+		finalMainQuery.Children = append(finalMainQuery.Children, &gql.GraphQuery{
+			Alias: query.Type().Name() + ".count",
+			Attr:  "max(val(countVar))",
+		})
+	}
+	return append([]*gql.GraphQuery{finalMainQuery, groupsQuery}, dgQuery...)
+}
+
+// aggregateGroupsQuery returns the query for the groups of the aggregate query, if they were
+// asked for and the query has the groupBy argument, otherwise nil. The groups are formed from
+// the nodes of mainQuery, and have the aggregates asked for in the groups. Eg:
+//
+//	PostAggregateResult.groups(func: type(Post)) @groupby(PostGroupKey.isPublished: Post.isPublished) {
+//	  PostAggregateGroup.count : count(uid)
+//	  PostAggregateGroup.numLikesMax : max(Post.numLikes)
+//	}
+func aggregateGroupsQuery(query schema.Query, mainQuery *gql.GraphQuery) *gql.GraphQuery {
+	groupBy, _ := query.ArgValue("groupBy").([]interface{})
+	if len(groupBy) == 0 {
+		return nil
+	}
+	var groupsFields []schema.Field
+	for _, f := range query.SelectionSet() {
+		if f.Name() == "groups" {
+			groupsFields = append(groupsFields, f)
+		}
+	}
+	if len(groupsFields) == 0 {
+		return nil
+	}
+
+	mainType := query.ConstructedFor()
+	groupsQuery := &gql.GraphQuery{
+		Attr:      schema.AggregateGroupsAlias(query),
+		Func:      mainQuery.Func,
+		Filter:    mainQuery.Filter,
+		IsGroupby: true,
+	}
+	isGroupedBy := make(map[string]bool)
+	for _, g := range groupBy {
+		fldName, _ := g.(string)
+		if isGroupedBy[fldName] {
+			continue
+		}
+		isGroupedBy[fldName] = true
+		groupsQuery.GroupbyAttrs = append(groupsQuery.GroupbyAttrs, gql.GroupByAttr{
+			Attr:  mainType.DgraphPredicate(fldName),
+			Alias: mainType.Name() + "GroupKey." + fldName,
+		})
+	}
+
+	// Unlike the final aggregate<Type> query, the aggregates of the groups are computed over
+	// the predicates directly, as @groupby doesn't allow value variables.
+	isAggregateAdded := make(map[string]bool)
+	for _, groups := range groupsFields {
+		for _, f := range groups.SelectionSet() {
+			if isAggregateAdded[f.DgraphAlias()] {
+				continue
+			}
+			fldName := f.Name()
+			var attr string
+			if fldName == "count" {
+				attr = "count(uid)"
+			}
+			for _, function := range []string{"Max", "Min", "Sum", "Avg"} {
+				if strings.HasSuffix(fldName, function) {
+					attr = strings.ToLower(function) + "(" + f.DgraphPredicateForAggregateField() +
+						")"
+					break
+				}
+			}
+			if attr == "" {
+				// key and __typename don't need any aggregates
+				continue
+			}
+			groupsQuery.Children = append(groupsQuery.Children, &gql.GraphQuery{
+				Alias: f.DgraphAlias(),
+				Attr:  attr,
+			})
+			isAggregateAdded[f.DgraphAlias()] = true
+		}
+	}
+	if len(groupsQuery.Children) == 0 {
+		// count the nodes of the groups, so that the @groupby block isn't empty even if only the
+		// keys of the groups were asked for
+		groupsQuery.Children = append(groupsQuery.Children, &gql.GraphQuery{
+			Alias: mainType.Name() + "AggregateGroup.count",
+			Attr:  "count(uid)",
+		})
+	}
+	return groupsQuery
 }
 
 func passwordQuery(m schema.Query, authRw *authRewriter) ([]*gql.GraphQuery, error) {
@@ -466,7 +808,8 @@ func rewriteAsQueryByIds(
 		addUIDFunc(dgQuery[0], intersection(ids, uids))
 	}
 
-	addArgumentsToField(dgQuery[0], field)
+	fv := newFilterVars(authRw)
+	addArgumentsToField(dgQuery[0], field, fv)
 
 	// The function getQueryByIds is called for passwordQuery or fetching query result types
 	// after making a mutation. In both cases, we want the selectionSet to use the `query` auth
@@ -482,6 +825,7 @@ func rewriteAsQueryByIds(
 	addCascadeDirective(dgQuery[0], field)
 
 	dgQuery = authRw.addAuthQueries(field.Type(), dgQuery, rbac)
+	dgQuery = fv.addTo(dgQuery)
 
 	if len(selectionAuth) > 0 {
 		dgQuery = append(dgQuery, selectionAuth...)
@@ -491,10 +835,10 @@ func rewriteAsQueryByIds(
 }
 
 // addArgumentsToField adds various different arguments to a field, such as
-// filter, order and pagination.
-func addArgumentsToField(dgQuery *gql.GraphQuery, field schema.Field) {
+// filter, order and pagination. The var blocks that the filter needs are added to fv.
+func addArgumentsToField(dgQuery *gql.GraphQuery, field schema.Field, fv *filterVars) {
 	filter, _ := field.ArgValue("filter").(map[string]interface{})
-	_ = addFilter(dgQuery, field.Type(), filter)
+	_ = addFilter(dgQuery, field.Type(), filter, fv)
 	addOrder(dgQuery, field)
 	addPagination(dgQuery, field)
 }
@@ -662,7 +1006,8 @@ func rewriteAsQuery(field schema.Field, authRw *authRewriter) []*gql.GraphQuery
 		return dgQuery
 	}
 
-	addArgumentsToField(dgQuery[0], field)
+	fv := newFilterVars(authRw)
+	addArgumentsToField(dgQuery[0], field, fv)
 	selectionAuth := addSelectionSetFrom(dgQuery[0], field, authRw)
 	// we don't need to query uid for auth queries, as they always have at least one field in their
 	// selection set.
@@ -672,6 +1017,7 @@ func rewriteAsQuery(field schema.Field, authRw *authRewriter) []*gql.GraphQuery
 	addCascadeDirective(dgQuery[0], field)
 
 	dgQuery = authRw.addAuthQueries(field.Type(), dgQuery, rbac)
+	dgQuery = fv.addTo(dgQuery)
 
 	if len(selectionAuth) > 0 {
 		return append(dgQuery, selectionAuth...)
@@ -816,8 +1162,11 @@ func (authRw *authRewriter) addAuthQueries(
 		}
 
 		// Adding the case of Query on interface in which None of the implementing type have
-		// Auth Query Rules, in that case, we also return simple query.
-		if typ.IsInterface() == true && implementingTypesHasAuthRules == false {
+		// Auth Query Rules, in that case, we also return simple query. The root query is still
+		// needed if the fields deeper in the query have auth rules, as their auth queries start
+		// from it.
+		if typ.IsInterface() == true && implementingTypesHasAuthRules == false &&
+			!authRw.hasAuthRules {
 			return dgQuery
 		}
 
@@ -927,6 +1276,249 @@ func passwordAuthSelector(t schema.Type) *schema.RuleNode {
 	return auth.Rules.Password
 }
 
+// fieldQueryRule returns the query rule of the @auth directive on the definition of the field.
+func fieldQueryRule(f schema.Field) *schema.RuleNode {
+	auth := f.AuthRules()
+	if auth == nil {
+		return nil
+	}
+
+	return auth.Query
+}
+
+// fieldDefQueryRule returns the query rule of the @auth directive on the field of the type with
+// the given name.
+func fieldDefQueryRule(t schema.Type, name string) *schema.RuleNode {
+	auth := t.AuthRules()
+	if auth == nil || auth.Fields[name] == nil {
+		return nil
+	}
+
+	return auth.Fields[name].Query
+}
+
+// rewriteFieldAuth builds the queries that read the field of child only for the nodes at the current
+// level which satisfy the query rule rn of the @auth directive on the field, like
+//
+//	var(func: uid(EmployeeRoot)) @filter(uid(Employee_Auth2)) {
+//	  Employee_3 as Employee.salary
+//	}
+//	Employee_Auth2 as var(func: uid(EmployeeRoot)) @cascade { ...auth query... }
+//
+// and changes child to read the value variable, so that the field is null for the other nodes.
+func (authRw *authRewriter) rewriteFieldAuth(
+	typ schema.Type,
+	rn *schema.RuleNode,
+	child *gql.GraphQuery) []*gql.GraphQuery {
+
+	ruleQueries, filter := (&authRewriter{
+		authVariables: authRw.authVariables,
+		varGen:        authRw.varGen,
+		isWritingAuth: true,
+		varName:       authRw.parentVarName,
+		selector:      authRw.selector,
+		parentVarName: authRw.parentVarName,
+		hasAuthRules:  authRw.hasAuthRules,
+	}).rewriteRuleNode(typ, rn)
+	if filter == nil {
+		return ruleQueries
+	}
+
+	valueVar := authRw.varGen.Next(typ, "", "", authRw.isWritingAuth)
+	fieldQry := &gql.GraphQuery{
+		Attr: "var",
+		Func: &gql.Function{
+			Name: "uid",
+			Args: []gql.Arg{{Value: authRw.parentVarName}},
+		},
+		Filter:   filter,
+		Children: []*gql.GraphQuery{{Var: valueVar, Attr: child.Attr}},
+	}
+	child.Attr = "val(" + valueVar + ")"
+	return append([]*gql.GraphQuery{fieldQry}, ruleQueries...)
+}
+
+// fieldRule is the rule of the @auth directive on the field of a type.
+type fieldRule struct {
+	typ   schema.Type
+	field string
+	rule  *schema.RuleNode
+}
+
+// readFieldRules appends to rules the query rules of the fields used by the filter, order or
+// aggregates of the field, or of the fields in its selection set.
+func readFieldRules(field schema.Field, rules []fieldRule) []fieldRule {
+	if field == nil || field.IsCustomHTTP() {
+		return rules
+	}
+
+	names := make(map[string]bool)
+	filter, _ := field.ArgValue("filter").(map[string]interface{})
+	filterFieldNames(filter, names)
+	orderFieldNames(field.ArgValue("order"), names)
+	if field.Type().IsAggregateResult() {
+		aggregateFieldNames(field.SelectionSet(), names)
+		for _, f := range field.SelectionSet() {
+			if f.Name() == "groups" {
+				aggregateFieldNames(f.SelectionSet(), names)
+			}
+		}
+		groupBy, _ := field.ArgValue("groupBy").([]interface{})
+		for _, g := range groupBy {
+			if name, ok := g.(string); ok {
+				names[name] = true
+			}
+		}
+	}
+	rules = namedFieldRules(field.ConstructedFor(), names, fieldDefQueryRule, rules)
+	rules = relatedFilterRules(field.ConstructedFor(), filter, rules)
+
+	for _, f := range field.SelectionSet() {
+		rules = readFieldRules(f, rules)
+	}
+	return rules
+}
+
+// aggregateFieldNames adds the names of the fields aggregated by the aggregate fields in
+// selSet to names.
+func aggregateFieldNames(selSet []schema.Field, names map[string]bool) {
+	for _, f := range selSet {
+		for _, function := range []string{"Max", "Min", "Sum", "Avg"} {
+			if strings.HasSuffix(f.Name(), function) {
+				names[strings.TrimSuffix(f.Name(), function)] = true
+			}
+		}
+	}
+}
+
+// namedFieldRules appends to rules the rules picked by selector for the fields of the type with
+// the given names.
+func namedFieldRules(
+	typ schema.Type,
+	names map[string]bool,
+	selector func(t schema.Type, name string) *schema.RuleNode,
+	rules []fieldRule) []fieldRule {
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		if rn := selector(typ, name); rn != nil {
+			rules = append(rules, fieldRule{typ: typ, field: name, rule: rn})
+		}
+	}
+	return rules
+}
+
+// checkReadRules returns an error if any of the query rules of the fields used to filter, order
+// or aggregate isn't statically satisfied. These rules are evaluated per node only when reading
+// the fields, so filtering, ordering or aggregating by the fields would leak their values.
+func checkReadRules(rules []fieldRule, authVariables map[string]interface{}) error {
+	for _, fr := range rules {
+		if fr.rule.EvaluateStatic(authVariables) != schema.Positive {
+			return errors.Errorf("field %s of type %s can't be used to filter, order or "+
+				"aggregate as @auth restricts reading it", fr.field, fr.typ.Name())
+		}
+	}
+	return nil
+}
+
+// filterFieldNames adds the names of the fields used by the filter to names.
+func filterFieldNames(filter map[string]interface{}, names map[string]bool) {
+	for key, val := range filter {
+		switch key {
+		case "and", "or":
+			switch v := val.(type) {
+			case map[string]interface{}:
+				filterFieldNames(v, names)
+			case []interface{}:
+				for _, obj := range v {
+					f, _ := obj.(map[string]interface{})
+					filterFieldNames(f, names)
+				}
+			}
+		case "not":
+			f, _ := val.(map[string]interface{})
+			filterFieldNames(f, names)
+		case "has":
+			switch v := val.(type) {
+			case string:
+				names[v] = true
+			case []interface{}:
+				for _, name := range v {
+					if n, ok := name.(string); ok {
+						names[n] = true
+					}
+				}
+			}
+		default:
+			names[key] = true
+		}
+	}
+}
+
+// relatedFilterRules appends to rules the query rules of the fields used by the filters on the
+// types related to typ, the type filtered by filter, like name in
+//
+//	filter: { author: { name: { eq: "A" } } }
+func relatedFilterRules(typ schema.Type, filter map[string]interface{},
+	rules []fieldRule) []fieldRule {
+	if typ.IsUnion() {
+		// The filters of unions don't filter by fields.
+		return rules
+	}
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch key {
+		case "and", "or":
+			switch v := filter[key].(type) {
+			case map[string]interface{}:
+				rules = relatedFilterRules(typ, v, rules)
+			case []interface{}:
+				for _, obj := range v {
+					f, _ := obj.(map[string]interface{})
+					rules = relatedFilterRules(typ, f, rules)
+				}
+			}
+		case "not":
+			f, _ := filter[key].(map[string]interface{})
+			rules = relatedFilterRules(typ, f, rules)
+		case "has":
+		default:
+			related, ok := filter[key].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fld := typ.Field(key)
+			if fld.Type().IsInbuiltOrEnumType() || fld.Type().IsUnion() {
+				continue
+			}
+			names := make(map[string]bool)
+			filterFieldNames(related, names)
+			rules = namedFieldRules(fld.Type(), names, fieldDefQueryRule, rules)
+			rules = relatedFilterRules(fld.Type(), related, rules)
+		}
+	}
+	return rules
+}
+
+// orderFieldNames adds the names of the fields used by the order to names.
+func orderFieldNames(order interface{}, names map[string]bool) {
+	for o, ok := order.(map[string]interface{}); ok; o, ok = o["then"].(map[string]interface{}) {
+		for _, dir := range []string{"asc", "desc"} {
+			if name, ok := o[dir].(string); ok {
+				names[name] = true
+			}
+		}
+	}
+}
+
 func (authRw *authRewriter) rewriteAuthQueries(typ schema.Type) ([]*gql.GraphQuery, *gql.FilterTree) {
 	if authRw == nil || authRw.isWritingAuth {
 		return nil, nil
@@ -1028,7 +1620,8 @@ func (authRw *authRewriter) rewriteRuleNode(
 			r1[0].Cascade = append(r1[0].Cascade, "__all__")
 		}
 
-		return []*gql.GraphQuery{r1[0]}, &gql.FilterTree{
+		// the rest are the var blocks of the filters on related types in the rule
+		return r1, &gql.FilterTree{
 			Func: &gql.Function{
 				Name: "uid",
 				Args: []gql.Arg{{Value: varName}},
@@ -1164,8 +1757,11 @@ func buildAggregateFields(
 
 	// Filter for aggregate Fields. This is added to all count aggregate fields
 	// and mainField
+	fv := newFilterVars(auth)
 	fieldFilter, _ := f.ArgValue("filter").(map[string]interface{})
-	_ = addFilter(mainField, constructedForType, fieldFilter)
+	_ = addFilter(mainField, constructedForType, fieldFilter, fv)
+	// the count fields share the filter, and so the var blocks it needs
+	countFilter := mainField.Filter
 
 	// Add type filter in case the Dgraph predicate for which the aggregate
 	// field belongs to is a reverse edge
@@ -1189,7 +1785,7 @@ func buildAggregateFields(
 				Attr:  "count(" + constructedForDgraphPredicate + ")",
 			}
 			// Add filter to count aggregation field.
-			_ = addFilter(aggregateChild, constructedForType, fieldFilter)
+			aggregateChild.Filter = countFilter
 
 			// Add type filter in case the Dgraph predicate for which the aggregate
 			// field belongs to is a reverse edge
@@ -1295,6 +1891,9 @@ func buildAggregateFields(
 	// otherAggregation Children are appended to aggregationChildren to return them.
 	// This step is performed at the end to ensure that auth and other filters are
 	// not added to them.
+	if len(aggregateChildren) > 0 {
+		retAuthQueries = append(retAuthQueries, fv.queries...)
+	}
 	aggregateChildren = append(aggregateChildren, otherAggregateChildren...)
 	retAuthQueries = append(retAuthQueries, fieldAuth...)
 	return aggregateChildren, retAuthQueries
@@ -1392,9 +1991,22 @@ func addSelectionSetFrom(
 			child.Attr = f.DgraphPredicate()
 		}
 
+		// The fields with a query rule in @auth on their definition are null for the nodes
+		// which don't satisfy the rule.
+		if rn := fieldQueryRule(f); rn != nil && !auth.isWritingAuth {
+			switch rn.EvaluateStatic(auth.authVariables) {
+			case schema.Negative:
+				fieldAdded[f.DgraphAlias()] = true
+				continue
+			case schema.Uncertain:
+				authQueries = append(authQueries, auth.rewriteFieldAuth(field.Type(), rn, child)...)
+			}
+		}
+
 		filter, _ := f.ArgValue("filter").(map[string]interface{})
+		fv := newFilterVars(auth)
 		// if this field has been filtered out by the filter, then don't add it in DQL query
-		if includeField := addFilter(child, f.Type(), filter); !includeField {
+		if includeField := addFilter(child, f.Type(), filter, fv); !includeField {
 			continue
 		}
 
@@ -1485,6 +2097,7 @@ func addSelectionSetFrom(
 			}
 			authQueries = append(authQueries, commonAuthQueryVars.parentQry, commonAuthQueryVars.selectionQry)
 		}
+		authQueries = append(authQueries, fv.queries...)
 		authQueries = append(authQueries, selectionAuth...)
 		authQueries = append(authQueries, fieldAuth...)
 		restoreAuthState()
@@ -1501,8 +2114,13 @@ func addSelectionSetFrom(
 	// Add fields required by other custom fields which haven't already been added as a
 	// child to be fetched from Dgraph.
 	for _, dgAlias := range rfset {
+		f := requiredFields[dgAlias]
+		if rn := fieldDefQueryRule(f.ParentType(), f.Name()); rn != nil && !auth.isWritingAuth &&
+			rn.EvaluateStatic(auth.authVariables) != schema.Positive {
+			// The field isn't readable for all the nodes, so it isn't sent to custom fields.
+			continue
+		}
 		if !fieldAdded[dgAlias] {
-			f := requiredFields[dgAlias]
 			child := &gql.GraphQuery{
 				Alias: f.DgraphAlias(),
 			}
@@ -1599,10 +2217,151 @@ func idFilter(filter map[string]interface{}, idField schema.FieldDefinition) []u
 	return convertIDs(idsSlice)
 }
 
+// filterVars collects the var blocks needed by filters on the fields that link to related types.
+// A filter on Post like
+//
+//	filter: { author: { name: { eq: "A" } } }
+//
+// finds the matching authors in a var block of their own
+//
+//	Author_1 as var(func: type(Author)) @filter(eq(Author.name, "A"))
+//
+// and is itself rewritten into
+//
+//	@filter(uid_in(Post.author, uid(Author_1)))
+//
+// Filtering like that, rather than with @cascade on the query, keeps first and offset working
+// on just the nodes that match the filter.
+type filterVars struct {
+	varGen  *VariableGenerator
+	queries []*gql.GraphQuery
+	// authRw is the auth rewriter of the query being filtered, if any. The related nodes are
+	// restricted to the ones that the query rules of their type let the user read.
+	authRw *authRewriter
+}
+
+func newFilterVars(authRw *authRewriter) *filterVars {
+	if authRw == nil || authRw.varGen == nil {
+		return &filterVars{varGen: NewVariableGenerator()}
+	}
+	return &filterVars{varGen: authRw.varGen, authRw: authRw}
+}
+
+// addTo appends the collected var blocks to dgQuery, the query that uses them in its filters.
+// A query that was found to be empty while rewriting it has no filters left, so it is returned
+// as it is.
+func (fv *filterVars) addTo(dgQuery []*gql.GraphQuery) []*gql.GraphQuery {
+	if len(dgQuery) == 0 || strings.HasSuffix(dgQuery[0].Attr, "()") {
+		return dgQuery
+	}
+	return append(dgQuery, fv.queries...)
+}
+
+// relatedFilter builds the filter for fld, a field of typ linking to another type, out of
+// filter, the filter given for that other type.
+func (fv *filterVars) relatedFilter(typ schema.Type, fld schema.FieldDefinition,
+	filter map[string]interface{}) *gql.FilterTree {
+	related := fld.Type()
+	relatedVar := fv.varGen.Next(related, "", "", false)
+	relatedQry := &gql.GraphQuery{
+		Var:  relatedVar,
+		Attr: "var",
+		Func: buildTypeFunc(related.DgraphName()),
+	}
+	_ = addFilter(relatedQry, related, filter, fv)
+	fv.addAuthorized(related, relatedQry)
+
+	pred := typ.DgraphPredicate(fld.Name())
+	if !strings.HasPrefix(pred, "~") {
+		return &gql.FilterTree{
+			Func: &gql.Function{
+				Name: "uid_in",
+				Args: []gql.Arg{{Value: pred}, {Value: "uid(" + relatedVar + ")"}},
+			},
+		}
+	}
+
+	// uid_in doesn't work on reverse edges, so the nodes of typ are found by following the
+	// forward edge from the related nodes:
+	//  var(func: uid(Author_1)) { Post_2 as Author.posts }
+	linkedVar := fv.varGen.Next(typ, "", "", false)
+	fv.queries = append(fv.queries, &gql.GraphQuery{
+		Attr: "var",
+		Func: &gql.Function{
+			Name: "uid",
+			Args: []gql.Arg{{Value: relatedVar}},
+		},
+		Children: []*gql.GraphQuery{{
+			Var:  linkedVar,
+			Attr: strings.TrimPrefix(pred, "~"),
+		}},
+	})
+	return &gql.FilterTree{
+		Func: &gql.Function{
+			Name: "uid",
+			Args: []gql.Arg{{Value: linkedVar}},
+		},
+	}
+}
+
+// addAuthorized adds the var block qry, that finds the nodes of typ matching a filter, restricted
+// to the nodes that the query rules of typ let the user read, so that the filter doesn't leak the
+// values of the nodes that the user can't read.
+//
+//	Author_1 as var(func: uid(Author_2)) @filter(uid(Author_Auth3))
+//	Author_2 as var(func: type(Author)) @filter(eq(Author.name, "A"))
+//	Author_Auth3 as var(func: uid(Author_2)) @filter(eq(Author.dob, "...")) @cascade
+func (fv *filterVars) addAuthorized(typ schema.Type, qry *gql.GraphQuery) {
+	// The var blocks of auth rules are not themselves restricted by auth rules.
+	if fv.authRw == nil || fv.authRw.isWritingAuth || queryAuthSelector(typ) == nil {
+		fv.queries = append(fv.queries, qry)
+		return
+	}
+
+	authRw := &authRewriter{
+		authVariables: fv.authRw.authVariables,
+		varGen:        fv.varGen,
+		selector:      queryAuthSelector,
+	}
+	switch authRw.evaluateStaticRules(typ) {
+	case schema.Positive:
+		fv.queries = append(fv.queries, qry)
+		return
+	case schema.Negative:
+		// None of the nodes can be read, so none of them match the filter.
+		qry.Func = &gql.Function{Name: "uid"}
+		qry.Filter = nil
+		fv.queries = append(fv.queries, qry)
+		return
+	}
+
+	authRw.varName = fv.varGen.Next(typ, "", "", false)
+	authQueries, authFilter := authRw.rewriteAuthQueries(typ)
+	if authFilter == nil {
+		fv.queries = append(fv.queries, qry)
+		return
+	}
+	filterQry := &gql.GraphQuery{
+		Var:    authRw.varName,
+		Attr:   "var",
+		Func:   qry.Func,
+		Filter: qry.Filter,
+	}
+	qry.Func = &gql.Function{
+		Name: "uid",
+		Args: []gql.Arg{{Value: authRw.varName}},
+	}
+	qry.Filter = authFilter
+	fv.queries = append(fv.queries, qry, filterQry)
+	fv.queries = append(fv.queries, authQueries...)
+}
+
 // addFilter adds a filter to the input DQL query. It returns false if the field for which the
 // filter was specified should not be included in the DQL query.
 // Currently, it would only be false for a union field when no memberTypes are queried.
-func addFilter(q *gql.GraphQuery, typ schema.Type, filter map[string]interface{}) bool {
+// The var blocks needed by filters on related types are added to fv.
+func addFilter(q *gql.GraphQuery, typ schema.Type, filter map[string]interface{},
+	fv *filterVars) bool {
 	if len(filter) == 0 {
 		return true
 	}
@@ -1626,13 +2385,13 @@ func addFilter(q *gql.GraphQuery, typ schema.Type, filter map[string]interface{}
 	}
 
 	if typ.IsUnion() {
-		if filter, includeField := buildUnionFilter(typ, filter); includeField {
+		if filter, includeField := buildUnionFilter(typ, filter, fv); includeField {
 			q.Filter = filter
 		} else {
 			return false
 		}
 	} else {
-		q.Filter = buildFilter(typ, filter)
+		q.Filter = buildFilter(typ, filter, fv)
 	}
 	if filterAtRoot {
 		addTypeFilter(q, typ)
@@ -1666,7 +2425,7 @@ func addFilter(q *gql.GraphQuery, typ schema.Type, filter map[string]interface{}
 // ATM those will probably generate junk that might cause a Dgraph error.  And
 // bubble back to the user as a GraphQL error when the query fails. Really,
 // they should fail query validation and never get here.
-func buildFilter(typ schema.Type, filter map[string]interface{}) *gql.FilterTree {
+func buildFilter(typ schema.Type, filter map[string]interface{}, fv *filterVars) *gql.FilterTree {
 
 	var ands []*gql.FilterTree
 	var or *gql.FilterTree
@@ -1701,11 +2460,11 @@ func buildFilter(typ schema.Type, filter map[string]interface{}) *gql.FilterTree
 			// ... and: [{}]
 			switch v := filter[field].(type) {
 			case map[string]interface{}:
-				ft := buildFilter(typ, v)
+				ft := buildFilter(typ, v, fv)
 				ands = append(ands, ft)
 			case []interface{}:
 				for _, obj := range v {
-					ft := buildFilter(typ, obj.(map[string]interface{}))
+					ft := buildFilter(typ, obj.(map[string]interface{}), fv)
 					ands = append(ands, ft)
 				}
 			}
@@ -1721,11 +2480,11 @@ func buildFilter(typ schema.Type, filter map[string]interface{}) *gql.FilterTree
 			// ... or: [{}]
 			switch v := filter[field].(type) {
 			case map[string]interface{}:
-				or = buildFilter(typ, v)
+				or = buildFilter(typ, v, fv)
 			case []interface{}:
 				ors := make([]*gql.FilterTree, 0, len(v))
 				for _, obj := range v {
-					ft := buildFilter(typ, obj.(map[string]interface{}))
+					ft := buildFilter(typ, obj.(map[string]interface{}), fv)
 					ors = append(ors, ft)
 				}
 				or = &gql.FilterTree{
@@ -1738,13 +2497,23 @@ func buildFilter(typ schema.Type, filter map[string]interface{}) *gql.FilterTree
 			//                       we are here ^^
 			// ->
 			// @filter(anyofterms(Post.title, "GraphQL") AND NOT eq(Post.isPublished, true))
-			not := buildFilter(typ, filter[field].(map[string]interface{}))
+			not := buildFilter(typ, filter[field].(map[string]interface{}), fv)
 			ands = append(ands,
 				&gql.FilterTree{
 					Op:    "not",
 					Child: []*gql.FilterTree{not},
 				})
 		default:
+			// It's a filter on a field linking to another type like:
+			// author: { name: { eq: "A" } } -> uid_in(Post.author, uid(Author_1))
+			if related, ok := filter[field].(map[string]interface{}); ok {
+				if fld := typ.Field(field); !fld.Type().IsInbuiltOrEnumType() &&
+					!fld.Type().IsUnion() {
+					ands = append(ands, fv.relatedFilter(typ, fld, related))
+					continue
+				}
+			}
+
 			//// It's a base case like:
 			//// title: { anyofterms: "GraphQL" } ->  anyofterms(Post.title: "GraphQL")
 			//// numLikes: { between : { min : 10,  max:100 }}
@@ -1761,7 +2530,7 @@ func buildFilter(typ schema.Type, filter map[string]interface{}) *gql.FilterTree
 					// the filters with null values will be ignored in query rewriting.
 					if fn == "eq" {
 						hasFilterMap := map[string]interface{}{"not": map[string]interface{}{"has": []interface{}{field}}}
-						ands = append(ands, buildFilter(typ, hasFilterMap))
+						ands = append(ands, buildFilter(typ, hasFilterMap, fv))
 					}
 					continue
 				}
@@ -1984,7 +2753,8 @@ func buildMultiPolygon(multipolygon map[string]interface{}, buf *bytes.Buffer) {
 	x.Check2(buf.WriteString("]"))
 }
 
-func buildUnionFilter(typ schema.Type, filter map[string]interface{}) (*gql.FilterTree, bool) {
+func buildUnionFilter(typ schema.Type, filter map[string]interface{},
+	fv *filterVars) (*gql.FilterTree, bool) {
 	memberTypesList, ok := filter["memberTypes"].([]interface{})
 	// if memberTypes was specified to be an empty list like: { memberTypes: [], ...},
 	// then we don't need to include the field, on which the filter was specified, in the query.
@@ -2011,7 +2781,7 @@ func buildUnionFilter(typ schema.Type, filter map[string]interface{}) (*gql.Filt
 				Op: "and",
 				Child: []*gql.FilterTree{
 					{Func: buildTypeFunc(memberType.DgraphName())},
-					buildFilter(memberType, memberTypeFilter),
+					buildFilter(memberType, memberTypeFilter, fv),
 				},
 			}
 		}
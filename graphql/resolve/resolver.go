@@ -19,6 +19,7 @@ package resolve
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"sort"
 	"strings"
@@ -238,6 +239,7 @@ func (rf *resolverFactory) WithConventionResolvers(
 	queries := append(s.Queries(schema.GetQuery), s.Queries(schema.FilterQuery)...)
 	queries = append(queries, s.Queries(schema.PasswordQuery)...)
 	queries = append(queries, s.Queries(schema.AggregateQuery)...)
+	queries = append(queries, s.Queries(schema.ConnectionQuery)...)
 	for _, q := range queries {
 		rf.WithQueryResolver(q, func(q schema.Query) QueryResolver {
 			return NewQueryResolver(fns.Qrw, fns.Ex)
@@ -352,44 +354,36 @@ func entitiesQueryCompletion(ctx context.Context, resolved *Resolved) {
 		resolved.Err = schema.AppendGQLErrs(resolved.Err, err)
 		return
 	}
-	keyFieldType := repr.KeyField.Type().Name()
 
 	// store the index of the keyField Values present in the argument in a map.
-	// key in the map is of type interface because there are multiple types like String,
-	// Int, Int64 allowed as @id. There could be duplicate keys in the representations
-	// so the value of map is a list of integers containing all the indices for a key.
-	indexMap := make(map[interface{}][]int)
-	uniqueKeyList := make([]interface{}, 0)
-	for i, key := range repr.KeyVals {
+	// key in the map is the string formatted version of the key field values, because there
+	// are multiple types like String, Int, Int64 allowed as @id, and compound keys have more
+	// than one value. There could be duplicate keys in the representations so the value of
+	// map is a list of integers containing all the indices for a key.
+	indexMap := make(map[string][]int)
+	uniqueKeyList := make([][]interface{}, 0)
+	for i, keyVals := range repr.KeyVals {
+		key := entityKey(keyVals)
+		if len(indexMap[key]) == 0 {
+			uniqueKeyList = append(uniqueKeyList, keyVals)
+		}
 		indexMap[key] = append(indexMap[key], i)
 	}
 
-	// Create a list containing unique keys and then sort in ascending order because this
-	// will be the order in which the data is received.
+	// Sort the list containing unique keys in ascending order because this will be the order
+	// in which the data is received.
 	// for eg: for keys: {1, 2, 4, 1, 3} is converted into {1, 2, 4, 3} and then {1, 2, 3, 4}
 	// this will be the order of received data from the dgraph.
-	for k := range indexMap {
-		uniqueKeyList = append(uniqueKeyList, k)
-	}
+	// Compound keys are ordered by their first field, then their second field, and so on.
 	sort.Slice(uniqueKeyList, func(i, j int) bool {
-		switch val := uniqueKeyList[i].(type) {
-		case string:
-			return val < uniqueKeyList[j].(string)
-		case json.Number:
-			switch keyFieldType {
-			case "Int", "Int64":
-				val1, _ := val.Int64()
-				val2, _ := uniqueKeyList[j].(json.Number).Int64()
-				return val1 < val2
-			case "Float":
-				val1, _ := val.Float64()
-				val2, _ := uniqueKeyList[j].(json.Number).Float64()
-				return val1 < val2
+		for k, keyField := range repr.KeyFields {
+			keyFieldType := keyField.Type().Name()
+			if lessKeyVal(uniqueKeyList[i][k], uniqueKeyList[j][k], keyFieldType) {
+				return true
+			}
+			if lessKeyVal(uniqueKeyList[j][k], uniqueKeyList[i][k], keyFieldType) {
+				return false
 			}
-		case int64:
-			return val < uniqueKeyList[j].(int64)
-		case float64:
-			return val < uniqueKeyList[j].(float64)
 		}
 		return false
 	})
@@ -406,8 +400,8 @@ func entitiesQueryCompletion(ctx context.Context, resolved *Resolved) {
 
 	// Reorder the output response according to the order of the keys in the representations argument.
 	output := make([]interface{}, len(repr.KeyVals))
-	for i, key := range uniqueKeyList {
-		for _, idx := range indexMap[key] {
+	for i, keyVals := range uniqueKeyList {
+		for _, idx := range indexMap[entityKey(keyVals)] {
 			output[idx] = entitiesQryResp[i]
 		}
 	}
@@ -421,6 +415,39 @@ func entitiesQueryCompletion(ctx context.Context, resolved *Resolved) {
 
 }
 
+// entityKey returns the string formatted version of the key field values of a representation.
+func entityKey(keyVals []interface{}) string {
+	keyValStrs := make([]string, 0, len(keyVals))
+	for _, keyVal := range keyVals {
+		keyValStrs = append(keyValStrs, fmt.Sprint(keyVal))
+	}
+	return schema.RepresentationKey(keyValStrs)
+}
+
+// lessKeyVal returns true if the key field value val1 comes before val2 in ascending order.
+func lessKeyVal(val1, val2 interface{}, keyFieldType string) bool {
+	switch val := val1.(type) {
+	case string:
+		return val < val2.(string)
+	case json.Number:
+		switch keyFieldType {
+		case "Int", "Int64":
+			v1, _ := val.Int64()
+			v2, _ := val2.(json.Number).Int64()
+			return v1 < v2
+		case "Float":
+			v1, _ := val.Float64()
+			v2, _ := val2.(json.Number).Float64()
+			return v1 < v2
+		}
+	case int64:
+		return val < val2.(int64)
+	case float64:
+		return val < val2.(float64)
+	}
+	return false
+}
+
 // noopCompletion just passes back it's result and err arguments
 func noopCompletion(ctx context.Context, resolved *Resolved) {}
 
@@ -503,6 +530,7 @@ func (r *RequestResolver) Resolve(ctx context.Context, gqlReq *schema.Request) (
 	}
 
 	ctx = x.AttachJWTNamespace(ctx)
+	ctx = schema.WithCustomHTTPDedup(ctx)
 	op, err := r.schema.Operation(gqlReq)
 	if err != nil {
 		resp.Errors = schema.AsGQLErrors(err)
@@ -709,8 +737,8 @@ func (hr *httpResolver) rewriteAndExecute(ctx context.Context, field schema.Fiel
 		hrc.Template = schema.GetBodyForLambda(ctx, field, nil, hrc.Template)
 	}
 
-	fieldData, errs, hardErrs := hrc.MakeAndDecodeHTTPRequest(hr.Client, hrc.URL, hrc.Template,
-		field)
+	fieldData, errs, hardErrs := hrc.MakeAndDecodeHTTPRequest(ctx, hr.Client, hrc.URL,
+		hrc.Template, field)
 	if hardErrs != nil {
 		// Not using EmptyResult() here as we don't want to wrap the errors returned from remote
 		// endpoints
@@ -299,6 +299,21 @@ func TestValueCoercion(t *testing.T) {
 			GQLQuery: `query { getAuthor(id: "0x1") { dob } }`,
 			Response: `{ "getAuthor": { "dob": "2012-11-01T22:08:41+05:30" }}`,
 			Expected: `{ "getAuthor": { "dob": "2012-11-01T22:08:41+05:30" }}`},
+
+		// test custom scalars are coerced like their base type
+		{Name: "object value should be coerced to JSON custom scalar",
+			GQLQuery: `query { getCustomer(id: "0x1") { metadata } }`,
+			Response: `{ "getCustomer": { "metadata": {"tier": "gold"} }}`,
+			Expected: `{ "getCustomer": { "metadata": {"tier": "gold"} }}`},
+		{Name: "out of range value should raise an error when coerced to Int custom scalar",
+			GQLQuery: `query { getCustomer(id: "0x1") { rating } }`,
+			Response: `{ "getCustomer": { "rating": 2147483648 }}`,
+			Errors: x.GqlErrorList{{
+				Message:   "Error coercing value '2147483648' for field 'rating' to type Rating.",
+				Locations: []x.Location{x.Location{Line: 1, Column: 34}},
+				Path:      []interface{}{"getCustomer", "rating"},
+			}},
+			Expected: `{ "getCustomer": { "rating": null }}`},
 	}
 
 	gqlSchema := test.LoadSchemaFromFile(t, "schema.graphql")
@@ -17,8 +17,10 @@
 package resolve
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 
 	"github.com/golang/glog"
@@ -36,6 +38,28 @@ type webhookPayload struct {
 	Event      eventPayload       `json:"event"`
 }
 
+type scalarValidationPayload struct {
+	Resolver   string             `json:"resolver"`
+	AccessJWT  string             `json:"X-Dgraph-AccessToken,omitempty"`
+	AuthHeader *authHeaderPayload `json:"authHeader,omitempty"`
+	Typename   string             `json:"__typename"`
+	Value      interface{}        `json:"value"`
+}
+
+type beforeMutatePayload struct {
+	Resolver   string             `json:"resolver"`
+	AccessJWT  string             `json:"X-Dgraph-AccessToken,omitempty"`
+	AuthHeader *authHeaderPayload `json:"authHeader,omitempty"`
+	Event      beforeMutateEvent  `json:"event"`
+}
+
+type beforeMutateEvent struct {
+	Typename  string                 `json:"__typename"`
+	Operation schema.MutationType    `json:"operation"`
+	Args      map[string]interface{} `json:"args"`
+	StartTs   uint64                 `json:"startTs,omitempty"`
+}
+
 type authHeaderPayload struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
@@ -115,10 +139,9 @@ func sendWebhookEvent(ctx context.Context, m schema.Mutation, commitTs uint64, r
 	}
 
 	// send the request
-	ns, _ := x.ExtractNamespace(ctx)
 	headers := http.Header{}
 	headers.Set("Content-Type", "application/json")
-	resp, err := schema.MakeHttpRequest(nil, http.MethodPost, x.LambdaUrl(ns), headers, b)
+	resp, err := schema.MakeLambdaRequest(ctx, headers, b)
 
 	// just log the response errors, if any.
 	if err != nil {
@@ -128,3 +151,210 @@ func sendWebhookEvent(ctx context.Context, m schema.Mutation, commitTs uint64, r
 		glog.V(3).Info(errors.Errorf("got unsuccessful status from webhook: %s", resp.Status))
 	}
 }
+
+// validateScalarWithLambda sends a value given for the field fieldDef of the custom scalar cs to
+// the lambda URL configured with Alpha to be validated. The lambda server answers with false or a
+// string explaining why the value is invalid, and with anything else if the value is valid.
+func validateScalarWithLambda(ctx context.Context, fieldDef schema.FieldDefinition,
+	cs *schema.CustomScalar, val interface{}) error {
+	accessJWT, _ := x.ExtractJwt(ctx)
+	payload := scalarValidationPayload{
+		Resolver:  "$validate",
+		AccessJWT: accessJWT,
+		Typename:  cs.Name,
+		Value:     val,
+	}
+	if fieldDef.GetAuthMeta() != nil {
+		payload.AuthHeader = &authHeaderPayload{
+			Key:   fieldDef.GetAuthMeta().GetHeader(),
+			Value: authorization.GetJwtToken(ctx),
+		}
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling validation payload")
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	resp, err := schema.MakeLambdaRequest(ctx, headers, b)
+	if err != nil {
+		return errors.Wrap(err, "unable to reach the lambda validator")
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "unable to read the lambda validator response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("got unsuccessful status from the lambda validator: %s", resp.Status)
+	}
+
+	var result interface{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &result); err != nil {
+			return errors.Wrap(err, "unable to decode the lambda validator response")
+		}
+	}
+	switch result := result.(type) {
+	case string:
+		return errors.New(result)
+	case bool:
+		if !result {
+			return errors.New("rejected by the lambda validator")
+		}
+	}
+	return nil
+}
+
+// callBeforeMutateHook sends the arguments of a mutation configured with the @lambdaBeforeMutate
+// directive to the lambda URL configured with Alpha, along with the start ts of the transaction
+// of the mutation, if its existence queries have started it already. The lambda server answers
+// with false or a string explaining why the mutation is rejected, with an object like
+// {"args": {...}} to replace some of the arguments of the mutation, or with anything else to let
+// the mutation go through as it is. It returns whether the arguments were replaced. As the
+// transaction is only committed after the hook has answered, nothing gets written when the hook
+// rejects the mutation, or when it can't be reached.
+func callBeforeMutateHook(ctx context.Context, m schema.Mutation, startTs uint64) (bool, error) {
+	accessJWT, _ := x.ExtractJwt(ctx)
+	payload := beforeMutatePayload{
+		Resolver:  "$beforeMutate",
+		AccessJWT: accessJWT,
+		Event: beforeMutateEvent{
+			Typename:  m.MutatedType().Name(),
+			Operation: m.MutationType(),
+			Args:      m.Arguments(),
+			StartTs:   startTs,
+		},
+	}
+	if m.GetAuthMeta() != nil {
+		payload.AuthHeader = &authHeaderPayload{
+			Key:   m.GetAuthMeta().GetHeader(),
+			Value: authorization.GetJwtToken(ctx),
+		}
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return false, errors.Wrap(err, "error marshalling before mutate hook payload")
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	resp, err := schema.MakeLambdaRequest(ctx, headers, b)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to reach the before mutate hook")
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to read the before mutate hook response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, errors.Errorf("got unsuccessful status from the before mutate hook: %s",
+			resp.Status)
+	}
+
+	var result interface{}
+	if len(body) > 0 {
+		// Decode the numbers as json.Number, like the numbers in the variables of a request.
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&result); err != nil {
+			return false, errors.Wrap(err, "unable to decode the before mutate hook response")
+		}
+	}
+	switch result := result.(type) {
+	case string:
+		return false, errors.New(result)
+	case bool:
+		if !result {
+			return false, errors.New("rejected by the before mutate hook")
+		}
+	case map[string]interface{}:
+		if args, ok := result["args"].(map[string]interface{}); ok {
+			if err := replaceMutationArgs(m, args); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// replaceMutationArgs replaces the arguments of the mutation with the ones that the before mutate
+// hook answered with. Only the arguments that the mutation was called with can be replaced, with
+// values that are valid as per the schema and that keep the @id fields and the filters that pick
+// the nodes the mutation acts on. The mutation is rejected if any of the arguments isn't valid,
+// and none of them is replaced then.
+func replaceMutationArgs(m schema.Mutation, args map[string]interface{}) error {
+	replaced := make(map[string]interface{}, len(m.Arguments()))
+	for name, val := range m.Arguments() {
+		replaced[name] = val
+	}
+	for name, val := range args {
+		if _, ok := m.Arguments()[name]; !ok {
+			return errors.Errorf("the before mutate hook replaced the argument %s, which the "+
+				"mutation wasn't called with", name)
+		}
+		if err := m.ValidateArgValue(name, val); err != nil {
+			return errors.Wrapf(err, "the before mutate hook replaced the argument %s with an "+
+				"invalid value", name)
+		}
+		replaced[name] = val
+	}
+
+	keys, err := mutatedNodeKeys(m, m.Arguments())
+	if err != nil {
+		return err
+	}
+	replacedKeys, err := mutatedNodeKeys(m, replaced)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(keys, replacedKeys) {
+		return errors.New("the before mutate hook can't change the @id fields or the filter " +
+			"of the mutation")
+	}
+
+	for name, val := range args {
+		m.SetArgTo(name, val)
+	}
+	return nil
+}
+
+// mutatedNodeKeys returns the JSON of what picks the nodes that a mutation with the arguments
+// acts on: the values of the @id fields of the objects that it adds or sets, and the filter of
+// the nodes that it updates or deletes.
+func mutatedNodeKeys(m schema.Mutation, args map[string]interface{}) ([]byte, error) {
+	xids := func(objs interface{}) []map[string]interface{} {
+		if obj, ok := objs.(map[string]interface{}); ok {
+			objs = []interface{}{obj}
+		}
+		list, _ := objs.([]interface{})
+		res := make([]map[string]interface{}, 0, len(list))
+		for _, obj := range list {
+			obj, _ := obj.(map[string]interface{})
+			values := make(map[string]interface{})
+			for _, xid := range m.MutatedType().XIDFields() {
+				values[xid.Name()] = obj[xid.Name()]
+			}
+			res = append(res, values)
+		}
+		return res
+	}
+
+	keys := make(map[string]interface{})
+	switch m.MutationType() {
+	case schema.AddMutation:
+		keys["input"] = xids(args[schema.InputArgName])
+	case schema.UpdateMutation:
+		input, _ := args[schema.InputArgName].(map[string]interface{})
+		keys["filter"] = input["filter"]
+		keys["set"] = xids(input["set"])
+	case schema.DeleteMutation:
+		keys["filter"] = args["filter"]
+	}
+	return json.Marshal(keys)
+}
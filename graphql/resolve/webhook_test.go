@@ -0,0 +1,248 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resolve
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dgoapi "github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/dgraph-io/ristretto/z"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/graphql/schema"
+	"github.com/vtta/dgraph/graphql/test"
+	"github.com/vtta/dgraph/x"
+)
+
+// mutationRecorder is an executor that records the mutations it gets, and the number of queries
+// that it gets in the transaction of the mutations. Like Dgraph, it starts the transaction of a
+// request that isn't read-only if it has no start ts.
+type mutationRecorder struct {
+	*executor
+	mutations []*dgoapi.Mutation
+	queries   int
+}
+
+const recorderStartTs = 5
+
+func (mr *mutationRecorder) Execute(ctx context.Context, req *dgoapi.Request,
+	field schema.Field) (*dgoapi.Response, error) {
+	if !req.ReadOnly {
+		if req.StartTs == 0 {
+			req.StartTs = recorderStartTs
+		}
+		if len(req.Mutations) == 0 {
+			mr.queries++
+		}
+	}
+	mr.mutations = append(mr.mutations, req.Mutations...)
+	return mr.executor.Execute(ctx, req, field)
+}
+
+func TestBeforeMutateHook(t *testing.T) {
+	var events []map[string]interface{}
+	var hookResponse string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(b, &payload))
+		require.Equal(t, "$beforeMutate", payload["resolver"])
+		events = append(events, payload["event"].(map[string]interface{}))
+		if hookResponse == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(hookResponse))
+	}))
+	defer ts.Close()
+
+	graphqlConfig := x.Config.GraphQL
+	defer func() { x.Config.GraphQL = graphqlConfig }()
+	x.Config.GraphQL = z.NewSuperFlag("lambda-url=" + ts.URL + ";").
+		MergeAndCheckDefault("lambda-url=; lambda-embedded=false;")
+
+	gqlSchema := test.LoadSchemaFromString(t, `
+		type Post @lambdaBeforeMutate(add: true) {
+			id: ID!
+			slug: String! @id
+			title: String!
+			rating: Int
+		}`)
+	addPost := `mutation {
+		addPost(input: [{slug: "a-post", title: "a post"}]) {
+			post { title }
+		}
+	}`
+
+	tests := []struct {
+		name         string
+		mutation     string
+		hookResponse string
+		// setJSON is what the title is set to in the mutation sent to Dgraph. The mutation
+		// isn't sent if it is empty.
+		setJSON string
+		events  int
+		// queries is the number of times the existence queries are run in the transaction.
+		queries int
+		errors  x.GqlErrorList
+	}{
+		{
+			name:         "hook rejects the mutation with a message",
+			mutation:     addPost,
+			hookResponse: `"the title must be in upper case"`,
+			events:       1,
+			queries:      1,
+			errors: x.GqlErrorList{{Message: "mutation addPost failed because " +
+				"the title must be in upper case",
+				Locations: []x.Location{{Line: 2, Column: 3}},
+				Path:      []interface{}{"addPost"}}},
+		},
+		{
+			name:         "hook rejects the mutation with false",
+			mutation:     addPost,
+			hookResponse: `false`,
+			events:       1,
+			queries:      1,
+			errors: x.GqlErrorList{{Message: "mutation addPost failed because " +
+				"rejected by the before mutate hook",
+				Locations: []x.Location{{Line: 2, Column: 3}},
+				Path:      []interface{}{"addPost"}}},
+		},
+		{
+			name:     "mutation fails when the hook fails",
+			mutation: addPost,
+			events:   1,
+			queries:  1,
+			errors: x.GqlErrorList{{Message: "mutation addPost failed because " +
+				"got unsuccessful status from the before mutate hook: 500 Internal Server Error",
+				Locations: []x.Location{{Line: 2, Column: 3}},
+				Path:      []interface{}{"addPost"}}},
+		},
+		{
+			name:         "hook replaces the input",
+			mutation:     addPost,
+			hookResponse: `{"args": {"input": [{"slug": "a-post", "title": "A POST", "rating": 5}]}}`,
+			setJSON:      `"Post.title":"A POST"`,
+			events:       1,
+			queries:      2,
+		},
+		{
+			name:         "hook can't add arguments",
+			mutation:     addPost,
+			hookResponse: `{"args": {"upsert": true}}`,
+			events:       1,
+			queries:      1,
+			errors: x.GqlErrorList{{Message: "mutation addPost failed because " +
+				"the before mutate hook replaced the argument upsert, which the mutation " +
+				"wasn't called with",
+				Locations: []x.Location{{Line: 2, Column: 3}},
+				Path:      []interface{}{"addPost"}}},
+		},
+		{
+			name:         "hook can't replace a field with a value of the wrong type",
+			mutation:     addPost,
+			hookResponse: `{"args": {"input": [{"slug": "a-post", "title": 5}]}}`,
+			events:       1,
+			queries:      1,
+			errors: x.GqlErrorList{{Message: "mutation addPost failed because " +
+				"the before mutate hook replaced the argument input with an invalid value: " +
+				"input: variable.input[0].title cannot use Number as String",
+				Locations: []x.Location{{Line: 2, Column: 3}},
+				Path:      []interface{}{"addPost"}}},
+		},
+		{
+			name:         "hook can't add unknown fields",
+			mutation:     addPost,
+			hookResponse: `{"args": {"input": [{"slug": "a-post", "title": "a post", "score": 5}]}}`,
+			events:       1,
+			queries:      1,
+			errors: x.GqlErrorList{{Message: "mutation addPost failed because " +
+				"the before mutate hook replaced the argument input with an invalid value: " +
+				"input: variable.input[0].score unknown field",
+				Locations: []x.Location{{Line: 2, Column: 3}},
+				Path:      []interface{}{"addPost"}}},
+		},
+		{
+			name:         "hook can't change the @id fields",
+			mutation:     addPost,
+			hookResponse: `{"args": {"input": [{"slug": "other-post", "title": "a post"}]}}`,
+			events:       1,
+			queries:      1,
+			errors: x.GqlErrorList{{Message: "mutation addPost failed because " +
+				"the before mutate hook can't change the @id fields or the filter of the mutation",
+				Locations: []x.Location{{Line: 2, Column: 3}},
+				Path:      []interface{}{"addPost"}}},
+		},
+		{
+			name:         "hook lets the mutation go through as it is",
+			mutation:     addPost,
+			hookResponse: `{}`,
+			setJSON:      `"Post.title":"a post"`,
+			events:       1,
+			queries:      1,
+		},
+		{
+			name: "hook isn't called for the mutations it isn't enabled for",
+			mutation: `mutation {
+				updatePost(input: {filter: {id: ["0x1"]}, set: {title: "a post"}}) {
+					post { title }
+				}
+			}`,
+			hookResponse: `false`,
+			setJSON:      `"Post.title":"a post"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			events = nil
+			hookResponse = tc.hookResponse
+			ex := &mutationRecorder{executor: &executor{
+				resp:     `{"post": [{"title": "a post"}]}`,
+				assigned: map[string]string{"Post_1": "0x1"},
+				result:   map[string]interface{}{"updatePost": []interface{}{map[string]string{"uid": "0x1"}}},
+			}}
+			resp := resolveWithClient(gqlSchema, tc.mutation, nil, ex)
+
+			require.Equal(t, tc.errors, resp.Errors)
+			require.Equal(t, tc.queries, ex.queries)
+			require.Len(t, events, tc.events)
+			if tc.events > 0 {
+				// The hook is called in the transaction started by the existence queries.
+				require.Equal(t, float64(recorderStartTs), events[0]["startTs"])
+				require.Equal(t, "Post", events[0]["__typename"])
+				require.Equal(t, "add", events[0]["operation"])
+				require.Equal(t, map[string]interface{}{
+					"input": []interface{}{
+						map[string]interface{}{"slug": "a-post", "title": "a post"},
+					},
+				}, events[0]["args"])
+			}
+			if tc.setJSON == "" {
+				require.Empty(t, ex.mutations)
+				return
+			}
+			require.Len(t, ex.mutations, 1)
+			require.Contains(t, string(ex.mutations[0].SetJson), tc.setJSON)
+		})
+	}
+}
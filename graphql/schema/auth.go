@@ -231,7 +231,7 @@ func authRules(sch *schema) (map[string]*TypeAuth, error) {
 
 		for _, field := range typ.Fields {
 			auth := field.Directives.ForName(authDirective)
-			if auth != nil {
+			if auth != nil && !isInheritedField(s, typ, field) {
 				authRules[name].Fields[field.Name], err = parseAuthDirective(sch, typ, auth)
 				errResult = AppendGQLErrs(errResult, err)
 			}
@@ -244,30 +244,50 @@ func authRules(sch *schema) (map[string]*TypeAuth, error) {
 		if typ.Kind == ast.Object {
 			for _, intrface := range typ.Interfaces {
 				interfaceName := typeName(s.Types[intrface])
-				if authRules[interfaceName] != nil && authRules[interfaceName].Rules != nil {
+				if authRules[interfaceName] == nil {
+					continue
+				}
+				if authRules[interfaceName].Rules != nil {
 					authRules[name].Rules = mergeAuthRules(
 						authRules[name].Rules,
 						authRules[interfaceName].Rules,
 						mergeAuthNodeWithAnd,
 					)
 				}
+				for fieldName, fieldAuth := range authRules[interfaceName].Fields {
+					authRules[name].Fields[fieldName] = mergeAuthRules(
+						authRules[name].Fields[fieldName], fieldAuth, mergeAuthNodeWithAnd)
+				}
 			}
 		}
 	}
 
 	// Reinitialize the Interface's auth to be empty as Any operation on interface
 	// will be broken into an operation on subsequent implementing types and auth rules
-	// will be verified against the types only.
+	// will be verified against the types only. The rules on the fields of the interface are
+	// kept, as the fields queried through the interface are the same for all the types.
 	for _, typ := range s.Types {
 		name := typeName(typ)
 		if typ.Kind == ast.Interface {
-			authRules[name] = &TypeAuth{}
+			authRules[name] = &TypeAuth{Fields: authRules[name].Fields}
 		}
 	}
 
 	return authRules, errResult
 }
 
+// isInheritedField tells if the field of the type is one of the fields of the interfaces it
+// implements.
+func isInheritedField(s *ast.Schema, typ *ast.Definition, field *ast.FieldDefinition) bool {
+	for _, implements := range typ.Interfaces {
+		if intrface := s.Types[implements]; intrface != nil &&
+			intrface.Fields.ForName(field.Name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func mergeAuthNodeWithOr(objectAuth, interfaceAuth *RuleNode) *RuleNode {
 	if objectAuth == nil {
 		return interfaceAuth
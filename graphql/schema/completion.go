@@ -189,6 +189,17 @@ func CompleteValue(
 
 	switch val := val.(type) {
 	case map[string]interface{}:
+		if cs := field.Type().CustomScalar(); cs != nil {
+			if !cs.IsJSON() {
+				return nil, x.GqlErrorList{field.GqlErrorf(path, ErrExpectedScalar)}
+			}
+			// JSON scalars can have objects as values.
+			b, err := json.Marshal(val)
+			if err != nil {
+				return nil, x.GqlErrorList{field.GqlErrorf(path, err.Error())}
+			}
+			return b, nil
+		}
 		switch field.Type().Name() {
 		case "String", "ID", "Boolean", "Float", "Int", "Int64", "DateTime":
 			return nil, x.GqlErrorList{field.GqlErrorf(path, ErrExpectedScalar)}
@@ -348,7 +359,17 @@ func coerceScalar(val interface{}, field Field, path []interface{}) (interface{}
 			val, field.Name(), field.Type().Name())}
 	}
 
-	switch field.Type().Name() {
+	// Scalars declared with @scalar are coerced like their base type. JSON scalars can have any
+	// value.
+	typName := field.Type().Name()
+	if cs := field.Type().CustomScalar(); cs != nil {
+		if cs.IsJSON() {
+			return val, nil
+		}
+		typName = cs.BaseType
+	}
+
+	switch typName {
 	case "String", "ID":
 		switch v := val.(type) {
 		case bool:
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Names of the types and fields generated for Relay connections.
+// See: https://relay.dev/graphql/connections.htm
+const (
+	pageInfoType            = "PageInfo"
+	pageInfoStartCursor     = "startCursor"
+	pageInfoEndCursor       = "endCursor"
+	pageInfoHasNextPage     = "hasNextPage"
+	pageInfoHasPreviousPage = "hasPreviousPage"
+	edgeNode                = "node"
+	edgeCursor              = "cursor"
+	connectionEdges         = "edges"
+	connectionPageInfo      = "pageInfo"
+
+	// ConnectionUidAlias and ConnectionOrderAlias are the aliases of the DQL fields that fetch
+	// the uid, and the value of the field the nodes are ordered by, for every node of a
+	// connection query. The cursor of a node is built from them.
+	ConnectionUidAlias   = "dgraph.cursorUid"
+	ConnectionOrderAlias = "dgraph.cursorOrder"
+)
+
+// Cursor is the decoded form of the opaque cursor given for an edge of a Relay connection.
+type Cursor struct {
+	// UID is the uid of the node of the edge.
+	UID string `json:"uid"`
+	// Order is the field that the nodes of the connection were ordered by first, and Value is
+	// the JSON encoded value of that field for the node. Both are empty if the connection wasn't
+	// ordered.
+	Order string          `json:"order,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+	// Ties is the number of nodes up to and including this one, in the order of the
+	// connection, that have the same Value. Paging after the cursor skips that many of the
+	// nodes having Value, so that the nodes sharing a value aren't repeated or left out.
+	Ties int `json:"ties,omitempty"`
+}
+
+// Encode returns the opaque string form of the cursor.
+func (c *Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseCursor decodes a cursor given as the `after` argument of a connection query. The cursor
+// comes from the client, and its uid and value end up in the DQL query, so the uid must be a uid,
+// which is given back in its canonical form, and the value must be a JSON scalar.
+func ParseCursor(s string) (*Cursor, error) {
+	invalid := errors.Errorf("%s isn't a valid cursor", s)
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	c := &Cursor{}
+	if err := json.Unmarshal(b, c); err != nil || c.Ties < 0 {
+		return nil, invalid
+	}
+	uid, err := strconv.ParseUint(c.UID, 0, 64)
+	if err != nil || uid == 0 {
+		return nil, invalid
+	}
+	c.UID = fmt.Sprintf("%#x", uid)
+
+	if len(c.Value) > 0 {
+		var val interface{}
+		d := json.NewDecoder(bytes.NewReader(c.Value))
+		d.UseNumber()
+		if err := d.Decode(&val); err != nil {
+			return nil, invalid
+		}
+		switch val.(type) {
+		case string, json.Number, bool:
+		default:
+			return nil, invalid
+		}
+	}
+	return c, nil
+}
+
+// ConnectionOrder returns the field that the nodes of the connection query q are ordered by
+// first, and whether they are in descending order. The field is "" if q isn't ordered.
+func ConnectionOrder(q Field) (string, bool) {
+	order, _ := q.ArgValue("order").(map[string]interface{})
+	if asc, ok := order["asc"].(string); ok {
+		return asc, false
+	}
+	if desc, ok := order["desc"].(string); ok {
+		return desc, true
+	}
+	return "", false
+}
+
+// ConnectionNodeType returns the type of the nodes of the connection type t.
+func ConnectionNodeType(t Type) Type {
+	return t.Field(connectionEdges).Type().Field(edgeNode).Type()
+}
+
+// ConnectionNode returns the first `node` field asked for in the `edges` of the connection
+// query q, or nil if q doesn't ask for any nodes.
+func ConnectionNode(q Field) Field {
+	for _, edges := range q.SelectionSet() {
+		if edges.Name() != connectionEdges {
+			continue
+		}
+		for _, node := range edges.SelectionSet() {
+			if node.Name() == edgeNode {
+				return node
+			}
+		}
+	}
+	return nil
+}
@@ -19,22 +19,213 @@ package schema
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
+	"github.com/dgraph-io/ristretto"
+
 	"github.com/vtta/dgraph/graphql/authorization"
+	"github.com/vtta/dgraph/graphql/lambda"
 
 	"github.com/vtta/dgraph/x"
 )
 
 var (
 	defaultHttpClient = &http.Client{Timeout: time.Minute}
+
+	// customHTTPCache caches the responses of the @custom fields that set a cacheTTL. It is nil
+	// when the cache is disabled.
+	customHTTPCache *ristretto.Cache
+	// customHTTPHostLimit is the max number of @custom HTTP requests that are sent to a remote
+	// host at the same time. It is 0 when the requests aren't limited.
+	customHTTPHostLimit int
+	// customHTTPHostSems has a semaphore for each remote host, when the requests to the remote
+	// hosts are limited.
+	customHTTPHostSems   = make(map[string]chan struct{})
+	customHTTPHostSemsMu sync.Mutex
 )
 
+type customHTTPCtxKey int
+
+// customHTTPCallsKey is the key for the customHTTPCalls of a GraphQL request in its context.
+const customHTTPCallsKey customHTTPCtxKey = iota
+
+// customHTTPResponse is the part of the response of a remote endpoint that is needed to decode
+// it. It is what gets shared between the identical requests of a GraphQL request, and cached.
+type customHTTPResponse struct {
+	statusCode int
+	body       []byte
+}
+
+// customHTTPCall is a request to a remote endpoint, which is made once for all the identical
+// requests of a GraphQL request. done is closed once resp and err are set.
+type customHTTPCall struct {
+	done chan struct{}
+	resp *customHTTPResponse
+	err  error
+}
+
+// customHTTPCalls are the requests to remote endpoints made while resolving a GraphQL request,
+// by their customHTTPKey.
+type customHTTPCalls struct {
+	sync.Mutex
+	calls map[string]*customHTTPCall
+}
+
+// InitCustomHTTP sets up the cache for the responses of @custom fields with the given size in MB,
+// and the max number of @custom HTTP requests that are sent to a remote host at the same time.
+// A cacheMB of 0 disables the cache, and a hostLimit of 0 doesn't limit the requests.
+func InitCustomHTTP(cacheMB int64, hostLimit int) {
+	customHTTPCache = nil
+	if cacheMB > 0 {
+		maxCost := cacheMB << 20
+		var err error
+		customHTTPCache, err = ristretto.NewCache(&ristretto.Config{
+			// As the responses are much bigger than 1 byte, this is more than enough counters.
+			NumCounters: maxCost / 100,
+			MaxCost:     maxCost,
+			BufferItems: 64,
+		})
+		x.Check(err)
+	}
+
+	customHTTPHostSemsMu.Lock()
+	defer customHTTPHostSemsMu.Unlock()
+	customHTTPHostLimit = hostLimit
+	customHTTPHostSems = make(map[string]chan struct{})
+}
+
+// WithCustomHTTPDedup returns a context for resolving a GraphQL request in which identical
+// requests to the remote endpoints of @custom fields are sent only once. All of them get the
+// response of the first one. This way, a @custom field that is queried for a list of 500 items
+// with the same arguments doesn't make 500 identical requests.
+func WithCustomHTTPDedup(ctx context.Context) context.Context {
+	return context.WithValue(ctx, customHTTPCallsKey,
+		&customHTTPCalls{calls: make(map[string]*customHTTPCall)})
+}
+
+// customHTTPKey returns the key that identifies a request to a remote endpoint. Two requests with
+// the same key get the same response.
+func customHTTPKey(method, url string, header http.Header, body []byte) string {
+	h := sha256.New()
+	x.Check2(h.Write([]byte(method + " " + url + "\n")))
+	// header.Write writes the headers sorted by their names
+	x.Check(header.Write(h))
+	x.Check2(h.Write(body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// makeCustomHTTPRequest sends an HTTP request to the remote endpoint of a @custom field, or to the
+// lambda server if the field has @lambda, unless the response can be taken from the identical
+// request of the same GraphQL request in ctx, or from the cache if the responses of the field are
+// cached for ttl.
+func makeCustomHTTPRequest(ctx context.Context, client *http.Client, method, url string,
+	header http.Header, body []byte, lambda, dedup bool,
+	ttl time.Duration) (*customHTTPResponse, error) {
+	key := customHTTPKey(method, url, header, body)
+	cache := customHTTPCache
+	if ttl > 0 && cache != nil {
+		if resp, ok := cache.Get(key); ok {
+			return resp.(*customHTTPResponse), nil
+		}
+	}
+
+	send := func() (*customHTTPResponse, error) {
+		resp, err := sendCustomHTTPRequest(ctx, client, method, url, header, body, lambda)
+		if err == nil && ttl > 0 && cache != nil && resp.statusCode >= 200 &&
+			resp.statusCode < 300 {
+			cache.SetWithTTL(key, resp, int64(len(resp.body)), ttl)
+		}
+		return resp, err
+	}
+
+	calls, _ := ctx.Value(customHTTPCallsKey).(*customHTTPCalls)
+	if !dedup || calls == nil {
+		return send()
+	}
+
+	calls.Lock()
+	call, ok := calls.calls[key]
+	if !ok {
+		call = &customHTTPCall{done: make(chan struct{})}
+		calls.calls[key] = call
+	}
+	calls.Unlock()
+
+	if !ok {
+		call.resp, call.err = send()
+		close(call.done)
+		return call.resp, call.err
+	}
+	select {
+	case <-call.done:
+		return call.resp, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// sendCustomHTTPRequest sends an HTTP request to a remote endpoint, waiting for its turn if the
+// requests to the remote host are limited. The requests of @lambda fields are handled by the
+// embedded lambda runtime if it is enabled.
+func sendCustomHTTPRequest(ctx context.Context, client *http.Client, method, reqURL string,
+	header http.Header, body []byte, lambda bool) (*customHTTPResponse, error) {
+	if lambda && x.Config.GraphQL.GetBool("lambda-embedded") {
+		return readCustomHTTPResponse(MakeLambdaRequest(ctx, header, body))
+	}
+	if sem := customHTTPHostSem(reqURL); sem != nil {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return readCustomHTTPResponse(MakeHttpRequest(client, method, reqURL, header, body))
+}
+
+func readCustomHTTPResponse(resp *http.Response, err error) (*customHTTPResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &customHTTPResponse{statusCode: resp.StatusCode, body: b}, nil
+}
+
+// customHTTPHostSem returns the semaphore for the host of reqURL, or nil if the requests to the
+// remote hosts aren't limited.
+func customHTTPHostSem(reqURL string) chan struct{} {
+	customHTTPHostSemsMu.Lock()
+	defer customHTTPHostSemsMu.Unlock()
+	if customHTTPHostLimit <= 0 {
+		return nil
+	}
+	// If the URL can't be parsed, the request is going to fail anyway.
+	u, err := url.Parse(reqURL)
+	if err != nil {
+		return nil
+	}
+	sem, ok := customHTTPHostSems[u.Host]
+	if !ok {
+		sem = make(chan struct{}, customHTTPHostLimit)
+		customHTTPHostSems[u.Host] = sem
+	}
+	return sem
+}
+
 // graphqlResp represents a GraphQL response returned from a @custom(http: {...}) endpoint.
 type graphqlResp struct {
 	Errors x.GqlErrorList         `json:"errors,omitempty"`
@@ -65,6 +256,18 @@ func MakeHttpRequest(client *http.Client, method, url string, header http.Header
 	return client.Do(req)
 }
 
+// MakeLambdaRequest sends the body to the lambda server of the namespace of ctx, as the @lambda
+// fields, the @lambdaOnMutate webhooks and the lambda validators do. The request is handled by
+// the embedded lambda runtime, without going over the network, if it is enabled.
+func MakeLambdaRequest(ctx context.Context, header http.Header, body []byte) (*http.Response,
+	error) {
+	if x.Config.GraphQL.GetBool("lambda-embedded") {
+		return lambda.Serve(ctx, body)
+	}
+	ns, _ := x.ExtractNamespace(ctx)
+	return MakeHttpRequest(nil, http.MethodPost, x.LambdaUrl(ns), header, body)
+}
+
 // MakeAndDecodeHTTPRequest sends an HTTP request using the given url and body and then decodes the
 // response correctly based on whether it was a GraphQL or REST request.
 // It returns the decoded response along with either soft or hard errors.
@@ -73,8 +276,10 @@ func MakeHttpRequest(client *http.Client, method, url string, header http.Header
 // For GraphQL requests, the GraphQL errors returned from the remote endpoint are considered soft
 // errors. Any other kind of error is a hard error.
 // For REST requests, any error is a hard error, including those returned from the remote endpoint.
-func (fconf *FieldHTTPConfig) MakeAndDecodeHTTPRequest(client *http.Client, url string,
-	body interface{}, field Field) (interface{}, x.GqlErrorList, x.GqlErrorList) {
+// Except for custom mutations, identical requests made while resolving the GraphQL request in ctx
+// are sent only once, and the responses are cached if fconf has a CacheTTL.
+func (fconf *FieldHTTPConfig) MakeAndDecodeHTTPRequest(ctx context.Context, client *http.Client,
+	url string, body interface{}, field Field) (interface{}, x.GqlErrorList, x.GqlErrorList) {
 	var b []byte
 	var err error
 	// need this check to make sure that we don't send body as []byte(`null`)
@@ -86,16 +291,13 @@ func (fconf *FieldHTTPConfig) MakeAndDecodeHTTPRequest(client *http.Client, url
 	}
 
 	// Make the request to external HTTP endpoint using the URL and body
-	resp, err := MakeHttpRequest(client, fconf.Method, url, fconf.ForwardHeaders, b)
-	if err != nil {
-		return nil, nil, x.GqlErrorList{externalRequestError(err, field)}
-	}
-
-	defer resp.Body.Close()
-	b, err = ioutil.ReadAll(resp.Body)
+	isMutation := field.GetObjectName() == "Mutation"
+	resp, err := makeCustomHTTPRequest(ctx, client, fconf.Method, url, fconf.ForwardHeaders, b,
+		fconf.Lambda, !isMutation, fconf.CacheTTL)
 	if err != nil {
 		return nil, nil, x.GqlErrorList{externalRequestError(err, field)}
 	}
+	b = resp.body
 
 	// Decode the HTTP response
 	var response interface{}
@@ -116,7 +318,7 @@ func (fconf *FieldHTTPConfig) MakeAndDecodeHTTPRequest(client *http.Client, url
 		}
 	} else {
 		// this was a REST request
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if resp.statusCode >= 200 && resp.statusCode < 300 {
 			// if this was a successful request, lets try to unmarshal the response
 			if err = Unmarshal(b, &response); err != nil {
 				return nil, nil, x.GqlErrorList{jsonUnmarshalError(err, field)}
@@ -126,7 +328,7 @@ func (fconf *FieldHTTPConfig) MakeAndDecodeHTTPRequest(client *http.Client, url
 			// if we get unsuccessful response from the REST api, lets try to see if
 			// it sent any errors in the form expected for GraphQL errors.
 			if err = Unmarshal(b, &graphqlResp); err != nil {
-				err = fmt.Errorf("unexpected error with: %v", resp.StatusCode)
+				err = fmt.Errorf("unexpected error with: %v", resp.statusCode)
 				return nil, nil, x.GqlErrorList{externalRequestError(err, field)}
 			} else {
 				return nil, nil, graphqlResp.Errors
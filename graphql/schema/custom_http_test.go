@@ -0,0 +1,216 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/ristretto/z"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/graphql/lambda"
+	"github.com/vtta/dgraph/x"
+)
+
+// customHTTPTestServer is a remote endpoint that counts the requests it gets, and the max number
+// of requests it was serving at the same time.
+type customHTTPTestServer struct {
+	*httptest.Server
+	requests      int32
+	inFlight      int32
+	maxInFlight   int32
+	responseDelay time.Duration
+}
+
+func newCustomHTTPTestServer(responseDelay time.Duration) *customHTTPTestServer {
+	ts := &customHTTPTestServer{responseDelay: responseDelay}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&ts.requests, 1)
+		inFlight := atomic.AddInt32(&ts.inFlight, 1)
+		defer atomic.AddInt32(&ts.inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&ts.maxInFlight)
+			if inFlight <= max || atomic.CompareAndSwapInt32(&ts.maxInFlight, max, inFlight) {
+				break
+			}
+		}
+		time.Sleep(ts.responseDelay)
+		_, _ = w.Write([]byte(`{"code": "` + r.URL.Path[1:] + `"}`))
+	}))
+	return ts
+}
+
+func customHTTPTestField(t *testing.T) Field {
+	schHandler, errs := NewHandler(`
+		type Country @remote {
+			code: String
+		}
+		type Query {
+			country(code: String!): Country @custom(http: {
+				url: "http://countries/$code",
+				method: "GET"
+			})
+		}`, false)
+	require.NoError(t, errs)
+	sch, err := FromString(schHandler.GQLSchema(), x.GalaxyNamespace)
+	require.NoError(t, err)
+	op, err := sch.Operation(&Request{Query: `query { country(code: "in") { code } }`})
+	require.NoError(t, err)
+	return op.Queries()[0]
+}
+
+// makeCustomHTTPRequests makes n requests at the same time to the paths given by path(i), and
+// checks that they all get the right response.
+func makeCustomHTTPRequests(t *testing.T, ctx context.Context, fconf *FieldHTTPConfig,
+	baseURL string, n int, path func(i int) string) {
+	field := customHTTPTestField(t)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, softErrs, hardErrs := fconf.MakeAndDecodeHTTPRequest(ctx, nil,
+				baseURL+"/"+path(i), nil, field)
+			require.Nil(t, softErrs)
+			require.Nil(t, hardErrs)
+			require.Equal(t, map[string]interface{}{"code": path(i)}, resp)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestCustomHTTPDedup(t *testing.T) {
+	ts := newCustomHTTPTestServer(10 * time.Millisecond)
+	defer ts.Close()
+	fconf := &FieldHTTPConfig{Method: http.MethodGet, ForwardHeaders: http.Header{}}
+	samePath := func(int) string { return "in" }
+
+	// Without deduplication, every request reaches the remote endpoint.
+	makeCustomHTTPRequests(t, context.Background(), fconf, ts.URL, 20, samePath)
+	require.Equal(t, int32(20), atomic.LoadInt32(&ts.requests))
+
+	// Identical requests of a GraphQL request are sent only once.
+	atomic.StoreInt32(&ts.requests, 0)
+	ctx := WithCustomHTTPDedup(context.Background())
+	makeCustomHTTPRequests(t, ctx, fconf, ts.URL, 20, samePath)
+	makeCustomHTTPRequests(t, ctx, fconf, ts.URL, 20, samePath)
+	require.Equal(t, int32(1), atomic.LoadInt32(&ts.requests))
+
+	// Different requests are all sent.
+	atomic.StoreInt32(&ts.requests, 0)
+	makeCustomHTTPRequests(t, ctx, fconf, ts.URL, 20, strconv.Itoa)
+	require.Equal(t, int32(20), atomic.LoadInt32(&ts.requests))
+}
+
+func TestCustomHTTPCache(t *testing.T) {
+	InitCustomHTTP(1, 0)
+	defer InitCustomHTTP(0, 0)
+	ts := newCustomHTTPTestServer(0)
+	defer ts.Close()
+	samePath := func(int) string { return "in" }
+
+	// The responses aren't cached for fields without a cacheTTL.
+	fconf := &FieldHTTPConfig{Method: http.MethodGet, ForwardHeaders: http.Header{}}
+	makeCustomHTTPRequests(t, context.Background(), fconf, ts.URL, 1, samePath)
+	customHTTPCache.Wait()
+	makeCustomHTTPRequests(t, context.Background(), fconf, ts.URL, 1, samePath)
+	require.Equal(t, int32(2), atomic.LoadInt32(&ts.requests))
+
+	// With a cacheTTL, the response is reused across GraphQL requests until it expires.
+	atomic.StoreInt32(&ts.requests, 0)
+	fconf.CacheTTL = time.Second
+	makeCustomHTTPRequests(t, WithCustomHTTPDedup(context.Background()), fconf, ts.URL, 1,
+		samePath)
+	customHTTPCache.Wait()
+	makeCustomHTTPRequests(t, WithCustomHTTPDedup(context.Background()), fconf, ts.URL, 1,
+		samePath)
+	require.Equal(t, int32(1), atomic.LoadInt32(&ts.requests))
+
+	// Requests with different headers don't share their responses.
+	fconf.ForwardHeaders = http.Header{"Authorization": []string{"token"}}
+	makeCustomHTTPRequests(t, context.Background(), fconf, ts.URL, 1, samePath)
+	require.Equal(t, int32(2), atomic.LoadInt32(&ts.requests))
+
+	time.Sleep(2 * time.Second)
+	makeCustomHTTPRequests(t, context.Background(), fconf, ts.URL, 1, samePath)
+	require.Equal(t, int32(3), atomic.LoadInt32(&ts.requests))
+}
+
+func TestCustomHTTPHostLimit(t *testing.T) {
+	InitCustomHTTP(0, 2)
+	defer InitCustomHTTP(0, 0)
+	ts := newCustomHTTPTestServer(20 * time.Millisecond)
+	defer ts.Close()
+
+	fconf := &FieldHTTPConfig{Method: http.MethodGet, ForwardHeaders: http.Header{}}
+	makeCustomHTTPRequests(t, context.Background(), fconf, ts.URL, 10, strconv.Itoa)
+	require.Equal(t, int32(10), atomic.LoadInt32(&ts.requests))
+	require.Equal(t, int32(2), atomic.LoadInt32(&ts.maxInFlight))
+}
+
+func TestEmbeddedLambda(t *testing.T) {
+	graphqlConfig := x.Config.GraphQL
+	defer func() { x.Config.GraphQL = graphqlConfig }()
+	x.Config.GraphQL = z.NewSuperFlag("lambda-embedded=true;").
+		MergeAndCheckDefault("lambda-url=; lambda-embedded=false;")
+
+	// The echo lambda module answers with the request it gets.
+	echo, err := ioutil.ReadFile("../lambda/testdata/echo.wasm")
+	require.NoError(t, err)
+	lambda.Init(16, time.Second, func(ns uint64) ([]byte, error) { return echo, nil })
+	defer lambda.Close()
+
+	schHandler, errs := NewHandler(`
+		type Echo @remote {
+			resolver: String
+			args: EchoArgs
+		}
+		type EchoArgs @remote {
+			text: String
+		}
+		type Query {
+			echo(text: String!): Echo @lambda
+		}`, false)
+	require.NoError(t, errs)
+	sch, err := FromString(schHandler.GQLSchema(), x.GalaxyNamespace)
+	require.NoError(t, err)
+	op, err := sch.Operation(&Request{Query: `query { echo(text: "hello") { resolver } }`})
+	require.NoError(t, err)
+	field := op.Queries()[0]
+
+	fconf, err := field.CustomHTTPConfig()
+	require.NoError(t, err)
+	require.Equal(t, "embedded://lambda", fconf.URL)
+	require.True(t, fconf.Lambda)
+	// The module that is run is the one of the namespace of the request.
+	ctx := x.AttachNamespace(context.Background(), x.GalaxyNamespace)
+	body := GetBodyForLambda(ctx, field, nil, fconf.Template)
+	resp, softErrs, hardErrs := fconf.MakeAndDecodeHTTPRequest(ctx, nil, fconf.URL, body, field)
+	require.Nil(t, softErrs)
+	require.Nil(t, hardErrs)
+	require.Equal(t, "Query.echo", resp.(map[string]interface{})["resolver"])
+	require.Equal(t, map[string]interface{}{"text": "hello"},
+		resp.(map[string]interface{})["args"])
+}
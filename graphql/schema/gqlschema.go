@@ -38,15 +38,30 @@ const (
 	dgraphTypeArg   = "type"
 	dgraphPredArg   = "pred"
 
-	idDirective             = "id"
-	subscriptionDirective   = "withSubscription"
-	secretDirective         = "secret"
-	authDirective           = "auth"
-	customDirective         = "custom"
-	remoteDirective         = "remote" // types with this directive are not stored in Dgraph.
-	remoteResponseDirective = "remoteResponse"
-	lambdaDirective         = "lambda"
-	lambdaOnMutateDirective = "lambdaOnMutate"
+	idDirective                 = "id"
+	compositeIdDirective        = "compositeId"
+	subscriptionDirective       = "withSubscription"
+	secretDirective             = "secret"
+	authDirective               = "auth"
+	customDirective             = "custom"
+	remoteDirective             = "remote" // types with this directive are not stored in Dgraph.
+	remoteResponseDirective     = "remoteResponse"
+	lambdaDirective             = "lambda"
+	lambdaOnMutateDirective     = "lambdaOnMutate"
+	lambdaBeforeMutateDirective = "lambdaBeforeMutate"
+
+	scalarDirective = "scalar"
+	scalarTypeArg   = "type"
+	scalarRegexArg  = "regex"
+	scalarLambdaArg = "lambda"
+	jsonScalarBase  = "JSON"
+
+	constraintDirective    = "constraint"
+	constraintMinLengthArg = "minLength"
+	constraintMaxLengthArg = "maxLength"
+	constraintPatternArg   = "pattern"
+	constraintMinArg       = "min"
+	constraintMaxArg       = "max"
 
 	generateDirective       = "generate"
 	generateQueryArg        = "query"
@@ -54,10 +69,12 @@ const (
 	generateQueryField      = "query"
 	generatePasswordField   = "password"
 	generateAggregateField  = "aggregate"
+	generateConnectionField = "connection"
 	generateMutationArg     = "mutation"
 	generateAddField        = "add"
 	generateUpdateField     = "update"
 	generateDeleteField     = "delete"
+	generateDiffField       = "diff"
 	generateSubscriptionArg = "subscription"
 
 	cascadeDirective = "cascade"
@@ -74,16 +91,30 @@ const (
 	apolloRequiresDirective = "requires"
 	apolloProvidesDirective = "provides"
 
+	// Directives added in Apollo Federation 2. They can only be used by schemas that link
+	// Federation 2 with `extend schema @link(url: "https://specs.apollo.dev/federation/v2.0")`.
+	apolloLinkDirective            = "link"
+	apolloLinkURLArg               = "url"
+	apolloLinkImportArg            = "import"
+	apolloFederation2URLPrefix     = "https://specs.apollo.dev/federation/v2."
+	apolloKeyResolvableArg         = "resolvable"
+	apolloShareableDirective       = "shareable"
+	apolloInaccessibleDirective    = "inaccessible"
+	apolloOverrideDirective        = "override"
+	apolloOverrideArg              = "from"
+	apolloInterfaceObjectDirective = "interfaceObject"
+
 	// custom directive args and fields
-	dqlArg      = "dql"
-	httpArg     = "http"
-	httpUrl     = "url"
-	httpMethod  = "method"
-	httpBody    = "body"
-	httpGraphql = "graphql"
-	mode        = "mode"
-	BATCH       = "BATCH"
-	SINGLE      = "SINGLE"
+	dqlArg       = "dql"
+	httpArg      = "http"
+	httpUrl      = "url"
+	httpMethod   = "method"
+	httpBody     = "body"
+	httpGraphql  = "graphql"
+	httpCacheTTL = "cacheTTL"
+	mode         = "mode"
+	BATCH        = "BATCH"
+	SINGLE       = "SINGLE"
 
 	// geo type names and fields
 	Point        = "Point"
@@ -98,6 +129,9 @@ const (
 	deprecatedDirective = "deprecated"
 	NumUid              = "numUids"
 	Msg                 = "msg"
+	Diff                = "diff"
+	DiffBefore          = "before"
+	DiffAfter           = "after"
 
 	Typename = "__typename"
 
@@ -192,6 +226,7 @@ input CustomHTTP {
 	secretHeaders: [String!]
 	introspectionHeaders: [String!]
 	skipIntrospection: Boolean
+	cacheTTL: Int
 }
 
 type Point {
@@ -264,12 +299,14 @@ input GenerateQueryParams {
 	query: Boolean
 	password: Boolean
 	aggregate: Boolean
+	connection: Boolean
 }
 
 input GenerateMutationParams {
 	add: Boolean
 	update: Boolean
 	delete: Boolean
+	diff: Boolean
 }
 `
 	directiveDefs = `
@@ -277,6 +314,7 @@ directive @hasInverse(field: String!) on FIELD_DEFINITION
 directive @search(by: [DgraphIndex!]) on FIELD_DEFINITION
 directive @dgraph(type: String, pred: String) on OBJECT | INTERFACE | FIELD_DEFINITION
 directive @id on FIELD_DEFINITION
+directive @compositeId on OBJECT
 directive @withSubscription on OBJECT | INTERFACE | FIELD_DEFINITION
 directive @secret(field: String!, pred: String) on OBJECT | INTERFACE
 directive @auth(
@@ -284,13 +322,16 @@ directive @auth(
 	query: AuthRule,
 	add: AuthRule,
 	update: AuthRule,
-	delete: AuthRule) on OBJECT | INTERFACE
+	delete: AuthRule) on OBJECT | INTERFACE | FIELD_DEFINITION
 directive @custom(http: CustomHTTP, dql: String) on FIELD_DEFINITION
 directive @remote on OBJECT | INTERFACE | UNION | INPUT_OBJECT | ENUM
 directive @remoteResponse(name: String) on FIELD_DEFINITION
 directive @cascade(fields: [String]) on FIELD
 directive @lambda on FIELD_DEFINITION
 directive @lambdaOnMutate(add: Boolean, update: Boolean, delete: Boolean) on OBJECT | INTERFACE
+directive @lambdaBeforeMutate(add: Boolean, update: Boolean, delete: Boolean) on OBJECT | INTERFACE
+directive @scalar(type: String!, regex: String, lambda: Boolean) on SCALAR
+directive @constraint(minLength: Int, maxLength: Int, pattern: String, min: Float, max: Float) on FIELD_DEFINITION | INPUT_FIELD_DEFINITION
 directive @cacheControl(maxAge: Int!) on QUERY
 directive @generate(
 	query: GenerateQueryParams,
@@ -308,12 +349,16 @@ directive @hasInverse(field: String!) on FIELD_DEFINITION
 directive @search(by: [DgraphIndex!]) on FIELD_DEFINITION
 directive @dgraph(type: String, pred: String) on OBJECT | INTERFACE | FIELD_DEFINITION
 directive @id on FIELD_DEFINITION
+directive @compositeId on OBJECT
 directive @withSubscription on OBJECT | INTERFACE | FIELD_DEFINITION
 directive @secret(field: String!, pred: String) on OBJECT | INTERFACE
 directive @remote on OBJECT | INTERFACE | UNION | INPUT_OBJECT | ENUM
 directive @remoteResponse(name: String) on FIELD_DEFINITION
 directive @lambda on FIELD_DEFINITION
 directive @lambdaOnMutate(add: Boolean, update: Boolean, delete: Boolean) on OBJECT | INTERFACE
+directive @lambdaBeforeMutate(add: Boolean, update: Boolean, delete: Boolean) on OBJECT | INTERFACE
+directive @scalar(type: String!, regex: String, lambda: Boolean) on SCALAR
+directive @constraint(minLength: Int, maxLength: Int, pattern: String, min: Float, max: Float) on FIELD_DEFINITION | INPUT_FIELD_DEFINITION
 `
 	filterInputs = `
 input IntFilter {
@@ -399,6 +444,26 @@ directive @requires(fields: _FieldSet!) on FIELD_DEFINITION
 directive @provides(fields: _FieldSet!) on FIELD_DEFINITION
 directive @key(fields: _FieldSet!) on OBJECT | INTERFACE
 directive @extends on OBJECT | INTERFACE
+`
+	// apolloFederation2SchemaExtras are the apolloSchemaExtras for schemas that link Federation 2.
+	apolloFederation2SchemaExtras = `
+scalar _Any
+scalar _FieldSet
+
+type _Service {
+	sdl: String
+}
+
+directive @external on FIELD_DEFINITION
+directive @requires(fields: _FieldSet!) on FIELD_DEFINITION
+directive @provides(fields: _FieldSet!) on FIELD_DEFINITION
+directive @key(fields: _FieldSet!, resolvable: Boolean = true) on OBJECT | INTERFACE
+directive @extends on OBJECT | INTERFACE
+directive @link(url: String!, import: [String]) on SCHEMA
+directive @shareable on OBJECT | FIELD_DEFINITION
+directive @inaccessible on FIELD_DEFINITION | OBJECT | INTERFACE | UNION | ARGUMENT_DEFINITION | SCALAR | ENUM | ENUM_VALUE | INPUT_OBJECT | INPUT_FIELD_DEFINITION
+directive @override(from: String!) on FIELD_DEFINITION
+directive @interfaceObject on OBJECT
 `
 	apolloSchemaQueries = `
 type Query {
@@ -473,6 +538,17 @@ var defaultSearches = map[string]string{
 	"MultiPolygon": "multiPolygon",
 }
 
+// customScalarBases holds the types that values of a scalar declared with @scalar can be stored as.
+var customScalarBases = map[string]bool{
+	"String":       true,
+	"Int":          true,
+	"Int64":        true,
+	"Float":        true,
+	"Boolean":      true,
+	"DateTime":     true,
+	jsonScalarBase: true,
+}
+
 // graphqlSpecScalars holds all the scalar types supported by the graphql spec.
 var graphqlSpecScalars = map[string]bool{
 	"Int":     true,
@@ -498,6 +574,17 @@ var orderable = map[string]bool{
 	"DateTime": true,
 }
 
+// GraphQL types that can be used to group the results of aggregate queries with @groupby.
+// Enums can also be used, and are checked for separately.
+var groupable = map[string]bool{
+	"Int":      true,
+	"Int64":    true,
+	"Float":    true,
+	"String":   true,
+	"DateTime": true,
+	"Boolean":  true,
+}
+
 // GraphQL types that can be summed. Types that have a well defined addition function.
 var summable = map[string]bool{
 	"Int":   true,
@@ -558,25 +645,34 @@ func ValidatorNoOp(
 }
 
 var directiveValidators = map[string]directiveValidator{
-	inverseDirective:        hasInverseValidation,
-	searchDirective:         searchValidation,
-	dgraphDirective:         dgraphDirectiveValidation,
-	idDirective:             idValidation,
-	subscriptionDirective:   ValidatorNoOp,
-	secretDirective:         passwordValidation,
-	authDirective:           ValidatorNoOp, // Just to get it printed into generated schema
-	customDirective:         customDirectiveValidation,
-	remoteDirective:         ValidatorNoOp,
-	deprecatedDirective:     ValidatorNoOp,
-	lambdaDirective:         lambdaDirectiveValidation,
-	lambdaOnMutateDirective: ValidatorNoOp,
-	generateDirective:       ValidatorNoOp,
-	apolloKeyDirective:      ValidatorNoOp,
-	apolloExtendsDirective:  ValidatorNoOp,
-	apolloExternalDirective: apolloExternalValidation,
-	apolloRequiresDirective: apolloRequiresValidation,
-	apolloProvidesDirective: apolloProvidesValidation,
-	remoteResponseDirective: remoteResponseValidation,
+	inverseDirective:            hasInverseValidation,
+	searchDirective:             searchValidation,
+	dgraphDirective:             dgraphDirectiveValidation,
+	idDirective:                 idValidation,
+	compositeIdDirective:        ValidatorNoOp,
+	subscriptionDirective:       ValidatorNoOp,
+	secretDirective:             passwordValidation,
+	authDirective:               authDirectiveValidation,
+	customDirective:             customDirectiveValidation,
+	remoteDirective:             ValidatorNoOp,
+	deprecatedDirective:         ValidatorNoOp,
+	lambdaDirective:             lambdaDirectiveValidation,
+	lambdaOnMutateDirective:     ValidatorNoOp,
+	lambdaBeforeMutateDirective: ValidatorNoOp,
+	scalarDirective:             ValidatorNoOp,
+	constraintDirective:         constraintValidation,
+	generateDirective:           ValidatorNoOp,
+	apolloKeyDirective:          ValidatorNoOp,
+	apolloExtendsDirective:      ValidatorNoOp,
+	apolloExternalDirective:     apolloExternalValidation,
+	apolloRequiresDirective:     apolloRequiresValidation,
+	apolloProvidesDirective:     apolloProvidesValidation,
+	remoteResponseDirective:     remoteResponseValidation,
+
+	apolloShareableDirective:       ValidatorNoOp,
+	apolloInaccessibleDirective:    ValidatorNoOp,
+	apolloOverrideDirective:        apolloOverrideValidation,
+	apolloInterfaceObjectDirective: ValidatorNoOp,
 }
 
 // directiveLocationMap stores the directives and their locations for the ones which can be
@@ -586,22 +682,54 @@ var directiveLocationMap = map[string]map[ast.DefinitionKind]bool{
 	searchDirective:       nil,
 	dgraphDirective:       {ast.Object: true, ast.Interface: true},
 	idDirective:           nil,
+	compositeIdDirective:  {ast.Object: true},
 	subscriptionDirective: {ast.Object: true, ast.Interface: true},
 	secretDirective:       {ast.Object: true, ast.Interface: true},
 	authDirective:         {ast.Object: true, ast.Interface: true},
 	customDirective:       nil,
 	remoteDirective: {ast.Object: true, ast.Interface: true, ast.Union: true,
 		ast.InputObject: true, ast.Enum: true},
-	lambdaDirective:         nil,
-	lambdaOnMutateDirective: {ast.Object: true, ast.Interface: true},
-	generateDirective:       {ast.Object: true, ast.Interface: true},
-	apolloKeyDirective:      {ast.Object: true, ast.Interface: true},
-	apolloExtendsDirective:  {ast.Object: true, ast.Interface: true},
-	apolloExternalDirective: nil,
-	apolloRequiresDirective: nil,
-	apolloProvidesDirective: nil,
-	remoteResponseDirective: nil,
-	cascadeDirective:        nil,
+	lambdaDirective:             nil,
+	lambdaOnMutateDirective:     {ast.Object: true, ast.Interface: true},
+	lambdaBeforeMutateDirective: {ast.Object: true, ast.Interface: true},
+	scalarDirective:             {ast.Scalar: true},
+	constraintDirective:         nil,
+	generateDirective:           {ast.Object: true, ast.Interface: true},
+	apolloKeyDirective:          {ast.Object: true, ast.Interface: true},
+	apolloExtendsDirective:      {ast.Object: true, ast.Interface: true},
+	apolloExternalDirective:     nil,
+	apolloRequiresDirective:     nil,
+	apolloProvidesDirective:     nil,
+	remoteResponseDirective:     nil,
+	cascadeDirective:            nil,
+
+	apolloShareableDirective: {ast.Object: true},
+	apolloInaccessibleDirective: {ast.Object: true, ast.Interface: true, ast.Union: true,
+		ast.InputObject: true, ast.Enum: true},
+	apolloOverrideDirective:        nil,
+	apolloInterfaceObjectDirective: {ast.Object: true},
+}
+
+// federation2Directives are the directives which only schemas that link Apollo Federation 2 can use.
+var federation2Directives = map[string]bool{
+	apolloShareableDirective:       true,
+	apolloInaccessibleDirective:    true,
+	apolloOverrideDirective:        true,
+	apolloInterfaceObjectDirective: true,
+}
+
+// federation2Imports are the names that a schema can import in the @link to Federation 2.
+var federation2Imports = map[string]bool{
+	"@" + apolloKeyDirective:             true,
+	"@" + apolloExternalDirective:        true,
+	"@" + apolloExtendsDirective:         true,
+	"@" + apolloRequiresDirective:        true,
+	"@" + apolloProvidesDirective:        true,
+	"@" + apolloShareableDirective:       true,
+	"@" + apolloInaccessibleDirective:    true,
+	"@" + apolloOverrideDirective:        true,
+	"@" + apolloInterfaceObjectDirective: true,
+	"FieldSet":                           true,
 }
 
 // Struct to store parameters of @generate directive
@@ -610,10 +738,16 @@ type GenerateDirectiveParams struct {
 	generateFilterQuery    bool
 	generatePasswordQuery  bool
 	generateAggregateQuery bool
-	generateAddMutation    bool
-	generateUpdateMutation bool
-	generateDeleteMutation bool
-	generateSubscription   bool
+	// generateConnectionQuery is false by default, as the Relay connection types are only
+	// generated for the types which ask for them.
+	generateConnectionQuery bool
+	generateAddMutation     bool
+	generateUpdateMutation  bool
+	generateDeleteMutation  bool
+	// generateUpdateDiff is false by default, as the diff costs an extra query for every update
+	// mutation that asks for it.
+	generateUpdateDiff   bool
+	generateSubscription bool
 }
 
 func parseGenerateDirectiveParams(defn *ast.Definition) *GenerateDirectiveParams {
@@ -651,6 +785,11 @@ func parseGenerateDirectiveParams(defn *ast.Definition) *GenerateDirectiveParams
 					ret.generateAggregateQuery = aggregateFieldVal.(bool)
 				}
 			}
+			if connectionField := queryArg.Value.Children.ForName(generateConnectionField); connectionField != nil {
+				if connectionFieldVal, err := connectionField.Value(nil); err == nil {
+					ret.generateConnectionQuery = connectionFieldVal.(bool)
+				}
+			}
 		}
 
 		if mutationArg := dir.Arguments.ForName(generateMutationArg); mutationArg != nil {
@@ -669,6 +808,11 @@ func parseGenerateDirectiveParams(defn *ast.Definition) *GenerateDirectiveParams
 					ret.generateDeleteMutation = deleteFieldVal.(bool)
 				}
 			}
+			if diffField := mutationArg.Value.Children.ForName(generateDiffField); diffField != nil {
+				if diffFieldVal, err := diffField.Value(nil); err == nil {
+					ret.generateUpdateDiff = diffFieldVal.(bool)
+				}
+			}
 		}
 
 		if subscriptionArg := dir.Arguments.ForName(generateSubscriptionArg); subscriptionArg != nil {
@@ -792,32 +936,45 @@ func expandSchemaWithApolloExtras(doc *ast.SchemaDocument) {
 			apolloKeyTypes = append(apolloKeyTypes, defn.Name)
 		}
 	}
+	federation2 := federationLink(doc) != nil
 
 	// No need to Expand with Apollo federation Extras
-	if len(apolloKeyTypes) == 0 {
+	if len(apolloKeyTypes) == 0 && !federation2 {
 		return
 	}
 
-	// Form _Entity union with all the entities
-	// for e.g : union _Entity = A | B
-	// where A and B are object with @key directives
-	entityUnionDefinition := &ast.Definition{Kind: ast.Union, Name: "_Entity", Types: apolloKeyTypes}
-	doc.Definitions = append(doc.Definitions, entityUnionDefinition)
-
 	// Parse Apollo Queries and append to the Parsed Schema
 	docApolloQueries, gqlErr := parser.ParseSchema(&ast.Source{Input: apolloSchemaQueries})
 	if gqlErr != nil {
 		x.Panic(gqlErr)
 	}
+	apolloQueries := docApolloQueries.Definitions[0].Fields
+
+	if len(apolloKeyTypes) > 0 {
+		// Form _Entity union with all the entities
+		// for e.g : union _Entity = A | B
+		// where A and B are object with @key directives
+		entityUnionDefinition := &ast.Definition{Kind: ast.Union, Name: "_Entity", Types: apolloKeyTypes}
+		doc.Definitions = append(doc.Definitions, entityUnionDefinition)
+	} else {
+		// A Federation 2 schema without any entities only has the _service query, as there is
+		// no _Entity union for _entities to return.
+		apolloQueries = ast.FieldList{apolloQueries.ForName("_service")}
+	}
 
 	queryDefinition := doc.Definitions.ForName("Query")
 	if queryDefinition == nil {
+		docApolloQueries.Definitions[0].Fields = apolloQueries
 		doc.Definitions = append(doc.Definitions, docApolloQueries.Definitions[0])
 	} else {
-		queryDefinition.Fields = append(queryDefinition.Fields, docApolloQueries.Definitions[0].Fields...)
+		queryDefinition.Fields = append(queryDefinition.Fields, apolloQueries...)
 	}
 
-	docExtras, gqlErr := parser.ParseSchema(&ast.Source{Input: apolloSchemaExtras})
+	apolloExtras := apolloSchemaExtras
+	if federation2 {
+		apolloExtras = apolloFederation2SchemaExtras
+	}
+	docExtras, gqlErr := parser.ParseSchema(&ast.Source{Input: apolloExtras})
 	if gqlErr != nil {
 		x.Panic(gqlErr)
 	}
@@ -826,6 +983,34 @@ func expandSchemaWithApolloExtras(doc *ast.SchemaDocument) {
 
 }
 
+// schemaDirectives returns the directives given on the schema, either in a schema definition or
+// in an `extend schema`.
+func schemaDirectives(doc *ast.SchemaDocument) ast.DirectiveList {
+	var dirs ast.DirectiveList
+	for _, def := range doc.Schema {
+		dirs = append(dirs, def.Directives...)
+	}
+	for _, ext := range doc.SchemaExtension {
+		dirs = append(dirs, ext.Directives...)
+	}
+	return dirs
+}
+
+// federationLink returns the @link directive with which the schema links Apollo Federation 2, or
+// nil if the schema doesn't link it and so is a Federation 1 schema.
+func federationLink(doc *ast.SchemaDocument) *ast.Directive {
+	for _, dir := range schemaDirectives(doc) {
+		if dir.Name != apolloLinkDirective {
+			continue
+		}
+		url := dir.Arguments.ForName(apolloLinkURLArg)
+		if url != nil && strings.HasPrefix(url.Value.Raw, apolloFederation2URLPrefix) {
+			return dir
+		}
+	}
+	return nil
+}
+
 // preGQLValidation validates schema before GraphQL validation.  Validation
 // before GraphQL validation means the schema only has allowed structures, and
 // means we can give better errors than GrqphQL validation would give if their
@@ -1000,7 +1185,7 @@ func completeSchema(
 		if params.generateUpdateMutation {
 			addPatchType(sch, defn, providesTypeMap)
 			addUpdateType(sch, defn)
-			addUpdatePayloadType(sch, defn, providesTypeMap)
+			addUpdatePayloadType(sch, defn, providesTypeMap, params.generateUpdateDiff)
 		}
 
 		if params.generateDeleteMutation {
@@ -1055,8 +1240,9 @@ func cleanupInput(sch *ast.Schema, def *ast.Definition, seen map[string]bool) {
 	for _, f := range def.Fields {
 		nt := f.Type.Name()
 		enum := sch.Types[nt] != nil && sch.Types[nt].Kind == "ENUM"
+		customScalar := sch.Types[nt] != nil && sch.Types[nt].Kind == ast.Scalar
 		// Lets skip scalar types and enums.
-		if _, ok := inbuiltTypeToDgraph[nt]; ok || enum {
+		if _, ok := inbuiltTypeToDgraph[nt]; ok || enum || customScalar {
 			def.Fields[i] = f
 			i++
 			continue
@@ -1345,7 +1531,8 @@ func addFieldFilters(
 
 		// Ordering and pagination, however, only makes sense for fields of
 		// list types (not scalar lists or enum lists).
-		if isTypeList(fld) && !isEnumList(fld, schema) {
+		if isTypeList(fld) && !isEnumList(fld, schema) &&
+			schema.Types[fld.Type.Name()].Kind != ast.Scalar {
 			addOrderArgument(schema, fld, providesTypeMap)
 
 			// Pagination even makes sense when there's no orderables because
@@ -1565,6 +1752,19 @@ func addFilterType(schema *ast.Schema, defn *ast.Definition, providesTypeMap map
 			continue
 		}
 
+		// Fields linking to other types are filtered by the filter of the type they link to,
+		// like author: { name: { eq: "A" } }.
+		if isRelatedFilterable(schema, fld) {
+			filter.Fields = append(filter.Fields,
+				&ast.FieldDefinition{
+					Name: fld.Name,
+					Type: &ast.Type{
+						NamedType: fld.Type.Name() + "Filter",
+					},
+				})
+			continue
+		}
+
 		filterTypes := getFilterTypes(schema, fld, filterName)
 		if len(filterTypes) > 0 {
 			filterName := strings.Join(filterTypes, "_")
@@ -1607,6 +1807,20 @@ func addFilterType(schema *ast.Schema, defn *ast.Definition, providesTypeMap map
 	schema.Types[filterName] = filter
 }
 
+// isRelatedFilterable returns whether fld links to another type in Dgraph, which has a filter
+// that fld can be filtered by.
+func isRelatedFilterable(schema *ast.Schema, fld *ast.FieldDefinition) bool {
+	if _, ok := inbuiltTypeToDgraph[fld.Type.Name()]; ok {
+		return false
+	}
+	related := schema.Types[fld.Type.Name()]
+	if related == nil || hasCustomOrLambda(fld) ||
+		related.Directives.ForName(remoteDirective) != nil {
+		return false
+	}
+	return (related.Kind == ast.Object || related.Kind == ast.Interface) && hasFilterable(related)
+}
+
 // hasFilterable Returns whether TypeFilter for a defn will be generated or not.
 // It returns true if any field have search arguments or it is an `ID` field or
 // there is atleast one non-custom filter which would be the part of the has filter.
@@ -1647,6 +1861,17 @@ func isOrderable(fld *ast.FieldDefinition, defn *ast.Definition, providesTypeMap
 	return isKeyField(fld, defn) || providesTypeMap[fld.Name]
 }
 
+// isGroupable returns true if the nodes of defn can be grouped by the values of fld in aggregate
+// queries. Like for ordering, lists can't be used, and NamedType will be empty for them.
+func isGroupable(sch *ast.Schema, fld *ast.FieldDefinition, defn *ast.Definition,
+	providesTypeMap map[string]bool) bool {
+	typ := sch.Types[fld.Type.NamedType]
+	if typ == nil || externalAndNonKeyField(fld, defn, providesTypeMap) || hasCustomOrLambda(fld) {
+		return false
+	}
+	return groupable[typ.Name] || typ.Kind == ast.Enum
+}
+
 // Returns true if the field is of type which can be summed. Eg: int, int64, float
 func isSummable(fld *ast.FieldDefinition, defn *ast.Definition, providesTypeMap map[string]bool) bool {
 	if externalAndNonKeyField(fld, defn, providesTypeMap) {
@@ -1814,7 +2039,8 @@ func addAddPayloadType(schema *ast.Schema, defn *ast.Definition, providesTypeMap
 	}
 }
 
-func addUpdatePayloadType(schema *ast.Schema, defn *ast.Definition, providesTypeMap map[string]bool) {
+func addUpdatePayloadType(schema *ast.Schema, defn *ast.Definition, providesTypeMap map[string]bool,
+	generateDiff bool) {
 	if !hasFilterable(defn) {
 		return
 	}
@@ -1846,6 +2072,42 @@ func addUpdatePayloadType(schema *ast.Schema, defn *ast.Definition, providesType
 			qry, numUids,
 		},
 	}
+
+	if generateDiff {
+		addUpdateDiffType(schema, defn)
+	}
+}
+
+// addUpdateDiffType adds the type giving the values of an updated node from before and after
+// the update, like:
+//
+//	type UpdateAuthorDiff {
+//		before: Author
+//		after: Author
+//	}
+//
+// and adds a field of that type to the update payload:
+//
+//	type UpdateAuthorPayload {
+//		...
+//		diff: [UpdateAuthorDiff]
+//	}
+func addUpdateDiffType(schema *ast.Schema, defn *ast.Definition) {
+	diffName := "Update" + defn.Name + "Diff"
+	schema.Types[diffName] = &ast.Definition{
+		Kind: ast.Object,
+		Name: diffName,
+		Fields: []*ast.FieldDefinition{
+			{Name: DiffBefore, Type: &ast.Type{NamedType: defn.Name}},
+			{Name: DiffAfter, Type: &ast.Type{NamedType: defn.Name}},
+		},
+	}
+
+	payload := schema.Types["Update"+defn.Name+"Payload"]
+	payload.Fields = append(payload.Fields, &ast.FieldDefinition{
+		Name: Diff,
+		Type: ast.ListType(&ast.Type{NamedType: diffName}, nil),
+	})
 }
 
 func addDeletePayloadType(schema *ast.Schema, defn *ast.Definition, providesTypeMap map[string]bool) {
@@ -1933,6 +2195,11 @@ func addAggregationResultType(schema *ast.Schema, defn *ast.Definition, provides
 		}
 	}
 
+	if groups := addAggregationGroupType(schema, defn, aggregateFields,
+		providesTypeMap); groups != nil {
+		aggregateFields = append(aggregateFields, groups)
+	}
+
 	schema.Types[aggregationResultTypeName] = &ast.Definition{
 		Kind:   ast.Object,
 		Name:   aggregationResultTypeName,
@@ -1940,6 +2207,75 @@ func addAggregationResultType(schema *ast.Schema, defn *ast.Definition, provides
 	}
 }
 
+// addAggregationGroupType adds the types needed to group the results of the aggregate query
+// for defn, if it has any groupable fields. For a type Post, it adds:
+//
+// enum PostGroupable { title, isPublished, ... }
+//
+// type PostGroupKey {
+//	title: String
+//	isPublished: Boolean
+//	...
+// }
+//
+// type PostAggregateGroup {
+//	key: PostGroupKey!
+//	count: Int
+//	... the other aggregateFields
+// }
+//
+// and returns the field `groups: [PostAggregateGroup!]` for PostAggregateResult.
+// `aggregatePost(groupBy: [title])` then gives the aggregates of every title in groups.
+func addAggregationGroupType(schema *ast.Schema, defn *ast.Definition,
+	aggregateFields ast.FieldList, providesTypeMap map[string]bool) *ast.FieldDefinition {
+	groupableName := defn.Name + "Groupable"
+	keyName := defn.Name + "GroupKey"
+	groupName := defn.Name + "AggregateGroup"
+
+	groupBy := &ast.Definition{
+		Kind: ast.Enum,
+		Name: groupableName,
+	}
+	key := &ast.Definition{
+		Kind: ast.Object,
+		Name: keyName,
+	}
+	for _, fld := range defn.Fields {
+		if isGroupable(schema, fld, defn, providesTypeMap) {
+			groupBy.EnumValues = append(groupBy.EnumValues,
+				&ast.EnumValueDefinition{Name: fld.Name})
+			// all the grouped fields are nullable, as only the ones in groupBy have values
+			key.Fields = append(key.Fields, &ast.FieldDefinition{
+				Name: fld.Name,
+				Type: &ast.Type{NamedType: fld.Type.NamedType},
+			})
+		}
+	}
+	if len(groupBy.EnumValues) == 0 {
+		return nil
+	}
+
+	groupFields := ast.FieldList{&ast.FieldDefinition{
+		Name: "key",
+		Type: &ast.Type{NamedType: keyName, NonNull: true},
+	}}
+	for _, fld := range aggregateFields {
+		f := *fld
+		groupFields = append(groupFields, &f)
+	}
+	schema.Types[groupableName] = groupBy
+	schema.Types[keyName] = key
+	schema.Types[groupName] = &ast.Definition{
+		Kind:   ast.Object,
+		Name:   groupName,
+		Fields: groupFields,
+	}
+	return &ast.FieldDefinition{
+		Name: "groups",
+		Type: ast.ListType(&ast.Type{NamedType: groupName, NonNull: true}, nil),
+	}
+}
+
 func addGetQuery(schema *ast.Schema, defn *ast.Definition, providesTypeMap map[string]bool, generateSubscription bool) {
 	hasIDField := hasID(defn)
 	hasXIDField := hasXID(defn)
@@ -2029,6 +2365,12 @@ func addAggregationQuery(schema *ast.Schema, defn *ast.Definition, generateSubsc
 		},
 	}
 	addFilterArgumentForField(schema, qry, defn.Name)
+	if schema.Types[defn.Name+"Groupable"] != nil {
+		qry.Arguments = append(qry.Arguments, &ast.ArgumentDefinition{
+			Name: "groupBy",
+			Type: ast.ListType(&ast.Type{NamedType: defn.Name + "Groupable", NonNull: true}, nil),
+		})
+	}
 
 	schema.Query.Fields = append(schema.Query.Fields, qry)
 	subs := defn.Directives.ForName(subscriptionDirective)
@@ -2038,6 +2380,82 @@ func addAggregationQuery(schema *ast.Schema, defn *ast.Definition, generateSubsc
 
 }
 
+// addConnectionQuery adds a query returning the nodes of type defn as a Relay connection,
+// along with the connection and edge types for defn, like:
+//
+// type PostConnection {
+//	edges: [PostEdge!]!
+//	pageInfo: PageInfo!
+// }
+//
+// type PostEdge {
+//	node: Post!
+//	cursor: String!
+// }
+//
+// queryPostConnection(filter: PostFilter, order: PostOrder, first: Int, after: String): PostConnection
+//
+// The PageInfo type is shared by all the connections, and is added along with the first of them.
+func addConnectionQuery(schema *ast.Schema, defn *ast.Definition, providesTypeMap map[string]bool) {
+	if schema.Types[pageInfoType] == nil {
+		schema.Types[pageInfoType] = &ast.Definition{
+			Kind: ast.Object,
+			Name: pageInfoType,
+			Fields: ast.FieldList{
+				{Name: pageInfoStartCursor, Type: &ast.Type{NamedType: "String"}},
+				{Name: pageInfoEndCursor, Type: &ast.Type{NamedType: "String"}},
+				{Name: pageInfoHasNextPage, Type: &ast.Type{NamedType: "Boolean", NonNull: true}},
+				{Name: pageInfoHasPreviousPage,
+					Type: &ast.Type{NamedType: "Boolean", NonNull: true}},
+			},
+		}
+	}
+
+	edgeTypeName := defn.Name + "Edge"
+	schema.Types[edgeTypeName] = &ast.Definition{
+		Kind: ast.Object,
+		Name: edgeTypeName,
+		Fields: ast.FieldList{
+			{Name: edgeNode, Type: &ast.Type{NamedType: defn.Name, NonNull: true}},
+			{Name: edgeCursor, Type: &ast.Type{NamedType: "String", NonNull: true}},
+		},
+	}
+
+	connectionTypeName := defn.Name + "Connection"
+	schema.Types[connectionTypeName] = &ast.Definition{
+		Kind: ast.Object,
+		Name: connectionTypeName,
+		Fields: ast.FieldList{
+			{Name: connectionEdges, Type: &ast.Type{
+				Elem:    &ast.Type{NamedType: edgeTypeName, NonNull: true},
+				NonNull: true,
+			}},
+			{Name: connectionPageInfo, Type: &ast.Type{NamedType: pageInfoType, NonNull: true}},
+		},
+	}
+
+	qry := &ast.FieldDefinition{
+		Name: "query" + connectionTypeName,
+		Type: &ast.Type{
+			NamedType: connectionTypeName,
+		},
+	}
+	addFilterArgumentForField(schema, qry, defn.Name)
+	if hasOrderables(defn, providesTypeMap) {
+		qry.Arguments = append(qry.Arguments,
+			&ast.ArgumentDefinition{
+				Name: "order",
+				Type: &ast.Type{NamedType: defn.Name + "Order"},
+			})
+	}
+	qry.Arguments = append(qry.Arguments,
+		&ast.ArgumentDefinition{Name: "first", Type: &ast.Type{NamedType: "Int"}},
+		&ast.ArgumentDefinition{Name: "after", Type: &ast.Type{NamedType: "String"}},
+	)
+
+	schema.Query.Fields = append(schema.Query.Fields, qry)
+}
+
 func addPasswordQuery(schema *ast.Schema, defn *ast.Definition, providesTypeMap map[string]bool) {
 	hasIDField := hasID(defn)
 	hasXIDField := hasXID(defn)
@@ -2097,6 +2515,10 @@ func addQueries(
 	if params.generateAggregateQuery {
 		addAggregationQuery(schema, defn, params.generateSubscription)
 	}
+
+	if params.generateConnectionQuery {
+		addConnectionQuery(schema, defn, providesTypeMap)
+	}
 }
 
 func addAddMutation(schema *ast.Schema, defn *ast.Definition) {
@@ -2214,7 +2636,9 @@ func createField(schema *ast.Schema, fld *ast.FieldDefinition) *ast.FieldDefinit
 	newFld := *fld
 	newFldType := *fld.Type
 	newFld.Type = &newFldType
-	newFld.Directives = nil
+	// @constraint is kept on the input field, so that literal values given for it in a mutation
+	// can be checked while validating the operation.
+	newFld.Directives = fld.Directives.ForNames(constraintDirective)
 	newFld.Arguments = nil
 	return &newFld
 }
@@ -2468,6 +2892,11 @@ func generateEnumString(typ *ast.Definition) string {
 	return sch.String()
 }
 
+func generateScalarString(typ *ast.Definition) string {
+	return fmt.Sprintf("%sscalar %s%s\n", generateDescription(typ.Description), typ.Name,
+		genDirectivesString(typ.Directives))
+}
+
 func generateDescription(description string) string {
 	if description == "" {
 		return ""
@@ -2532,7 +2961,7 @@ func Stringify(schema *ast.Schema, originalTypes []string, apolloServiceQuery bo
 	// Marked "_Service" type as printed as it will be printed in the
 	// Extended Apollo Definitions
 	printed["_Service"] = true
-	// original defs can only be interface, type, union, enum or input.
+	// original defs can only be interface, type, union, enum, input or scalar.
 	// print those in the same order as the original schema.
 	for _, typName := range originalTypes {
 		if isQueryOrMutation(typName) {
@@ -2551,6 +2980,8 @@ func Stringify(schema *ast.Schema, originalTypes []string, apolloServiceQuery bo
 			x.Check2(original.WriteString(generateEnumString(typ) + "\n"))
 		case ast.InputObject:
 			x.Check2(original.WriteString(generateInputString(typ) + "\n"))
+		case ast.Scalar:
+			x.Check2(original.WriteString(generateScalarString(typ) + "\n"))
 		}
 		printed[typName] = true
 	}
@@ -2613,12 +3044,19 @@ func Stringify(schema *ast.Schema, originalTypes []string, apolloServiceQuery bo
 		"#######################\n# Extended Definitions\n#######################\n"))
 	x.Check2(sch.WriteString(schemaExtras))
 	x.Check2(sch.WriteString("\n"))
-	// Add Apollo Extras to the schema only when "_Entity" union is generated.
-	if schema.Types["_Entity"] != nil {
+	// Add Apollo Extras to the schema only when it is federated, which is when it has entities or
+	// links Federation 2. They aren't part of the result of Apollo service query.
+	if !apolloServiceQuery && schema.Types["_Service"] != nil {
 		x.Check2(sch.WriteString(
 			"#######################\n# Extended Apollo Definitions\n#######################\n"))
-		x.Check2(sch.WriteString(generateUnionString(schema.Types["_Entity"])))
-		x.Check2(sch.WriteString(apolloSchemaExtras))
+		if schema.Types["_Entity"] != nil {
+			x.Check2(sch.WriteString(generateUnionString(schema.Types["_Entity"])))
+		}
+		if isFederation2(schema) {
+			x.Check2(sch.WriteString(apolloFederation2SchemaExtras))
+		} else {
+			x.Check2(sch.WriteString(apolloSchemaExtras))
+		}
 		x.Check2(sch.WriteString("\n"))
 	}
 	if object.Len() > 0 {
@@ -2674,6 +3112,34 @@ func appendIfNotNull(errs []*gqlerror.Error, err *gqlerror.Error) gqlerror.List
 	return errs
 }
 
+// customScalarBase returns the base type given in the @scalar directive of the scalar named
+// typName, or "" if typName isn't a scalar declared with @scalar.
+func customScalarBase(sch *ast.Schema, typName string) string {
+	defn := sch.Types[typName]
+	if defn == nil || defn.Kind != ast.Scalar {
+		return ""
+	}
+	dir := defn.Directives.ForName(scalarDirective)
+	if dir == nil {
+		return ""
+	}
+	return dir.Arguments.ForName(scalarTypeArg).Value.Raw
+}
+
+// storageScalar returns the built-in scalar that values of the scalar named typName are stored
+// as. That is typName itself for the built-in scalars, and the base type for scalars declared
+// with @scalar, where JSON values are stored as strings.
+func storageScalar(sch *ast.Schema, typName string) string {
+	switch base := customScalarBase(sch, typName); base {
+	case "":
+		return typName
+	case jsonScalarBase:
+		return "String"
+	default:
+		return base
+	}
+}
+
 func isGraphqlSpecScalar(typ string) bool {
 	_, ok := graphqlSpecScalars[typ]
 	return ok
@@ -64,6 +64,14 @@ func (s *schema) Operation(req *Request) (Operation, error) {
 	if len(listErr) != 0 {
 		return nil, listErr
 	}
+	if listErr = s.constraintCheck(doc, req.Variables); len(listErr) != 0 {
+		return nil, listErr
+	}
+	if !s.admin {
+		if listErr = queryLimitsCheck(doc, req.Variables); len(listErr) != 0 {
+			return nil, listErr
+		}
+	}
 
 	if len(doc.Operations) == 1 && doc.Operations[0].Operation == ast.Subscription &&
 		s.schema.Subscription == nil {
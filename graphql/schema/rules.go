@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -38,16 +39,17 @@ const (
 
 func init() {
 	schemaDocValidations = append(schemaDocValidations, typeNameValidation,
-		customQueryNameValidation, customMutationNameValidation)
+		customQueryNameValidation, customMutationNameValidation, apolloLinkValidation)
 	defnValidations = append(defnValidations, dataTypeCheck, nameCheck, directiveLocationCheck)
 
 	schemaValidations = append(schemaValidations, dgraphDirectivePredicateValidation)
 	typeValidations = append(typeValidations, idCountCheck, dgraphDirectiveTypeValidation,
 		passwordDirectiveValidation, conflictingDirectiveValidation, nonIdFieldsCheck,
 		remoteTypeValidation, generateDirectiveValidation, apolloKeyValidation,
-		apolloExtendsValidation, lambdaOnMutateValidation)
+		apolloExtendsValidation, lambdaOnMutateValidation, lambdaBeforeMutateValidation,
+		scalarDirectiveValidation, compositeIdValidation, apolloInterfaceObjectValidation)
 	fieldValidations = append(fieldValidations, listValidityCheck, fieldArgumentCheck,
-		fieldNameCheck, isValidFieldForList, hasAuthDirective, fieldDirectiveCheck)
+		fieldNameCheck, isValidFieldForList, fieldDirectiveCheck)
 
 	validator.AddRuleWithOrder("Check variable type is correct", baseRules, variableTypeCheck)
 	validator.AddRuleWithOrder("Check arguments of cascade directive", baseRules, directiveArgumentsCheck)
@@ -332,6 +334,18 @@ func typeNameValidation(schema *ast.SchemaDocument) gqlerror.List {
 			forbiddenTypeNames[defName+"Filter"] = true
 			forbiddenTypeNames[defName+"Order"] = true
 			forbiddenTypeNames[defName+"Orderable"] = true
+
+			// the Relay connection types and the update diffs are generated only for the types
+			// asking for them
+			params := parseGenerateDirectiveParams(defn)
+			if params.generateConnectionQuery {
+				forbiddenTypeNames[defName+"Connection"] = true
+				forbiddenTypeNames[defName+"Edge"] = true
+				forbiddenTypeNames[pageInfoType] = true
+			}
+			if params.generateUpdateDiff {
+				forbiddenTypeNames["Update"+defName+"Diff"] = true
+			}
 		}
 	}
 
@@ -430,11 +444,104 @@ func customMutationNameValidation(schema *ast.SchemaDocument) gqlerror.List {
 	return errs
 }
 
+// apolloLinkValidation validates the directives on the schema. The only one of them that is
+// supported is @link, with which a schema links Apollo Federation 2, like:
+//
+//	extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@key"])
+//
+// Schemas that don't link Federation 2 can't use the directives that were added in it.
+func apolloLinkValidation(schema *ast.SchemaDocument) gqlerror.List {
+	var errs []*gqlerror.Error
+
+	links := 0
+	for _, dir := range schemaDirectives(schema) {
+		if dir.Name != apolloLinkDirective {
+			errs = append(errs, gqlerror.ErrorPosf(dir.Position,
+				"Schema; @%s directive is not supported on the schema, only @link is.", dir.Name))
+			continue
+		}
+		links++
+		if links > 1 {
+			errs = append(errs, gqlerror.ErrorPosf(dir.Position,
+				"Schema; @link directive should not be defined more than once."))
+			continue
+		}
+		url := dir.Arguments.ForName(apolloLinkURLArg)
+		if url == nil || url.Value.Kind != ast.StringValue ||
+			!strings.HasPrefix(url.Value.Raw, apolloFederation2URLPrefix) {
+			errs = append(errs, gqlerror.ErrorPosf(dir.Position,
+				"Schema; @link directive only supports linking Federation 2, its url must be "+
+					"like \"%s0\".", apolloFederation2URLPrefix))
+			continue
+		}
+		errs = append(errs, apolloLinkImportValidation(dir)...)
+	}
+	if federationLink(schema) != nil {
+		return errs
+	}
+
+	for _, defn := range schema.Definitions {
+		if defn.BuiltIn {
+			continue
+		}
+		for _, dir := range defn.Directives {
+			if federation2Directives[dir.Name] {
+				errs = append(errs, gqlerror.ErrorPosf(dir.Position,
+					"Type %s; @%s directive is only supported when the schema links "+
+						"Federation 2 with @link.", defn.Name, dir.Name))
+			}
+			if dir.Name == apolloKeyDirective &&
+				dir.Arguments.ForName(apolloKeyResolvableArg) != nil {
+				errs = append(errs, gqlerror.ErrorPosf(dir.Position,
+					"Type %s; Argument %s inside @key directive is only supported when the "+
+						"schema links Federation 2 with @link.", defn.Name, apolloKeyResolvableArg))
+			}
+		}
+		for _, fld := range defn.Fields {
+			for _, dir := range fld.Directives {
+				if federation2Directives[dir.Name] {
+					errs = append(errs, gqlerror.ErrorPosf(dir.Position,
+						"Type %s; Field %s: @%s directive is only supported when the schema "+
+							"links Federation 2 with @link.", defn.Name, fld.Name, dir.Name))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func apolloLinkImportValidation(dir *ast.Directive) gqlerror.List {
+	arg := dir.Arguments.ForName(apolloLinkImportArg)
+	if arg == nil {
+		return nil
+	}
+	if arg.Value.Kind != ast.ListValue {
+		return []*gqlerror.Error{gqlerror.ErrorPosf(arg.Position,
+			"Schema; Argument %s inside @link directive must be a list of names, "+
+				"like [\"@key\"].", apolloLinkImportArg)}
+	}
+
+	var errs []*gqlerror.Error
+	for _, child := range arg.Value.Children {
+		switch {
+		case child.Value.Kind != ast.StringValue:
+			errs = append(errs, gqlerror.ErrorPosf(child.Value.Position,
+				"Schema; @link directive imports %s, but only names can be imported, "+
+					"renaming imports is not supported.", child.Value.String()))
+		case !federation2Imports[child.Value.Raw]:
+			errs = append(errs, gqlerror.ErrorPosf(child.Value.Position,
+				"Schema; @link directive imports %s, which is not supported.", child.Value.Raw))
+		}
+	}
+	return errs
+}
+
 func dataTypeCheck(schema *ast.Schema, defn *ast.Definition) gqlerror.List {
-	if defn.Kind == ast.Scalar {
+	if defn.Kind == ast.Scalar && defn.Directives.ForName(scalarDirective) == nil {
 		return []*gqlerror.Error{gqlerror.ErrorPosf(
-			defn.Position, "You can't add scalar definitions. "+
-				"Only type, interface, union, input and enums are allowed in initial schema.")}
+			defn.Position, "You can't add scalar definitions without the @scalar directive. "+
+				"Only type, interface, union, input, enums and scalars with @scalar are allowed "+
+				"in initial schema.")}
 	}
 	return nil
 }
@@ -723,16 +830,75 @@ func idCountCheck(schema *ast.Schema, typ *ast.Definition) gqlerror.List {
 	return errs
 }
 
-func hasAuthDirective(typ *ast.Definition, field *ast.FieldDefinition) gqlerror.List {
-	for _, directive := range field.Directives {
-		if directive.Name != authDirective {
-			continue
+func authDirectiveValidation(sch *ast.Schema,
+	typ *ast.Definition,
+	field *ast.FieldDefinition,
+	dir *ast.Directive,
+	secrets map[string]x.SensitiveByteSlice) gqlerror.List {
+	// Fields inherited from an interface get their rules from the interface, where they are
+	// validated.
+	if isInheritedField(sch, typ, field) {
+		return nil
+	}
+
+	var errs []*gqlerror.Error
+	for _, arg := range dir.Arguments {
+		if arg.Name == "password" || arg.Name == "delete" {
+			errs = append(errs, gqlerror.ErrorPosf(arg.Position,
+				"Type %s; Field %s: @%s directive on fields only supports query, add and "+
+					"update rules, found %s.", typ.Name, field.Name, authDirective, arg.Name))
 		}
-		return []*gqlerror.Error{gqlerror.ErrorPosf(field.Position,
-			"Type %s; Field %s: @%s directive is not allowed on fields",
-			typ.Name, field.Name, authDirective)}
 	}
-	return nil
+
+	switch {
+	case typ.Directives.ForName(remoteDirective) != nil:
+		errs = append(errs, gqlerror.ErrorPosf(dir.Position,
+			"Type %s; Field %s: @%s directive is not allowed on fields of @remote types.",
+			typ.Name, field.Name, authDirective))
+	case hasCustomOrLambda(field):
+		errs = append(errs, gqlerror.ErrorPosf(dir.Position,
+			"Type %s; Field %s: @%s directive is not allowed on fields with @custom or "+
+				"@lambda directive.", typ.Name, field.Name, authDirective))
+	case isID(field):
+		errs = append(errs, gqlerror.ErrorPosf(dir.Position,
+			"Type %s; Field %s: @%s directive is not allowed on fields of type ID.",
+			typ.Name, field.Name, authDirective))
+	}
+
+	// A field that isn't readable is returned as null, so it has to be nullable. Rules that
+	// aren't RBAC rules are evaluated with value variables, which don't hold lists or edges.
+	qry := dir.Arguments.ForName("query")
+	if qry == nil || qry.Value == nil {
+		return errs
+	}
+	if field.Type.NonNull {
+		errs = append(errs, gqlerror.ErrorPosf(qry.Position,
+			"Type %s; Field %s: a field with a query rule in @%s directive must be nullable.",
+			typ.Name, field.Name, authDirective))
+	}
+	fieldTyp := sch.Types[field.Type.Name()]
+	if hasGraphAuthRule(qry.Value) && (field.Type.Elem != nil ||
+		(!isScalar(field.Type.Name()) && customScalarBase(sch, field.Type.Name()) == "" &&
+			(fieldTyp == nil || fieldTyp.Kind != ast.Enum))) {
+		errs = append(errs, gqlerror.ErrorPosf(qry.Position,
+			"Type %s; Field %s: query rules in @%s directive that aren't RBAC rules are only "+
+				"supported on scalar and enum fields which aren't lists.",
+			typ.Name, field.Name, authDirective))
+	}
+	return errs
+}
+
+// hasGraphAuthRule tells if the auth rule has a rule that isn't an RBAC rule.
+func hasGraphAuthRule(val *ast.Value) bool {
+	for _, child := range val.Children {
+		if child.Name == "rule" && !strings.HasPrefix(child.Value.Raw, RBACQueryPrefix) {
+			return true
+		}
+		if hasGraphAuthRule(child.Value) {
+			return true
+		}
+	}
+	return false
 }
 
 func isValidFieldForList(typ *ast.Definition, field *ast.FieldDefinition) gqlerror.List {
@@ -973,6 +1139,7 @@ func validateSearchArg(searchArg string,
 	dir *ast.Directive) *gqlerror.Error {
 
 	isEnum := sch.Types[field.Type.Name()].Kind == ast.Enum
+	fieldType := storageScalar(sch, field.Type.Name())
 	search, ok := supportedSearches[searchArg]
 	switch {
 	case !ok:
@@ -984,7 +1151,7 @@ func validateSearchArg(searchArg string,
 				"Fields of type %s %s.",
 			typ.Name, field.Name, searchArg, field.Type.Name(), searchMessage(sch, field))
 
-	case search.gqlType != field.Type.Name() && !isEnum:
+	case search.gqlType != fieldType && !isEnum:
 		return gqlerror.ErrorPosf(
 			dir.Position,
 			"Type %s; Field %s: has the @search directive but the argument %s "+
@@ -1021,6 +1188,23 @@ func searchValidation(
 	var errs []*gqlerror.Error
 
 	arg := dir.Arguments.ForName(searchArgs)
+	switch base := customScalarBase(sch, field.Type.Name()); {
+	case base == jsonScalarBase:
+		errs = append(errs, gqlerror.ErrorPosf(
+			dir.Position,
+			"Type %s; Field %s: has the @search directive but fields of type %s "+
+				"can't have the @search directive as it is a JSON scalar.",
+			typ.Name, field.Name, field.Type.Name()))
+		return errs
+	case base != "" && arg == nil:
+		errs = append(errs, gqlerror.ErrorPosf(
+			dir.Position,
+			"Type %s; Field %s: has the @search directive without arguments, but fields of "+
+				"scalar %s declared with @scalar need the indexes, like @search(by: [hash]).",
+			typ.Name, field.Name, field.Type.Name()))
+		return errs
+	}
+
 	if arg == nil {
 		// If there's no arg, then it can be an enum or Geo type or has to be a scalar that's
 		// not ID. The schema generation will add the default search
@@ -1254,16 +1438,75 @@ func lambdaDirectiveValidation(sch *ast.Schema,
 			typ.Name, field.Name)}
 	}
 	// reuse @custom directive validation
-	errs := customDirectiveValidation(sch, typ, field, buildCustomDirectiveForLambda(typ, field,
-		dir, x.GalaxyNamespace, func(f *ast.FieldDefinition) bool { return false }), secrets)
+	errs := validateCustomDirective(sch, typ, field, buildCustomDirectiveForLambda(typ, field,
+		dir, x.GalaxyNamespace, func(f *ast.FieldDefinition) bool { return false }), secrets, true)
 	for _, err := range errs {
 		err.Message = "While building @custom for @lambda: " + err.Message
 	}
 	return errs
 }
 
+func scalarDirectiveValidation(sch *ast.Schema, typ *ast.Definition) gqlerror.List {
+	dir := typ.Directives.ForName(scalarDirective)
+	if dir == nil {
+		return nil
+	}
+
+	var errs []*gqlerror.Error
+
+	if isScalar(typ.Name) || isGraphqlSpecScalar(typ.Name) {
+		errs = append(errs, gqlerror.ErrorPosf(dir.Position,
+			"Scalar %s; is a built-in scalar and can't be declared with the @scalar directive.",
+			typ.Name))
+	}
+
+	base := dir.Arguments.ForName(scalarTypeArg)
+	if !customScalarBases[base.Value.Raw] {
+		bases := make([]string, 0, len(customScalarBases))
+		for b := range customScalarBases {
+			bases = append(bases, b)
+		}
+		sort.Strings(bases)
+		errs = append(errs, gqlerror.ErrorPosf(base.Position,
+			"Scalar %s; @scalar directive has type %s, but it must be one of %s.",
+			typ.Name, base.Value.Raw, strings.Join(bases, ", ")))
+	}
+
+	if regex := dir.Arguments.ForName(scalarRegexArg); regex != nil {
+		if base.Value.Raw != "String" {
+			errs = append(errs, gqlerror.ErrorPosf(regex.Position,
+				"Scalar %s; @scalar directive can only have a regex when its type is String.",
+				typ.Name))
+		}
+		if _, err := regexp.Compile(regex.Value.Raw); err != nil {
+			errs = append(errs, gqlerror.ErrorPosf(regex.Position,
+				"Scalar %s; @scalar directive has an invalid regex %s: %s.",
+				typ.Name, regex.Value.Raw, err))
+		}
+	}
+
+	lambda := dir.Arguments.ForName(scalarLambdaArg)
+	if lambda != nil && lambda.Value.Raw == "true" && x.LambdaUrl(x.GalaxyNamespace) == "" {
+		errs = append(errs, gqlerror.ErrorPosf(lambda.Position,
+			"Scalar %s; has a lambda validator in the @scalar directive, but the "+
+				"`--graphql lambda-url` flag wasn't specified during alpha startup.", typ.Name))
+	}
+
+	return errs
+}
+
 func lambdaOnMutateValidation(sch *ast.Schema, typ *ast.Definition) gqlerror.List {
-	dir := typ.Directives.ForName(lambdaOnMutateDirective)
+	return lambdaMutateDirectiveValidation(typ, lambdaOnMutateDirective)
+}
+
+func lambdaBeforeMutateValidation(sch *ast.Schema, typ *ast.Definition) gqlerror.List {
+	return lambdaMutateDirectiveValidation(typ, lambdaBeforeMutateDirective)
+}
+
+// lambdaMutateDirectiveValidation validates the directives which configure the lambdas to call
+// for the add/update/delete mutations of a type, i.e., @lambdaOnMutate and @lambdaBeforeMutate.
+func lambdaMutateDirectiveValidation(typ *ast.Definition, dirName string) gqlerror.List {
+	dir := typ.Directives.ForName(dirName)
 	if dir == nil {
 		return nil
 	}
@@ -1273,15 +1516,16 @@ func lambdaOnMutateValidation(sch *ast.Schema, typ *ast.Definition) gqlerror.Lis
 	// lambda url must be specified during alpha startup
 	if x.LambdaUrl(x.GalaxyNamespace) == "" {
 		errs = append(errs, gqlerror.ErrorPosf(dir.Position,
-			"Type %s: has the @lambdaOnMutate directive, but the "+
-				"`--graphql lambda-url` flag wasn't specified during alpha startup.", typ.Name))
+			"Type %s: has the @%s directive, but the "+
+				"`--graphql lambda-url` flag wasn't specified during alpha startup.",
+			typ.Name, dirName))
 	}
 
 	if typ.Directives.ForName(remoteDirective) != nil {
 		errs = append(errs, gqlerror.ErrorPosf(
 			dir.Position,
-			"Type %s; @lambdaOnMutate directive not allowed along with @remote directive.",
-			typ.Name))
+			"Type %s; @%s directive not allowed along with @remote directive.",
+			typ.Name, dirName))
 	}
 
 	for _, arg := range dir.Arguments {
@@ -1289,11 +1533,42 @@ func lambdaOnMutateValidation(sch *ast.Schema, typ *ast.Definition) gqlerror.Lis
 		if arg.Value.Kind != ast.BooleanValue {
 			errs = append(errs, gqlerror.ErrorPosf(
 				arg.Position,
-				"Type %s; %s argument in @lambdaOnMutate directive can only be "+
+				"Type %s; %s argument in @%s directive can only be "+
 					"true/false, found: `%s`.",
-				typ.Name, arg.Name, arg.Value.String()))
+				typ.Name, arg.Name, dirName, arg.Value.String()))
+		}
+	}
+
+	return errs
+}
+
+func compositeIdValidation(sch *ast.Schema, typ *ast.Definition) gqlerror.List {
+	dir := typ.Directives.ForName(compositeIdDirective)
+	if dir == nil {
+		return nil
+	}
+
+	var errs []*gqlerror.Error
+
+	if typ.Directives.ForName(remoteDirective) != nil {
+		errs = append(errs, gqlerror.ErrorPosf(
+			dir.Position,
+			"Type %s; @compositeId directive not allowed along with @remote directive.",
+			typ.Name))
+	}
+
+	xidCount := 0
+	for _, f := range typ.Fields {
+		if f.Directives.ForName(idDirective) != nil {
+			xidCount++
 		}
 	}
+	if xidCount < 2 {
+		errs = append(errs, gqlerror.ErrorPosf(
+			dir.Position,
+			"Type %s; @compositeId directive needs at least two fields with @id, but the "+
+				"type has %d.", typ.Name, xidCount))
+	}
 
 	return errs
 }
@@ -1350,6 +1625,15 @@ func generateDirectiveValidation(schema *ast.Schema, typ *ast.Definition) gqlerr
 					"only be true/false, found: `%s",
 				typ.Name, aggregateField.Raw))
 		}
+
+		connectionField := queryArg.Value.Children.ForName(generateConnectionField)
+		if connectionField != nil && connectionField.Kind != ast.BooleanValue {
+			errs = append(errs, gqlerror.ErrorPosf(
+				connectionField.Position,
+				"Type %s; connection field inside query argument of @generate directive can "+
+					"only be true/false, found: `%s",
+				typ.Name, connectionField.Raw))
+		}
 	}
 
 	mutationArg := dir.Arguments.ForName(generateMutationArg)
@@ -1387,6 +1671,15 @@ func generateDirectiveValidation(schema *ast.Schema, typ *ast.Definition) gqlerr
 					"only be true/false, found: `%s",
 				typ.Name, deleteField.Raw))
 		}
+
+		diffField := mutationArg.Value.Children.ForName(generateDiffField)
+		if diffField != nil && diffField.Kind != ast.BooleanValue {
+			errs = append(errs, gqlerror.ErrorPosf(
+				diffField.Position,
+				"Type %s; diff field inside mutation argument of @generate directive can "+
+					"only be true/false, found: `%s",
+				typ.Name, diffField.Raw))
+		}
 	}
 
 	subscriptionArg := dir.Arguments.ForName(generateSubscriptionArg)
@@ -1405,6 +1698,17 @@ func customDirectiveValidation(sch *ast.Schema,
 	field *ast.FieldDefinition,
 	dir *ast.Directive,
 	secrets map[string]x.SensitiveByteSlice) gqlerror.List {
+	return validateCustomDirective(sch, typ, field, dir, secrets, false)
+}
+
+// validateCustomDirective validates a @custom directive, or the one built for a @lambda directive
+// if lambda is true. Only the latter can have the URL of the embedded lambda runtime.
+func validateCustomDirective(sch *ast.Schema,
+	typ *ast.Definition,
+	field *ast.FieldDefinition,
+	dir *ast.Directive,
+	secrets map[string]x.SensitiveByteSlice,
+	lambda bool) gqlerror.List {
 	var errs []*gqlerror.Error
 
 	// 1. Validating custom directive itself
@@ -1536,6 +1840,15 @@ func customDirectiveValidation(sch *ast.Schema,
 			field.Name))
 		return errs
 	}
+	if parsedURL.Scheme == x.EmbeddedLambdaScheme && !lambda {
+		// The embedded lambda runtime is only reachable through @lambda, for the namespace that
+		// the request is made in.
+		errs = append(errs, gqlerror.ErrorPosf(
+			httpUrl.Position,
+			"Type %s; Field %s; url field inside @custom directive can't use the %s scheme, "+
+				"use @lambda instead.", typ.Name, field.Name, x.EmbeddedLambdaScheme))
+		return errs
+	}
 
 	// collect all the url variables
 	type urlVar struct {
@@ -1640,6 +1953,23 @@ func customDirectiveValidation(sch *ast.Schema,
 		}
 	}
 
+	// 6.1 Validating cacheTTL
+	if cacheTTL := httpArg.Value.Children.ForName(httpCacheTTL); cacheTTL != nil {
+		if typ.Name == "Mutation" {
+			errs = append(errs, gqlerror.ErrorPosf(
+				cacheTTL.Position,
+				"Type %s; Field %s; cacheTTL field inside @custom directive can't be "+
+					"present on Mutation, as mutations must always reach the remote endpoint.",
+				typ.Name, field.Name))
+		}
+		if ttl, err := strconv.Atoi(cacheTTL.Raw); err != nil || ttl <= 0 {
+			errs = append(errs, gqlerror.ErrorPosf(
+				cacheTTL.Position,
+				"Type %s; Field %s; cacheTTL field inside @custom directive must be a positive "+
+					"number of seconds, found: `%s`.", typ.Name, field.Name, cacheTTL.Raw))
+		}
+	}
+
 	// 7. Validating graphql combination with url params, method and body
 	body := httpArg.Value.Children.ForName(httpBody)
 	graphql := httpArg.Value.Children.ForName(httpGraphql)
@@ -2090,12 +2420,32 @@ func apolloKeyValidation(sch *ast.Schema, typ *ast.Definition) gqlerror.List {
 		return nil
 	}
 
-	if len(dirList) > 1 {
+	federation2 := isFederation2(sch)
+	if len(dirList) > 1 && !federation2 {
 		return []*gqlerror.Error{gqlerror.ErrorPosf(
 			dirList[1].Position,
-			"Type %s; @key directive should not be defined more than once.", typ.Name)}
+			"Type %s; @key directive should not be defined more than once, unless the schema "+
+				"links Federation 2 with @link.", typ.Name)}
 	}
-	dir := dirList[0]
+	for _, dir := range dirList {
+		if errs := apolloKeyDirectiveValidation(typ, dir, federation2); errs != nil {
+			return errs
+		}
+	}
+
+	remoteDirective := typ.Directives.ForName(remoteDirective)
+	if remoteDirective != nil {
+		return []*gqlerror.Error{gqlerror.ErrorPosf(
+			remoteDirective.Definition.Position,
+			"Type %s; @remote directive cannot be defined with @key directive", typ.Name)}
+	}
+	return nil
+}
+
+// apolloKeyDirectiveValidation validates a single @key directive dir of typ. Only Federation 2
+// schemas can have compound keys, which are made up of more than one field.
+func apolloKeyDirectiveValidation(typ *ast.Definition, dir *ast.Directive,
+	federation2 bool) gqlerror.List {
 	arg := dir.Arguments.ForName(apolloKeyArg)
 	if arg == nil || arg.Value.Raw == "" {
 		return []*gqlerror.Error{gqlerror.ErrorPosf(
@@ -2103,27 +2453,78 @@ func apolloKeyValidation(sch *ast.Schema, typ *ast.Definition) gqlerror.List {
 			"Type %s; Argument %s inside @key directive must be defined.", typ.Name, apolloKeyArg)}
 	}
 
-	fld := typ.Fields.ForName(arg.Value.Raw)
-	if fld == nil {
+	if strings.ContainsAny(arg.Value.Raw, "{}") {
 		return []*gqlerror.Error{gqlerror.ErrorPosf(
 			arg.Position,
-			"Type %s; @key directive uses a field %s which is not defined inside the type.", typ.Name, arg.Value.Raw)}
+			"Type %s; @key directive uses nested fields in %s, which are not supported.",
+			typ.Name, arg.Value.Raw)}
 	}
 
-	if !(isID(fld) || hasIDDirective(fld)) {
+	fldNames := strings.Fields(arg.Value.Raw)
+	if len(fldNames) > 1 && !federation2 {
 		return []*gqlerror.Error{gqlerror.ErrorPosf(
 			arg.Position,
-			"Type %s: Field %s: used inside @key directive should be of type ID or have @id directive.",
-			typ.Name,
-			fld.Name,
-		)}
+			"Type %s; @key directive uses more than one field in %s, which is only supported "+
+				"when the schema links Federation 2 with @link.", typ.Name, arg.Value.Raw)}
 	}
 
-	remoteDirective := typ.Directives.ForName(remoteDirective)
-	if remoteDirective != nil {
+	seen := make(map[string]bool, len(fldNames))
+	for _, fldName := range fldNames {
+		fld := typ.Fields.ForName(fldName)
+		if fld == nil {
+			return []*gqlerror.Error{gqlerror.ErrorPosf(
+				arg.Position,
+				"Type %s; @key directive uses a field %s which is not defined inside the type.",
+				typ.Name, fldName)}
+		}
+		if seen[fldName] {
+			return []*gqlerror.Error{gqlerror.ErrorPosf(
+				arg.Position,
+				"Type %s; @key directive uses the field %s more than once.", typ.Name, fldName)}
+		}
+		seen[fldName] = true
+
+		if len(fldNames) == 1 && !(isID(fld) || hasIDDirective(fld)) {
+			return []*gqlerror.Error{gqlerror.ErrorPosf(
+				arg.Position,
+				"Type %s: Field %s: used inside @key directive should be of type ID or have @id directive.",
+				typ.Name,
+				fld.Name,
+			)}
+		}
+		// The fields of a compound key are looked up together with eq(), so they can't be of
+		// type ID, which is looked up by uid.
+		if len(fldNames) > 1 && !hasIDDirective(fld) {
+			return []*gqlerror.Error{gqlerror.ErrorPosf(
+				arg.Position,
+				"Type %s: Field %s: used inside a compound @key directive should have @id directive.",
+				typ.Name,
+				fld.Name,
+			)}
+		}
+	}
+	return nil
+}
+
+// apolloInterfaceObjectValidation validates @interfaceObject, which marks a type that stands for
+// an interface of the supergraph. The supergraph resolves those types as entities, so they need
+// a @key.
+func apolloInterfaceObjectValidation(sch *ast.Schema, typ *ast.Definition) gqlerror.List {
+	dir := typ.Directives.ForName(apolloInterfaceObjectDirective)
+	if dir == nil {
+		return nil
+	}
+	if typ.Directives.ForName(apolloKeyDirective) == nil {
 		return []*gqlerror.Error{gqlerror.ErrorPosf(
-			remoteDirective.Definition.Position,
-			"Type %s; @remote directive cannot be defined with @key directive", typ.Name)}
+			dir.Position,
+			"Type %s; @interfaceObject directive cannot be defined without @key directive",
+			typ.Name)}
+	}
+	if hasExtends(typ) {
+		return []*gqlerror.Error{gqlerror.ErrorPosf(
+			dir.Position,
+			"Type %s; @interfaceObject directive cannot be defined on type extensions",
+			typ.Name)}
 	}
 	return nil
 }
@@ -2271,6 +2672,30 @@ func apolloExternalValidation(sch *ast.Schema,
 	return nil
 }
 
+func apolloOverrideValidation(sch *ast.Schema,
+	typ *ast.Definition,
+	field *ast.FieldDefinition,
+	dir *ast.Directive,
+	secrets map[string]x.SensitiveByteSlice) gqlerror.List {
+
+	arg := dir.Arguments.ForName(apolloOverrideArg)
+	if arg == nil || arg.Value.Raw == "" {
+		return []*gqlerror.Error{gqlerror.ErrorPosf(
+			dir.Position,
+			"Type %s: Field %s: Argument %s inside @override directive must be defined.",
+			typ.Name, field.Name, apolloOverrideArg)}
+	}
+
+	if hasExternal(field) {
+		return []*gqlerror.Error{gqlerror.ErrorPosf(
+			dir.Position,
+			"Type %s: Field %s: @override directive can not be defined on @external fields, as "+
+				"those are resolved by another service.",
+			typ.Name, field.Name)}
+	}
+	return nil
+}
+
 func remoteResponseValidation(sch *ast.Schema,
 	typ *ast.Definition,
 	field *ast.FieldDefinition,
@@ -2300,10 +2725,80 @@ func remoteResponseValidation(sch *ast.Schema,
 	return nil
 }
 
+func constraintValidation(sch *ast.Schema,
+	typ *ast.Definition,
+	field *ast.FieldDefinition,
+	dir *ast.Directive,
+	secrets map[string]x.SensitiveByteSlice) gqlerror.List {
+	if len(dir.Arguments) == 0 {
+		return []*gqlerror.Error{gqlerror.ErrorPosf(dir.Position,
+			"Type %s; Field %s: @constraint directive needs at least one of the arguments "+
+				"minLength, maxLength, pattern, min and max.", typ.Name, field.Name)}
+	}
+
+	var errs []*gqlerror.Error
+
+	// JSON scalars are stored as strings, but their values can be any JSON value.
+	fieldType := storageScalar(sch, field.Type.Name())
+	if customScalarBase(sch, field.Type.Name()) == jsonScalarBase {
+		fieldType = jsonScalarBase
+	}
+
+	for _, arg := range []string{constraintMinLengthArg, constraintMaxLengthArg,
+		constraintPatternArg} {
+		if a := dir.Arguments.ForName(arg); a != nil && fieldType != "String" {
+			errs = append(errs, gqlerror.ErrorPosf(a.Position,
+				"Type %s; Field %s: argument %s of @constraint directive can only be used on "+
+					"fields of type String, but the field has type %s.",
+				typ.Name, field.Name, arg, field.Type.Name()))
+		}
+	}
+	for _, arg := range []string{constraintMinArg, constraintMaxArg} {
+		if a := dir.Arguments.ForName(arg); a != nil &&
+			fieldType != "Int" && fieldType != "Int64" && fieldType != "Float" {
+			errs = append(errs, gqlerror.ErrorPosf(a.Position,
+				"Type %s; Field %s: argument %s of @constraint directive can only be used on "+
+					"fields of type Int, Int64 or Float, but the field has type %s.",
+				typ.Name, field.Name, arg, field.Type.Name()))
+		}
+	}
+
+	for _, arg := range []string{constraintMinLengthArg, constraintMaxLengthArg} {
+		if a := dir.Arguments.ForName(arg); a != nil && strings.HasPrefix(a.Value.Raw, "-") {
+			errs = append(errs, gqlerror.ErrorPosf(a.Position,
+				"Type %s; Field %s: argument %s of @constraint directive can't be negative.",
+				typ.Name, field.Name, arg))
+		}
+	}
+
+	if pattern := dir.Arguments.ForName(constraintPatternArg); pattern != nil {
+		if _, err := regexp.Compile(pattern.Value.Raw); err != nil {
+			errs = append(errs, gqlerror.ErrorPosf(pattern.Position,
+				"Type %s; Field %s: @constraint directive has an invalid pattern %s: %s.",
+				typ.Name, field.Name, pattern.Value.Raw, err))
+		}
+	}
+
+	c := constraintFromDirective(dir)
+	if c.MinLength != nil && c.MaxLength != nil && *c.MinLength > *c.MaxLength {
+		errs = append(errs, gqlerror.ErrorPosf(dir.Position,
+			"Type %s; Field %s: @constraint directive has minLength %d greater than maxLength %d.",
+			typ.Name, field.Name, *c.MinLength, *c.MaxLength))
+	}
+	if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+		errs = append(errs, gqlerror.ErrorPosf(dir.Position,
+			"Type %s; Field %s: @constraint directive has min %v greater than max %v.",
+			typ.Name, field.Name, *c.Min, *c.Max))
+	}
+
+	return errs
+}
+
 func searchMessage(sch *ast.Schema, field *ast.FieldDefinition) string {
 	var possibleSearchArgs []string
+	fieldType := storageScalar(sch, field.Type.Name())
 	for name, typ := range supportedSearches {
-		if typ.gqlType == field.Type.Name() {
+		if typ.gqlType == fieldType {
 			possibleSearchArgs = append(possibleSearchArgs, name)
 		}
 	}
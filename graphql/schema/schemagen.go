@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/vtta/dgraph/graphql/authorization"
@@ -47,6 +48,9 @@ type handler struct {
 	completeSchema *ast.Schema
 	dgraphSchema   string
 	schemaMeta     *metaInfo
+	// apolloLink is the `extend schema @link(...)` to Federation 2 that is part of the Apollo
+	// service SDL, if the schema links Federation 2.
+	apolloLink string
 }
 
 // FromString builds a GraphQL Schema from input string, or returns any parsing
@@ -67,6 +71,18 @@ func FromString(schema string, ns uint64) (Schema, error) {
 	return AsSchema(gqlSchema, ns)
 }
 
+// AdminFromString is like FromString, but for the schema of the /admin endpoint. The operations
+// of the admin schema aren't subject to the query limits, so that the limits, or the GraphQL
+// schema, can still be changed if they are set too low.
+func AdminFromString(sch string) (Schema, error) {
+	adminSchema, err := FromString(sch, x.GalaxyNamespace)
+	if err != nil {
+		return nil, err
+	}
+	adminSchema.(*schema).admin = true
+	return adminSchema, nil
+}
+
 func (s *handler) MetaInfo() *metaInfo {
 	return s.schemaMeta
 }
@@ -96,8 +112,8 @@ func (s *handler) GQLSchemaWithoutApolloExtras() string {
 		for _, fld := range defn.Fields {
 			fldDirectiveListCopy := make(ast.DirectiveList, 0)
 			for _, dir := range fld.Directives {
-				// Drop "@custom" directive from the field's definition.
-				if dir.Name == "custom" {
+				// Drop "@custom" and "@auth" directives from the field's definition.
+				if dir.Name == "custom" || dir.Name == "auth" {
 					continue
 				}
 				fldDirectiveListCopy = append(fldDirectiveListCopy, dir)
@@ -171,7 +187,54 @@ func (s *handler) GQLSchemaWithoutApolloExtras() string {
 		PossibleTypes: s.completeSchema.PossibleTypes,
 		Implements:    s.completeSchema.Implements,
 	}
-	return Stringify(astSchemaCopy, s.originalDefs, true)
+	sdl := Stringify(astSchemaCopy, s.originalDefs, true)
+	if s.apolloLink != "" {
+		sdl = s.apolloLink + "\n\n" + sdl
+	}
+	return sdl
+}
+
+// apolloLinkSDL returns the `extend schema @link(...)` to Federation 2 for the Apollo service
+// SDL of the schema doc, or "" if doc doesn't link Federation 2. Along with the names that the
+// schema imports, it imports the Federation directives which the schema uses, as the types
+// given with `extend type` get @extends even if the schema doesn't import it.
+func apolloLinkSDL(doc *ast.SchemaDocument) string {
+	link := federationLink(doc)
+	if link == nil {
+		return ""
+	}
+
+	imports := make(map[string]bool)
+	if arg := link.Arguments.ForName(apolloLinkImportArg); arg != nil {
+		for _, child := range arg.Value.Children {
+			imports[child.Value.Raw] = true
+		}
+	}
+	addImports := func(dirs ast.DirectiveList) {
+		for _, dir := range dirs {
+			if federation2Imports["@"+dir.Name] {
+				imports["@"+dir.Name] = true
+			}
+		}
+	}
+	for _, defn := range doc.Definitions {
+		if defn.BuiltIn {
+			continue
+		}
+		addImports(defn.Directives)
+		for _, fld := range defn.Fields {
+			addImports(fld.Directives)
+		}
+	}
+
+	importList := make([]string, 0, len(imports))
+	for name := range imports {
+		importList = append(importList, strconv.Quote(name))
+	}
+	sort.Strings(importList)
+	return fmt.Sprintf("extend schema @link(url: %s, import: [%s])",
+		strconv.Quote(link.Arguments.ForName(apolloLinkURLArg).Value.Raw),
+		strings.Join(importList, ", "))
 }
 
 // metaInfo stores all the meta data extracted from a schema
@@ -341,6 +404,7 @@ func NewHandler(input string, apolloServiceQuery bool) (Handler, error) {
 	if gqlErrList != nil {
 		return nil, gqlErrList
 	}
+	apolloLink := apolloLinkSDL(doc)
 
 	typesToComplete := make([]string, 0, len(doc.Definitions))
 	defns := make([]string, 0, len(doc.Definitions))
@@ -422,6 +486,7 @@ func NewHandler(input string, apolloServiceQuery bool) (Handler, error) {
 		completeSchema: sch,
 		originalDefs:   defns,
 		schemaMeta:     metaInfo,
+		apolloLink:     apolloLink,
 	}, nil
 }
 
@@ -629,7 +694,8 @@ func genDgSchema(gqlSch *ast.Schema, definitions []string,
 					}
 					typ.fields = append(typ.fields, field{fname, parentInt != nil})
 				case ast.Scalar:
-					fldType := inbuiltTypeToDgraph[f.Type.Name()]
+					// Scalars declared with @scalar are stored as their base type.
+					fldType := inbuiltTypeToDgraph[storageScalar(gqlSch, f.Type.Name())]
 					// fldType can be "uid" only in case if it is @external and @key
 					// in this case it needs to be stored as string in dgraph.
 					if fldType == "uid" {
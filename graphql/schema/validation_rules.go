@@ -17,9 +17,13 @@
 package schema
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
+	"sync/atomic"
 
 	"github.com/vtta/dgraph/x"
 	"github.com/dgraph-io/gqlparser/v2/ast"
@@ -206,6 +210,281 @@ func intRangeCheck(observers *validator.Events, addError validator.AddErrFunc) {
 	})
 }
 
+// constraintCheck returns the errors for the literal values in doc given for input fields having
+// the @constraint directive. Values given in variables are only known while resolving the
+// operation, and are checked by the mutation rewriter. It isn't a validator rule, so that the
+// values are checked against the Constraints built with the schema, whose patterns are compiled
+// once.
+func (s *schema) constraintCheck(doc *ast.QueryDocument,
+	vars map[string]interface{}) gqlerror.List {
+	if len(s.constraints) == 0 {
+		return nil
+	}
+
+	var errs gqlerror.List
+	addError := func(options ...validator.ErrorOption) {
+		err := &gqlerror.Error{Rule: "Check values against @constraint"}
+		for _, o := range options {
+			o(err)
+		}
+		errs = append(errs, err)
+	}
+	observers := &validator.Events{}
+	observers.OnField(func(walker *validator.Walker, field *ast.Field) {
+		if field.Definition == nil {
+			return
+		}
+
+		for _, arg := range field.Arguments {
+			argDefn := field.Definition.Arguments.ForName(arg.Name)
+			if argDefn == nil {
+				continue
+			}
+			path := ast.Path{ast.PathName(field.Alias), ast.PathName(arg.Name)}
+			s.checkConstraints(argDefn.Type, nil, arg.Value, path, addError)
+		}
+	})
+	validator.Walk(s.schema, doc, observers, vars)
+	return errs
+}
+
+// checkConstraints checks value, whose type is typ, against the Constraint c of the input field
+// it is given for, and then checks its fields if it is an input object.
+func (s *schema) checkConstraints(typ *ast.Type, c *Constraint, value *ast.Value,
+	path ast.Path, addError validator.AddErrFunc) {
+	if value == nil || typ == nil {
+		return
+	}
+
+	switch value.Kind {
+	case ast.Variable, ast.NullValue:
+		return
+	case ast.ListValue:
+		elemType := typ
+		if typ.Elem != nil {
+			elemType = typ.Elem
+		}
+		for i, child := range value.Children {
+			s.checkConstraints(elemType, c, child.Value, append(path, ast.PathIndex(i)),
+				addError)
+		}
+	case ast.ObjectValue:
+		defn := s.schema.Types[typ.Name()]
+		if defn == nil || defn.Kind != ast.InputObject {
+			return
+		}
+		for _, child := range value.Children {
+			fld := defn.Fields.ForName(child.Name)
+			if fld == nil {
+				continue
+			}
+			s.checkConstraints(fld.Type, s.constraints[defn.Name][fld.Name], child.Value,
+				append(path, ast.PathName(child.Name)), addError)
+		}
+	default:
+		if c == nil {
+			return
+		}
+		val, err := value.Value(nil)
+		if err != nil {
+			return
+		}
+		if err := c.Validate(val); err != nil {
+			fieldPath := make(ast.Path, len(path))
+			copy(fieldPath, path)
+			addError(
+				validator.Message("Value %s for field `%s` %s.", value.String(),
+					lastPathName(path), err),
+				validator.At(value.Position),
+				func(err *gqlerror.Error) { err.Path = fieldPath },
+			)
+		}
+	}
+}
+
+// QueryLimits are the limits on the GraphQL operations, which are checked while validating an
+// operation, so that the expensive ones are rejected before any DQL is generated for them. A limit
+// of 0 means no limit.
+type QueryLimits struct {
+	// MaxDepth is the max nesting of the fields in an operation.
+	MaxDepth int64
+	// MaxAliases is the max number of aliased fields in an operation.
+	MaxAliases int64
+	// MaxCost is the max cost of an operation. Each field costs 1, and the cost of the fields
+	// selected in a field with a first argument is multiplied by the value of first, or by
+	// DefaultListMultiplier in a list field without one.
+	MaxCost int64
+	// DefaultListMultiplier multiplies the cost of the fields selected in a list field without a
+	// first argument. It isn't a limit, and values below 1 count as 1.
+	DefaultListMultiplier int64
+}
+
+var queryLimits atomic.Value
+
+// SetQueryLimits sets the limits on the GraphQL operations.
+func SetQueryLimits(limits QueryLimits) {
+	queryLimits.Store(limits)
+}
+
+// GetQueryLimits returns the limits on the GraphQL operations.
+func GetQueryLimits() QueryLimits {
+	limits, _ := queryLimits.Load().(QueryLimits)
+	return limits
+}
+
+// queryLimitsCheck returns the errors for the operations in doc which exceed the QueryLimits.
+// The introspection fields aren't counted, as the introspection queries of the GraphQL clients
+// are deeply nested. It isn't a validator rule, as the validator is also used for the queries in
+// the @auth rules, which are a part of the schema and not requests.
+func queryLimitsCheck(doc *ast.QueryDocument, vars map[string]interface{}) gqlerror.List {
+	limits := GetQueryLimits()
+	if limits.MaxDepth == 0 && limits.MaxAliases == 0 && limits.MaxCost == 0 {
+		return nil
+	}
+
+	var errs gqlerror.List
+	oc := &operationCost{
+		doc:                   doc,
+		vars:                  vars,
+		defaultListMultiplier: limits.DefaultListMultiplier,
+		fragments:             make(map[string]selectionCost),
+	}
+	for _, operation := range doc.Operations {
+		c := oc.selectionSet(operation.SelectionSet)
+		if limits.MaxDepth > 0 && c.depth > limits.MaxDepth {
+			errs = append(errs, gqlerror.ErrorPosf(operation.Position,
+				"Operation depth %d exceeds the limit of %d.", c.depth, limits.MaxDepth))
+		}
+		if limits.MaxAliases > 0 && c.aliases > limits.MaxAliases {
+			errs = append(errs, gqlerror.ErrorPosf(operation.Position,
+				"Operation has %d aliases, which exceeds the limit of %d.",
+				c.aliases, limits.MaxAliases))
+		}
+		if limits.MaxCost > 0 && c.cost > limits.MaxCost {
+			errs = append(errs, gqlerror.ErrorPosf(operation.Position,
+				"Operation cost %d exceeds the limit of %d.", c.cost, limits.MaxCost))
+		}
+	}
+	return errs
+}
+
+// selectionCost is the depth, number of aliases and cost of a selection set.
+type selectionCost struct {
+	depth, aliases, cost int64
+}
+
+// operationCost computes the selectionCost of an operation. The cost of each fragment is computed
+// once, so that the fragments spread many times don't make it exponential.
+type operationCost struct {
+	doc                   *ast.QueryDocument
+	vars                  map[string]interface{}
+	defaultListMultiplier int64
+	fragments             map[string]selectionCost
+}
+
+func (oc *operationCost) selectionSet(set ast.SelectionSet) selectionCost {
+	var res selectionCost
+	for _, sel := range set {
+		var c selectionCost
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+			children := oc.selectionSet(sel.SelectionSet)
+			c.depth = children.depth + 1
+			c.aliases = children.aliases
+			if sel.Alias != sel.Name {
+				c.aliases = saturatingAdd(c.aliases, 1)
+			}
+			c.cost = saturatingAdd(1, saturatingMul(oc.multiplier(sel), children.cost))
+		case *ast.InlineFragment:
+			c = oc.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			c = oc.fragment(sel.Name)
+		}
+		if c.depth > res.depth {
+			res.depth = c.depth
+		}
+		res.aliases = saturatingAdd(res.aliases, c.aliases)
+		res.cost = saturatingAdd(res.cost, c.cost)
+	}
+	return res
+}
+
+func (oc *operationCost) fragment(name string) selectionCost {
+	if c, ok := oc.fragments[name]; ok {
+		return c
+	}
+	// The fragment cycles are reported by the validator, so a fragment counts as empty inside
+	// itself.
+	oc.fragments[name] = selectionCost{}
+	def := oc.doc.Fragments.ForName(name)
+	if def == nil {
+		return selectionCost{}
+	}
+	c := oc.selectionSet(def.SelectionSet)
+	oc.fragments[name] = c
+	return c
+}
+
+// multiplier returns the value of the first argument of field. If it doesn't have a valid one,
+// it returns the default list multiplier for a list field, and 1 for any other field.
+func (oc *operationCost) multiplier(field *ast.Field) int64 {
+	def := int64(1)
+	if field.Definition != nil && field.Definition.Type.Elem != nil &&
+		oc.defaultListMultiplier > 1 {
+		def = oc.defaultListMultiplier
+	}
+	arg := field.Arguments.ForName("first")
+	if arg == nil || arg.Value == nil {
+		return def
+	}
+	val, err := arg.Value.Value(oc.vars)
+	if err != nil {
+		return def
+	}
+	var first int64
+	switch val := val.(type) {
+	case int64:
+		first = val
+	case int:
+		first = int64(val)
+	case float64:
+		first = int64(val)
+	case json.Number:
+		first, _ = val.Int64()
+	}
+	if first < 1 {
+		return 1
+	}
+	return first
+}
+
+func saturatingAdd(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+func saturatingMul(a, b int64) int64 {
+	if a != 0 && b > math.MaxInt64/a {
+		return math.MaxInt64
+	}
+	return a * b
+}
+
+// lastPathName returns the name of the innermost field in path.
+func lastPathName(path ast.Path) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if name, ok := path[i].(ast.PathName); ok {
+			return string(name)
+		}
+	}
+	return ""
+}
+
 func valueKindToString(valKind ast.ValueKind) string {
 	switch valKind {
 	case ast.Variable:
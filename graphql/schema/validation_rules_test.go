@@ -0,0 +1,146 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dgraph-io/gqlparser/v2/gqlerror"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/x"
+)
+
+func TestQueryLimits(t *testing.T) {
+	defer SetQueryLimits(QueryLimits{})
+
+	schHandler, errs := NewHandler(`
+		type Post {
+			id: ID!
+			title: String
+			comments: [Comment]
+		}
+		type Comment {
+			id: ID!
+			text: String
+			post: Post
+		}`, false)
+	require.NoError(t, errs)
+	sch, err := FromString(schHandler.GQLSchema(), x.GalaxyNamespace)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		limits QueryLimits
+		query  string
+		vars   map[string]interface{}
+		err    string
+	}{
+		{
+			name:   "no limits",
+			limits: QueryLimits{},
+			query:  `query { a: queryPost { b: comments { c: post { d: title } } } }`,
+		},
+		{
+			name:   "depth within the limit",
+			limits: QueryLimits{MaxDepth: 4},
+			query:  `query { queryPost { comments { post { title } } } }`,
+		},
+		{
+			name:   "depth over the limit",
+			limits: QueryLimits{MaxDepth: 3},
+			query:  `query { queryPost { comments { post { title } } } }`,
+			err:    "Operation depth 4 exceeds the limit of 3.",
+		},
+		{
+			name:   "depth of the fields in fragments",
+			limits: QueryLimits{MaxDepth: 3},
+			query: `query { queryPost { ...postComments } }
+				fragment postComments on Post { comments { ... on Comment { post { title } } } }`,
+			err: "Operation depth 4 exceeds the limit of 3.",
+		},
+		{
+			name:   "introspection fields aren't counted",
+			limits: QueryLimits{MaxDepth: 1, MaxCost: 1},
+			query: `query { __typename __schema { types { fields { type { ofType { name } } } } }
+				queryPost { __typename } }`,
+		},
+		{
+			name:   "aliases over the limit",
+			limits: QueryLimits{MaxAliases: 2},
+			query:  `query { a: queryPost { title } b: queryPost { t: title } queryComment { text } }`,
+			err:    "Operation has 3 aliases, which exceeds the limit of 2.",
+		},
+		{
+			name:   "cost is multiplied by first",
+			limits: QueryLimits{MaxCost: 71},
+			query:  `query { queryPost(first: 10) { title comments(first: 5) { text } } }`,
+		},
+		{
+			name:   "cost over the limit",
+			limits: QueryLimits{MaxCost: 70},
+			query:  `query { queryPost(first: 10) { title comments(first: 5) { text } } }`,
+			err:    "Operation cost 71 exceeds the limit of 70.",
+		},
+		{
+			name:   "cost of list fields without first",
+			limits: QueryLimits{MaxCost: 80, DefaultListMultiplier: 10},
+			query:  `query { queryPost { title comments(first: 2) { text post { title } } } }`,
+			err:    "Operation cost 81 exceeds the limit of 80.",
+		},
+		{
+			name:   "cost of single fields without first",
+			limits: QueryLimits{MaxCost: 3, DefaultListMultiplier: 10},
+			query:  `query { getPost(id: "0x1") { title } }`,
+		},
+		{
+			name:   "cost with first given in a variable",
+			limits: QueryLimits{MaxCost: 100},
+			query:  `query ($first: Int) { queryPost(first: $first) { title } }`,
+			vars:   map[string]interface{}{"first": json.Number("100")},
+			err:    "Operation cost 101 exceeds the limit of 100.",
+		},
+		{
+			name:   "cost doesn't overflow",
+			limits: QueryLimits{MaxCost: 1000},
+			query: `query { queryPost(first: 2147483647) { comments(first: 2147483647) {
+				post { comments(first: 2147483647) { text } } } } }`,
+			err: "Operation cost 9223372036854775807 exceeds the limit of 1000.",
+		},
+		{
+			name:   "all the exceeded limits are reported",
+			limits: QueryLimits{MaxDepth: 1, MaxAliases: 1, MaxCost: 1},
+			query:  `query { a: queryPost { t: title } }`,
+			err: "Operation depth 2 exceeds the limit of 1.\n" +
+				"input:1: Operation has 2 aliases, which exceeds the limit of 1.\n" +
+				"input:1: Operation cost 2 exceeds the limit of 1.",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			SetQueryLimits(tc.limits)
+			_, err := sch.Operation(&Request{Query: tc.query, Variables: tc.vars})
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.IsType(t, gqlerror.List{}, err)
+			require.EqualError(t, err, "input:1: "+tc.err+"\n")
+		})
+	}
+}
@@ -22,15 +22,19 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/vtta/dgraph/graphql/authorization"
 	"github.com/dgraph-io/gqlparser/v2/parser"
 
 	"github.com/vtta/dgraph/x"
 	"github.com/dgraph-io/gqlparser/v2/ast"
+	"github.com/dgraph-io/gqlparser/v2/validator"
 	"github.com/pkg/errors"
 )
 
@@ -62,6 +66,9 @@ type FieldHTTPConfig struct {
 	Template       interface{}
 	Mode           string
 	ForwardHeaders http.Header
+	// CacheTTL is how long the responses of the remote endpoint are cached for, so that the same
+	// request isn't sent again until then. It is 0 if the responses aren't cached.
+	CacheTTL time.Duration
 	// would be empty for non-GraphQL requests
 	RemoteGqlQueryName string
 	RemoteGqlQuery     string
@@ -71,23 +78,151 @@ type FieldHTTPConfig struct {
 	// the GraphqlBatchModeArgument would be sinput, we use it to know the GraphQL variable that
 	// we should send the data in.
 	GraphqlBatchModeArgument string
+	// Lambda tells if the field has @lambda, in which case the request is sent to the lambda
+	// server of the namespace of the request rather than to URL.
+	Lambda bool
+}
+
+// CustomScalar is a scalar declared in the input schema with the @scalar directive.
+type CustomScalar struct {
+	Name string
+	// BaseType is the built-in scalar, or JSON, that values of the scalar are stored as.
+	BaseType string
+	// would be nil if there is no regex in @scalar
+	Regex *regexp.Regexp
+	// Lambda tells whether values must also be validated by the lambda server.
+	Lambda bool
+}
+
+// IsJSON tells whether values of the scalar can be any JSON value, which is stored as a string.
+func (cs *CustomScalar) IsJSON() bool {
+	return cs.BaseType == jsonScalarBase
+}
+
+// Constraint holds the bounds that the @constraint directive puts on the values of a field.
+// The bounds that aren't given in the directive are nil.
+type Constraint struct {
+	MinLength *int64
+	MaxLength *int64
+	Pattern   *regexp.Regexp
+	Min       *float64
+	Max       *float64
+}
+
+// constraintFromDirective builds the Constraint given by a @constraint directive, whose
+// arguments have already been validated while building the schema.
+func constraintFromDirective(dir *ast.Directive) *Constraint {
+	c := &Constraint{}
+	for _, arg := range dir.Arguments {
+		switch arg.Name {
+		case constraintMinLengthArg, constraintMaxLengthArg:
+			n, err := strconv.ParseInt(arg.Value.Raw, 10, 64)
+			if err != nil {
+				continue
+			}
+			if arg.Name == constraintMinLengthArg {
+				c.MinLength = &n
+			} else {
+				c.MaxLength = &n
+			}
+		case constraintPatternArg:
+			c.Pattern, _ = regexp.Compile(arg.Value.Raw)
+		case constraintMinArg, constraintMaxArg:
+			f, err := strconv.ParseFloat(arg.Value.Raw, 64)
+			if err != nil {
+				continue
+			}
+			if arg.Name == constraintMinArg {
+				c.Min = &f
+			} else {
+				c.Max = &f
+			}
+		}
+	}
+	return c
+}
+
+// Validate checks a single value of a field against the constraint, and returns an error
+// saying which bound the value breaks. Values that aren't strings or numbers, like nulls, pass.
+func (c *Constraint) Validate(val interface{}) error {
+	if str, ok := val.(string); ok {
+		length := int64(utf8.RuneCountInString(str))
+		if c.MinLength != nil && length < *c.MinLength {
+			return errors.Errorf("must be at least %d characters long", *c.MinLength)
+		}
+		if c.MaxLength != nil && length > *c.MaxLength {
+			return errors.Errorf("must be at most %d characters long", *c.MaxLength)
+		}
+		if c.Pattern != nil && !c.Pattern.MatchString(str) {
+			return errors.Errorf("must match the pattern %s", c.Pattern.String())
+		}
+	}
+
+	if c.Min == nil && c.Max == nil {
+		return nil
+	}
+	var num float64
+	switch v := val.(type) {
+	case int64:
+		num = float64(v)
+	case int:
+		num = float64(v)
+	case float64:
+		num = v
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return nil
+		}
+		num = f
+	case string:
+		// Int64 values can be given as strings.
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil
+		}
+		num = f
+	default:
+		return nil
+	}
+	if c.Min != nil && num < *c.Min {
+		return errors.Errorf("must be at least %s", strconv.FormatFloat(*c.Min, 'f', -1, 64))
+	}
+	if c.Max != nil && num > *c.Max {
+		return errors.Errorf("must be at most %s", strconv.FormatFloat(*c.Max, 'f', -1, 64))
+	}
+	return nil
 }
 
 // EntityRepresentations is the parsed form of the `representations` argument in `_entities` query
 type EntityRepresentations struct {
-	TypeDefn Type            // the type corresponding to __typename in the representations argument
-	KeyField FieldDefinition // the definition of the @key field
-	KeyVals  []interface{}   // the list of values corresponding to the key field
-	// a map of key field value to the input representation for that value. The keys in this map
-	// are the string formatted version of the key field value.
+	TypeDefn Type // the type corresponding to __typename in the representations argument
+	// the definitions of the fields of the @key used for the representations. There is more than
+	// one of them for a compound key.
+	KeyFields []FieldDefinition
+	// the list of values of the key fields in each representation, in the order of KeyFields
+	KeyVals [][]interface{}
+	// a map of key field values to the input representation for those values. The keys in this
+	// map are built by RepresentationKey from the string formatted version of the key field values.
 	KeyValToRepresentation map[string]map[string]interface{}
 }
 
+// RepresentationKey returns the key in EntityRepresentations.KeyValToRepresentation for the
+// string formatted values of the key fields of a representation.
+func RepresentationKey(keyVals []string) string {
+	if len(keyVals) == 1 {
+		return keyVals[0]
+	}
+	b, _ := json.Marshal(keyVals)
+	return string(b)
+}
+
 // Query/Mutation types and arg names
 const (
 	GetQuery             QueryType    = "get"
 	FilterQuery          QueryType    = "query"
 	AggregateQuery       QueryType    = "aggregate"
+	ConnectionQuery      QueryType    = "connection"
 	SchemaQuery          QueryType    = "schema"
 	EntitiesQuery        QueryType    = "entities"
 	PasswordQuery        QueryType    = "checkPassword"
@@ -172,6 +307,9 @@ type Field interface {
 	TypeName(dgraphTypes []string) string
 	GetObjectName() string
 	IsAuthQuery() bool
+	// AuthRules returns the rules of the @auth directive on the definition of this field, or nil
+	// if it has none.
+	AuthRules() *AuthContainer
 	CustomHTTPConfig() (*FieldHTTPConfig, error)
 	EnumValues() []string
 	ConstructedFor() Type
@@ -211,7 +349,10 @@ type Mutation interface {
 	MutatedType() Type
 	QueryField() Field
 	NumUidsField() Field
+	DiffField() Field
 	HasLambdaOnMutate() bool
+	HasLambdaBeforeMutate() bool
+	ValidateArgValue(name string, val interface{}) error
 }
 
 // A Query is a field (from the schema's Query type) from an Operation
@@ -234,6 +375,8 @@ type Type interface {
 	Fields() []FieldDefinition
 	IDField() FieldDefinition
 	XIDFields() []FieldDefinition
+	// true if the XIDFields together identify a node, instead of each of them on its own
+	HasCompositeID() bool
 	InterfaceImplHasAuthRules() bool
 	PasswordField() FieldDefinition
 	Name() string
@@ -253,7 +396,12 @@ type Type interface {
 	AuthRules() *TypeAuth
 	IsGeo() bool
 	IsAggregateResult() bool
+	// IsConnection tells whether the type is a Relay connection type generated for the nodes
+	// of another type.
+	IsConnection() bool
 	IsInbuiltOrEnumType() bool
+	// CustomScalar returns the scalar declared with @scalar that this type names, or nil.
+	CustomScalar() *CustomScalar
 	fmt.Stringer
 }
 
@@ -275,6 +423,8 @@ type FieldDefinition interface {
 	ForwardEdge() FieldDefinition
 	// GetAuthMeta returns the Dgraph.Authorization meta information stored in schema
 	GetAuthMeta() *authorization.AuthMeta
+	// Constraint returns the bounds given by @constraint on the field, or nil if it has none.
+	Constraint() *Constraint
 }
 
 type astType struct {
@@ -310,16 +460,32 @@ type schema struct {
 	// enables lambdas for that mutation.
 	// It is read-only.
 	lambdaOnMutate map[string]bool
+	// lambdaBeforeMutate stores the mapping of mutationName -> true, if the config of
+	// @lambdaBeforeMutate enables lambdas for that mutation.
+	// It is read-only.
+	lambdaBeforeMutate map[string]bool
 	// requiresDirectives stores the mapping of typeName->fieldName->list of fields given in
 	// @requires. It is read-only.
 	requiresDirectives map[string]map[string][]string
 	// remoteResponse stores the mapping of typeName->fieldName->responseName which will be used in result
 	// completion step.
 	remoteResponse map[string]map[string]string
+	// customScalars stores the mapping of scalarName -> scalar for scalars declared with @scalar.
+	// It is read-only.
+	customScalars map[string]*CustomScalar
+	// constraints stores the mapping of typeName -> fieldName -> bounds given by @constraint.
+	// It is read-only.
+	constraints map[string]map[string]*Constraint
+	// connectionTypes stores the names of the Relay connection types generated for the types
+	// having @generate(query: {connection: true}). It is read-only.
+	connectionTypes map[string]bool
 	// Map from typename to auth rules
 	authRules map[string]*TypeAuth
 	// meta is the meta information extracted from input schema
 	meta *metaInfo
+	// admin is true for the schema of the /admin endpoint, whose operations aren't subject to
+	// the query limits.
+	admin bool
 }
 
 type operation struct {
@@ -367,7 +533,8 @@ func (s *schema) Queries(t QueryType) []string {
 	}
 	var result []string
 	for _, q := range s.schema.Query.Fields {
-		if queryType(q.Name, s.customDirectives["Query"][q.Name]) == t {
+		if queryType(q.Name, s.customDirectives["Query"][q.Name],
+			s.connectionTypes[q.Type.Name()]) == t {
 			result = append(result, q.Name)
 		}
 	}
@@ -388,7 +555,7 @@ func (s *schema) Mutations(t MutationType) []string {
 }
 
 func (s *schema) IsFederated() bool {
-	return s.schema.Types["_Entity"] != nil
+	return s.schema.Types["_Service"] != nil
 }
 
 func (s *schema) SetMeta(meta *metaInfo) {
@@ -846,11 +1013,37 @@ func hasCustomOrLambda(f *ast.FieldDefinition) bool {
 }
 
 func isKeyField(f *ast.FieldDefinition, typ *ast.Definition) bool {
-	keyDirective := typ.Directives.ForName(apolloKeyDirective)
-	if keyDirective == nil {
-		return false
+	for _, keyDirective := range typ.Directives.ForNames(apolloKeyDirective) {
+		for _, keyFld := range apolloKeyFields(keyDirective) {
+			if f.Name == keyFld {
+				return true
+			}
+		}
 	}
-	return f.Name == keyDirective.Arguments[0].Value.Raw
+	return false
+}
+
+// apolloKeyFields returns the names of the fields in the @key directive dir. There is more than
+// one of them for a compound key like @key(fields: "course student").
+func apolloKeyFields(dir *ast.Directive) []string {
+	arg := dir.Arguments.ForName(apolloKeyArg)
+	if arg == nil {
+		return nil
+	}
+	return strings.Fields(arg.Value.Raw)
+}
+
+// isResolvableKey returns false for a @key directive with resolvable: false, which Federation 2
+// uses for entities that this service references but can't resolve.
+func isResolvableKey(dir *ast.Directive) bool {
+	arg := dir.Arguments.ForName(apolloKeyResolvableArg)
+	return arg == nil || arg.Value.Raw != "false"
+}
+
+// isFederation2 returns true if the schema links Apollo Federation 2, in which case the @link
+// directive is defined along with the other Federation 2 directives.
+func isFederation2(sch *ast.Schema) bool {
+	return sch.Directives[apolloLinkDirective] != nil
 }
 
 // Filter out those fields which have @external directive and are not @key fields
@@ -951,10 +1144,12 @@ func getChildValue(name, raw string, kind ast.ValueKind, position *ast.Position)
 	}
 }
 
-func lambdaOnMutateMappings(s *ast.Schema) map[string]bool {
+// lambdaMutateMappings returns the names of the mutations that the directive dirName, i.e.,
+// @lambdaOnMutate or @lambdaBeforeMutate, enables lambdas for.
+func lambdaMutateMappings(s *ast.Schema, dirName string) map[string]bool {
 	result := make(map[string]bool)
 	for _, typ := range s.Types {
-		dir := typ.Directives.ForName(lambdaOnMutateDirective)
+		dir := typ.Directives.ForName(dirName)
 		if dir == nil {
 			continue
 		}
@@ -969,6 +1164,56 @@ func lambdaOnMutateMappings(s *ast.Schema) map[string]bool {
 	return result
 }
 
+func customScalarMappings(s *ast.Schema) map[string]*CustomScalar {
+	result := make(map[string]*CustomScalar)
+	for _, typ := range s.Types {
+		base := customScalarBase(s, typ.Name)
+		if base == "" {
+			continue
+		}
+
+		dir := typ.Directives.ForName(scalarDirective)
+		cs := &CustomScalar{Name: typ.Name, BaseType: base}
+		if regex := dir.Arguments.ForName(scalarRegexArg); regex != nil {
+			// The regex has already been validated while building the schema.
+			cs.Regex, _ = regexp.Compile(regex.Value.Raw)
+		}
+		if lambda := dir.Arguments.ForName(scalarLambdaArg); lambda != nil {
+			cs.Lambda = lambda.Value.Raw == "true"
+		}
+		result[typ.Name] = cs
+	}
+	return result
+}
+
+func constraintMappings(s *ast.Schema) map[string]map[string]*Constraint {
+	result := make(map[string]map[string]*Constraint)
+	for _, typ := range s.Types {
+		for _, field := range typ.Fields {
+			dir := field.Directives.ForName(constraintDirective)
+			if dir == nil {
+				continue
+			}
+			if result[typ.Name] == nil {
+				result[typ.Name] = make(map[string]*Constraint)
+			}
+			result[typ.Name][field.Name] = constraintFromDirective(dir)
+		}
+	}
+	return result
+}
+
+func connectionTypeMappings(s *ast.Schema) map[string]bool {
+	result := make(map[string]bool)
+	for _, typ := range s.Types {
+		if (typ.Kind == ast.Object || typ.Kind == ast.Interface) &&
+			parseGenerateDirectiveParams(typ).generateConnectionQuery {
+			result[typ.Name+"Connection"] = true
+		}
+	}
+	return result
+}
+
 // AsSchema wraps a github.com/dgraph-io/gqlparser/ast.Schema.
 func AsSchema(s *ast.Schema, ns uint64) (Schema, error) {
 	customDirs, lambdaDirs := customAndLambdaMappings(s, ns)
@@ -979,9 +1224,13 @@ func AsSchema(s *ast.Schema, ns uint64) (Schema, error) {
 		typeNameAst:        typeMappings(s),
 		customDirectives:   customDirs,
 		lambdaDirectives:   lambdaDirs,
-		lambdaOnMutate:     lambdaOnMutateMappings(s),
+		lambdaOnMutate:     lambdaMutateMappings(s, lambdaOnMutateDirective),
+		lambdaBeforeMutate: lambdaMutateMappings(s, lambdaBeforeMutateDirective),
 		requiresDirectives: requiresMappings(s),
 		remoteResponse:     remoteResponseMapping(s),
+		customScalars:      customScalarMappings(s),
+		constraints:        constraintMappings(s),
+		connectionTypes:    connectionTypeMappings(s),
 		meta:               &metaInfo{}, // initialize with an empty metaInfo
 	}
 	sch.mutatedType = mutatedTypeMapping(sch, dgraphPredicate)
@@ -1355,6 +1604,14 @@ func (f *field) HasLambdaDirective() bool {
 	return f.op.inSchema.lambdaDirectives[f.GetObjectName()][f.Name()]
 }
 
+func (f *field) AuthRules() *AuthContainer {
+	auth := f.op.inSchema.authRules[typeName(f.field.ObjectDefinition)]
+	if auth == nil {
+		return nil
+	}
+	return auth.Fields[f.Name()]
+}
+
 func (f *field) XIDArgs() map[string]string {
 	xidToDgraphPredicate := make(map[string]string)
 	passwordField := f.Type().PasswordField()
@@ -1470,7 +1727,11 @@ func (f *field) GetObjectName() string {
 
 func (t *astType) IsInbuiltOrEnumType() bool {
 	_, ok := inbuiltTypeToDgraph[t.Name()]
-	return ok || (t.inSchema.schema.Types[t.Name()].Kind == ast.Enum)
+	return ok || (t.inSchema.schema.Types[t.Name()].Kind == ast.Enum) || t.CustomScalar() != nil
+}
+
+func (t *astType) CustomScalar() *CustomScalar {
+	return t.inSchema.customScalars[t.Name()]
 }
 
 func getCustomHTTPConfig(f *field, isQueryOrMutation bool) (*FieldHTTPConfig, error) {
@@ -1479,6 +1740,7 @@ func getCustomHTTPConfig(f *field, isQueryOrMutation bool) (*FieldHTTPConfig, er
 	fconf := &FieldHTTPConfig{
 		URL:    httpArg.Value.Children.ForName(httpUrl).Raw,
 		Method: httpArg.Value.Children.ForName(httpMethod).Raw,
+		Lambda: f.op.inSchema.lambdaDirectives[f.GetObjectName()][f.Name()],
 	}
 
 	fconf.Mode = SINGLE
@@ -1487,6 +1749,12 @@ func getCustomHTTPConfig(f *field, isQueryOrMutation bool) (*FieldHTTPConfig, er
 		fconf.Mode = op.Raw
 	}
 
+	if cacheTTL := httpArg.Value.Children.ForName(httpCacheTTL); cacheTTL != nil {
+		// cacheTTL is validated to be a positive number of seconds
+		ttl, _ := strconv.Atoi(cacheTTL.Raw)
+		fconf.CacheTTL = time.Duration(ttl) * time.Second
+	}
+
 	// both body and graphql can't be present together
 	bodyArg := httpArg.Value.Children.ForName(httpBody)
 	graphqlArg := httpArg.Value.Children.ForName(httpGraphql)
@@ -1722,11 +1990,14 @@ func (q *query) RepresentationsArg() (*EntityRepresentations, error) {
 	if typ == nil {
 		return nil, fmt.Errorf("type %s not found in the schema", typename)
 	}
-	keyDir := typ.Directives.ForName(apolloKeyDirective)
-	if keyDir == nil {
+	if typ.Directives.ForName(apolloKeyDirective) == nil {
 		return nil, fmt.Errorf("type %s doesn't have a key Directive", typename)
 	}
-	keyFldName := keyDir.Arguments[0].Value.Raw
+	keyDir := representationKeyDirective(typ, representation)
+	if keyDir == nil {
+		return nil, fmt.Errorf("type %s doesn't have a resolvable key Directive", typename)
+	}
+	keyFldNames := apolloKeyFields(keyDir)
 
 	// initialize the struct to return
 	entityReprs := &EntityRepresentations{
@@ -1735,10 +2006,14 @@ func (q *query) RepresentationsArg() (*EntityRepresentations, error) {
 			inSchema:        q.op.inSchema,
 			dgraphPredicate: q.op.inSchema.dgraphPredicate,
 		},
-		KeyVals:                make([]interface{}, 0, len(representations)),
+		KeyFields:              make([]FieldDefinition, 0, len(keyFldNames)),
+		KeyVals:                make([][]interface{}, 0, len(representations)),
 		KeyValToRepresentation: make(map[string]map[string]interface{}),
 	}
-	entityReprs.KeyField = entityReprs.TypeDefn.Field(keyFldName)
+	for _, keyFldName := range keyFldNames {
+		entityReprs.KeyFields = append(entityReprs.KeyFields,
+			entityReprs.TypeDefn.Field(keyFldName))
+	}
 
 	// iterate over all the representations and parse
 	for i, rep := range representations {
@@ -1757,18 +2032,54 @@ func (q *query) RepresentationsArg() (*EntityRepresentations, error) {
 				" argument, got: [%s, %s]", entityReprs.TypeDefn.Name(), typename)
 		}
 
-		keyVal, ok := representation[keyFldName]
-		if !ok {
-			return nil, fmt.Errorf("unable to extract value for key field `%s` from %dth item in"+
-				" the `_representations` argument", keyFldName, i)
+		keyVals := make([]interface{}, 0, len(keyFldNames))
+		keyValStrs := make([]string, 0, len(keyFldNames))
+		for _, keyFldName := range keyFldNames {
+			keyVal, ok := representation[keyFldName]
+			if !ok {
+				return nil, fmt.Errorf("unable to extract value for key field `%s` from %dth item"+
+					" in the `_representations` argument", keyFldName, i)
+			}
+			keyVals = append(keyVals, keyVal)
+			keyValStrs = append(keyValStrs, fmt.Sprint(keyVal))
 		}
-		entityReprs.KeyVals = append(entityReprs.KeyVals, keyVal)
-		entityReprs.KeyValToRepresentation[fmt.Sprint(keyVal)] = representation
+		entityReprs.KeyVals = append(entityReprs.KeyVals, keyVals)
+		entityReprs.KeyValToRepresentation[RepresentationKey(keyValStrs)] = representation
 	}
 
 	return entityReprs, nil
 }
 
+// representationKeyDirective returns the @key directive of typ to resolve the representations
+// with. That is the first resolvable @key whose fields are all given in representation, or the
+// first resolvable @key if there is no such @key.
+func representationKeyDirective(typ *ast.Definition,
+	representation map[string]interface{}) *ast.Directive {
+	var resolvableKeys ast.DirectiveList
+	for _, keyDir := range typ.Directives.ForNames(apolloKeyDirective) {
+		if isResolvableKey(keyDir) {
+			resolvableKeys = append(resolvableKeys, keyDir)
+		}
+	}
+	if len(resolvableKeys) == 0 {
+		return nil
+	}
+
+	for _, keyDir := range resolvableKeys {
+		hasAllFields := true
+		for _, keyFld := range apolloKeyFields(keyDir) {
+			if _, ok := representation[keyFld]; !ok {
+				hasAllFields = false
+				break
+			}
+		}
+		if hasAllFields {
+			return keyDir
+		}
+	}
+	return resolvableKeys[0]
+}
+
 func (q *query) AuthFor(jwtVars map[string]interface{}) Query {
 	// copy the template, so that multiple queries can run rewriting for the rule.
 	return &query{
@@ -1854,6 +2165,10 @@ func (q *query) HasLambdaDirective() bool {
 	return (*field)(q).HasLambdaDirective()
 }
 
+func (q *query) AuthRules() *AuthContainer {
+	return (*field)(q).AuthRules()
+}
+
 func (q *query) IDArgValue() (map[string]string, uint64, error) {
 	return (*field)(q).IDArgValue()
 }
@@ -1914,6 +2229,9 @@ func (f *field) ConstructedFor() Type {
 }
 
 func (q *query) ConstructedFor() Type {
+	if q.QueryType() == ConnectionQuery {
+		return ConnectionNodeType(q.Type())
+	}
 	if q.QueryType() != AggregateQuery {
 		return q.Type()
 	}
@@ -1974,24 +2292,36 @@ func (f *field) DgraphPredicateForAggregateField() string {
 		return f.DgraphPredicate()
 	}
 	// aggregateResultTypeName contains name of the type in which the aggregate field is defined,
-	// it will be of the form <Type>AggregateResult
+	// it will be of the form <Type>AggregateResult, or <Type>AggregateGroup for the fields of
+	// the groups of an aggregate query.
 	// we need to obtain the type name from <Type> from <Type>AggregateResult
 	aggregateResultTypeName := f.field.ObjectDefinition.Name
 
-	// If aggregateResultTypeName is found to not end with AggregateResult, just return DgraphPredicate()
-	if !strings.HasSuffix(aggregateResultTypeName, "AggregateResult") {
+	var mainTypeName string
+	switch {
+	case strings.HasSuffix(aggregateResultTypeName, "AggregateResult"):
+		mainTypeName = strings.TrimSuffix(aggregateResultTypeName, "AggregateResult")
+	case strings.HasSuffix(aggregateResultTypeName, "AggregateGroup"):
+		mainTypeName = strings.TrimSuffix(aggregateResultTypeName, "AggregateGroup")
+	default:
 		// This is an extra precaution and ideally, the code should not reach over here.
 		return f.DgraphPredicate()
 	}
-	mainTypeName := aggregateResultTypeName[:len(aggregateResultTypeName)-15]
 	// Remove last 3 characters of the field name.
 	// Eg. to get "FieldName" from "FieldNameMax"
 	// As all Aggregate functions are of length 3, removing last 3 characters from fldName
 	return f.op.inSchema.dgraphPredicate[mainTypeName][fldName[:len(fldName)-3]]
 }
 
+// AggregateGroupsAlias returns the name of the DQL query that fetches the groups of the aggregate
+// query q, when it is given the groupBy argument.
+func AggregateGroupsAlias(q Field) string {
+	return q.Type().Name() + ".groups"
+}
+
 func (q *query) QueryType() QueryType {
-	return queryType(q.Name(), q.op.inSchema.customDirectives["Query"][q.Name()])
+	return queryType(q.Name(), q.op.inSchema.customDirectives["Query"][q.Name()],
+		q.op.inSchema.connectionTypes[q.field.Definition.Type.Name()])
 }
 
 func (q *query) DQLQuery() string {
@@ -2003,13 +2333,15 @@ func (q *query) DQLQuery() string {
 	return ""
 }
 
-func queryType(name string, custom *ast.Directive) QueryType {
+func queryType(name string, custom *ast.Directive, connection bool) QueryType {
 	switch {
 	case custom != nil:
 		if custom.Arguments.ForName(dqlArg) != nil {
 			return DQLQuery
 		}
 		return HTTPQuery
+	case connection:
+		return ConnectionQuery
 	case name == "_entities":
 		return EntitiesQuery
 	case strings.HasPrefix(name, "get"):
@@ -2115,6 +2447,10 @@ func (m *mutation) HasLambdaDirective() bool {
 	return (*field)(m).HasLambdaDirective()
 }
 
+func (m *mutation) AuthRules() *AuthContainer {
+	return (*field)(m).AuthRules()
+}
+
 func (m *mutation) Type() Type {
 	return (*field)(m).Type()
 }
@@ -2137,7 +2473,7 @@ func (m *mutation) SelectionSet() []Field {
 
 func (m *mutation) QueryField() Field {
 	for _, f := range m.SelectionSet() {
-		if f.Name() == NumUid || f.Name() == Typename || f.Name() == Msg {
+		if f.Name() == NumUid || f.Name() == Typename || f.Name() == Msg || f.Name() == Diff {
 			continue
 		}
 		// if @cascade was given on mutation itself, then it should get applied for the query which
@@ -2163,10 +2499,45 @@ func (m *mutation) NumUidsField() Field {
 	return nil
 }
 
+func (m *mutation) DiffField() Field {
+	for _, f := range m.SelectionSet() {
+		if f.Name() == Diff {
+			return f
+		}
+	}
+	return nil
+}
+
 func (m *mutation) HasLambdaOnMutate() bool {
 	return m.op.inSchema.lambdaOnMutate[m.Name()]
 }
 
+func (m *mutation) HasLambdaBeforeMutate() bool {
+	return m.op.inSchema.lambdaBeforeMutate[m.Name()]
+}
+
+// ValidateArgValue checks that val is a valid value for the argument of m, as per the type of the
+// argument in the schema, the same way as the values of the variables of a request are checked.
+func (m *mutation) ValidateArgValue(name string, val interface{}) error {
+	argDef := m.field.Definition.Arguments.ForName(name)
+	if argDef == nil {
+		return errors.Errorf("unknown argument %s", name)
+	}
+	op := &ast.OperationDefinition{
+		Operation: ast.Mutation,
+		VariableDefinitions: ast.VariableDefinitionList{{
+			Variable:   name,
+			Type:       argDef.Type,
+			Definition: m.op.inSchema.schema.Types[argDef.Type.Name()],
+		}},
+	}
+	if _, gqlErr := validator.VariableValues(m.op.inSchema.schema, op,
+		map[string]interface{}{name: val}); gqlErr != nil {
+		return gqlErr
+	}
+	return nil
+}
+
 func (m *mutation) Location() x.Location {
 	return (*field)(m).Location()
 }
@@ -2280,6 +2651,10 @@ func (t *astType) IsAggregateResult() bool {
 	return strings.HasSuffix(t.Name(), "AggregateResult")
 }
 
+func (t *astType) IsConnection() bool {
+	return t.inSchema.connectionTypes[t.Name()]
+}
+
 func (t *astType) Field(name string) FieldDefinition {
 	return &fieldDefinition{
 		// this ForName lookup is a loop in the underlying schema :-(
@@ -2338,6 +2713,10 @@ func isID(fd *ast.FieldDefinition) bool {
 	return fd.Type.Name() == "ID"
 }
 
+func (fd *fieldDefinition) Constraint() *Constraint {
+	return fd.inSchema.constraints[fd.parentType.Name()][fd.Name()]
+}
+
 func (fd *fieldDefinition) Type() Type {
 	return &astType{
 		typ:             fd.fieldDef.Type,
@@ -2608,6 +2987,11 @@ func (t *astType) XIDFields() []FieldDefinition {
 	return xids
 }
 
+func (t *astType) HasCompositeID() bool {
+	def := t.inSchema.schema.Types[t.Name()]
+	return def != nil && def.Directives.ForName(compositeIdDirective) != nil
+}
+
 // InterfaceImplHasAuthRules checks if an interface's implementation has auth rules.
 func (t *astType) InterfaceImplHasAuthRules() bool {
 	schema := t.inSchema.schema
@@ -104,6 +104,7 @@ type Starship {
 		"count":         "AuthorAggregateResult.count",
 		"dobMax":        "AuthorAggregateResult.dobMax",
 		"dobMin":        "AuthorAggregateResult.dobMin",
+		"groups":        "AuthorAggregateResult.groups",
 		"nameMax":       "AuthorAggregateResult.nameMax",
 		"nameMin":       "AuthorAggregateResult.nameMin",
 		"reputationAvg": "AuthorAggregateResult.reputationAvg",
@@ -111,12 +112,21 @@ type Starship {
 		"reputationMin": "AuthorAggregateResult.reputationMin",
 		"reputationSum": "AuthorAggregateResult.reputationSum",
 	}
+	authorGroupKey := map[string]string{
+		"dob":        "AuthorGroupKey.dob",
+		"name":       "AuthorGroupKey.name",
+		"reputation": "AuthorGroupKey.reputation",
+	}
 	post := map[string]string{
 		"postType": "Post.postType",
 		"author":   "Post.author",
 	}
 	postAggregateResult := map[string]string{
-		"count": "PostAggregateResult.count",
+		"count":  "PostAggregateResult.count",
+		"groups": "PostAggregateResult.groups",
+	}
+	postGroupKey := map[string]string{
+		"postType": "PostGroupKey.postType",
 	}
 	character := map[string]string{
 		"name":      "Character.name",
@@ -124,9 +134,13 @@ type Starship {
 	}
 	characterAggregateResult := map[string]string{
 		"count":   "CharacterAggregateResult.count",
+		"groups":  "CharacterAggregateResult.groups",
 		"nameMax": "CharacterAggregateResult.nameMax",
 		"nameMin": "CharacterAggregateResult.nameMin",
 	}
+	characterGroupKey := map[string]string{
+		"name": "CharacterGroupKey.name",
+	}
 	employee := map[string]string{
 		"ename": "Employee.ename",
 	}
@@ -134,6 +148,10 @@ type Starship {
 		"count":    "EmployeeAggregateResult.count",
 		"enameMax": "EmployeeAggregateResult.enameMax",
 		"enameMin": "EmployeeAggregateResult.enameMin",
+		"groups":   "EmployeeAggregateResult.groups",
+	}
+	employeeGroupKey := map[string]string{
+		"ename": "EmployeeGroupKey.ename",
 	}
 	human := map[string]string{
 		"ename":              "Employee.ename",
@@ -147,6 +165,7 @@ type Starship {
 		"count":           "HumanAggregateResult.count",
 		"enameMax":        "HumanAggregateResult.enameMax",
 		"enameMin":        "HumanAggregateResult.enameMin",
+		"groups":          "HumanAggregateResult.groups",
 		"nameMax":         "HumanAggregateResult.nameMax",
 		"nameMin":         "HumanAggregateResult.nameMin",
 		"totalCreditsAvg": "HumanAggregateResult.totalCreditsAvg",
@@ -154,6 +173,11 @@ type Starship {
 		"totalCreditsMin": "HumanAggregateResult.totalCreditsMin",
 		"totalCreditsSum": "HumanAggregateResult.totalCreditsSum",
 	}
+	humanGroupKey := map[string]string{
+		"ename":        "HumanGroupKey.ename",
+		"name":         "HumanGroupKey.name",
+		"totalCredits": "HumanGroupKey.totalCredits",
+	}
 	droid := map[string]string{
 		"name":            "Character.name",
 		"appearsIn":       "Character.appearsIn",
@@ -161,17 +185,23 @@ type Starship {
 	}
 	droidAggregateResult := map[string]string{
 		"count":              "DroidAggregateResult.count",
+		"groups":             "DroidAggregateResult.groups",
 		"nameMax":            "DroidAggregateResult.nameMax",
 		"nameMin":            "DroidAggregateResult.nameMin",
 		"primaryFunctionMax": "DroidAggregateResult.primaryFunctionMax",
 		"primaryFunctionMin": "DroidAggregateResult.primaryFunctionMin",
 	}
+	droidGroupKey := map[string]string{
+		"name":            "DroidGroupKey.name",
+		"primaryFunction": "DroidGroupKey.primaryFunction",
+	}
 	starship := map[string]string{
 		"name":   "Starship.name",
 		"length": "Starship.length",
 	}
 	starshipAggregateResult := map[string]string{
 		"count":     "StarshipAggregateResult.count",
+		"groups":    "StarshipAggregateResult.groups",
 		"lengthAvg": "StarshipAggregateResult.lengthAvg",
 		"lengthMax": "StarshipAggregateResult.lengthMax",
 		"lengthMin": "StarshipAggregateResult.lengthMin",
@@ -179,6 +209,10 @@ type Starship {
 		"nameMax":   "StarshipAggregateResult.nameMax",
 		"nameMin":   "StarshipAggregateResult.nameMin",
 	}
+	starshipGroupKey := map[string]string{
+		"name":   "StarshipGroupKey.name",
+		"length": "StarshipGroupKey.length",
+	}
 
 	expected := map[string]map[string]string{
 		"Author":                   author,
@@ -209,6 +243,20 @@ type Starship {
 		"HumanAggregateResult":     humanAggregateResult,
 		"PostAggregateResult":      postAggregateResult,
 		"StarshipAggregateResult":  starshipAggregateResult,
+		"AuthorAggregateGroup":     aggregateGroupPredicates("Author", authorAggregateResult),
+		"AuthorGroupKey":           authorGroupKey,
+		"CharacterAggregateGroup":  aggregateGroupPredicates("Character", characterAggregateResult),
+		"CharacterGroupKey":        characterGroupKey,
+		"DroidAggregateGroup":      aggregateGroupPredicates("Droid", droidAggregateResult),
+		"DroidGroupKey":            droidGroupKey,
+		"EmployeeAggregateGroup":   aggregateGroupPredicates("Employee", employeeAggregateResult),
+		"EmployeeGroupKey":         employeeGroupKey,
+		"HumanAggregateGroup":      aggregateGroupPredicates("Human", humanAggregateResult),
+		"HumanGroupKey":            humanGroupKey,
+		"PostAggregateGroup":       aggregateGroupPredicates("Post", postAggregateResult),
+		"PostGroupKey":             postGroupKey,
+		"StarshipAggregateGroup":   aggregateGroupPredicates("Starship", starshipAggregateResult),
+		"StarshipGroupKey":         starshipGroupKey,
 	}
 
 	if diff := cmp.Diff(expected, s.dgraphPredicate); diff != "" {
@@ -216,6 +264,18 @@ type Starship {
 	}
 }
 
+// aggregateGroupPredicates returns the dgraph predicates of the fields of the groups of the
+// aggregate query for typ, given those of its result.
+func aggregateGroupPredicates(typ string, result map[string]string) map[string]string {
+	group := map[string]string{"key": typ + "AggregateGroup.key"}
+	for fld := range result {
+		if fld != "groups" {
+			group[fld] = typ + "AggregateGroup." + fld
+		}
+	}
+	return group
+}
+
 func TestDgraphMapping_WithDirectives(t *testing.T) {
 	schemaStr := `
 	type Author @dgraph(type: "dgraph.author") {
@@ -289,6 +349,7 @@ func TestDgraphMapping_WithDirectives(t *testing.T) {
 		"count":         "AuthorAggregateResult.count",
 		"dobMax":        "AuthorAggregateResult.dobMax",
 		"dobMin":        "AuthorAggregateResult.dobMin",
+		"groups":        "AuthorAggregateResult.groups",
 		"nameMax":       "AuthorAggregateResult.nameMax",
 		"nameMin":       "AuthorAggregateResult.nameMin",
 		"reputationAvg": "AuthorAggregateResult.reputationAvg",
@@ -296,12 +357,21 @@ func TestDgraphMapping_WithDirectives(t *testing.T) {
 		"reputationMin": "AuthorAggregateResult.reputationMin",
 		"reputationSum": "AuthorAggregateResult.reputationSum",
 	}
+	authorGroupKey := map[string]string{
+		"dob":        "AuthorGroupKey.dob",
+		"name":       "AuthorGroupKey.name",
+		"reputation": "AuthorGroupKey.reputation",
+	}
 	post := map[string]string{
 		"postType": "dgraph.post_type",
 		"author":   "dgraph.post_author",
 	}
 	postAggregateResult := map[string]string{
-		"count": "PostAggregateResult.count",
+		"count":  "PostAggregateResult.count",
+		"groups": "PostAggregateResult.groups",
+	}
+	postGroupKey := map[string]string{
+		"postType": "PostGroupKey.postType",
 	}
 	character := map[string]string{
 		"name":      "performance.character.name",
@@ -309,9 +379,13 @@ func TestDgraphMapping_WithDirectives(t *testing.T) {
 	}
 	characterAggregateResult := map[string]string{
 		"count":   "CharacterAggregateResult.count",
+		"groups":  "CharacterAggregateResult.groups",
 		"nameMax": "CharacterAggregateResult.nameMax",
 		"nameMin": "CharacterAggregateResult.nameMin",
 	}
+	characterGroupKey := map[string]string{
+		"name": "CharacterGroupKey.name",
+	}
 	human := map[string]string{
 		"ename":              "dgraph.employee.en.ename",
 		"name":               "performance.character.name",
@@ -324,6 +398,7 @@ func TestDgraphMapping_WithDirectives(t *testing.T) {
 		"count":           "HumanAggregateResult.count",
 		"enameMax":        "HumanAggregateResult.enameMax",
 		"enameMin":        "HumanAggregateResult.enameMin",
+		"groups":          "HumanAggregateResult.groups",
 		"nameMax":         "HumanAggregateResult.nameMax",
 		"nameMin":         "HumanAggregateResult.nameMin",
 		"totalCreditsAvg": "HumanAggregateResult.totalCreditsAvg",
@@ -331,6 +406,11 @@ func TestDgraphMapping_WithDirectives(t *testing.T) {
 		"totalCreditsMin": "HumanAggregateResult.totalCreditsMin",
 		"totalCreditsSum": "HumanAggregateResult.totalCreditsSum",
 	}
+	humanGroupKey := map[string]string{
+		"ename":        "HumanGroupKey.ename",
+		"name":         "HumanGroupKey.name",
+		"totalCredits": "HumanGroupKey.totalCredits",
+	}
 	droid := map[string]string{
 		"name":            "performance.character.name",
 		"appearsIn":       "appears_in",
@@ -338,11 +418,16 @@ func TestDgraphMapping_WithDirectives(t *testing.T) {
 	}
 	droidAggregateResult := map[string]string{
 		"count":              "DroidAggregateResult.count",
+		"groups":             "DroidAggregateResult.groups",
 		"nameMax":            "DroidAggregateResult.nameMax",
 		"nameMin":            "DroidAggregateResult.nameMin",
 		"primaryFunctionMax": "DroidAggregateResult.primaryFunctionMax",
 		"primaryFunctionMin": "DroidAggregateResult.primaryFunctionMin",
 	}
+	droidGroupKey := map[string]string{
+		"name":            "DroidGroupKey.name",
+		"primaryFunction": "DroidGroupKey.primaryFunction",
+	}
 	employee := map[string]string{
 		"ename": "dgraph.employee.en.ename",
 	}
@@ -350,6 +435,10 @@ func TestDgraphMapping_WithDirectives(t *testing.T) {
 		"count":    "EmployeeAggregateResult.count",
 		"enameMax": "EmployeeAggregateResult.enameMax",
 		"enameMin": "EmployeeAggregateResult.enameMin",
+		"groups":   "EmployeeAggregateResult.groups",
+	}
+	employeeGroupKey := map[string]string{
+		"ename": "EmployeeGroupKey.ename",
 	}
 	starship := map[string]string{
 		"name":   "star.ship.name",
@@ -357,6 +446,7 @@ func TestDgraphMapping_WithDirectives(t *testing.T) {
 	}
 	starshipAggregateResult := map[string]string{
 		"count":     "StarshipAggregateResult.count",
+		"groups":    "StarshipAggregateResult.groups",
 		"lengthAvg": "StarshipAggregateResult.lengthAvg",
 		"lengthMax": "StarshipAggregateResult.lengthMax",
 		"lengthMin": "StarshipAggregateResult.lengthMin",
@@ -364,6 +454,10 @@ func TestDgraphMapping_WithDirectives(t *testing.T) {
 		"nameMax":   "StarshipAggregateResult.nameMax",
 		"nameMin":   "StarshipAggregateResult.nameMin",
 	}
+	starshipGroupKey := map[string]string{
+		"name":   "StarshipGroupKey.name",
+		"length": "StarshipGroupKey.length",
+	}
 
 	expected := map[string]map[string]string{
 		"Author":                   author,
@@ -394,6 +488,20 @@ func TestDgraphMapping_WithDirectives(t *testing.T) {
 		"HumanAggregateResult":     humanAggregateResult,
 		"PostAggregateResult":      postAggregateResult,
 		"StarshipAggregateResult":  starshipAggregateResult,
+		"AuthorAggregateGroup":     aggregateGroupPredicates("Author", authorAggregateResult),
+		"AuthorGroupKey":           authorGroupKey,
+		"CharacterAggregateGroup":  aggregateGroupPredicates("Character", characterAggregateResult),
+		"CharacterGroupKey":        characterGroupKey,
+		"DroidAggregateGroup":      aggregateGroupPredicates("Droid", droidAggregateResult),
+		"DroidGroupKey":            droidGroupKey,
+		"EmployeeAggregateGroup":   aggregateGroupPredicates("Employee", employeeAggregateResult),
+		"EmployeeGroupKey":         employeeGroupKey,
+		"HumanAggregateGroup":      aggregateGroupPredicates("Human", humanAggregateResult),
+		"HumanGroupKey":            humanGroupKey,
+		"PostAggregateGroup":       aggregateGroupPredicates("Post", postAggregateResult),
+		"PostGroupKey":             postGroupKey,
+		"StarshipAggregateGroup":   aggregateGroupPredicates("Starship", starshipAggregateResult),
+		"StarshipGroupKey":         starshipGroupKey,
 	}
 
 	if diff := cmp.Diff(expected, s.dgraphPredicate); diff != "" {
@@ -1352,3 +1460,42 @@ func TestParseSecrets(t *testing.T) {
 		})
 	}
 }
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursors := []*Cursor{
+		{UID: "0x5"},
+		{UID: "0x5", Order: "title", Value: json.RawMessage(`"Bike \"X\""`), Ties: 2},
+		{UID: "0x2a", Order: "price", Value: json.RawMessage(`10.5`), Ties: 1},
+	}
+
+	for _, c := range cursors {
+		parsed, err := ParseCursor(c.Encode())
+		require.NoError(t, err)
+		require.Equal(t, c, parsed)
+	}
+}
+
+func TestParseCursor_Invalid(t *testing.T) {
+	invalid := []string{"", "not a cursor!", "eyJvcmRlciI6InRpdGxlIn0"}
+	// The uid and the value of a cursor go into the DQL query, so anything else than a uid and a
+	// JSON scalar is rejected.
+	for _, c := range []*Cursor{
+		{UID: "0x0"},
+		{UID: "0x5) { uid } q2(func: has(secret)"},
+		{UID: "0x5", Order: "title", Value: json.RawMessage(`{"a": 1}`)},
+		{UID: "0x5", Order: "title", Value: json.RawMessage(`["a"]`)},
+		{UID: "0x5", Order: "title", Value: json.RawMessage(`null`)},
+		{UID: "0x5", Order: "title", Value: json.RawMessage(`"a"`), Ties: -1},
+	} {
+		invalid = append(invalid, c.Encode())
+	}
+	for _, s := range invalid {
+		_, err := ParseCursor(s)
+		require.EqualError(t, err, s+" isn't a valid cursor")
+	}
+
+	// The uid is given back in its canonical form.
+	c, err := ParseCursor((&Cursor{UID: "42"}).Encode())
+	require.NoError(t, err)
+	require.Equal(t, "0x2a", c.UID)
+}
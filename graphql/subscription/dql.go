@@ -0,0 +1,165 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgryski/go-farm"
+	"github.com/pkg/errors"
+
+	"github.com/vtta/dgraph/gql"
+	"github.com/vtta/dgraph/graphql/schema"
+	"github.com/vtta/dgraph/x"
+)
+
+// DqlRunner runs the DQL queries of subscriptions, as the user whose credentials are attached to
+// the context.
+type DqlRunner func(ctx context.Context, req *api.Request) (*api.Response, error)
+
+// NewDqlPoller returns a Poller for the subscriptions to DQL queries in the namespace. The
+// subscriptions are terminated when globalEpoch changes.
+func NewDqlPoller(globalEpoch *uint64, run DqlRunner, ns uint64, watch CommitWatcher) *Poller {
+	p := NewPoller(globalEpoch, nil, ns, watch)
+	p.dql = run
+	return p
+}
+
+// AddDqlSubscriber subscribes to the result of a DQL query, which is run with the credentials in
+// the header: the X-Dgraph-AccessToken or X-Dgraph-ApiKey headers if ACL is enabled. The
+// subscribers to the same query with the same variables and credentials share a bucket, so that
+// the query is run once for all of them. The subscription is terminated when the access JWT
+// expires.
+func (p *Poller) AddDqlSubscriber(req *api.Request, header http.Header) (*SubscriberResponse,
+	error) {
+	reads, err := dqlReads(req.Query, req.Vars)
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := accessJwtExpiry(header.Get("X-Dgraph-AccessToken"))
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := json.Marshal(struct {
+		Query     string            `json:"query"`
+		Variables map[string]string `json:"variables"`
+		AccessJwt string            `json:"accessJwt"`
+		ApiKey    string            `json:"apiKey"`
+	}{
+		Query:     req.Query,
+		Variables: req.Vars,
+		AccessJwt: header.Get("X-Dgraph-AccessToken"),
+		ApiKey:    header.Get("X-Dgraph-ApiKey"),
+	})
+	x.Check(err)
+
+	query := &api.Request{Query: req.Query, Vars: req.Vars, ReadOnly: true}
+	pollR := &pollRequest{
+		bucketID:   farm.Fingerprint64(buf),
+		localEpoch: atomic.LoadUint64(p.globalEpoch),
+		reads:      reads,
+		resolve: func() *schema.Response {
+			ctx := x.AttachAccessJwt(context.Background(), &http.Request{Header: header})
+			resp, err := p.dql(ctx, query)
+			if err != nil {
+				return schema.ErrorResponse(err)
+			}
+			res := &schema.Response{}
+			x.Check2(res.Data.Write(resp.GetJson()))
+			return res
+		},
+	}
+	return p.addSubscriber(pollR, expiry, header)
+}
+
+// accessJwtExpiry returns when the access JWT expires, or the zero time if there is no JWT or it
+// doesn't expire. The JWT is only parsed here, it is validated when the query is run.
+func accessJwtExpiry(accessJwt string) (time.Time, error) {
+	if accessJwt == "" {
+		return time.Time{}, nil
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(accessJwt, claims); err != nil {
+		return time.Time{}, errors.Wrapf(err, "unable to parse jwt token")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, nil
+	}
+	return time.Unix(int64(exp), 0), nil
+}
+
+// dqlReads returns the predicates read by a DQL query.
+func dqlReads(query string, vars map[string]string) (*readSet, error) {
+	parsed, err := gql.Parse(gql.Request{Str: query, Variables: vars})
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed.Query) == 0 {
+		return nil, errors.New("no query to subscribe to")
+	}
+
+	rs := &readSet{preds: map[string]struct{}{"dgraph.type": {}}}
+	var addFilter func(ft *gql.FilterTree)
+	addFunc := func(fn *gql.Function) {
+		if fn != nil {
+			rs.add(fn.Attr)
+		}
+	}
+	addFilter = func(ft *gql.FilterTree) {
+		if ft == nil {
+			return
+		}
+		addFunc(ft.Func)
+		for _, child := range ft.Child {
+			addFilter(child)
+		}
+	}
+	var addQuery func(gq *gql.GraphQuery, root bool)
+	addQuery = func(gq *gql.GraphQuery, root bool) {
+		// The predicates of expanded types aren't known from the query alone.
+		if gq.Expand != "" {
+			rs.all = true
+		}
+		// The attribute of a query block is its name.
+		if !root {
+			rs.add(gq.Attr)
+		}
+		addFunc(gq.Func)
+		addFilter(gq.Filter)
+		for _, order := range gq.Order {
+			rs.add(order.Attr)
+		}
+		for _, attr := range gq.GroupbyAttrs {
+			rs.add(attr.Attr)
+		}
+		for _, child := range gq.Children {
+			addQuery(child, false)
+		}
+	}
+	for _, gq := range parsed.Query {
+		addQuery(gq, true)
+	}
+	return rs, nil
+}
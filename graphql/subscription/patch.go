@@ -0,0 +1,126 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subscription
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vtta/dgraph/graphql/schema"
+	"github.com/vtta/dgraph/x"
+)
+
+// patchOp is an operation of a JSON patch, as per RFC 6902.
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// value returns the JSON of a value of an operation. A null value is kept as such, unlike the
+// missing value of a remove operation.
+func value(v interface{}) json.RawMessage {
+	b, err := json.Marshal(v)
+	x.Check(err)
+	return b
+}
+
+// patchOutput returns the update sent to the incremental subscribers: the JSON patch that turns
+// the data they were sent last into the data of the response. The whole response is sent if
+// either data can't be read.
+func patchOutput(res *schema.Response, prev, cur []byte) interface{} {
+	var prevData, curData interface{}
+	if json.Unmarshal(prev, &prevData) != nil || json.Unmarshal(cur, &curData) != nil {
+		return res.Output()
+	}
+	return struct {
+		Errors []*x.GqlError `json:"errors,omitempty"`
+		Patch  []patchOp     `json:"patch"`
+	}{
+		Errors: res.Errors,
+		Patch:  diff("", prevData, curData, nil),
+	}
+}
+
+// diff appends to ops the operations that turn the value at path from prev into cur. Lists are
+// diffed element by element, with elements added or removed at their end.
+func diff(path string, prev, cur interface{}, ops []patchOp) []patchOp {
+	switch prev := prev.(type) {
+	case map[string]interface{}:
+		cur, ok := cur.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(prev)+len(cur))
+		for k := range prev {
+			keys = append(keys, k)
+		}
+		for k := range cur {
+			if _, ok := prev[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := path + "/" + escapePointer(k)
+			prevVal, inPrev := prev[k]
+			curVal, inCur := cur[k]
+			switch {
+			case !inCur:
+				ops = append(ops, patchOp{Op: "remove", Path: p})
+			case !inPrev:
+				ops = append(ops, patchOp{Op: "add", Path: p, Value: value(curVal)})
+			default:
+				ops = diff(p, prevVal, curVal, ops)
+			}
+		}
+		return ops
+	case []interface{}:
+		cur, ok := cur.([]interface{})
+		if !ok {
+			break
+		}
+		common := len(prev)
+		if len(cur) < common {
+			common = len(cur)
+		}
+		for i := 0; i < common; i++ {
+			ops = diff(path+"/"+strconv.Itoa(i), prev[i], cur[i], ops)
+		}
+		for i := common; i < len(cur); i++ {
+			ops = append(ops, patchOp{Op: "add", Path: path + "/" + strconv.Itoa(i), Value: value(cur[i])})
+		}
+		// The elements are removed from the last, so that the indexes of the others hold.
+		for i := len(prev) - 1; i >= common; i-- {
+			ops = append(ops, patchOp{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+		return ops
+	}
+
+	if !reflect.DeepEqual(prev, cur) {
+		ops = append(ops, patchOp{Op: "replace", Path: path, Value: value(cur)})
+	}
+	return ops
+}
+
+// escapePointer escapes a key to be used in a JSON pointer, as per RFC 6901.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
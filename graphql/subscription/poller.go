@@ -30,25 +30,54 @@ import (
 	"github.com/vtta/dgraph/graphql/resolve"
 	"github.com/vtta/dgraph/graphql/schema"
 	"github.com/vtta/dgraph/x"
+	"github.com/dgraph-io/ristretto/z"
 	"github.com/dgryski/go-farm"
 	"github.com/golang/glog"
 )
 
-// Poller is used to poll user subscription query.
+// IncrementalHeader is the header, passed in the INIT payload of the websocket connection, through
+// which the clients ask for the updates to be sent as JSON patches against the previous result
+// instead of as the whole result.
+const IncrementalHeader = "X-Dgraph-Incremental"
+
+// resyncTicks is the number of poll intervals after which the buckets are resolved even if no
+// commit touched their predicates, so that the updates missed while reconnecting to the commit
+// stream are eventually sent.
+const resyncTicks = 60
+
+// CommitWatcher calls notify with the predicates of the namespace that are written by each batch
+// of committed transactions, until the closer is signalled.
+type CommitWatcher func(ns uint64, notify func(preds map[string]struct{}), closer *z.Closer)
+
+// Poller is used to push the updates of user subscription queries. The queries are resolved again
+// when a commit touches the predicates that they read.
 type Poller struct {
 	sync.RWMutex
 	resolver       *resolve.RequestResolver
 	pollRegistry   map[uint64]map[uint64]subscriber
 	subscriptionID uint64
 	globalEpoch    *uint64
+
+	// dql runs the queries of the subscriptions to DQL queries, in which case resolver is nil.
+	dql       DqlRunner
+	namespace uint64
+	watch     CommitWatcher
+	// watcher is the closer of the commit watch, which only runs while there are buckets.
+	watcher *z.Closer
+	// buckets maps a bucket to the request which is resolved for its subscriptions.
+	buckets map[uint64]*pollRequest
 }
 
 // NewPoller returns Poller.
-func NewPoller(globalEpoch *uint64, resolver *resolve.RequestResolver) *Poller {
+func NewPoller(globalEpoch *uint64, resolver *resolve.RequestResolver, ns uint64,
+	watch CommitWatcher) *Poller {
 	return &Poller{
 		resolver:     resolver,
 		pollRegistry: make(map[uint64]map[uint64]subscriber),
 		globalEpoch:  globalEpoch,
+		namespace:    ns,
+		watch:        watch,
+		buckets:      make(map[uint64]*pollRequest),
 	}
 }
 
@@ -62,6 +91,11 @@ type SubscriberResponse struct {
 type subscriber struct {
 	expiry   time.Time
 	updateCh chan interface{}
+	// incremental is set if the subscriber asked for JSON patches instead of whole results, in
+	// which case sent is the data it was sent last, and sentHash its hash.
+	incremental bool
+	sent        []byte
+	sentHash    uint64
 }
 
 // AddSubscriber tries to add subscription into the existing polling goroutine if it exists.
@@ -75,6 +109,10 @@ func (p *Poller) AddSubscriber(req *schema.Request) (*SubscriberResponse, error)
 	if err := resolver.ValidateSubscription(req); err != nil {
 		return nil, err
 	}
+	op, err := resolver.Schema().Operation(req)
+	if err != nil {
+		return nil, err
+	}
 
 	// find out the custom claims for auth, if any. As,
 	// We also need to use authVariables in generating the hashed bucketID
@@ -107,16 +145,34 @@ func (p *Poller) AddSubscriber(req *schema.Request) (*SubscriberResponse, error)
 	} else {
 		bucketID = farm.Fingerprint64(buf)
 	}
+	pollR := &pollRequest{
+		bucketID:      bucketID,
+		graphqlReq:    req,
+		authVariables: customClaims.AuthVariables,
+		localEpoch:    localEpoch,
+		reads:         operationReads(op),
+		resolve: func() *schema.Response {
+			ctx := x.AttachAccessJwt(context.Background(), &http.Request{Header: req.Header})
+			return resolver.Resolve(ctx, req)
+		},
+	}
+	return p.addSubscriber(pollR, customClaims.StandardClaims.ExpiresAt.Time, req.Header)
+}
+
+// addSubscriber adds a subscriber to the bucket of the request, which it is sent the current
+// result of. If the bucket is new, a goroutine is started to publish the updates of its result.
+func (p *Poller) addSubscriber(req *pollRequest, expiry time.Time,
+	header http.Header) (*SubscriberResponse, error) {
 	p.Lock()
 	defer p.Unlock()
 
-	res := resolver.Resolve(x.AttachAccessJwt(context.Background(),
-		&http.Request{Header: req.Header}), req)
+	res := req.resolve()
 	if len(res.Errors) != 0 {
 		return nil, res.Errors
 	}
 
-	prevHash := farm.Fingerprint64(res.Data.Bytes())
+	data := res.Data.Bytes()
+	prevHash := farm.Fingerprint64(data)
 
 	updateCh := make(chan interface{}, 10)
 	updateCh <- res.Output()
@@ -124,21 +180,26 @@ func (p *Poller) AddSubscriber(req *schema.Request) (*SubscriberResponse, error)
 	subscriptionID := p.subscriptionID
 	// Increment ID for next subscription.
 	p.subscriptionID++
-	subscriptions, ok := p.pollRegistry[bucketID]
+	subscriptions, ok := p.pollRegistry[req.bucketID]
 	if !ok {
 		subscriptions = make(map[uint64]subscriber)
 	}
 	glog.Infof("Subscription polling is started for the ID %d", subscriptionID)
 
 	subscriptions[subscriptionID] = subscriber{
-		expiry: customClaims.StandardClaims.ExpiresAt.Time, updateCh: updateCh}
-	p.pollRegistry[bucketID] = subscriptions
+		expiry:      expiry,
+		updateCh:    updateCh,
+		incremental: header.Get(IncrementalHeader) == "true",
+		sent:        data,
+		sentHash:    prevHash,
+	}
+	p.pollRegistry[req.bucketID] = subscriptions
 
 	if ok {
 		// Already there is a running go routine for this bucket. So,no need to poll the server.
 		// We can use the existing polling routine to publish the update.
 		return &SubscriberResponse{
-			BucketID:       bucketID,
+			BucketID:       req.bucketID,
 			SubscriptionID: subscriptionID,
 			UpdateCh:       subscriptions[subscriptionID].updateCh,
 		}, nil
@@ -146,17 +207,17 @@ func (p *Poller) AddSubscriber(req *schema.Request) (*SubscriberResponse, error)
 
 	// There is no goroutine running to check updates for this query. So, run one to publish
 	// the updates.
-	pollR := &pollRequest{
-		bucketID:      bucketID,
-		prevHash:      prevHash,
-		graphqlReq:    req,
-		authVariables: customClaims.AuthVariables,
-		localEpoch:    localEpoch,
+	req.prevHash = prevHash
+	req.updated = make(chan struct{}, 1)
+	p.buckets[req.bucketID] = req
+	if p.watcher == nil && p.watch != nil {
+		p.watcher = z.NewCloser(0)
+		p.watch(p.namespace, p.notify, p.watcher)
 	}
-	go p.poll(pollR)
+	go p.poll(req)
 
 	return &SubscriberResponse{
-		BucketID:       bucketID,
+		BucketID:       req.bucketID,
 		SubscriptionID: subscriptionID,
 		UpdateCh:       subscriptions[subscriptionID].updateCh,
 	}, nil
@@ -168,62 +229,105 @@ type pollRequest struct {
 	bucketID      uint64
 	localEpoch    uint64
 	authVariables map[string]interface{}
+	reads         *readSet
+	// resolve returns the current result of the request.
+	resolve func() *schema.Response
+	// updated is signalled when a commit touches the predicates that the request reads.
+	updated chan struct{}
 }
 
-func (p *Poller) poll(req *pollRequest) {
+// notify signals the buckets whose requests read any of the predicates that a commit wrote.
+func (p *Poller) notify(preds map[string]struct{}) {
 	p.RLock()
-	resolver := p.resolver
-	p.RUnlock()
-
-	pollID := uint64(0)
-	for {
-		pollID++
-		time.Sleep(x.Config.GraphQL.GetDuration("poll-interval"))
-
-		globalEpoch := atomic.LoadUint64(p.globalEpoch)
-		if req.localEpoch != globalEpoch || globalEpoch == math.MaxUint64 {
-			// There is a schema change since local epoch is diffrent from global schema epoch.
-			// We'll terminate all the subscription for this bucket. So, that all client can
-			// reconnect and listen for new schema.
-			p.terminateSubscriptions(req.bucketID)
+	defer p.RUnlock()
+	for _, req := range p.buckets {
+		if !req.reads.touchedBy(preds) {
+			continue
 		}
+		select {
+		case req.updated <- struct{}{}:
+		default:
+			// The bucket is already due to be resolved again.
+		}
+	}
+}
 
-		ctx := x.AttachAccessJwt(context.Background(), &http.Request{Header: req.graphqlReq.Header})
-		res := resolver.Resolve(ctx, req.graphqlReq)
+// removeBucket forgets the bucket, and stops watching the commits if no bucket remains. It must be
+// called with the lock held.
+func (p *Poller) removeBucket(bucketID uint64) {
+	delete(p.pollRegistry, bucketID)
+	delete(p.buckets, bucketID)
+	if len(p.buckets) == 0 && p.watcher != nil {
+		p.watcher.Signal()
+		p.watcher = nil
+	}
+}
 
-		currentHash := farm.Fingerprint64(res.Data.Bytes())
+// housekeep terminates the subscriptions that have expired, and tells if the bucket has any
+// subscriptions left.
+func (p *Poller) housekeep(req *pollRequest) bool {
+	globalEpoch := atomic.LoadUint64(p.globalEpoch)
+	if req.localEpoch != globalEpoch || globalEpoch == math.MaxUint64 {
+		// There is a schema change since local epoch is diffrent from global schema epoch.
+		// We'll terminate all the subscription for this bucket. So, that all client can
+		// reconnect and listen for new schema.
+		p.terminateSubscriptions(req.bucketID)
+		return false
+	}
 
-		if req.prevHash == currentHash {
-			if pollID%2 != 0 {
-				// Don't update if there is no change in response.
-				continue
+	p.Lock()
+	defer p.Unlock()
+	subscribers, ok := p.pollRegistry[req.bucketID]
+	if ok {
+		for subscriberID, subscriber := range subscribers {
+			if !subscriber.expiry.IsZero() && time.Now().After(subscriber.expiry) {
+				p.terminateSubscription(req.bucketID, subscriberID)
 			}
-			// Every second poll, we'll check if there is any active subscription for the
-			// current goroutine. If not we'll terminate this poll.
-			p.Lock()
-			subscribers, ok := p.pollRegistry[req.bucketID]
-			if !ok || len(subscribers) == 0 {
-				delete(p.pollRegistry, req.bucketID)
-				p.Unlock()
+		}
+	}
+	if !ok || len(subscribers) == 0 {
+		p.removeBucket(req.bucketID)
+		return false
+	}
+	return true
+}
+
+func (p *Poller) poll(req *pollRequest) {
+	ticker := time.NewTicker(x.Config.GraphQL.GetDuration("poll-interval"))
+	defer ticker.Stop()
+
+	// The commits made between the first resolution and the start of the commit watch are only
+	// picked up by resolving again, which is done on the first tick.
+	tick := resyncTicks - 1
+	for {
+		select {
+		case <-req.updated:
+		case <-ticker.C:
+			if !p.housekeep(req) {
 				return
 			}
-			for subscriberID, subscriber := range subscribers {
-				if !subscriber.expiry.IsZero() && time.Now().After(subscriber.expiry) {
-					p.terminateSubscription(req.bucketID, subscriberID)
-				}
-
+			tick++
+			// The requests that read from @custom or @lambda fields can change without any
+			// commit, so they are still polled, as are all the requests if commits aren't watched.
+			if !req.reads.polled && p.watch != nil && tick%resyncTicks != 0 {
+				continue
 			}
-			p.Unlock()
+		}
+
+		res := req.resolve()
+		data := res.Data.Bytes()
+		currentHash := farm.Fingerprint64(data)
+		if req.prevHash == currentHash {
+			// Don't update if there is no change in response.
 			continue
 		}
-		req.prevHash = currentHash
 
 		p.Lock()
 		subscribers, ok := p.pollRegistry[req.bucketID]
 		if !ok || len(subscribers) == 0 {
 			// There is no subscribers to push the update. So, kill the current polling
 			// go routine.
-			delete(p.pollRegistry, req.bucketID)
+			p.removeBucket(req.bucketID)
 			p.Unlock()
 			return
 		}
@@ -232,12 +336,26 @@ func (p *Poller) poll(req *pollRequest) {
 			if !subscriber.expiry.IsZero() && time.Now().After(subscriber.expiry) {
 				p.terminateSubscription(req.bucketID, subscriberID)
 			}
-
 		}
-		for _, subscriber := range subscribers {
-			subscriber.updateCh <- res.Output()
+		// The subscribers that joined the bucket since the last update may have been sent other
+		// data than the others, so the patches are made for each data that was sent.
+		patches := make(map[uint64]interface{})
+		for subscriberID, subscriber := range subscribers {
+			if !subscriber.incremental {
+				subscriber.updateCh <- res.Output()
+				continue
+			}
+			patch, ok := patches[subscriber.sentHash]
+			if !ok {
+				patch = patchOutput(res, subscriber.sent, data)
+				patches[subscriber.sentHash] = patch
+			}
+			subscriber.updateCh <- patch
+			subscriber.sent, subscriber.sentHash = data, currentHash
+			subscribers[subscriberID] = subscriber
 		}
 		p.Unlock()
+		req.prevHash = currentHash
 	}
 }
 
@@ -253,7 +371,7 @@ func (p *Poller) terminateSubscriptions(bucketID uint64) {
 		// Closing the channel will close the graphQL websocket connection as well.
 		close(subscriber.updateCh)
 	}
-	delete(p.pollRegistry, bucketID)
+	p.removeBucket(bucketID)
 }
 
 func (p *Poller) TerminateSubscription(bucketID, subscriptionID uint64) {
@@ -0,0 +1,193 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/dgraph-io/ristretto/z"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/graphql/schema"
+	"github.com/vtta/dgraph/graphql/test"
+	"github.com/vtta/dgraph/x"
+)
+
+const subscriptionSchema = `
+type Author @withSubscription {
+	id: ID!
+	name: String! @search(by: [hash])
+	country: String
+	posts: [Post] @hasInverse(field: author)
+}
+
+type Post @withSubscription @auth(
+	query: { rule: "query { queryPost(filter: { isPublished: true }) { author { name } } }" }
+) {
+	id: ID!
+	title: String! @search(by: [hash])
+	isPublished: Boolean
+	author: Author
+}
+
+type Tag @withSubscription @auth(
+	query: { rule: "{ $role: { eq: \"ADMIN\" } }" }
+) {
+	id: ID!
+	label: String!
+}
+
+type Comment @withSubscription @auth(
+	query: { rule: "query { queryComment { text } }" }
+) {
+	id: ID!
+	text: String!
+	score: Int @custom(http: { url: "http://score.io", method: "GET" })
+}
+
+# Dgraph.Authorization {"VerificationKey":"secret","Header":"X-Test-Auth","Namespace":"https://xyz.io/jwt/claims","Algo":"HS256"}
+`
+
+func TestOperationReads(t *testing.T) {
+	sch := test.LoadSchemaFromString(t, subscriptionSchema)
+	reads := func(query string) *readSet {
+		op, err := sch.Operation(&schema.Request{Query: query})
+		require.NoError(t, err)
+		return operationReads(op)
+	}
+
+	rs := reads(`subscription { queryAuthor(filter: { name: { eq: "A" } }) { name } }`)
+	require.Equal(t, map[string]struct{}{"dgraph.type": {}, "Author.name": {},
+		"Author.country": {}, "Author.posts": {}}, rs.preds)
+	require.False(t, rs.polled)
+	require.False(t, rs.touchedBy(map[string]struct{}{"Post.title": {}}))
+	require.True(t, rs.touchedBy(map[string]struct{}{"Post.title": {}, "Author.country": {}}))
+
+	// The predicates read by the auth rules count, and so do the nested types and aggregates.
+	rs = reads(`subscription { queryPost { title } }`)
+	require.Contains(t, rs.preds, "Post.isPublished")
+	require.Contains(t, rs.preds, "Author.name")
+	// So do the types that the filters on related types go through.
+	rs = reads(`subscription {
+		queryAuthor(filter: { not: { posts: { title: { eq: "A" } } } }) { name }
+	}`)
+	require.Contains(t, rs.preds, "Post.title")
+	require.Contains(t, rs.preds, "Post.isPublished")
+	rs = reads(`subscription { aggregateTag { count } }`)
+	require.Equal(t, map[string]struct{}{"dgraph.type": {}, "Tag.label": {}}, rs.preds)
+
+	rs = reads(`subscription { queryComment { text score } }`)
+	require.True(t, rs.polled)
+	require.False(t, rs.all)
+}
+
+func TestPatchDiff(t *testing.T) {
+	parse := func(s string) interface{} {
+		var v interface{}
+		require.NoError(t, json.Unmarshal([]byte(s), &v))
+		return v
+	}
+	ops := diff("", parse(`{"queryPost": [{"title": "A", "tags": ["x"]}, {"title": "B"}],
+		"a/b": 1, "old": true}`),
+		parse(`{"queryPost": [{"title": "C", "tags": ["x", "y"]}], "a/b": null, "new": 1}`), nil)
+	out, err := json.Marshal(ops)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"op": "replace", "path": "/a~1b", "value": null},
+		{"op": "add", "path": "/new", "value": 1},
+		{"op": "remove", "path": "/old"},
+		{"op": "add", "path": "/queryPost/0/tags/1", "value": "y"},
+		{"op": "replace", "path": "/queryPost/0/title", "value": "C"},
+		{"op": "remove", "path": "/queryPost/1"}
+	]`, string(out))
+
+	require.Empty(t, diff("", parse(`{"a": [1, {"b": 2}]}`), parse(`{"a": [1, {"b": 2}]}`), nil))
+}
+
+func TestDqlReads(t *testing.T) {
+	rs, err := dqlReads(`query q($name: string) {
+		me(func: eq(name, $name)) @filter(has(~friend) AND NOT lt(age, 18)) {
+			friend(orderasc: score) @groupby(city) {
+				count(uid)
+			}
+			n as nickname
+		}
+		other(func: uid(n)) { val(n) }
+	}`, map[string]string{"$name": "alice"})
+	require.NoError(t, err)
+	require.False(t, rs.all)
+	for _, pred := range []string{"dgraph.type", "name", "friend", "age", "score", "city",
+		"nickname"} {
+		require.Contains(t, rs.preds, pred)
+	}
+	require.NotContains(t, rs.preds, "me")
+	require.False(t, rs.touchedBy(map[string]struct{}{"email": {}}))
+
+	rs, err = dqlReads(`{ q(func: type(Person)) { expand(_all_) } }`, nil)
+	require.NoError(t, err)
+	require.True(t, rs.all)
+
+	_, err = dqlReads(`schema {}`, nil)
+	require.Error(t, err)
+}
+
+func TestDqlSubscriptionExpiry(t *testing.T) {
+	graphqlConfig := x.Config.GraphQL
+	defer func() { x.Config.GraphQL = graphqlConfig }()
+	x.Config.GraphQL = z.NewSuperFlag("poll-interval=10ms;").MergeAndCheckDefault("poll-interval=1s;")
+
+	run := func(ctx context.Context, req *api.Request) (*api.Response, error) {
+		return &api.Response{Json: []byte(`{"q":[]}`)}, nil
+	}
+	p := NewDqlPoller(new(uint64), run, x.GalaxyNamespace, nil)
+	subscribe := func(exp time.Time) *SubscriberResponse {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"userid": "alice",
+			"exp":    exp.Unix(),
+		}).SignedString([]byte("secret"))
+		require.NoError(t, err)
+		header := http.Header{}
+		header.Set("X-Dgraph-AccessToken", token)
+		res, err := p.AddDqlSubscriber(&api.Request{Query: `{ q(func: has(name)) { name } }`},
+			header)
+		require.NoError(t, err)
+		<-res.UpdateCh
+		return res
+	}
+
+	// The subscription lasts as long as the access JWT it was made with.
+	live := subscribe(time.Now().Add(time.Hour))
+	expired := subscribe(time.Now().Add(-time.Second))
+	select {
+	case _, ok := <-expired.UpdateCh:
+		require.False(t, ok)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the subscription wasn't terminated when its access JWT expired")
+	}
+	select {
+	case <-live.UpdateCh:
+		t.Fatal("the subscription was terminated before its access JWT expired")
+	default:
+	}
+	p.TerminateSubscription(live.BucketID, live.SubscriptionID)
+}
@@ -0,0 +1,182 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package subscription
+
+import (
+	"strings"
+
+	"github.com/vtta/dgraph/graphql/schema"
+)
+
+// readSet is the set of Dgraph predicates that a subscription query may read, and so the set of
+// predicates whose commits may change its result.
+type readSet struct {
+	preds map[string]struct{}
+	// all is set if the predicates can't be told, as is the case when an auth rule is written in
+	// DQL. A commit to any predicate may then change the result.
+	all bool
+	// polled is set if the result depends on more than what is stored in Dgraph, i.e. on @custom
+	// or @lambda fields.
+	polled bool
+	// seen holds the types whose predicates have already been added.
+	seen map[string]bool
+}
+
+// operationReads returns the predicates read by the queries of the operation. Besides the fields
+// that are selected, these are all the fields of the types that the queries go through, as they
+// may be used in filters and orders, and the fields read by the auth rules of those types.
+func operationReads(op schema.Operation) *readSet {
+	rs := &readSet{
+		// Adding and deleting nodes changes their type.
+		preds: map[string]struct{}{"dgraph.type": {}},
+		seen:  make(map[string]bool),
+	}
+	for _, q := range op.Queries() {
+		rs.addField(q)
+	}
+	return rs
+}
+
+func (rs *readSet) add(pred string) {
+	if pred == "" {
+		return
+	}
+	// Commits to a predicate write its reverse edges too.
+	rs.preds[strings.TrimPrefix(pred, "~")] = struct{}{}
+}
+
+func (rs *readSet) addField(f schema.Field) {
+	if f.IsCustomHTTP() || f.HasLambdaDirective() {
+		rs.polled = true
+	}
+	switch {
+	case f.IsAggregateField():
+		rs.add(f.ConstructedForDgraphPredicate())
+	case strings.HasSuffix(f.GetObjectName(), "AggregateResult"):
+		// The fields of aggregate results read the type that is aggregated, which is added already.
+	default:
+		rs.add(f.DgraphPredicate())
+	}
+	rs.addType(f.ConstructedFor())
+	filter, _ := f.ArgValue("filter").(map[string]interface{})
+	rs.addFilter(f.ConstructedFor(), filter)
+	for _, child := range f.SelectionSet() {
+		rs.addField(child)
+	}
+}
+
+func (rs *readSet) addType(t schema.Type) {
+	if t == nil || rs.seen[t.Name()] {
+		return
+	}
+	rs.seen[t.Name()] = true
+
+	for _, fd := range t.Fields() {
+		// The aggregate fields read the predicates of the fields they aggregate.
+		if !fd.Type().IsAggregateResult() {
+			rs.add(fd.DgraphPredicate())
+		}
+	}
+	for _, impl := range t.ImplementingTypes() {
+		rs.addType(impl)
+	}
+	if t.IsUnion() {
+		for _, member := range t.UnionMembers(nil) {
+			rs.addType(member)
+		}
+	}
+	if auth := t.AuthRules(); auth != nil {
+		if auth.Rules != nil {
+			rs.addRule(auth.Rules.Query)
+		}
+		for _, fieldAuth := range auth.Fields {
+			rs.addRule(fieldAuth.Query)
+		}
+	}
+}
+
+// addFilter adds the types that the filter, of the nodes of t, goes through to filter them by the
+// fields of related types, like Author in
+//
+//	filter: { author: { name: { eq: "A" } } }
+func (rs *readSet) addFilter(t schema.Type, filter map[string]interface{}) {
+	if t == nil || t.IsUnion() {
+		return
+	}
+	for key, val := range filter {
+		switch key {
+		case "and", "or":
+			switch v := val.(type) {
+			case map[string]interface{}:
+				rs.addFilter(t, v)
+			case []interface{}:
+				for _, obj := range v {
+					f, _ := obj.(map[string]interface{})
+					rs.addFilter(t, f)
+				}
+			}
+		case "not":
+			f, _ := val.(map[string]interface{})
+			rs.addFilter(t, f)
+		case "has":
+		default:
+			related, ok := val.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fd := t.Field(key)
+			if fd.Type().IsInbuiltOrEnumType() || fd.Type().IsUnion() {
+				continue
+			}
+			rs.add(fd.DgraphPredicate())
+			rs.addType(fd.Type())
+			rs.addFilter(fd.Type(), related)
+		}
+	}
+}
+
+func (rs *readSet) addRule(rn *schema.RuleNode) {
+	if rn == nil {
+		return
+	}
+	for _, or := range rn.Or {
+		rs.addRule(or)
+	}
+	for _, and := range rn.And {
+		rs.addRule(and)
+	}
+	rs.addRule(rn.Not)
+	if rn.Rule != nil {
+		rs.addField(rn.Rule)
+	}
+	if rn.DQLRule != nil {
+		rs.all = true
+	}
+}
+
+// touchedBy tells if a commit that wrote the given predicates may change the result.
+func (rs *readSet) touchedBy(preds map[string]struct{}) bool {
+	if rs.all {
+		return true
+	}
+	for pred := range preds {
+		if _, ok := rs.preds[pred]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,301 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package query
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vtta/dgraph/algo"
+	"github.com/vtta/dgraph/protos/pb"
+	"github.com/vtta/dgraph/worker"
+)
+
+const (
+	// swapRatio is how many times fewer uids a filter must be estimated to match than the
+	// function at root for the two to be swapped.
+	swapRatio = 2
+	// estimateTTL is how long the estimated number of uids matched by a function is reused for.
+	// Estimates only decide the order in which a query is evaluated, so they can be a bit stale.
+	estimateTTL = time.Minute
+	// maxEstimates is the maximum number of estimates held by the cache.
+	maxEstimates = 10000
+)
+
+var estimates = &estimateCache{entries: make(map[string]estimate)}
+
+type estimate struct {
+	uids uint64
+	// ok is false if the function couldn't be estimated, e.g. because its predicate isn't
+	// indexed. It is cached too, so that we don't keep asking.
+	ok bool
+	at time.Time
+}
+
+// estimateCache holds the recently estimated number of uids matched by functions, keyed by the
+// namespaced predicate and the function.
+type estimateCache struct {
+	sync.Mutex
+	entries map[string]estimate
+}
+
+func (c *estimateCache) get(key string) (estimate, bool) {
+	c.Lock()
+	defer c.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Since(e.at) > estimateTTL {
+		return estimate{}, false
+	}
+	return e, true
+}
+
+func (c *estimateCache) set(key string, e estimate) {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.entries) >= maxEstimates {
+		c.entries = make(map[string]estimate)
+	}
+	c.entries[key] = e
+}
+
+// isEstimable tells if the number of uids matched by the function of the SubGraph can be
+// estimated cheaply from the lengths of the index posting lists for its tokens. Functions like
+// inequalities, regexp or has would need to read as much as evaluating them does.
+func isEstimable(sg *SubGraph) bool {
+	fn := sg.SrcFunc
+	if fn == nil || sg.Attr == "" || strings.HasPrefix(sg.Attr, "~") || fn.IsCount ||
+		fn.IsValueVar || fn.IsLenVar || len(sg.Params.NeedsVar) > 0 {
+		return false
+	}
+	for _, arg := range fn.Args {
+		if arg.IsValueVar {
+			return false
+		}
+	}
+	switch fn.Name {
+	case "eq", "anyofterms", "allofterms", "anyoftext", "alloftext", "anyof", "allof":
+		return true
+	}
+	return false
+}
+
+// isMovable tells if the function of the SubGraph gives the same result whether it is evaluated
+// at root or as a filter of the uids found at root.
+func isMovable(sg *SubGraph) bool {
+	fn := sg.SrcFunc
+	if fn == nil || sg.Attr == "" || fn.IsValueVar || fn.IsLenVar {
+		return false
+	}
+	for _, arg := range fn.Args {
+		if arg.IsValueVar {
+			return false
+		}
+	}
+	switch fn.Name {
+	case "uid", "uid_in", "val", "checkpwd":
+		return false
+	}
+	return true
+}
+
+// estimateFunc returns the number of uids matched by the function of the SubGraph, evaluated at
+// root, as given by the lengths of the index posting lists for its tokens.
+func estimateFunc(ctx context.Context, sg *SubGraph) (uint64, bool) {
+	if !isEstimable(sg) {
+		return 0, false
+	}
+	// The function is evaluated as if it were at root, whether sg is a filter or not.
+	taskQuery, err := createTaskQuery(ctx, &SubGraph{
+		Attr:    sg.Attr,
+		SrcFunc: sg.SrcFunc,
+		ReadTs:  sg.ReadTs,
+		Cache:   sg.Cache,
+		Params:  params{Langs: sg.Params.Langs},
+	})
+	if err != nil {
+		return 0, false
+	}
+	key := strings.Join(append([]string{taskQuery.Attr, taskQuery.SrcFunc.Name,
+		strings.Join(taskQuery.Langs, ",")}, taskQuery.SrcFunc.Args...), "\x00")
+	if e, ok := estimates.get(key); ok {
+		return e.uids, e.ok
+	}
+
+	// For functions served by an index, a count task returns the length of the posting list of
+	// every token of the function.
+	taskQuery.DoCount = true
+	result, err := worker.ProcessTaskOverNetwork(ctx, taskQuery)
+	if err != nil {
+		if ctx.Err() == nil {
+			estimates.set(key, estimate{at: time.Now()})
+		}
+		return 0, false
+	}
+	var uids uint64
+	for i, count := range result.Counts {
+		switch {
+		case strings.HasPrefix(sg.SrcFunc.Name, "allof"):
+			// The uids must be in all the posting lists.
+			if i == 0 || uint64(count) < uids {
+				uids = uint64(count)
+			}
+		default:
+			uids += uint64(count)
+		}
+	}
+	estimates.set(key, estimate{uids: uids, ok: true, at: time.Now()})
+	return uids, true
+}
+
+// orderByCost estimates the number of uids matched by the function at root of a query block and
+// by the filters that are and-ed with it. The most selective of them is used to find the uids at
+// root, and the filters are evaluated one after another, the most selective first, so that every
+// filter only looks at the uids that passed the previous ones.
+func (sg *SubGraph) orderByCost(ctx context.Context) {
+	if sg.SrcFunc == nil || sg.SrcFunc.Name == "uid" || sg.Params.Recurse ||
+		sg.Params.Alias == "shortest" || sg.Params.IsEmpty || len(sg.Filters) != 1 {
+		return
+	}
+	// and is the SubGraph holding the filters that are and-ed with the function at root.
+	and := sg
+	switch sg.Filters[0].FilterOp {
+	case "":
+	case "and":
+		and = sg.Filters[0]
+		and.flattenAnd()
+	default:
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, node := range append([]*SubGraph{sg}, and.Filters...) {
+		wg.Add(1)
+		go func(node *SubGraph) {
+			defer wg.Done()
+			if est, ok := estimateFunc(ctx, node); ok {
+				node.estimate = &est
+			}
+		}(node)
+	}
+	wg.Wait()
+
+	best := -1
+	for i, filter := range and.Filters {
+		if filter.estimate == nil || !isMovable(filter) {
+			continue
+		}
+		if best == -1 || *filter.estimate < *and.Filters[best].estimate {
+			best = i
+		}
+	}
+	if best != -1 && isMovable(sg) {
+		filterEst := *and.Filters[best].estimate
+		switch {
+		case sg.estimate == nil && sg.SrcFunc.Name == "has":
+			// has at root reads every uid having the predicate, while the filter reads the
+			// index.
+			sg.swapWithFilter(and, best)
+		case sg.estimate != nil && filterEst*swapRatio <= *sg.estimate:
+			sg.swapWithFilter(and, best)
+		}
+	}
+
+	if and != sg {
+		sortByEstimate(and.Filters)
+		and.filtersInOrder = true
+	}
+}
+
+// flattenAnd merges the and filters nested in an and filter into it, e.g. the filter
+// (a and b) and c, which the parser nests, becomes and(a, b, c).
+func (sg *SubGraph) flattenAnd() {
+	filters := make([]*SubGraph, 0, len(sg.Filters))
+	for _, filter := range sg.Filters {
+		if filter.FilterOp == "and" {
+			filter.flattenAnd()
+			filters = append(filters, filter.Filters...)
+			continue
+		}
+		filters = append(filters, filter)
+	}
+	sg.Filters = filters
+}
+
+// swapWithFilter makes the function of the i-th filter of parent the function at root, and the
+// function at root that filter. The SubGraph results in the same uids as both are and-ed.
+func (sg *SubGraph) swapWithFilter(parent *SubGraph, i int) {
+	filter := parent.Filters[i]
+	root := &SubGraph{
+		Attr:     sg.Attr,
+		SrcFunc:  sg.SrcFunc,
+		ReadTs:   sg.ReadTs,
+		Cache:    sg.Cache,
+		Params:   params{Langs: sg.Params.Langs},
+		estimate: sg.estimate,
+	}
+	sg.Attr, sg.SrcFunc, sg.Params.Langs = filter.Attr, filter.SrcFunc, filter.Params.Langs
+	sg.estimate = filter.estimate
+	parent.Filters[i] = root
+}
+
+// sortByEstimate sorts the filters by their estimated number of uids. The filters that couldn't
+// be estimated are kept last, in the order they were written in.
+func sortByEstimate(filters []*SubGraph) {
+	sort.SliceStable(filters, func(i, j int) bool {
+		ei, ej := filters[i].estimate, filters[j].estimate
+		switch {
+		case ei == nil:
+			return false
+		case ej == nil:
+			return true
+		}
+		return *ei < *ej
+	})
+}
+
+// applyFiltersInOrder and-s the filters of the SubGraph by evaluating them one after another,
+// each for the uids that passed the previous ones.
+func (sg *SubGraph) applyFiltersInOrder(ctx context.Context) error {
+	uids := sg.DestUIDs
+	for _, filter := range sg.Filters {
+		if len(uids.GetUids()) == 0 {
+			// Nothing left to filter.
+			filter.DestUIDs = &pb.List{}
+			continue
+		}
+
+		isUidFuncWithoutVar := filter.SrcFunc != nil && filter.SrcFunc.Name == "uid" &&
+			len(filter.Params.NeedsVar) == 0
+		if isUidFuncWithoutVar {
+			filter.DestUIDs = filter.SrcUIDs
+		} else {
+			filter.SrcUIDs = uids
+			filter.Params.ParentVars = sg.Params.ParentVars
+			errCh := make(chan error, 1)
+			ProcessGraph(ctx, filter, sg, errCh)
+			if err := <-errCh; err != nil {
+				return err
+			}
+		}
+		uids = algo.IntersectSorted([]*pb.List{uids, filter.DestUIDs})
+	}
+	sg.DestUIDs = uids
+	return nil
+}
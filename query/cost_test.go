@@ -0,0 +1,100 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/gql"
+)
+
+func costTestSubGraph(t *testing.T, q string) *SubGraph {
+	res, err := gql.Parse(gql.Request{Str: q})
+	require.NoError(t, err)
+	// The query block to test is the last one, the others define its variables.
+	sg, err := ToSubGraph(context.Background(), res.Query[len(res.Query)-1])
+	require.NoError(t, err)
+	return sg
+}
+
+func TestIsEstimable(t *testing.T) {
+	sg := costTestSubGraph(t, `{
+		var(func: uid(0x1)) {
+			a as age
+		}
+		me(func: has(name)) @filter(eq(name, "alice") and gt(count(friend), 2) and
+			anyofterms(bio, "graph") and uid(0x1) and eq(age, val(a))) {
+			name
+		}
+	}`)
+	require.False(t, isEstimable(sg))
+	require.True(t, isMovable(sg))
+
+	and := sg.Filters[0]
+	and.flattenAnd()
+	require.Len(t, and.Filters, 5)
+	estimable := []bool{true, false, true, false, false}
+	movable := []bool{true, true, true, false, false}
+	for i, filter := range and.Filters {
+		require.Equal(t, estimable[i], isEstimable(filter), "filter %d", i)
+		require.Equal(t, movable[i], isMovable(filter), "filter %d", i)
+	}
+}
+
+func TestSwapWithFilter(t *testing.T) {
+	sg := costTestSubGraph(t, `{
+		me(func: has(name)) @filter(eq(name@en, "alice") and anyofterms(bio, "graph")) {
+			name
+		}
+	}`)
+	and := sg.Filters[0]
+	and.flattenAnd()
+	est := uint64(3)
+	and.Filters[0].estimate = &est
+	sg.swapWithFilter(and, 0)
+
+	require.Equal(t, "name", sg.Attr)
+	require.Equal(t, "eq", sg.SrcFunc.Name)
+	require.Equal(t, []string{"en"}, sg.Params.Langs)
+	require.Equal(t, &est, sg.estimate)
+
+	require.Equal(t, "name", and.Filters[0].Attr)
+	require.Equal(t, "has", and.Filters[0].SrcFunc.Name)
+	require.Empty(t, and.Filters[0].Params.Langs)
+	require.Nil(t, and.Filters[0].estimate)
+	require.Equal(t, "anyofterms", and.Filters[1].SrcFunc.Name)
+}
+
+func TestSortByEstimate(t *testing.T) {
+	estimate := func(uids uint64) *uint64 { return &uids }
+	filters := []*SubGraph{
+		{Attr: "a"},
+		{Attr: "b", estimate: estimate(100)},
+		{Attr: "c"},
+		{Attr: "d", estimate: estimate(10)},
+	}
+	sortByEstimate(filters)
+
+	var attrs []string
+	for _, filter := range filters {
+		attrs = append(attrs, filter.Attr)
+	}
+	require.Equal(t, []string{"d", "b", "a", "c"}, attrs)
+}
@@ -0,0 +1,326 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/vtta/dgraph/gql"
+	"github.com/vtta/dgraph/protos/pb"
+	"github.com/vtta/dgraph/worker"
+	"github.com/vtta/dgraph/x"
+)
+
+// ExplainMode tells whether the plan of a query is returned along with its response.
+type ExplainMode int
+
+const (
+	// ExplainNone doesn't return the plan of the query.
+	ExplainNone ExplainMode = iota
+	// ExplainPlan returns the plan of the query without executing it.
+	ExplainPlan
+	// ExplainAnalyze executes the query and returns its plan annotated with the number of uids
+	// seen by every node and the time taken to execute it.
+	ExplainAnalyze
+)
+
+// maxPlanUids is the maximum number of uids printed in the plan of a uid function.
+const maxPlanUids = 10
+
+// ParseExplainMode parses the value of the explain option of a request. The plan of the query is
+// returned for "true" or "plan", and the query is also executed and analyzed for "analyze".
+func ParseExplainMode(s string) (ExplainMode, error) {
+	switch strings.ToLower(s) {
+	case "", "false":
+		return ExplainNone, nil
+	case "true", "plan":
+		return ExplainPlan, nil
+	case "analyze":
+		return ExplainAnalyze, nil
+	}
+	return ExplainNone, errors.Errorf("Invalid value for explain: %q. It must be one of "+
+		"true, plan or analyze", s)
+}
+
+// GetExplainMode returns the explain mode asked for by the request in the context.
+func GetExplainMode(ctx context.Context) ExplainMode {
+	// HTTP passes information about explain as query parameter which is attached to context. It
+	// is also attached to turn explain off for the internal queries run on behalf of a request.
+	if mode, ok := ctx.Value(ExplainKey).(ExplainMode); ok {
+		return mode
+	}
+
+	// gRPC client passes information about explain as metadata.
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md["explain"]) > 0 {
+		// We ignore the error here, because in error case, the mode would be ExplainNone.
+		mode, _ := ParseExplainMode(md["explain"][0])
+		return mode
+	}
+	return ExplainNone
+}
+
+// PlanNode describes how a node of a query, i.e. a SubGraph, is executed.
+type PlanNode struct {
+	// Name is the name of the query block at root and the alias of the predicate otherwise.
+	Name string `json:"name,omitempty"`
+	Attr string `json:"attr,omitempty"`
+	// Func is the function that finds the uids of a query block, or that filters the uids of
+	// a filter.
+	Func string `json:"func,omitempty"`
+	// Index is the index used to evaluate Func. It is empty if Func reads the values of the
+	// predicate instead.
+	Index string `json:"index,omitempty"`
+	// FilterOp is the operator, i.e. and, or or not, that combines the results of Filters.
+	FilterOp string `json:"filter_op,omitempty"`
+	// Pushdown is true if the node is evaluated by the group serving its predicate, and false if
+	// it is evaluated by the alpha running the query.
+	Pushdown bool        `json:"pushdown"`
+	Sort     []*PlanSort `json:"sort,omitempty"`
+	// EstimatedUids is the number of uids the node is estimated to result in, if it can be known
+	// before the query is executed.
+	EstimatedUids *uint64 `json:"estimated_uids,omitempty"`
+	// Actual holds what was measured while executing the node. It is only set if the query was
+	// analyzed.
+	Actual   *PlanStats  `json:"actual,omitempty"`
+	Filters  []*PlanNode `json:"filters,omitempty"`
+	Children []*PlanNode `json:"children,omitempty"`
+}
+
+// PlanSort describes how the results of a node are sorted by one of its orderings.
+type PlanSort struct {
+	Attr string `json:"attr"`
+	Desc bool   `json:"desc,omitempty"`
+	// Index is the sortable index used to sort by Attr. It is empty if the values of Attr are
+	// read to sort by it.
+	Index string `json:"index,omitempty"`
+	// Pushdown is true if the results are sorted by the group serving Attr, and false if they are
+	// sorted by the alpha running the query, as with value variables and facets.
+	Pushdown bool `json:"pushdown"`
+}
+
+// PlanStats holds what was measured while executing a node of a query.
+type PlanStats struct {
+	// SrcUids is the number of uids that the node was executed for.
+	SrcUids int `json:"src_uids"`
+	// DestUids is the number of uids that the node resulted in after its filters and pagination.
+	DestUids int `json:"dest_uids"`
+	// Values is the number of uids for which the node found a value.
+	Values int `json:"values,omitempty"`
+	// LatencyNs is the time taken to execute the node, including its filters and children.
+	LatencyNs uint64 `json:"latency_ns"`
+}
+
+type planBuilder struct {
+	namespace uint64
+	analyze   bool
+	// schema holds the schema of the predicates that the plan needs to know the indexes of.
+	schema map[string]*pb.SchemaNode
+}
+
+// buildPlan returns the plans of the given query blocks. If analyze is set, the blocks must have
+// been executed and their plans include what was measured while executing them.
+func buildPlan(ctx context.Context, sgs []*SubGraph, analyze bool) ([]*PlanNode, error) {
+	namespace, err := x.ExtractNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b := &planBuilder{
+		namespace: namespace,
+		analyze:   analyze,
+		schema:    make(map[string]*pb.SchemaNode),
+	}
+
+	// Fetch the schema of the predicates used by functions and orderings in one go.
+	predSet := make(map[string]struct{})
+	for _, sg := range sgs {
+		sg.recurse(func(sg *SubGraph) {
+			if sg.SrcFunc != nil && sg.Attr != "" {
+				predSet[b.schemaAttr(sg.Attr)] = struct{}{}
+			}
+			for _, o := range sg.Params.Order {
+				predSet[b.schemaAttr(o.Attr)] = struct{}{}
+			}
+		})
+	}
+	if len(predSet) > 0 {
+		preds := make([]string, 0, len(predSet))
+		for pred := range predSet {
+			preds = append(preds, pred)
+		}
+		nodes, err := worker.GetSchemaOverNetwork(ctx, &pb.SchemaRequest{
+			Predicates: preds,
+			Fields:     []string{"type", "index", "tokenizer", "count"},
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "while fetching schema for the query plan")
+		}
+		for _, node := range nodes {
+			b.schema[node.Predicate] = node
+		}
+	}
+
+	plan := make([]*PlanNode, 0, len(sgs))
+	for _, sg := range sgs {
+		plan = append(plan, b.node(sg, true))
+	}
+	return plan, nil
+}
+
+// schemaAttr returns the namespaced predicate whose schema applies to the given attribute.
+func (b *planBuilder) schemaAttr(attr string) string {
+	return x.NamespaceAttr(b.namespace, strings.TrimPrefix(attr, "~"))
+}
+
+func (b *planBuilder) node(sg *SubGraph, isRoot bool) *PlanNode {
+	n := &PlanNode{
+		Name:     sg.Params.Alias,
+		Attr:     sg.Attr,
+		FilterOp: sg.FilterOp,
+		Pushdown: isPushdown(sg),
+	}
+	if sg.SrcFunc != nil {
+		n.Func = sg.SrcFunc.describe(sg)
+		if sg.Attr != "" {
+			args := make([]string, 0, len(sg.SrcFunc.Args))
+			for _, arg := range sg.SrcFunc.Args {
+				args = append(args, arg.Value)
+			}
+			n.Index = worker.IndexForFunc(sg.SrcFunc.Name, args, sg.SrcFunc.IsCount, isRoot,
+				b.schema[b.schemaAttr(sg.Attr)])
+		}
+	}
+
+	for i, o := range sg.Params.Order {
+		s := &PlanSort{Attr: o.Attr, Desc: o.Desc, Pushdown: true}
+		for _, v := range sg.Params.NeedsVar {
+			if v.Name == o.Attr && v.Typ == gql.ValueVar {
+				s.Pushdown = false
+			}
+		}
+		// Only the first ordering can be served by an index, the rest sort by values.
+		if s.Pushdown && i == 0 {
+			s.Index = worker.SortIndex(b.schema[b.schemaAttr(o.Attr)])
+		}
+		n.Sort = append(n.Sort, s)
+	}
+	for _, o := range sg.Params.FacetsOrder {
+		n.Sort = append(n.Sort, &PlanSort{Attr: "@facets(" + o.Key + ")", Desc: o.Desc})
+	}
+
+	if est, ok := estimateUids(sg, isRoot); ok {
+		n.EstimatedUids = &est
+	}
+	if b.analyze && !sg.IsInternal() {
+		n.Actual = &PlanStats{
+			SrcUids:   len(sg.SrcUIDs.GetUids()),
+			DestUids:  len(sg.DestUIDs.GetUids()),
+			LatencyNs: uint64(sg.latency.Nanoseconds()),
+		}
+		for _, vl := range sg.valueMatrix {
+			if len(vl.GetValues()) > 0 {
+				n.Actual.Values++
+			}
+		}
+	}
+
+	for _, filter := range sg.Filters {
+		n.Filters = append(n.Filters, b.node(filter, false))
+	}
+	for _, child := range sg.Children {
+		n.Children = append(n.Children, b.node(child, false))
+	}
+	return n
+}
+
+// isPushdown tells if the SubGraph is sent as a task to the group serving its predicate, the same
+// way as ProcessGraph decides it.
+func isPushdown(sg *SubGraph) bool {
+	switch {
+	case sg.Attr == "uid" || sg.Attr == "" || sg.IsInternal() || sg.Params.IsEmpty:
+		return false
+	case sg.SrcFunc == nil:
+		return true
+	case sg.SrcFunc.Name == "uid":
+		return false
+	case isInequalityFn(sg.SrcFunc.Name) && (sg.SrcFunc.IsValueVar || sg.SrcFunc.IsLenVar):
+		return false
+	}
+	return true
+}
+
+// estimateUids returns the number of uids that the SubGraph is estimated to result in, if it is
+// known before executing the query.
+func estimateUids(sg *SubGraph, isRoot bool) (uint64, bool) {
+	var est uint64
+	switch {
+	case sg.SrcFunc != nil && sg.SrcFunc.Name == "uid" && len(sg.Params.NeedsVar) == 0:
+		// The uids are given in the query.
+		est = uint64(len(sg.SrcUIDs.GetUids()))
+	case sg.estimate != nil:
+		// The lengths of the index posting lists read by the function.
+		est = *sg.estimate
+	default:
+		return 0, false
+	}
+	if isRoot && sg.Params.Count > 0 && uint64(sg.Params.Count) < est {
+		est = uint64(sg.Params.Count)
+	}
+	return est, true
+}
+
+// describe returns the function in the form it is written in a query, e.g. eq(name, "alice").
+func (fn *Function) describe(sg *SubGraph) string {
+	var args []string
+	if fn.Name == "uid" {
+		for _, v := range sg.Params.NeedsVar {
+			args = append(args, v.Name)
+		}
+		if len(args) == 0 {
+			uids := sg.SrcUIDs.GetUids()
+			for i, uid := range uids {
+				if i == maxPlanUids {
+					args = append(args, fmt.Sprintf("... %d more", len(uids)-i))
+					break
+				}
+				args = append(args, fmt.Sprintf("%#x", uid))
+			}
+		}
+		return "uid(" + strings.Join(args, ", ") + ")"
+	}
+
+	if sg.Attr != "" {
+		attr := sg.Attr
+		if fn.IsCount {
+			attr = "count(" + attr + ")"
+		}
+		args = append(args, attr)
+	}
+	for _, arg := range fn.Args {
+		if arg.IsValueVar {
+			args = append(args, "val("+arg.Value+")")
+			continue
+		}
+		args = append(args, strconv.Quote(arg.Value))
+	}
+	return fn.Name + "(" + strings.Join(args, ", ") + ")"
+}
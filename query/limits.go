@@ -0,0 +1,150 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/vtta/dgraph/protos/pb"
+)
+
+// Names of the limits reported by LimitError.
+const (
+	LimitUids        = "uids"
+	LimitDepth       = "depth"
+	LimitResultBytes = "result_bytes"
+	LimitMemoryBytes = "memory_bytes"
+)
+
+type limitsKey struct{}
+
+// Limits bound the resources that a single query can use. A limit of zero means no limit.
+type Limits struct {
+	// Uids is the maximum number of uids that the query can touch, counted over all its nodes.
+	Uids uint64 `json:"uids,omitempty"`
+	// Depth is the maximum depth of the query, including the levels expanded by @recurse.
+	Depth uint64 `json:"depth,omitempty"`
+	// ResultBytes is the maximum size of the encoded response.
+	ResultBytes uint64 `json:"result_bytes,omitempty"`
+	// MemoryBytes is the maximum memory used to build the response, i.e. its nodes and the
+	// arena holding its values.
+	MemoryBytes uint64 `json:"memory_bytes,omitempty"`
+}
+
+// IsZero tells if none of the limits are set.
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// Override returns the limits with the ones set in o replacing them.
+func (l Limits) Override(o Limits) Limits {
+	override := func(v *uint64, ov uint64) {
+		if ov > 0 {
+			*v = ov
+		}
+	}
+	override(&l.Uids, o.Uids)
+	override(&l.Depth, o.Depth)
+	override(&l.ResultBytes, o.ResultBytes)
+	override(&l.MemoryBytes, o.MemoryBytes)
+	return l
+}
+
+// LimitError is returned when a query exceeds one of its limits. The query is aborted.
+type LimitError struct {
+	// Limit is the name of the limit that was exceeded, e.g. LimitUids.
+	Limit string
+	Max   uint64
+	// Actual is the value that exceeded the limit when the query was aborted.
+	Actual uint64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("Query exceeded the limit on %s: %d is more than the allowed %d",
+		e.Limit, e.Actual, e.Max)
+}
+
+// limitTracker keeps track of the resources used by a query.
+type limitTracker struct {
+	Limits
+	uids uint64
+}
+
+// WithLimits returns a context which applies the given limits to the query executed with it.
+func WithLimits(ctx context.Context, limits Limits) context.Context {
+	if limits.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, limitsKey{}, &limitTracker{Limits: limits})
+}
+
+func trackerFromContext(ctx context.Context) *limitTracker {
+	t, _ := ctx.Value(limitsKey{}).(*limitTracker)
+	return t
+}
+
+func checkLimit(limit string, max, actual uint64) error {
+	if max > 0 && actual > max {
+		return &LimitError{Limit: limit, Max: max, Actual: actual}
+	}
+	return nil
+}
+
+// addUids adds the uids in the given lists to the uids touched by the query.
+func addUids(ctx context.Context, lists []*pb.List) error {
+	t := trackerFromContext(ctx)
+	if t == nil || t.Uids == 0 {
+		return nil
+	}
+	var n uint64
+	for _, l := range lists {
+		n += uint64(len(l.GetUids()))
+	}
+	return checkLimit(LimitUids, t.Uids, atomic.AddUint64(&t.uids, n))
+}
+
+// checkDepth checks the depth of the query block against the limits of the query.
+func checkDepth(ctx context.Context, sg *SubGraph) error {
+	t := trackerFromContext(ctx)
+	if t == nil || t.Depth == 0 {
+		return nil
+	}
+	return checkLimit(LimitDepth, t.Depth, sg.depth())
+}
+
+// checkRecurseDepth checks the depth reached by a @recurse query block, whose root is at depth 1,
+// against the limits of the query.
+func checkRecurseDepth(ctx context.Context, depth uint64) error {
+	t := trackerFromContext(ctx)
+	if t == nil {
+		return nil
+	}
+	return checkLimit(LimitDepth, t.Depth, depth)
+}
+
+// depth returns the number of levels of the SubGraph and its children.
+func (sg *SubGraph) depth() uint64 {
+	var max uint64
+	for _, child := range sg.Children {
+		if d := child.depth(); d > max {
+			max = d
+		}
+	}
+	return max + 1
+}
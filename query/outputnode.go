@@ -104,6 +104,9 @@ type encoder struct {
 
 	// buf is the buffer which stores the JSON encoded response
 	buf *bytes.Buffer
+
+	// limits holds the limits of the query on the size of the response, if any.
+	limits *limitTracker
 }
 
 type node struct {
@@ -339,6 +342,15 @@ func (enc *encoder) setScalarVal(fj fastJsonNode, sv []byte) error {
 		return fmt.Errorf("estimated response size: %d is bigger than threshold: %d",
 			size, maxEncodedSize)
 	}
+	if enc.limits != nil {
+		if err := checkLimit(LimitResultBytes, enc.limits.ResultBytes, enc.curSize); err != nil {
+			return err
+		}
+		memory := uint64(enc.alloc.Size() + len(enc.arena.buf))
+		if err := checkLimit(LimitMemoryBytes, enc.limits.MemoryBytes, memory); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -1150,6 +1162,7 @@ func (sg *SubGraph) toFastJSON(ctx context.Context, l *Latency, field gqlSchema.
 	}()
 
 	enc := newEncoder()
+	enc.limits = trackerFromContext(ctx)
 	defer func() {
 		// Put encoder's arena back to arena pool.
 		arenaPool.Put(enc.arena)
@@ -1185,6 +1198,12 @@ func (sg *SubGraph) toFastJSON(ctx context.Context, l *Latency, field gqlSchema.
 		return nil, fmt.Errorf("while writing to buffer. Encoded response size: %d"+
 			" is bigger than threshold: %d", enc.buf.Len(), maxEncodedSize)
 	}
+	if enc.limits != nil {
+		if err := checkLimit(LimitResultBytes, enc.limits.ResultBytes,
+			uint64(enc.buf.Len())); err != nil {
+			return nil, err
+		}
+	}
 
 	return enc.buf.Bytes(), err
 }
@@ -124,6 +124,17 @@ func (genc *graphQLEncoder) encode(encInp encodeInput) bool {
 				genc.errs = append(genc.errs, err)
 				return false
 			}
+		} else if cs := encInp.parentField.Type().CustomScalar(); cs != nil {
+			// we got a scalar declared with @scalar, so the value needs to be coerced from the
+			// form it is stored in.
+			scalarVal, ok := coerceCustomScalar(val, cs)
+			if !ok {
+				genc.errs = append(genc.errs, encInp.parentField.GqlErrorf(encInp.parentPath,
+					"Error coercing value '%s' for field '%s' to type %s.",
+					string(val), encInp.parentField.Name(), encInp.parentField.Type().Name()))
+				return false
+			}
+			x.Check2(genc.buf.Write(scalarVal))
 		} else {
 			// we got a GraphQL scalar
 			// check coercion rules to see if it matches the GraphQL spec requirements.
@@ -289,6 +300,10 @@ func (genc *graphQLEncoder) encode(encInp encodeInput) bool {
 				// handles null writing for case 2
 				child = genc.completeAggregateChildren(cur, curSelection,
 					append(encInp.parentPath, curSelection.ResponseName()), true)
+			} else if encInp.fjIsRoot && curSelection.Type().IsConnection() {
+				// a connection query without any results is an empty connection
+				genc.completeRootConnectionQuery(nil, curSelection,
+					append(encInp.parentPath, curSelection.ResponseName()))
 			} else {
 				// handles null writing for case 1
 				if nullWritten = writeGraphQLNull(curSelection, genc.buf,
@@ -320,7 +335,12 @@ func (genc *graphQLEncoder) encode(encInp encodeInput) bool {
 			//    current fastJson node == list type
 			//    => This is not a mismatch between the GraphQL and DQL schema and should be
 			//       handled appropriately.
-			if curSelectionIsDgList && genc.getList(cur) {
+			if encInp.fjIsRoot && curSelection.Type().IsConnection() {
+				// handles special case of connection queries at root
+				next = genc.completeRootConnectionQuery(cur, curSelection,
+					append(encInp.parentPath, curSelection.ResponseName()))
+				child = next
+			} else if curSelectionIsDgList && genc.getList(cur) {
 				// handles case 1
 				itemPos := genc.buf.Len()
 				// List items which are scalars will never have null as a value returned
@@ -497,6 +517,9 @@ func (genc *graphQLEncoder) encode(encInp encodeInput) bool {
 			encInp.parentPath) {
 			// do nothing, value for field has already been written.
 			// If the value weren't written, the next else would write null.
+		} else if encInp.fjIsRoot && curSelection.Type().IsConnection() {
+			genc.completeRootConnectionQuery(nil, curSelection,
+				append(encInp.parentPath, curSelection.ResponseName()))
 		} else {
 			if !writeGraphQLNull(curSelection, genc.buf, genc.buf.Len()) {
 				genc.errs = append(genc.errs, curSelection.GqlErrorf(append(encInp.parentPath,
@@ -678,7 +701,15 @@ func (genc *graphQLEncoder) processCustomFields(field gqlSchema.Field, n fastJso
 			genc.entityRepresentations, _ = q.RepresentationsArg()
 		}
 		// start resolving the custom fields
-		genc.resolveCustomFields(field.SelectionSet(), []fastJsonNode{genc.children(n)})
+		selSet := field.SelectionSet()
+		if field.Type().IsConnection() {
+			// the results of a connection query are the nodes of its edges
+			selSet = nil
+			if node := gqlSchema.ConnectionNode(field); node != nil {
+				selSet = node.SelectionSet()
+			}
+		}
+		genc.resolveCustomFields(selSet, []fastJsonNode{genc.children(n)})
 		// close the error and result channels, to terminate the goroutines started above
 		close(genc.errCh)
 		close(genc.customFieldResultCh)
@@ -822,11 +853,16 @@ func (genc *graphQLEncoder) resolveCustomField(childField gqlSchema.Field,
 			// This would override any data returned for that field from dgraph.
 			apolloRequiredFields := childField.ApolloRequiredFields()
 			if len(apolloRequiredFields) > 0 && genc.entityRepresentations != nil {
-				keyFldName := genc.entityRepresentations.KeyField.Name()
-				// key fields will always have a non-list value, so it must be json.RawMessage
-				keyFldVal := toString(rfData[keyFldName].(json.RawMessage))
-				representation, ok := genc.entityRepresentations.KeyValToRepresentation[keyFldVal]
-				if ok {
+				keyFldVals := make([]string, 0, len(genc.entityRepresentations.KeyFields))
+				for _, keyFld := range genc.entityRepresentations.KeyFields {
+					// key fields will always have a non-list value, so it must be json.RawMessage
+					if val, _ := rfData[keyFld.Name()].(json.RawMessage); val != nil {
+						keyFldVals = append(keyFldVals, toString(val))
+					}
+				}
+				reprKey := gqlSchema.RepresentationKey(keyFldVals)
+				representation, ok := genc.entityRepresentations.KeyValToRepresentation[reprKey]
+				if ok && len(keyFldVals) == len(genc.entityRepresentations.KeyFields) {
 					for _, fName := range apolloRequiredFields {
 						rfData[fName] = representation[fName]
 					}
@@ -893,8 +929,8 @@ func (genc *graphQLEncoder) resolveCustomField(childField gqlSchema.Field,
 
 				// Step-3 & 4: Make the request to external HTTP endpoint using the URL and
 				// body. Then, Decode the HTTP response.
-				response, errs, hardErrs := fconf.MakeAndDecodeHTTPRequest(nil, url, body,
-					childField)
+				response, errs, hardErrs := fconf.MakeAndDecodeHTTPRequest(genc.ctx, nil, url,
+					body, childField)
 				if hardErrs != nil {
 					genc.errCh <- hardErrs
 					return
@@ -951,7 +987,8 @@ func (genc *graphQLEncoder) resolveCustomField(childField gqlSchema.Field,
 
 		// Step-3 & 4: Make the request to external HTTP endpoint using the URL and
 		// body. Then, Decode the HTTP response.
-		response, errs, hardErrs := fconf.MakeAndDecodeHTTPRequest(nil, fconf.URL, body, childField)
+		response, errs, hardErrs := fconf.MakeAndDecodeHTTPRequest(genc.ctx, nil, fconf.URL, body,
+			childField)
 		if hardErrs != nil {
 			genc.errCh <- hardErrs
 			return
@@ -1088,6 +1125,8 @@ func (genc *graphQLEncoder) resolveNestedFields(childField gqlSchema.Field,
 // 		}
 // which doesn't request any aggregate properties. In this case the fastJson node won't have any
 // children and we just need to write null as the value of the query.
+// If the query was given the groupBy argument, its groups are returned by another query, right
+// after the nodes for the aggregate properties. See completeAggregateGroups.
 func (genc *graphQLEncoder) completeRootAggregateQuery(fj fastJsonNode, query gqlSchema.Field,
 	qryPath []interface{}) fastJsonNode {
 	if genc.children(fj) == nil {
@@ -1095,6 +1134,18 @@ func (genc *graphQLEncoder) completeRootAggregateQuery(fj fastJsonNode, query gq
 		return fj.next
 	}
 
+	// find the node for the groups, and the one after all the nodes for the query
+	attrId := genc.getAttr(fj)
+	end := fj
+	for end != nil && genc.getAttr(end) == attrId {
+		end = end.next
+	}
+	var groups fastJsonNode
+	if end != nil && genc.attrForID(genc.getAttr(end)) == gqlSchema.AggregateGroupsAlias(query) {
+		groups = end
+		end = end.next
+	}
+
 	var val []byte
 	var err error
 	comma := ""
@@ -1102,7 +1153,7 @@ func (genc *graphQLEncoder) completeRootAggregateQuery(fj fastJsonNode, query gq
 	x.Check2(genc.buf.WriteString("{"))
 	for _, f := range query.SelectionSet() {
 		if f.Skip() || !f.Include() {
-			if f.Name() != gqlSchema.Typename {
+			if f.Name() != gqlSchema.Typename && f.Name() != "groups" {
 				fj = fj.next // need to skip data as well for this field
 			}
 			continue
@@ -1110,10 +1161,19 @@ func (genc *graphQLEncoder) completeRootAggregateQuery(fj fastJsonNode, query gq
 
 		x.Check2(genc.buf.WriteString(comma))
 		f.CompleteAlias(genc.buf)
+		comma = ","
 
-		if f.Name() == gqlSchema.Typename {
+		switch f.Name() {
+		case gqlSchema.Typename:
 			val = getTypename(f, nil)
-		} else {
+		case "groups":
+			if query.ArgValue("groupBy") == nil {
+				val = gqlSchema.JsonNull
+				break
+			}
+			genc.completeAggregateGroups(groups, f, append(qryPath, f.ResponseName()))
+			continue
+		default:
 			val, err = genc.getScalarVal(genc.children(fj))
 			if err != nil {
 				genc.errs = append(genc.errs, f.GqlErrorf(append(qryPath,
@@ -1124,6 +1184,239 @@ func (genc *graphQLEncoder) completeRootAggregateQuery(fj fastJsonNode, query gq
 			fj = fj.next
 		}
 		x.Check2(genc.buf.Write(val))
+	}
+	x.Check2(genc.buf.WriteString("}"))
+
+	return end
+}
+
+// completeAggregateGroups builds GraphQL JSON for the groups of an aggregate query at root.
+// Dgraph result:
+// 		{
+// 		  "PostAggregateResult.groups": [
+// 		    {
+// 		      "@groupby": [
+// 		        {
+// 		          "PostGroupKey.isPublished": false,
+// 		          "PostAggregateGroup.count": 1
+// 		        }, {
+// 		          "PostGroupKey.isPublished": true,
+// 		          "PostAggregateGroup.count": 2
+// 		        }
+// 		      ]
+// 		    }
+// 		  ]
+// 		}
+// GraphQL result:
+// 		{
+// 		  "groups": [
+// 		    {
+// 		      "key": { "isPublished": false },
+// 		      "count": 1
+// 		    }, {
+// 		      "key": { "isPublished": true },
+// 		      "count": 2
+// 		    }
+// 		  ]
+// 		}
+// fj is nil if Dgraph didn't find any groups.
+func (genc *graphQLEncoder) completeAggregateGroups(fj fastJsonNode, groups gqlSchema.Field,
+	groupsPath []interface{}) {
+	x.Check2(genc.buf.WriteString("["))
+	if fj != nil {
+		for i, grp := 0, genc.children(fj); grp != nil; i, grp = i+1, grp.next {
+			if i > 0 {
+				x.Check2(genc.buf.WriteString(","))
+			}
+			// the keys and aggregates of a group can be in any order, as they are read by
+			// their aliases.
+			vals := make(map[string]fastJsonNode)
+			for child := genc.children(grp); child != nil; child = child.next {
+				vals[genc.attrForID(genc.getAttr(child))] = child
+			}
+			genc.completeAggregateGroup(vals, groups, append(groupsPath, i))
+		}
+	}
+	x.Check2(genc.buf.WriteString("]"))
+}
+
+// completeAggregateGroup writes a group of an aggregate query, given the nodes for its keys and
+// aggregates by their aliases.
+func (genc *graphQLEncoder) completeAggregateGroup(vals map[string]fastJsonNode,
+	groups gqlSchema.Field, groupPath []interface{}) {
+	comma := ""
+	x.Check2(genc.buf.WriteString("{"))
+	for _, f := range groups.SelectionSet() {
+		if f.Skip() || !f.Include() {
+			continue
+		}
+
+		x.Check2(genc.buf.WriteString(comma))
+		f.CompleteAlias(genc.buf)
+		comma = ","
+
+		switch f.Name() {
+		case gqlSchema.Typename:
+			x.Check2(genc.buf.Write(getTypename(f, nil)))
+		case "key":
+			genc.completeAggregateGroupKey(vals, f, append(groupPath, f.ResponseName()))
+		default:
+			val := gqlSchema.JsonNull
+			if n, ok := vals[f.DgraphAlias()]; ok {
+				var err error
+				if val, err = genc.getScalarVal(n); err != nil {
+					genc.errs = append(genc.errs, f.GqlErrorf(append(groupPath,
+						f.ResponseName()), err.Error()))
+					// all aggregate properties are nullable, so no special checks are required
+					val = gqlSchema.JsonNull
+				}
+			}
+			x.Check2(genc.buf.Write(val))
+		}
+	}
+	x.Check2(genc.buf.WriteString("}"))
+}
+
+// completeAggregateGroupKey writes the key of a group of an aggregate query. Only the fields
+// which the query was grouped by have values in the key, the rest of them are null.
+func (genc *graphQLEncoder) completeAggregateGroupKey(vals map[string]fastJsonNode,
+	key gqlSchema.Field, keyPath []interface{}) {
+	comma := ""
+	x.Check2(genc.buf.WriteString("{"))
+	for _, f := range key.SelectionSet() {
+		if f.Skip() || !f.Include() {
+			continue
+		}
+
+		x.Check2(genc.buf.WriteString(comma))
+		f.CompleteAlias(genc.buf)
+		comma = ","
+
+		if f.Name() == gqlSchema.Typename {
+			x.Check2(genc.buf.Write(getTypename(f, nil)))
+			continue
+		}
+		n, ok := vals[f.GetObjectName()+"."+f.Name()]
+		if !ok || !genc.encode(encodeInput{
+			parentField: f,
+			parentPath:  append(keyPath, f.ResponseName()),
+			fj:          n,
+			fjIsRoot:    false,
+		}) {
+			// the fields of the key are nullable
+			x.Check2(genc.buf.Write(gqlSchema.JsonNull))
+		}
+	}
+	x.Check2(genc.buf.WriteString("}"))
+}
+
+// completeRootConnectionQuery builds GraphQL JSON for Relay connection queries at root.
+// Dgraph returns the nodes of a connection as the results of the query, each one with the data
+// needed to build its cursor as its last children. It also returns one node more than the
+// query asked for when there is a next page. So, the nodes need to be wrapped in edges, and the
+// page info is found from the nodes and the arguments of the query.
+// Dgraph result:
+// 		{
+// 		  "queryPostConnection": [
+// 		    {
+// 		      "Post.title": "A",
+// 		      "dgraph.cursorUid": "0x1",
+// 		      "dgraph.cursorOrder": "A"
+// 		    }, {
+// 		      "Post.title": "B",
+// 		      "dgraph.cursorUid": "0x2",
+// 		      "dgraph.cursorOrder": "B"
+// 		    }
+// 		  ]
+// 		}
+// GraphQL result, given `first: 1`:
+// 		{
+// 		  "queryPostConnection": {
+// 		    "edges": [
+// 		      {
+// 		        "cursor": "eyJ1aWQiOiIweDEiLC...",
+// 		        "node": { "title": "A" }
+// 		      }
+// 		    ],
+// 		    "pageInfo": { "hasNextPage": true }
+// 		  }
+// 		}
+// fj is nil if Dgraph didn't return any nodes for the query. It returns the fastJson node after
+// the ones for the query.
+func (genc *graphQLEncoder) completeRootConnectionQuery(fj fastJsonNode, query gqlSchema.Field,
+	qryPath []interface{}) fastJsonNode {
+	var nodes []fastJsonNode
+	if fj != nil {
+		attrId := genc.getAttr(fj)
+		for ; fj != nil && genc.getAttr(fj) == attrId; fj = fj.next {
+			nodes = append(nodes, fj)
+		}
+	}
+	hasNextPage := false
+	if first, ok := query.ArgValue("first").(int64); ok && int64(len(nodes)) > first {
+		nodes = nodes[:first]
+		hasNextPage = true
+	}
+
+	order, _ := gqlSchema.ConnectionOrder(query)
+	var after *gqlSchema.Cursor
+	if s, ok := query.ArgValue("after").(string); ok {
+		// the cursor has already been validated while rewriting the query
+		after, _ = gqlSchema.ParseCursor(s)
+	}
+	cursors := make([]string, len(nodes))
+	prev := after
+	for i, n := range nodes {
+		c := &gqlSchema.Cursor{Order: order}
+		for child := genc.children(n); child != nil; child = child.next {
+			switch genc.attrForID(genc.getAttr(child)) {
+			case gqlSchema.ConnectionUidAlias:
+				if val, err := genc.getScalarVal(child); err == nil {
+					c.UID = toString(val)
+				}
+			case gqlSchema.ConnectionOrderAlias:
+				if val, err := genc.getScalarVal(child); err == nil {
+					c.Value = val
+				}
+			}
+		}
+		if order != "" {
+			// count the nodes having the same value up to this one, so that paging after this
+			// node can skip them.
+			c.Ties = 1
+			if prev != nil && bytes.Equal(prev.Value, c.Value) {
+				c.Ties = prev.Ties + 1
+			}
+		}
+		prev = c
+		cursors[i] = c.Encode()
+	}
+
+	startPos := genc.buf.Len()
+	comma := ""
+	x.Check2(genc.buf.WriteString("{"))
+	for _, f := range query.SelectionSet() {
+		if f.Skip() || !f.Include() {
+			continue
+		}
+
+		x.Check2(genc.buf.WriteString(comma))
+		f.CompleteAlias(genc.buf)
+
+		switch f.Name() {
+		case gqlSchema.Typename:
+			x.Check2(genc.buf.Write(getTypename(f, nil)))
+		case "edges":
+			if !genc.completeConnectionEdges(f, nodes, cursors,
+				append(qryPath, f.ResponseName())) {
+				// edges and their nodes are non-nullable, so the whole connection becomes null
+				genc.buf.Truncate(startPos)
+				x.Check2(genc.buf.Write(gqlSchema.JsonNull))
+				return fj
+			}
+		case "pageInfo":
+			genc.completePageInfo(f, cursors, hasNextPage, after != nil)
+		}
 		comma = ","
 	}
 	x.Check2(genc.buf.WriteString("}"))
@@ -1131,6 +1424,87 @@ func (genc *graphQLEncoder) completeRootAggregateQuery(fj fastJsonNode, query gq
 	return fj
 }
 
+// completeConnectionEdges writes the edges of a connection, given its nodes and their cursors.
+// It returns false if any of the edges couldn't be written.
+func (genc *graphQLEncoder) completeConnectionEdges(edges gqlSchema.Field, nodes []fastJsonNode,
+	cursors []string, edgesPath []interface{}) bool {
+	x.Check2(genc.buf.WriteString("["))
+	for i, n := range nodes {
+		if i > 0 {
+			x.Check2(genc.buf.WriteString(","))
+		}
+		comma := ""
+		x.Check2(genc.buf.WriteString("{"))
+		for _, f := range edges.SelectionSet() {
+			if f.Skip() || !f.Include() {
+				continue
+			}
+
+			x.Check2(genc.buf.WriteString(comma))
+			f.CompleteAlias(genc.buf)
+
+			switch f.Name() {
+			case gqlSchema.Typename:
+				x.Check2(genc.buf.Write(getTypename(f, nil)))
+			case "cursor":
+				x.Check2(genc.buf.WriteString(strconv.Quote(cursors[i])))
+			case "node":
+				if !genc.encode(encodeInput{
+					parentField: f,
+					parentPath:  append(edgesPath, i, f.ResponseName()),
+					fj:          n,
+					fjIsRoot:    false,
+					childSelSet: f.SelectionSet(),
+				}) {
+					return false
+				}
+			}
+			comma = ","
+		}
+		x.Check2(genc.buf.WriteString("}"))
+	}
+	x.Check2(genc.buf.WriteString("]"))
+	return true
+}
+
+// completePageInfo writes the page info of a connection, given the cursors of its edges.
+func (genc *graphQLEncoder) completePageInfo(pageInfo gqlSchema.Field, cursors []string,
+	hasNextPage, hasPreviousPage bool) {
+	comma := ""
+	x.Check2(genc.buf.WriteString("{"))
+	for _, f := range pageInfo.SelectionSet() {
+		if f.Skip() || !f.Include() {
+			continue
+		}
+
+		x.Check2(genc.buf.WriteString(comma))
+		f.CompleteAlias(genc.buf)
+
+		switch f.Name() {
+		case gqlSchema.Typename:
+			x.Check2(genc.buf.Write(getTypename(f, nil)))
+		case "startCursor":
+			if len(cursors) == 0 {
+				x.Check2(genc.buf.Write(gqlSchema.JsonNull))
+			} else {
+				x.Check2(genc.buf.WriteString(strconv.Quote(cursors[0])))
+			}
+		case "endCursor":
+			if len(cursors) == 0 {
+				x.Check2(genc.buf.Write(gqlSchema.JsonNull))
+			} else {
+				x.Check2(genc.buf.WriteString(strconv.Quote(cursors[len(cursors)-1])))
+			}
+		case "hasNextPage":
+			x.Check2(genc.buf.WriteString(strconv.FormatBool(hasNextPage)))
+		case "hasPreviousPage":
+			x.Check2(genc.buf.WriteString(strconv.FormatBool(hasPreviousPage)))
+		}
+		comma = ","
+	}
+	x.Check2(genc.buf.WriteString("}"))
+}
+
 // completeAggregateChildren build GraphQL JSON for aggregate fields at child levels.
 // Dgraph result:
 // 		{
@@ -1392,6 +1766,28 @@ func cantCoerceScalar(val []byte, field gqlSchema.Field) bool {
 	return false
 }
 
+// coerceCustomScalar coerces a scalar value to the custom scalar cs, and returns the coerced value.
+// Values are stored as the base type of the scalar, so only Int needs a range check, and JSON
+// values, which are stored as their JSON encoding, need to be decoded. It returns false if the
+// value can't be coerced.
+func coerceCustomScalar(val []byte, cs *gqlSchema.CustomScalar) ([]byte, bool) {
+	switch {
+	case cs.BaseType == "Int":
+		if _, err := strconv.ParseInt(string(val), 0, 32); err != nil {
+			return nil, false
+		}
+	case cs.IsJSON():
+		var encoded string
+		if err := json.Unmarshal(val, &encoded); err != nil {
+			// Not a string, so it was stored as a JSON value in some other way, e.g. by a
+			// DQL mutation.
+			return val, json.Valid(val)
+		}
+		return []byte(encoded), json.Valid([]byte(encoded))
+	}
+	return val, true
+}
+
 // toString converts the json encoded string value val to a go string.
 // It should be used only in scenarios where the underlying string is simple, i.e., it doesn't
 // contain any escape sequence or any other string magic. Otherwise, better to use json.Unmarshal().
@@ -298,6 +298,15 @@ type SubGraph struct {
 	List     bool // whether predicate is of list type
 
 	pathMeta *pathMetadata
+	// latency is the time taken to process this SubGraph. It is only measured when the query
+	// is analyzed.
+	latency time.Duration
+	// estimate is the number of uids matched by the function of this SubGraph, as estimated from
+	// the lengths of its index posting lists before executing the query.
+	estimate *uint64
+	// filtersInOrder is true if the filters are and-ed by evaluating them one after another, in
+	// the order they are in, instead of in parallel.
+	filtersInOrder bool
 }
 
 func (sg *SubGraph) recurse(set func(sg *SubGraph)) {
@@ -763,6 +772,8 @@ type ContextKey int
 const (
 	// DebugKey is the key used to toggle debug mode.
 	DebugKey ContextKey = iota
+	// ExplainKey is the key used to set the ExplainMode of a query.
+	ExplainKey
 )
 
 func isDebug(ctx context.Context) bool {
@@ -2045,6 +2056,20 @@ func expandSubgraph(ctx context.Context, sg *SubGraph) ([]*SubGraph, error) {
 // ProcessGraph processes the SubGraph instance accumulating result for the query
 // from different instances. Note: taskQuery is nil for root node.
 func ProcessGraph(ctx context.Context, sg, parent *SubGraph, rch chan error) {
+	if GetExplainMode(ctx) != ExplainAnalyze {
+		processGraph(ctx, sg, parent, rch)
+		return
+	}
+	// processGraph sends exactly one error before it returns, so the SubGraph is done once it
+	// returns.
+	start := time.Now()
+	ech := make(chan error, 1)
+	processGraph(ctx, sg, parent, ech)
+	sg.latency = time.Since(start)
+	rch <- <-ech
+}
+
+func processGraph(ctx context.Context, sg, parent *SubGraph, rch chan error) {
 	var suffix string
 	if len(sg.Params.Alias) > 0 {
 		suffix += "." + sg.Params.Alias
@@ -2157,6 +2182,11 @@ func ProcessGraph(ctx context.Context, sg, parent *SubGraph, rch chan error) {
 				return
 			}
 
+			if err = addUids(ctx, result.UidMatrix); err != nil {
+				rch <- err
+				return
+			}
+
 			sg.uidMatrix = result.UidMatrix
 			sg.valueMatrix = result.ValueMatrix
 			sg.facetsMatrix = result.FacetMatrix
@@ -2199,7 +2229,12 @@ func ProcessGraph(ctx context.Context, sg, parent *SubGraph, rch chan error) {
 	}
 
 	// Run filters if any.
-	if len(sg.Filters) > 0 {
+	if len(sg.Filters) > 0 && sg.filtersInOrder {
+		if err = sg.applyFiltersInOrder(ctx); err != nil {
+			rch <- err
+			return
+		}
+	} else if len(sg.Filters) > 0 {
 		// Run all filters in parallel.
 		filterChan := make(chan error, len(sg.Filters))
 		for _, filter := range sg.Filters {
@@ -2777,10 +2812,21 @@ func (req *Request) ProcessQuery(ctx context.Context) (err error) {
 			sg.ReadTs = req.ReadTs
 			sg.Cache = req.Cache
 		})
+		if !sg.Params.Recurse {
+			// The depth of @recurse blocks is checked while expanding them.
+			if err := checkDepth(ctx, sg); err != nil {
+				return err
+			}
+		}
+		sg.orderByCost(ctx)
 		span.Annotate(nil, "Query parsed")
 		req.Subgraphs = append(req.Subgraphs, sg)
 	}
 	req.Latency.Parsing += time.Since(loopStart)
+	if GetExplainMode(ctx) == ExplainPlan {
+		// Only the plan of the query is asked for, so it isn't executed.
+		return nil
+	}
 
 	execStart := time.Now()
 	hasExecuted := make([]bool, len(req.Subgraphs))
@@ -2915,6 +2961,8 @@ type ExecutionResult struct {
 	SchemaNode []*pb.SchemaNode
 	Types      []*pb.TypeUpdate
 	Metrics    map[string]uint64
+	// Plan is the plan of the query. It is only set if the request asked to explain the query.
+	Plan []*PlanNode
 }
 
 // Process handles a query request.
@@ -2930,6 +2978,14 @@ func (req *Request) Process(ctx context.Context) (er ExecutionResult, err error)
 		calculateMetrics(sg, metrics)
 	}
 	er.Metrics = metrics
+	if mode := GetExplainMode(ctx); mode != ExplainNone {
+		if er.Plan, err = buildPlan(ctx, er.Subgraphs, mode == ExplainAnalyze); err != nil {
+			return er, errors.Wrapf(err, "while building the query plan")
+		}
+		if mode == ExplainPlan {
+			return er, nil
+		}
+	}
 	namespace, err := x.ExtractNamespace(ctx)
 	if err != nil {
 		return er, errors.Wrapf(err, "While processing query")
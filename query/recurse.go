@@ -67,6 +67,10 @@ func (start *SubGraph) expandRecurse(ctx context.Context, maxDepth uint64) error
 			return nil
 		}
 		depth++
+		// The root is at depth 1.
+		if err := checkRecurseDepth(ctx, depth+1); err != nil {
+			return err
+		}
 
 		// When the maximum depth has been reached, avoid retrieving any facets as
 		// the nodes at the other end of the edge will not be a part of this query.
@@ -618,6 +618,26 @@ func initialTypesInternal(namespace uint64, all bool) []*pb.TypeUpdate {
 					ValueType: pb.Posting_STRING,
 				},
 			},
+		}, &pb.TypeUpdate{
+			TypeName: "dgraph.graphql.schema_version",
+			Fields: []*pb.SchemaUpdate{
+				{
+					Predicate: "dgraph.graphql.version",
+					ValueType: pb.Posting_STRING,
+				},
+			},
+		}, &pb.TypeUpdate{
+			TypeName: "dgraph.dql.persisted_query",
+			Fields: []*pb.SchemaUpdate{
+				{
+					Predicate: "dgraph.dql.p_query_id",
+					ValueType: pb.Posting_STRING,
+				},
+				{
+					Predicate: "dgraph.dql.p_query",
+					ValueType: pb.Posting_STRING,
+				},
+			},
 		})
 
 	if all || x.WorkerConfig.AclEnabled {
@@ -651,6 +671,10 @@ func initialTypesInternal(namespace uint64, all bool) []*pb.TypeUpdate {
 						Predicate: "dgraph.acl.rule",
 						ValueType: pb.Posting_UID,
 					},
+					{
+						Predicate: "dgraph.acl.node_rule",
+						ValueType: pb.Posting_UID,
+					},
 				},
 			},
 			&pb.TypeUpdate{
@@ -665,6 +689,44 @@ func initialTypesInternal(namespace uint64, all bool) []*pb.TypeUpdate {
 						ValueType: pb.Posting_INT,
 					},
 				},
+			},
+			&pb.TypeUpdate{
+				TypeName: "dgraph.type.NodeRule",
+				Fields: []*pb.SchemaUpdate{
+					{
+						Predicate: "dgraph.rule.type",
+						ValueType: pb.Posting_STRING,
+					},
+					{
+						Predicate: "dgraph.rule.filter",
+						ValueType: pb.Posting_STRING,
+					},
+				},
+			},
+			&pb.TypeUpdate{
+				TypeName: "dgraph.type.ApiKey",
+				Fields: []*pb.SchemaUpdate{
+					{
+						Predicate: "dgraph.api_key.id",
+						ValueType: pb.Posting_STRING,
+					},
+					{
+						Predicate: "dgraph.api_key.secret",
+						ValueType: pb.Posting_PASSWORD,
+					},
+					{
+						Predicate: "dgraph.api_key.owner",
+						ValueType: pb.Posting_UID,
+					},
+					{
+						Predicate: "dgraph.api_key.expiry",
+						ValueType: pb.Posting_DATETIME,
+					},
+					{
+						Predicate: "dgraph.api_key.scope",
+						ValueType: pb.Posting_STRING,
+					},
+				},
 			})
 	}
 
@@ -720,6 +782,30 @@ func initialSchemaInternal(namespace uint64, all bool) []*pb.SchemaUpdate {
 			ValueType: pb.Posting_STRING,
 			Directive: pb.SchemaUpdate_INDEX,
 			Tokenizer: []string{"sha256"},
+		}, &pb.SchemaUpdate{
+			Predicate: "dgraph.graphql.version",
+			ValueType: pb.Posting_STRING,
+		}, &pb.SchemaUpdate{
+			Predicate: "dgraph.dql.p_query_id",
+			ValueType: pb.Posting_STRING,
+			Directive: pb.SchemaUpdate_INDEX,
+			Tokenizer: []string{"exact"},
+			Upsert:    true,
+		}, &pb.SchemaUpdate{
+			Predicate: "dgraph.dql.p_query",
+			ValueType: pb.Posting_STRING,
+		}, &pb.SchemaUpdate{
+			Predicate: "dgraph.query_limits",
+			ValueType: pb.Posting_STRING,
+		}, &pb.SchemaUpdate{
+			Predicate: "dgraph.query_limits_key",
+			ValueType: pb.Posting_STRING,
+			Directive: pb.SchemaUpdate_INDEX,
+			Tokenizer: []string{"exact"},
+			Upsert:    true,
+		}, &pb.SchemaUpdate{
+			Predicate: "dgraph.graphql.lambda_module",
+			ValueType: pb.Posting_STRING,
 		})
 
 	if all || x.WorkerConfig.AclEnabled {
@@ -758,6 +844,48 @@ func initialSchemaInternal(namespace uint64, all bool) []*pb.SchemaUpdate {
 				Predicate: "dgraph.rule.permission",
 				ValueType: pb.Posting_INT,
 			},
+			{
+				Predicate: "dgraph.acl.node_rule",
+				ValueType: pb.Posting_UID,
+				List:      true,
+			},
+			{
+				Predicate: "dgraph.rule.type",
+				ValueType: pb.Posting_STRING,
+				Directive: pb.SchemaUpdate_INDEX,
+				Tokenizer: []string{"exact"},
+			},
+			{
+				Predicate: "dgraph.rule.filter",
+				ValueType: pb.Posting_STRING,
+			},
+			{
+				Predicate: "dgraph.acl.jwt_key",
+				ValueType: pb.Posting_STRING,
+			},
+			{
+				Predicate: "dgraph.api_key.id",
+				ValueType: pb.Posting_STRING,
+				Directive: pb.SchemaUpdate_INDEX,
+				Tokenizer: []string{"exact"},
+				Upsert:    true,
+			},
+			{
+				Predicate: "dgraph.api_key.secret",
+				ValueType: pb.Posting_PASSWORD,
+			},
+			{
+				Predicate: "dgraph.api_key.owner",
+				ValueType: pb.Posting_UID,
+			},
+			{
+				Predicate: "dgraph.api_key.expiry",
+				ValueType: pb.Posting_DATETIME,
+			},
+			{
+				Predicate: "dgraph.api_key.scope",
+				ValueType: pb.Posting_STRING,
+			},
 		}...)
 	}
 	for _, sch := range initialSchema {
@@ -339,13 +339,15 @@ func runRestore(t *testing.T, lastDir string, commitTs uint64) map[string]string
 	restoredPreds, err := testutil.GetPredicateNames(pdir)
 	require.NoError(t, err)
 	require.ElementsMatch(t, []string{"dgraph.graphql.schema", "dgraph.graphql.xid", "dgraph.type",
-		"movie", "dgraph.graphql.p_query", "dgraph.drop.op"},
-		restoredPreds)
+		"movie", "dgraph.graphql.p_query", "dgraph.drop.op", "dgraph.dql.p_query_id",
+		"dgraph.dql.p_query", "dgraph.query_limits", "dgraph.query_limits_key",
+		"dgraph.graphql.version", "dgraph.graphql.lambda_module"}, restoredPreds)
 
 	restoredTypes, err := testutil.GetTypeNames(pdir)
 	require.NoError(t, err)
 	require.ElementsMatch(t, []string{"Node", "dgraph.graphql",
-		"dgraph.graphql.persisted_query"}, restoredTypes)
+		"dgraph.graphql.persisted_query", "dgraph.dql.persisted_query",
+		"dgraph.graphql.schema_version"}, restoredTypes)
 
 	require.NoError(t, err)
 	t.Logf("--- Restored values: %+v\n", restored)
@@ -229,8 +229,11 @@ func TestBackupFilesystem(t *testing.T) {
 	// Check the predicates and types in the schema are as expected.
 	// TODO: refactor tests so that minio and filesystem tests share most of their logic.
 	preds := []string{"dgraph.graphql.schema", "name", "dgraph.graphql.xid", "dgraph.type",
-		"movie", "dgraph.graphql.p_query", "dgraph.drop.op"}
-	types := []string{"Node", "dgraph.graphql", "dgraph.graphql.persisted_query"}
+		"movie", "dgraph.graphql.p_query", "dgraph.drop.op", "dgraph.dql.p_query_id",
+		"dgraph.dql.p_query", "dgraph.query_limits", "dgraph.query_limits_key",
+		"dgraph.graphql.version", "dgraph.graphql.lambda_module"}
+	types := []string{"Node", "dgraph.graphql", "dgraph.graphql.persisted_query",
+		"dgraph.dql.persisted_query", "dgraph.graphql.schema_version"}
 	testutil.CheckSchema(t, preds, types)
 
 	verifyUids := func(count int) {
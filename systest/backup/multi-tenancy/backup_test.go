@@ -117,10 +117,18 @@ func TestBackupMultiTenancy(t *testing.T) {
 
 	preds := []string{"dgraph.graphql.schema", "name", "dgraph.graphql.xid", "dgraph.type", "movie",
 		"dgraph.graphql.p_query", "dgraph.drop.op", "dgraph.xid", "dgraph.acl.rule",
-		"dgraph.password", "dgraph.user.group", "dgraph.rule.predicate", "dgraph.rule.permission"}
+		"dgraph.password", "dgraph.user.group", "dgraph.rule.predicate", "dgraph.rule.permission",
+		"dgraph.dql.p_query_id", "dgraph.dql.p_query", "dgraph.query_limits",
+		"dgraph.query_limits_key", "dgraph.acl.node_rule", "dgraph.rule.type",
+		"dgraph.rule.filter", "dgraph.acl.jwt_key",
+		"dgraph.api_key.id", "dgraph.api_key.secret", "dgraph.api_key.owner",
+		"dgraph.api_key.expiry", "dgraph.api_key.scope", "dgraph.graphql.version",
+		"dgraph.graphql.lambda_module"}
 	preds = append(preds, preds...)
 	types := []string{"Node", "dgraph.graphql", "dgraph.graphql.persisted_query",
-		"dgraph.type.Rule", "dgraph.type.User", "dgraph.type.Group"} // ACL
+		"dgraph.dql.persisted_query", "dgraph.graphql.schema_version",
+		"dgraph.type.Rule", "dgraph.type.NodeRule", "dgraph.type.User", "dgraph.type.Group",
+		"dgraph.type.ApiKey"} // ACL
 	types = append(types, types...)
 	testutil.CheckSchema(t, preds, types)
 
@@ -85,9 +85,19 @@ var expectedSchema = `[0x0] <movie>:string .` + " " + `
 [0x0] <dgraph.graphql.xid>:string @index(exact) @upsert .` + " " + `
 [0x0] <dgraph.graphql.schema>:string .` + " " + `
 [0x0] <dgraph.graphql.p_query>:string @index(sha256) .` + " " + `
+[0x0] <dgraph.graphql.version>:string .` + " " + `
+[0x0] <dgraph.dql.p_query_id>:string @index(exact) @upsert .` + " " + `
+[0x0] <dgraph.dql.p_query>:string .` + " " + `
+[0x0] <dgraph.query_limits>:string .` + " " + `
+[0x0] <dgraph.query_limits_key>:string @index(exact) @upsert .` + " " + `
+[0x0] <dgraph.graphql.lambda_module>:string .` + " " + `
 [0x0] type <Node> {
 	movie
 }
+[0x0] type <dgraph.dql.persisted_query> {
+	dgraph.dql.p_query_id
+	dgraph.dql.p_query
+}
 [0x0] type <dgraph.graphql> {
 	dgraph.graphql.schema
 	dgraph.graphql.xid
@@ -95,6 +105,9 @@ var expectedSchema = `[0x0] <movie>:string .` + " " + `
 [0x0] type <dgraph.graphql.persisted_query> {
 	dgraph.graphql.p_query
 }
+[0x0] type <dgraph.graphql.schema_version> {
+	dgraph.graphql.version
+}
 `
 var moviesData = `<_:x1> <movie> "BIRDS MAN OR (THE UNEXPECTED VIRTUE OF IGNORANCE)" .
 	<_:x2> <movie> "Spotlight" .
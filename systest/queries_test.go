@@ -375,6 +375,18 @@ func SchemaQueryTestPredicate1(t *testing.T, c *dgo.Dgraph) {
 	  {
 		"predicate": "dgraph.drop.op"
 	  },
+	  {
+		"predicate": "dgraph.dql.p_query"
+	  },
+	  {
+		"predicate": "dgraph.dql.p_query_id"
+	  },
+	  {
+		"predicate": "dgraph.query_limits"
+	  },
+	  {
+		"predicate": "dgraph.query_limits_key"
+	  },
 	  {
 		"predicate": "dgraph.graphql.p_query"
 	  },
@@ -393,9 +405,42 @@ func SchemaQueryTestPredicate1(t *testing.T, c *dgo.Dgraph) {
 	  {
 		  "predicate": "dgraph.rule.permission"
 	  },
+	  {
+		  "predicate": "dgraph.acl.node_rule"
+	  },
+	  {
+		  "predicate": "dgraph.rule.type"
+	  },
+	  {
+		  "predicate": "dgraph.rule.filter"
+	  },
+	  {
+		  "predicate": "dgraph.acl.jwt_key"
+	  },
+	  {
+		  "predicate": "dgraph.api_key.id"
+	  },
+	  {
+		  "predicate": "dgraph.api_key.secret"
+	  },
+	  {
+		  "predicate": "dgraph.api_key.owner"
+	  },
+	  {
+		  "predicate": "dgraph.api_key.expiry"
+	  },
+	  {
+		  "predicate": "dgraph.api_key.scope"
+	  },
 	  {
         "predicate": "dgraph.graphql.schema"
 	  },
+	  {
+		"predicate": "dgraph.graphql.version"
+	  },
+	  {
+		"predicate": "dgraph.graphql.lambda_module"
+	  },
 	  {
         "predicate": "dgraph.graphql.xid"
 	  },
@@ -32,13 +32,28 @@ const (
 {"predicate":"dgraph.user.group","list":true, "reverse":true, "type":"uid"},
 {"predicate":"dgraph.acl.rule","type":"uid","list":true},
 {"predicate":"dgraph.rule.predicate","type":"string","index":true,"tokenizer":["exact"],"upsert":true},
-{"predicate":"dgraph.rule.permission","type":"int"}
+{"predicate":"dgraph.rule.permission","type":"int"},
+{"predicate":"dgraph.acl.node_rule","type":"uid","list":true},
+{"predicate":"dgraph.rule.type","type":"string","index":true,"tokenizer":["exact"]},
+{"predicate":"dgraph.rule.filter","type":"string"},
+{"predicate":"dgraph.acl.jwt_key","type":"string"},
+{"predicate":"dgraph.api_key.id","type":"string","index":true,"tokenizer":["exact"],"upsert":true},
+{"predicate":"dgraph.api_key.secret","type":"password"},
+{"predicate":"dgraph.api_key.owner","type":"uid"},
+{"predicate":"dgraph.api_key.expiry","type":"datetime"},
+{"predicate":"dgraph.api_key.scope","type":"string"}
 `
 	otherInternalPreds = `
 {"predicate":"dgraph.type","type":"string","index":true,"tokenizer":["exact"],"list":true},
 {"predicate":"dgraph.drop.op", "type": "string"},
+{"predicate":"dgraph.dql.p_query", "type": "string"},
+{"predicate":"dgraph.dql.p_query_id","type":"string","index":true,"tokenizer":["exact"],"upsert":true},
+{"predicate":"dgraph.query_limits","type":"string"},
+{"predicate":"dgraph.query_limits_key","type":"string","index":true,"tokenizer":["exact"],"upsert":true},
+{"predicate":"dgraph.graphql.lambda_module","type":"string"},
 {"predicate":"dgraph.graphql.p_query","type":"string","index":true,"tokenizer":["sha256"]},
 {"predicate":"dgraph.graphql.schema", "type": "string"},
+{"predicate":"dgraph.graphql.version","type":"string"},
 {"predicate":"dgraph.graphql.xid","type":"string","index":true,"tokenizer":["exact"],"upsert":true}
 `
 	aclTypes = `
@@ -46,20 +61,32 @@ const (
 	"fields": [{"name": "dgraph.password"},{"name": "dgraph.xid"},{"name": "dgraph.user.group"}],
 	"name": "dgraph.type.User"
 },{
-	"fields": [{"name": "dgraph.acl.rule"},{"name": "dgraph.xid"}],
+	"fields": [{"name": "dgraph.acl.rule"},{"name": "dgraph.acl.node_rule"},{"name": "dgraph.xid"}],
 	"name": "dgraph.type.Group"
 },{
 	"fields": [{"name": "dgraph.rule.predicate"},{"name": "dgraph.rule.permission"}],
 	"name": "dgraph.type.Rule"
+},{
+	"fields": [{"name": "dgraph.rule.type"},{"name": "dgraph.rule.filter"}],
+	"name": "dgraph.type.NodeRule"
+},{
+	"fields": [{"name": "dgraph.api_key.id"},{"name": "dgraph.api_key.secret"},{"name": "dgraph.api_key.owner"},{"name": "dgraph.api_key.expiry"},{"name": "dgraph.api_key.scope"}],
+	"name": "dgraph.type.ApiKey"
 }
 `
 	otherInternalTypes = `
 {
+	"fields": [{"name": "dgraph.dql.p_query_id"},{"name": "dgraph.dql.p_query"}],
+	"name": "dgraph.dql.persisted_query"
+},{
 	"fields": [{"name": "dgraph.graphql.schema"},{"name": "dgraph.graphql.xid"}],
 	"name": "dgraph.graphql"
 },{
 	"fields": [{"name": "dgraph.graphql.p_query"}],
 	"name": "dgraph.graphql.persisted_query"
+},{
+	"fields": [{"name": "dgraph.graphql.version"}],
+	"name": "dgraph.graphql.schema_version"
 }
 `
 )
@@ -27,6 +27,9 @@ type AclCache struct {
 	loaded        bool
 	predPerms     map[string]map[string]int32
 	userPredPerms map[string]map[string]int32
+	// nodeRules maps a type to a submap, and the submap maps a group to the filter that the
+	// nodes of the type must match for the members of the group to access them.
+	nodeRules map[string]map[string]string
 }
 
 func (cache *AclCache) reset() {
@@ -55,6 +58,7 @@ var AclCachePtr = &AclCache{
 	loaded:        false,
 	predPerms:     make(map[string]map[string]int32),
 	userPredPerms: make(map[string]map[string]int32),
+	nodeRules:     make(map[string]map[string]string),
 }
 
 func (cache *AclCache) GetUserPredPerms(userId string) map[string]int32 {
@@ -63,6 +67,26 @@ func (cache *AclCache) GetUserPredPerms(userId string) map[string]int32 {
 	return cache.userPredPerms[userId]
 }
 
+// GetGroupsPredPerms returns the permissions that the given groups have on the predicates of the
+// namespace, i.e. the permissions of a user who is a member of all of them.
+func (cache *AclCache) GetGroupsPredPerms(ns uint64, groups []string) map[string]int32 {
+	cache.RLock()
+	defer cache.RUnlock()
+
+	perms := make(map[string]int32)
+	for pred, groupPerms := range cache.predPerms {
+		if predNs, _ := x.ParseNamespaceAttr(pred); predNs != ns {
+			continue
+		}
+		for _, group := range groups {
+			if perm, found := groupPerms[group]; found {
+				perms[pred] |= perm
+			}
+		}
+	}
+	return perms
+}
+
 func (cache *AclCache) Update(ns uint64, groups []acl.Group) {
 	// In dgraph, acl rules are divided by groups, e.g.
 	// the dev group has the following blob representing its ACL rules
@@ -97,7 +121,19 @@ func (cache *AclCache) Update(ns uint64, groups []acl.Group) {
 
 	predPerms := make(map[string]map[string]int32)
 	userPredPerms := make(map[string]map[string]int32)
+	nodeRules := make(map[string]map[string]string)
 	for _, group := range groups {
+		for _, rule := range group.NodeRules {
+			if len(rule.Type) == 0 || len(rule.Filter) == 0 {
+				continue
+			}
+			typ := x.NamespaceAttr(ns, rule.Type)
+			if _, found := nodeRules[typ]; !found {
+				nodeRules[typ] = make(map[string]string)
+			}
+			nodeRules[typ][group.GroupID] = rule.Filter
+		}
+
 		acls := group.Rules
 		users := group.Users
 
@@ -150,6 +186,12 @@ func (cache *AclCache) Update(ns uint64, groups []acl.Group) {
 		}
 	}
 
+	for k := range AclCachePtr.nodeRules {
+		if x.ParseNamespace(k) == ns {
+			delete(AclCachePtr.nodeRules, k)
+		}
+	}
+
 	// Set new rules in the cache
 	for k, v := range predPerms {
 		AclCachePtr.predPerms[k] = v
@@ -158,6 +200,40 @@ func (cache *AclCache) Update(ns uint64, groups []acl.Group) {
 	for k, v := range userPredPerms {
 		AclCachePtr.userPredPerms[k] = v
 	}
+
+	if AclCachePtr.nodeRules == nil {
+		AclCachePtr.nodeRules = make(map[string]map[string]string)
+	}
+	for k, v := range nodeRules {
+		AclCachePtr.nodeRules[k] = v
+	}
+}
+
+// NodeFilters returns the filters that the nodes of every type with node rules must match for
+// a member of the given groups to access them. Only the rules of the given groups apply, and a
+// node is accessible if it matches the filter of any of them.
+func (cache *AclCache) NodeFilters(ns uint64, groups []string) map[string][]string {
+	cache.RLock()
+	defer cache.RUnlock()
+
+	var filters map[string][]string
+	for typ, groupFilters := range cache.nodeRules {
+		typNs, typName := x.ParseNamespaceAttr(typ)
+		if typNs != ns {
+			continue
+		}
+		for _, group := range groups {
+			filter, found := groupFilters[group]
+			if !found {
+				continue
+			}
+			if filters == nil {
+				filters = make(map[string][]string)
+			}
+			filters[typName] = append(filters[typName], filter)
+		}
+	}
+	return filters
 }
 
 func (cache *AclCache) AuthorizePredicate(groups []string, predicate string,
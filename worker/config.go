@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/vtta/dgraph/ee"
 	"github.com/vtta/dgraph/x"
 )
 
@@ -49,6 +50,12 @@ type Options struct {
 	AccessJwtTtl time.Duration
 	// RefreshJwtTtl is the TTL of the refresh JWT.
 	RefreshJwtTtl time.Duration
+	// AclJwtAlg is the algorithm that the access and refresh JWTs are signed with. They are signed
+	// with the HMAC secret if it is empty or HS256.
+	AclJwtAlg string
+	// AclOidc is the configuration for accepting access JWTs issued by an external identity
+	// provider. It is nil if they aren't accepted.
+	AclOidc *ee.OidcConfig
 
 	// CachePercentage is the comma-separated list of cache percentages
 	// used to split the total cache size among the multiple caches.
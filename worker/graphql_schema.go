@@ -18,6 +18,7 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
 	"sort"
 	"sync"
 	"time"
@@ -38,9 +39,10 @@ const (
 	ErrGraphQLSchemaAlterFailed  = "succeeded in saving GraphQL schema but failed to alter Dgraph schema - " +
 		"GraphQL layer may exhibit unexpected behaviour, reapplying the old GraphQL schema may prevent any issues"
 
-	GqlSchemaPred    = "dgraph.graphql.schema"
-	gqlSchemaXidPred = "dgraph.graphql.xid"
-	gqlSchemaXidVal  = "dgraph.graphql.schema"
+	GqlSchemaPred        = "dgraph.graphql.schema"
+	gqlSchemaXidPred     = "dgraph.graphql.xid"
+	gqlSchemaXidVal      = "dgraph.graphql.schema"
+	gqlSchemaVersionPred = "dgraph.graphql.version"
 )
 
 var (
@@ -50,6 +52,25 @@ var (
 	ErrMultipleGraphQLSchemaNodes = errors.New("found multiple nodes for GraphQL schema")
 )
 
+// GQLSchemaVersion is a version of the schema of a namespace. Every update that changes the
+// GraphQL schema records the new schema as the next version in the schema history, and so does
+// every DQL alter of the schema.
+type GQLSchemaVersion struct {
+	Version int `json:"version"`
+	// Schema is the GraphQL schema. It is empty for the versions recording a DQL alter.
+	Schema string `json:"schema"`
+	// DQLSchema is the schema that a DQL alter applied, for the versions recording one.
+	DQLSchema string    `json:"dqlSchema,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Author is the ACL user that updated the schema. It is empty if ACL isn't enabled.
+	Author string `json:"author,omitempty"`
+}
+
+// IsDQL tells if the version records a DQL alter rather than a GraphQL schema update.
+func (v *GQLSchemaVersion) IsDQL() bool {
+	return v.DQLSchema != ""
+}
+
 // UpdateGQLSchemaOverNetwork sends the request to the group one leader for execution.
 func UpdateGQLSchemaOverNetwork(ctx context.Context, req *pb.UpdateGraphQLSchemaRequest) (*pb.
 	UpdateGraphQLSchemaResponse, error) {
@@ -172,6 +193,13 @@ func (w *grpcWorker) UpdateGraphQLSchema(ctx context.Context,
 			Op:        pb.DirectedEdge_SET,
 		})
 	}
+	// record the new schema in the schema history as part of the same mutation, so that the
+	// history only ever has the schemas that were actually committed.
+	versionEdges, err := gqlSchemaVersionEdges(ctx, namespace, req)
+	if err != nil {
+		return nil, err
+	}
+	m.Edges = append(m.Edges, versionEdges...)
 	// mutate the GraphQL schema. As it is a reserved predicate, and we are in group 1,
 	// so this call is gonna come back to all the group 1 servers only
 	tctx, err := MutateOverNetwork(ctx, m)
@@ -209,6 +237,160 @@ func (w *grpcWorker) UpdateGraphQLSchema(ctx context.Context,
 	return &pb.UpdateGraphQLSchemaResponse{Uid: schemaNodeUid}, nil
 }
 
+// GetGQLSchemaHistory returns the versions of the GraphQL schema of the namespace in the context
+// as of readTs, sorted by their version.
+func GetGQLSchemaHistory(ctx context.Context, readTs uint64) ([]*GQLSchemaVersion, error) {
+	namespace, err := x.ExtractNamespace(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "While reading gql schema history")
+	}
+	return gqlSchemaHistory(ctx, namespace, readTs)
+}
+
+func gqlSchemaHistory(ctx context.Context, namespace, readTs uint64) ([]*GQLSchemaVersion,
+	error) {
+	attr := x.NamespaceAttr(namespace, gqlSchemaVersionPred)
+	res, err := ProcessTaskOverNetwork(ctx, &pb.Query{
+		Attr:    attr,
+		SrcFunc: &pb.SrcFunction{Name: "has"},
+		ReadTs:  readTs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.GetUidMatrix()) == 0 || len(res.GetUidMatrix()[0].GetUids()) == 0 {
+		return nil, nil
+	}
+
+	res, err = ProcessTaskOverNetwork(ctx, &pb.Query{
+		Attr:    attr,
+		UidList: res.GetUidMatrix()[0],
+		ReadTs:  readTs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var history []*GQLSchemaVersion
+	for _, vals := range res.GetValueMatrix() {
+		for _, val := range vals.GetValues() {
+			var v GQLSchemaVersion
+			if err := json.Unmarshal(val.GetVal(), &v); err != nil {
+				return nil, errors.Wrapf(err, "while unmarshalling GraphQL schema version")
+			}
+			history = append(history, &v)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Version < history[j].Version
+	})
+	return history, nil
+}
+
+// gqlSchemaVersionEdges returns the edges that record the GraphQL schema in the request as the
+// next version in the schema history. It returns no edges if the schema is the same as the latest
+// version, or if it is an empty schema without any history, e.g. the one set after a drop all.
+func gqlSchemaVersionEdges(ctx context.Context, namespace uint64,
+	req *pb.UpdateGraphQLSchemaRequest) ([]*pb.DirectedEdge, error) {
+	history, err := gqlSchemaHistory(ctx, namespace, req.StartTs)
+	if err != nil {
+		return nil, err
+	}
+	var latest *GQLSchemaVersion
+	for i := len(history) - 1; i >= 0 && latest == nil; i-- {
+		if !history[i].IsDQL() {
+			latest = history[i]
+		}
+	}
+	if (latest == nil && req.GraphqlSchema == "") ||
+		(latest != nil && latest.Schema == req.GraphqlSchema) {
+		return nil, nil
+	}
+	return schemaVersionEdges(ctx, namespace, history, &GQLSchemaVersion{
+		Schema: req.GraphqlSchema,
+	})
+}
+
+// RecordDQLSchemaVersion records the schema that a DQL alter applied as the next version in the
+// schema history of the namespace in the context.
+func RecordDQLSchemaVersion(ctx context.Context, dqlSchema string) error {
+	namespace, err := x.ExtractNamespace(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "While recording DQL schema version")
+	}
+	schemaLock.Lock()
+	defer schemaLock.Unlock()
+	startTs := State.GetTimestamp(false)
+	history, err := gqlSchemaHistory(ctx, namespace, startTs)
+	if err != nil {
+		return err
+	}
+	edges, err := schemaVersionEdges(ctx, namespace, history, &GQLSchemaVersion{
+		DQLSchema: dqlSchema,
+	})
+	if err != nil {
+		return err
+	}
+	tctx, err := MutateOverNetwork(ctx, &pb.Mutations{StartTs: startTs, Edges: edges})
+	if err != nil {
+		return err
+	}
+	_, err = CommitOverNetwork(ctx, tctx)
+	return err
+}
+
+// schemaVersionEdges returns the edges that record the version as the one following the history.
+func schemaVersionEdges(ctx context.Context, namespace uint64, history []*GQLSchemaVersion,
+	version *GQLSchemaVersion) ([]*pb.DirectedEdge, error) {
+	version.Version = 1
+	if len(history) > 0 {
+		version.Version = history[len(history)-1].Version + 1
+	}
+	version.CreatedAt = time.Now().UTC()
+	version.Author = gqlSchemaAuthor(ctx)
+	val, err := json.Marshal(version)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := AssignUidsOverNetwork(ctx, &pb.Num{Val: 1, Type: pb.Num_UID})
+	if err != nil {
+		return nil, err
+	}
+	return []*pb.DirectedEdge{
+		{
+			Entity:    res.StartId,
+			Attr:      x.NamespaceAttr(namespace, gqlSchemaVersionPred),
+			Value:     val,
+			ValueType: pb.Posting_STRING,
+			Op:        pb.DirectedEdge_SET,
+		},
+		{
+			Entity:    res.StartId,
+			Attr:      x.NamespaceAttr(namespace, "dgraph.type"),
+			Value:     []byte("dgraph.graphql.schema_version"),
+			ValueType: pb.Posting_STRING,
+			Op:        pb.DirectedEdge_SET,
+		},
+	}, nil
+}
+
+// gqlSchemaAuthor returns the ACL user that sent the GraphQL schema update in the context, or an
+// empty string if ACL isn't enabled.
+func gqlSchemaAuthor(ctx context.Context) string {
+	if !x.WorkerConfig.AclEnabled {
+		return ""
+	}
+	jwt, err := x.ExtractJwt(ctx)
+	if err != nil {
+		return ""
+	}
+	userId, err := x.ExtractUserName(jwt)
+	if err != nil {
+		return ""
+	}
+	return userId
+}
+
 // WaitForIndexing does a busy wait for indexing to finish or the context to error out,
 // if the input flag shouldWait is true. Otherwise, it just returns nil straight away.
 // If the context errors, it returns that error.
@@ -29,6 +29,7 @@ import (
 	"github.com/dgraph-io/dgo/v210/protos/api"
 	"github.com/vtta/dgraph/conn"
 	"github.com/vtta/dgraph/ee/enc"
+	"github.com/vtta/dgraph/posting"
 	"github.com/vtta/dgraph/protos/pb"
 	"github.com/vtta/dgraph/raftwal"
 	"github.com/vtta/dgraph/schema"
@@ -1230,3 +1231,32 @@ func SubscribeForUpdates(prefixes [][]byte, ignore string, cb func(kvs *badgerpb
 		time.Sleep(time.Second)
 	}
 }
+
+// WatchCommits calls notify with the predicates of the namespace that are written by each batch of
+// committed transactions, until the closer is signalled. The writes are streamed from a member of
+// every group, so the commits to all the predicates are seen whichever group serves them. Rollups
+// don't write any new data, so they are skipped.
+func WatchCommits(ns uint64, notify func(preds map[string]struct{}), closer *z.Closer) {
+	cb := func(kvs *badgerpb.KVList) {
+		preds := make(map[string]struct{})
+		for _, kv := range kvs.GetKv() {
+			if len(kv.GetUserMeta()) == 0 || kv.GetUserMeta()[0]&posting.BitDeltaPosting == 0 {
+				continue
+			}
+			pk, err := x.Parse(kv.GetKey())
+			if err != nil {
+				continue
+			}
+			preds[x.ParseAttr(pk.Attr)] = struct{}{}
+		}
+		if len(preds) > 0 {
+			notify(preds)
+		}
+	}
+
+	prefixes := [][]byte{x.DataPrefix(ns)}
+	for _, gid := range KnownGroups() {
+		closer.AddRunning(1)
+		go SubscribeForUpdates(prefixes, "", cb, gid, closer)
+	}
+}
@@ -42,17 +42,20 @@ const (
 	BadgerDefaults = `compression=snappy; numgoroutines=8;`
 	RaftDefaults   = `learner=false; snapshot-after-entries=10000; ` +
 		`snapshot-after-duration=30m; pending-proposals=256; idx=; group=;`
-	SecurityDefaults  = `token=; whitelist=;`
+	SecurityDefaults  = `token=; whitelist=; persisted-queries-only=false;`
 	LudicrousDefaults = `enabled=false; concurrency=2000;`
 	CDCDefaults       = `file=; kafka=; sasl_user=; sasl_password=; ca_cert=; client_cert=; ` +
 		`client_key=; sasl-mechanism=PLAIN;`
 	LimitDefaults = `mutations=allow; query-edge=1000000; normalize-node=10000; ` +
 		`mutations-nquad=1000000; disallow-drop=false; query-timeout=0ms; txn-abort-after=5m; ` +
-		` max-retries=-1;max-pending-queries=10000`
+		` max-retries=-1;max-pending-queries=10000; query-uids=0; query-depth=0; ` +
+		`query-result-bytes=0; query-memory-bytes=0;`
 	ZeroLimitsDefaults = `uid-lease=0; refill-interval=30s; disable-admin-http=false;`
 	GraphQLDefaults    = `introspection=true; debug=false; extensions=true; poll-interval=1s; ` +
-		`lambda-url=;`
-	CacheDefaults = `size-mb=1024; percentage=0,65,35;`
+		`lambda-url=; lambda-embedded=false; lambda-memory-mb=16; lambda-timeout=10s; ` +
+		`custom-http-cache-mb=32; custom-http-conns-per-host=0; ` +
+		`max-query-depth=0; max-query-aliases=0; max-query-cost=0; default-list-multiplier=10;`
+	CacheDefaults = `size-mb=1024; percentage=0,65,35; query-plans=1000;`
 )
 
 // ServerState holds the state of the Dgraph server.
@@ -23,6 +23,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/dgraph-io/badger/v3"
+	"github.com/vtta/dgraph/protos/pb"
 	"github.com/vtta/dgraph/schema"
 	"github.com/vtta/dgraph/tok"
 	"github.com/vtta/dgraph/types"
@@ -87,39 +88,118 @@ func pickTokenizer(ctx context.Context, attr string, f string) (tok.Tokenizer, e
 	if tokenizers == nil {
 		return nil, errors.Errorf("Schema state not found for %s.", attr)
 	}
+	typ, err := schema.State().TypeOf(attr)
+	if t := chooseTokenizer(tokenizers, f, err == nil && typ == types.StringID); t != nil {
+		return t, nil
+	}
+	return nil, errors.Errorf("Attribute:%s does not have proper index for comparison", attr)
+}
+
+// chooseTokenizer returns the tokenizer, out of the given ones of a predicate, that the compare
+// function f uses. It returns nil if none of them can be used by f.
+func chooseTokenizer(tokenizers []tok.Tokenizer, f string, isString bool) tok.Tokenizer {
 	for _, t := range tokenizers {
 		// If function is eq and we found a tokenizer that's !Lossy(), lets return it
 		switch f {
 		case "eq":
 			// For equality, find a non-lossy tokenizer.
 			if !t.IsLossy() {
-				return t, nil
+				return t
 			}
 		default:
 			// rest of the cases: ge, gt, le, lt require a sortable tokenizer.
 			if t.IsSortable() {
-				return t, nil
+				return t
 			}
 		}
 	}
 
 	// Should we return an error if we don't find a non-lossy tokenizer for eq function.
-	if f != "eq" {
-		return nil, errors.Errorf("Attribute:%s does not have proper index for comparison", attr)
+	if f != "eq" || len(tokenizers) == 0 {
+		return nil
 	}
 
 	// If we didn't find a !isLossy() tokenizer for eq function on string type predicates,
 	// then let's see if we can find a non-trigram tokenizer
-	if typ, err := schema.State().TypeOf(attr); err == nil && typ == types.StringID {
+	if isString {
 		for _, t := range tokenizers {
 			if t.Identifier() != tok.IdentTrigram {
-				return t, nil
+				return t
 			}
 		}
 	}
 
 	// otherwise, lets return the first one.
-	return tokenizers[0], nil
+	return tokenizers[0]
+}
+
+// IndexForFunc returns the name of the index that the function with the given name and arguments
+// uses to find its results on a predicate with the given schema. It returns an empty string if
+// the function reads the values of the predicate instead. isCount tells if the function compares
+// the count of the predicate, and isRoot if it is the root function of a query block.
+func IndexForFunc(name string, args []string, isCount, isRoot bool, node *pb.SchemaNode) string {
+	fnType, f := parseFuncTypeHelper(name)
+	if isCount && fnType == compareAttrFn {
+		// Only a function at root looks up the count index, filters count the postings.
+		if isRoot && node.GetCount() {
+			return "count"
+		}
+		return ""
+	}
+	if !node.GetIndex() {
+		return ""
+	}
+	tokenizers, err := tok.GetTokenizers(node.GetTokenizer())
+	if err != nil {
+		return ""
+	}
+	hasTokenizer := func(name string) string {
+		for _, t := range tokenizers {
+			if t.Name() == name {
+				return name
+			}
+		}
+		return ""
+	}
+
+	switch fnType {
+	case compareAttrFn:
+		if t := chooseTokenizer(tokenizers, f, node.GetType() == types.StringID.Name()); t != nil {
+			return t.Name()
+		}
+	case fullTextSearchFn:
+		return hasTokenizer(tok.FullTextTokenizer{}.Name())
+	case regexFn, matchFn:
+		return hasTokenizer(tok.TrigramTokenizer{}.Name())
+	case geoFn:
+		return hasTokenizer(tok.GeoTokenizer{}.Name())
+	case standardFn:
+		return hasTokenizer(tok.TermTokenizer{}.Name())
+	case customIndexFn:
+		// The name of the tokenizer is the first argument of anyof and allof.
+		if len(args) > 0 {
+			return hasTokenizer(args[0])
+		}
+	}
+	return ""
+}
+
+// SortIndex returns the name of the sortable index that is used to sort by a predicate with the
+// given schema, or an empty string if the predicate has none and its values are read to sort.
+func SortIndex(node *pb.SchemaNode) string {
+	if !node.GetIndex() {
+		return ""
+	}
+	tokenizers, err := tok.GetTokenizers(node.GetTokenizer())
+	if err != nil {
+		return ""
+	}
+	for _, t := range tokenizers {
+		if t.IsSortable() {
+			return t.Name()
+		}
+	}
+	return ""
 }
 
 // getInequalityTokens gets tokens ge/le/between compared to given tokens using the first sortable
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2022 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vtta/dgraph/protos/pb"
+)
+
+func TestIndexForFunc(t *testing.T) {
+	name := &pb.SchemaNode{
+		Type:      "string",
+		Index:     true,
+		Tokenizer: []string{"term", "exact", "trigram", "fulltext"},
+	}
+	age := &pb.SchemaNode{Type: "int", Index: true, Tokenizer: []string{"int"}}
+	friend := &pb.SchemaNode{Type: "uid", Count: true}
+	nickname := &pb.SchemaNode{Type: "string"}
+
+	tests := []struct {
+		fn      string
+		args    []string
+		isCount bool
+		isRoot  bool
+		node    *pb.SchemaNode
+		index   string
+	}{
+		{fn: "eq", args: []string{"alice"}, isRoot: true, node: name, index: "exact"},
+		{fn: "ge", args: []string{"alice"}, isRoot: true, node: name, index: "exact"},
+		{fn: "anyofterms", args: []string{"alice"}, isRoot: true, node: name, index: "term"},
+		{fn: "alloftext", args: []string{"alice"}, node: name, index: "fulltext"},
+		{fn: "regexp", args: []string{"/^a/"}, node: name, index: "trigram"},
+		{fn: "anyof", args: []string{"trigram", "ali"}, node: name, index: "trigram"},
+		{fn: "has", isRoot: true, node: name, index: ""},
+		{fn: "lt", args: []string{"30"}, isRoot: true, node: age, index: "int"},
+		{fn: "gt", args: []string{"3"}, isCount: true, isRoot: true, node: friend, index: "count"},
+		{fn: "gt", args: []string{"3"}, isCount: true, node: friend, index: ""},
+		{fn: "eq", args: []string{"ali"}, node: nickname, index: ""},
+		{fn: "eq", args: []string{"ali"}, node: nil, index: ""},
+	}
+	for _, tc := range tests {
+		require.Equal(t, tc.index, IndexForFunc(tc.fn, tc.args, tc.isCount, tc.isRoot, tc.node),
+			"%s(%v)", tc.fn, tc.args)
+	}
+}
+
+func TestSortIndex(t *testing.T) {
+	require.Equal(t, "exact", SortIndex(&pb.SchemaNode{
+		Type: "string", Index: true, Tokenizer: []string{"term", "exact"}}))
+	require.Equal(t, "", SortIndex(&pb.SchemaNode{
+		Type: "string", Index: true, Tokenizer: []string{"term"}}))
+	require.Equal(t, "", SortIndex(&pb.SchemaNode{Type: "int"}))
+}
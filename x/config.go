@@ -38,13 +38,26 @@ type Options struct {
 	// mutations-nquad int - maximum number of nquads that can be inserted in a mutation request
 	// BlockDropAll bool - if set to true, the drop all operation will be rejected by the server.
 	// query-timeout duration - Maximum time after which a query execution will fail.
-	Limit                *z.SuperFlag
-	LimitMutationsNquad  int
-	LimitQueryEdge       uint64
-	BlockClusterWideDrop bool
-	LimitNormalizeNode   int
-	QueryTimeout         time.Duration
-	MaxRetries           int64
+	// query-uids, query-depth, query-result-bytes, query-memory-bytes uint64 - default limits
+	//                      on the resources used by a single query, zero meaning no limit
+	Limit                 *z.SuperFlag
+	LimitMutationsNquad   int
+	LimitQueryEdge        uint64
+	BlockClusterWideDrop  bool
+	LimitNormalizeNode    int
+	QueryTimeout          time.Duration
+	MaxRetries            int64
+	LimitQueryUids        uint64
+	LimitQueryDepth       uint64
+	LimitQueryResultBytes uint64
+	LimitQueryMemoryBytes uint64
+
+	// PersistedQueriesOnly, when set, rejects any DQL request with a query that doesn't execute
+	// a persisted query by its id.
+	PersistedQueriesOnly bool
+	// QueryPlanCacheSize is the maximum number of parsed DQL queries cached for reuse. Caching is
+	// disabled if it is zero.
+	QueryPlanCacheSize int
 
 	// GraphQL options:
 	//
@@ -60,7 +73,15 @@ type Options struct {
 	// 	| http://localhost:8686/graphql-worker     |  1  | http://localhost:8686/graphql-worker   |
 	// 	|=========================================================================================|
 	//
-	// poll-interval duration - The polling interval for graphql subscription.
+	// lambda-embedded bool - Runs the lambda resolvers in the embedded WASM runtime of the alpha,
+	// instead of calling lambda-url.
+	// lambda-memory-mb int64, lambda-timeout duration - The limits of an embedded lambda call.
+	// poll-interval duration - The polling interval for graphql subscriptions with @custom or
+	// @lambda fields. Other subscriptions are updated on commits.
+	// max-query-depth, max-query-aliases, max-query-cost int64 - The limits on the GraphQL
+	// operations, where 0 means no limit.
+	// default-list-multiplier int64 - The multiplier of the cost of the fields selected in a list
+	// field without a first argument.
 	GraphQL      *z.SuperFlag
 	GraphQLDebug bool
 }
@@ -108,6 +129,9 @@ type WorkerOptions struct {
 	AclEnabled bool
 	// HmacSecret stores the secret used to sign JSON Web Tokens (JWT).
 	HmacSecret SensitiveByteSlice
+	// AclJwtAlg is the algorithm that the JWTs issued by Dgraph are signed with. The JWTs signed
+	// with the HMAC secret are only accepted if it is empty or HS256.
+	AclJwtAlg string
 	// AbortOlderThan tells Dgraph to discard transactions that are older than this duration.
 	AbortOlderThan time.Duration
 	// ProposedGroupId will be used if there's a file in the p directory called group_id with the
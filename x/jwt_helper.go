@@ -23,12 +23,36 @@ import (
 	"github.com/pkg/errors"
 )
 
+// JwtValidator, if set, validates the JWTs that aren't signed with the HMAC secret, and returns
+// their claims in the same form as the claims of the JWTs that are.
+var JwtValidator func(jwtStr string) (jwt.MapClaims, error)
+
+// ApiKeyJwt, if set, returns an access JWT for the API key that a request is authenticated with,
+// through the X-Dgraph-ApiKey header or the apikey gRPC metadata.
+var ApiKeyJwt func(apiKey string) (string, error)
+
 func ParseJWT(jwtStr string) (jwt.MapClaims, error) {
+	if JwtValidator != nil {
+		token, _, err := new(jwt.Parser).ParseUnverified(jwtStr, jwt.MapClaims{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse jwt token")
+		}
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return JwtValidator(jwtStr)
+		}
+	}
+
 	token, err := jwt.Parse(jwtStr, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.Errorf("unexpected signing method: %v",
 				token.Header["alg"])
 		}
+		// Once the JWTs are signed with an asymmetric algorithm, the HMAC secret isn't used to
+		// verify them anymore, so that it can't be used to forge them.
+		if alg := WorkerConfig.AclJwtAlg; alg != "" && alg != jwt.SigningMethodHS256.Alg() {
+			return nil, errors.Errorf("unexpected signing method: %v, the JWTs must be "+
+				"signed with %s", token.Header["alg"], alg)
+		}
 		return []byte(WorkerConfig.HmacSecret), nil
 	})
 
@@ -661,16 +661,31 @@ var aclPredicateMap = map[string]struct{}{
 	"dgraph.rule.predicate":  {},
 	"dgraph.rule.permission": {},
 	"dgraph.acl.rule":        {},
+	"dgraph.acl.node_rule":   {},
+	"dgraph.rule.type":       {},
+	"dgraph.rule.filter":     {},
+	"dgraph.acl.jwt_key":     {},
+	"dgraph.api_key.id":      {},
+	"dgraph.api_key.secret":  {},
+	"dgraph.api_key.owner":   {},
+	"dgraph.api_key.expiry":  {},
+	"dgraph.api_key.scope":   {},
 }
 
 // TODO: rename this map to a better suited name as per its properties. It is not just for GraphQL
 // predicates, but for all those which are PreDefined and whose value is not allowed to be mutated
 // by users. When renaming this also rename the IsGraphql context key in edgraph/server.go.
 var graphqlReservedPredicate = map[string]struct{}{
-	"dgraph.graphql.xid":     {},
-	"dgraph.graphql.schema":  {},
-	"dgraph.drop.op":         {},
-	"dgraph.graphql.p_query": {},
+	"dgraph.graphql.xid":           {},
+	"dgraph.graphql.schema":        {},
+	"dgraph.drop.op":               {},
+	"dgraph.graphql.p_query":       {},
+	"dgraph.graphql.version":       {},
+	"dgraph.dql.p_query_id":        {},
+	"dgraph.dql.p_query":           {},
+	"dgraph.query_limits":          {},
+	"dgraph.query_limits_key":      {},
+	"dgraph.graphql.lambda_module": {},
 }
 
 // internalPredicateMap stores a set of Dgraph's internal predicate. An internal
@@ -686,7 +701,11 @@ var preDefinedTypeMap = map[string]struct{}{
 	"dgraph.type.User":               {},
 	"dgraph.type.Group":              {},
 	"dgraph.type.Rule":               {},
+	"dgraph.type.NodeRule":           {},
+	"dgraph.type.ApiKey":             {},
 	"dgraph.graphql.persisted_query": {},
+	"dgraph.graphql.schema_version":  {},
+	"dgraph.dql.persisted_query":     {},
 }
 
 // IsGraphqlReservedPredicate returns true if it is the predicate is reserved by graphql.
@@ -146,6 +146,9 @@ var (
 	// RaftLeaderChanges records the total number of leader changes seen.
 	RaftLeaderChanges = stats.Int64("raft_leader_changes_total",
 		"Total number of leader changes seen", stats.UnitDimensionless)
+	// NumQueriesOverLimit records the number of queries aborted for exceeding their limits.
+	NumQueriesOverLimit = stats.Int64("num_queries_over_limit_total",
+		"Total number of queries aborted for exceeding a resource limit", stats.UnitDimensionless)
 
 	// Conf holds the metrics config.
 	// TODO: Request statistics, latencies, 500, timeouts
@@ -164,6 +167,9 @@ var (
 	// KeyDirType is the tag key used to record the group for FileSystem metrics
 	KeyDirType, _ = tag.NewKey("dir")
 
+	// KeyLimit is the tag key used to record the query limit that was exceeded.
+	KeyLimit, _ = tag.NewKey("limit")
+
 	// Tag values.
 
 	// TagValueStatusOK is the tag value used to signal a successful operation.
@@ -407,6 +413,13 @@ var (
 			Aggregation: view.Count(),
 			TagKeys:     allRaftKeys,
 		},
+		{
+			Name:        NumQueriesOverLimit.Name(),
+			Measure:     NumQueriesOverLimit,
+			Description: NumQueriesOverLimit.Description(),
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{KeyLimit},
+		},
 	}
 )
 
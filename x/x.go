@@ -91,6 +91,8 @@ const (
 	Error = "Error"
 	// ErrorNoData is an error returned when the requested data cannot be returned.
 	ErrorNoData = "ErrorNoData"
+	// ErrorLimitExceeded is an error returned when a query exceeds one of its resource limits.
+	ErrorLimitExceeded = "ErrorLimitExceeded"
 	// ValidHostnameRegex is a regex that accepts our expected hostname format.
 	ValidHostnameRegex = `^([a-zA-Z0-9_]{1}[a-zA-Z0-9_-]{0,62}){1}(\.[a-zA-Z0-9_]{1}` +
 		`[a-zA-Z0-9_-]{0,62})*[._]?$`
@@ -135,7 +137,7 @@ const (
 	// DefaultCreds is the default credentials for login via dgo client.
 	DefaultCreds = "user=; password=; namespace=0;"
 
-	AccessControlAllowedHeaders = "X-Dgraph-AccessToken, X-Dgraph-AuthToken, " +
+	AccessControlAllowedHeaders = "X-Dgraph-AccessToken, X-Dgraph-ApiKey, X-Dgraph-AuthToken, " +
 		"Content-Type, Content-Length, Accept-Encoding, Cache-Control, " +
 		"X-CSRF-Token, X-Auth-Token, X-Requested-With"
 	DgraphCostHeader = "Dgraph-TouchedUids"
@@ -313,6 +315,11 @@ func ExtractJwt(ctx context.Context) (string, error) {
 	}
 	accessJwt := md.Get("accessJwt")
 	if len(accessJwt) == 0 {
+		// Machine clients may authenticate with an API key instead, for which we get an access
+		// JWT from the ACL module.
+		if apiKey := md.Get("apikey"); len(apiKey) > 0 && ApiKeyJwt != nil {
+			return ApiKeyJwt(apiKey[0])
+		}
 		return "", ErrNoJwt
 	}
 
@@ -525,6 +532,15 @@ func AttachAccessJwt(ctx context.Context, r *http.Request) context.Context {
 		md.Append("accessJwt", accessJwt)
 		ctx = metadata.NewIncomingContext(ctx, md)
 	}
+	if apiKey := r.Header.Get("X-Dgraph-ApiKey"); apiKey != "" {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			md = metadata.New(nil)
+		}
+
+		md.Append("apikey", apiKey)
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
 	return ctx
 }
 
@@ -1476,8 +1492,17 @@ func (r *RateLimiter) RefillPeriodically() {
 	}
 }
 
-// LambdaUrl returns the correct lambda-url for the given namespace
+// EmbeddedLambdaScheme is the scheme of the lambda URL when the lambda resolvers run in the
+// embedded lambda runtime of the alpha. The requests to such a URL aren't sent over the network.
+const EmbeddedLambdaScheme = "embedded"
+
+// LambdaUrl returns the correct lambda-url for the given namespace. With the embedded lambda
+// runtime, it is embedded://lambda, as the module that is run is the one of the namespace of the
+// request.
 func LambdaUrl(ns uint64) string {
+	if Config.GraphQL.GetBool("lambda-embedded") {
+		return EmbeddedLambdaScheme + "://lambda"
+	}
 	return strings.Replace(Config.GraphQL.GetString("lambda-url"), "$ns", strconv.FormatUint(ns,
 		10), 1)
 }